@@ -0,0 +1,83 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ArgumentCaptor matches any argument of its type, like AnyMatcher, and
+// captures the arguments of the invocations a verification verified, once the
+// verification succeeded. Typed captors wrap it, e.g. StringCaptor,
+// CaptorOf[T] or the <Type>Captor functions generated with the matchers, so
+// the captured arguments can be used without casts:
+//
+//	captor := StringCaptor()
+//	display.VerifyWasCalledOnce().Show(captor.Capture())
+//	Expect(captor.GetValue()).To(Equal("Hello"))
+//
+// Captured arguments accumulate over verifications.
+type ArgumentCaptor struct {
+	matcher *AnyMatcher
+	mutex   sync.Mutex
+	values  []Param
+}
+
+// NewArgumentCaptor returns a captor for arguments of type typ.
+func NewArgumentCaptor(typ reflect.Type) *ArgumentCaptor {
+	return &ArgumentCaptor{matcher: NewAnyMatcher(typ)}
+}
+
+func (captor *ArgumentCaptor) Matches(param Param) bool {
+	return captor.matcher.Matches(param)
+}
+
+func (captor *ArgumentCaptor) FailureMessage() string {
+	return captor.matcher.FailureMessage()
+}
+
+func (captor *ArgumentCaptor) String() string {
+	return fmt.Sprintf("Captor(%v)", captor.matcher.Type)
+}
+
+// Values returns the captured arguments in the order the invocations were made.
+func (captor *ArgumentCaptor) Values() []Param {
+	captor.mutex.Lock()
+	defer captor.mutex.Unlock()
+	return append([]Param(nil), captor.values...)
+}
+
+// Value returns the last captured argument. It fails the test if nothing was
+// captured.
+func (captor *ArgumentCaptor) Value() Param {
+	values := captor.Values()
+	if len(values) == 0 {
+		message := fmt.Sprintf("Captor(%v) captured no argument. Pass it to a verification that succeeds before getting its value.", captor.matcher.Type)
+		if GlobalFailHandler == nil {
+			panic(message)
+		}
+		GlobalFailHandler(message, 1)
+		return nil
+	}
+	return values[len(values)-1]
+}
+
+func (captor *ArgumentCaptor) capture(param Param) {
+	captor.mutex.Lock()
+	defer captor.mutex.Unlock()
+	captor.values = append(captor.values, param)
+}
+
+// captureArguments makes the captors among matchers capture the arguments of
+// invocations.
+func captureArguments(matchers []Matcher, invocations []MethodInvocation) {
+	for i, matcher := range matchers {
+		if captor, isCaptor := matcher.(*ArgumentCaptor); isCaptor {
+			for _, invocation := range invocations {
+				if i < len(invocation.params) {
+					captor.capture(invocation.params[i])
+				}
+			}
+		}
+	}
+}
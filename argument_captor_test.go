@@ -0,0 +1,60 @@
+package pegomock_test
+
+import (
+	"net/http"
+
+	. "github.com/petergtz/pegomock"
+	. "github.com/petergtz/pegomock/matchers"
+)
+
+var _ = Describe("Argument captors", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("captures the argument of a verified invocation", func() {
+		display.Show("Hello")
+
+		captor := StringCaptor()
+		display.VerifyWasCalledOnce().Show(captor.Capture())
+
+		Expect(captor.GetValue()).To(Equal("Hello"))
+	})
+
+	It("captures the arguments of all verified invocations in order", func() {
+		display.Flash("Hello", 1)
+		display.Flash("World", 2)
+		display.Flash("Other", 3)
+
+		textCaptor, numberCaptor := StringCaptor(), IntCaptor()
+		display.VerifyWasCalled(Times(3)).Flash(textCaptor.Capture(), numberCaptor.Capture())
+
+		Expect(textCaptor.GetAllValues()).To(Equal([]string{"Hello", "World", "Other"}))
+		Expect(textCaptor.GetValue()).To(Equal("Other"))
+		Expect(numberCaptor.GetAllValues()).To(Equal([]int{1, 2, 3}))
+	})
+
+	It("captures custom types with generated captors", func() {
+		request := &http.Request{Method: "GET"}
+		display.NetHttpRequestPtrParam(request)
+		display.NetHttpRequestPtrParam(nil)
+
+		captor := PtrToHttpRequestCaptor()
+		display.VerifyWasCalled(Times(2)).NetHttpRequestPtrParam(captor.Capture())
+
+		Expect(captor.GetAllValues()).To(Equal([]*http.Request{request, nil}))
+	})
+
+	It("captures nothing when the verification fails", func() {
+		display.Show("Hello")
+
+		captor := StringCaptor()
+		Expect(func() { display.VerifyWasCalled(Times(2)).Show(captor.Capture()) }).To(Panic())
+
+		Expect(captor.GetAllValues()).To(HaveLen(0))
+		Expect(func() { captor.GetValue() }).To(PanicWith(
+			"Captor(string) captured no argument. Pass it to a verification that succeeds before getting its value."))
+	})
+})
@@ -0,0 +1,99 @@
+package pegomock
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+type bufferedFailuresT interface {
+	Fatalf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// failureBuffer collects failures reported on goroutines other than the test
+// goroutine, because the testing package only allows t.Fatalf on the goroutine
+// running the test.
+type failureBuffer struct {
+	t               bufferedFailuresT
+	testGoroutineID int64
+
+	mutex    sync.Mutex
+	failures []string
+}
+
+var (
+	currentFailureBufferMutex sync.Mutex
+	// currentFailureBuffer is the buffer registered via RegisterBufferedMockTestingT.
+	// It is reset by RegisterMockFailHandler and when the test finishes.
+	currentFailureBuffer *failureBuffer
+)
+
+// RegisterBufferedMockTestingT registers a global fail handler that is safe to
+// trigger from any goroutine. Failures on the goroutine calling
+// RegisterBufferedMockTestingT are reported immediately via t.Fatalf. Failures on
+// other goroutines, e.g. in stubbed callbacks or in code under test that runs
+// asynchronously, are queued instead and reported the next time a mock is
+// used on the test goroutine, or at the latest when the test finishes.
+func RegisterBufferedMockTestingT(t bufferedFailuresT) {
+	buffer := &failureBuffer{t: t, testGoroutineID: currentGoroutineID()}
+	RegisterMockFailHandler(buffer.failHandler)
+	setCurrentFailureBuffer(buffer)
+	t.Cleanup(func() {
+		currentFailureBufferMutex.Lock()
+		if currentFailureBuffer == buffer {
+			currentFailureBuffer = nil
+		}
+		currentFailureBufferMutex.Unlock()
+		buffer.flush()
+	})
+}
+
+func (buffer *failureBuffer) failHandler(message string, callerSkip ...int) {
+	skip := 0
+	if len(callerSkip) > 0 {
+		skip = callerSkip[0]
+	}
+	// Same as in BuildTestingTFailHandler: skip debug.Stack and this fail handler.
+	failure := fmt.Sprintf("\n%s\n%s", pruneStack(string(debug.Stack()), skip+2), message)
+	if currentGoroutineID() != buffer.testGoroutineID {
+		buffer.mutex.Lock()
+		defer buffer.mutex.Unlock()
+		buffer.failures = append(buffer.failures, failure)
+		return
+	}
+	buffer.flush()
+	buffer.t.Fatalf("%s", failure)
+}
+
+// flush reports all queued failures via a single t.Fatalf. It must only be
+// called on the test goroutine.
+func (buffer *failureBuffer) flush() {
+	buffer.mutex.Lock()
+	failures := buffer.failures
+	buffer.failures = nil
+	buffer.mutex.Unlock()
+
+	if len(failures) == 0 {
+		return
+	}
+	buffer.t.Fatalf("%v failure(s) on other goroutines:\n%s", len(failures), strings.Join(failures, "\n"))
+}
+
+// flushBufferedFailuresIfOnTestGoroutine is called whenever a mock is used, so
+// that failures from other goroutines surface as early as possible.
+func flushBufferedFailuresIfOnTestGoroutine() {
+	currentFailureBufferMutex.Lock()
+	buffer := currentFailureBuffer
+	currentFailureBufferMutex.Unlock()
+	if buffer != nil && currentGoroutineID() == buffer.testGoroutineID {
+		buffer.flush()
+	}
+}
+
+func setCurrentFailureBuffer(buffer *failureBuffer) {
+	currentFailureBufferMutex.Lock()
+	defer currentFailureBufferMutex.Unlock()
+	currentFailureBuffer = buffer
+}
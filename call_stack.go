@@ -0,0 +1,40 @@
+package pegomock
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+var pegomockFunctionPrefix = reflect.TypeOf(GenericMock{}).PkgPath() + "."
+
+// captureStack returns the program counters of up to depth callers, skipping
+// skip frames like runtime.Callers does. They are only turned into file and line
+// by testCodeLocations when reporting, since that is slow.
+func captureStack(skip int, depth int) []uintptr {
+	pcs := make([]uintptr, depth)
+	// also skip captureStack
+	return pcs[:runtime.Callers(skip+1, pcs)]
+}
+
+// testCodeLocations returns the file and line of up to max frames of pcs,
+// skipping frames of Pegomock and of generated matchers, so they point to test
+// code or the code under test.
+func testCodeLocations(pcs []uintptr, max int) (locations []string) {
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs)
+	for len(locations) < max {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pegomockFunctionPrefix) && filepath.Base(filepath.Dir(frame.File)) != "matchers" {
+			locations = append(locations, fmt.Sprintf("%v:%v", frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return
+}
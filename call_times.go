@@ -0,0 +1,31 @@
+package pegomock
+
+import "time"
+
+// GetLastCallTime returns when methodName of mock was last invoked, according to
+// the mock's clock (see WithClock), e.g. to assert how long code under test
+// waited before calling it. It returns the zero time if methodName wasn't
+// invoked since the mock was created or last reset with ResetForNextTest.
+func GetLastCallTime(mock Mock, methodName string) time.Time {
+	genericMock := GetGenericMockFrom(mock)
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	var lastCallTime time.Time
+	for _, invocation := range genericMock.allMethodInvocations(methodName) {
+		if invocation.time.After(lastCallTime) {
+			lastCallTime = invocation.time
+		}
+	}
+	return lastCallTime
+}
+
+// invocationsUntil returns the invocations made at or before deadline.
+func invocationsUntil(invocations []MethodInvocation, deadline time.Time) []MethodInvocation {
+	var result []MethodInvocation
+	for _, invocation := range invocations {
+		if !invocation.time.After(deadline) {
+			result = append(result, invocation)
+		}
+	}
+	return result
+}
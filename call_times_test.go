@@ -0,0 +1,93 @@
+package pegomock_test
+
+import (
+	"time"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("Call times", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	Describe("GetLastCallTime", func() {
+		It("returns the time of the most recent invocation", func() {
+			display.Show("first")
+			first := GetLastCallTime(display, "Show")
+			time.Sleep(50 * time.Millisecond)
+			display.Show("second")
+
+			Expect(GetLastCallTime(display, "Show").Sub(first)).To(gomega.BeNumerically(">=", 50*time.Millisecond))
+			Expect(GetLastCallTime(display, "Show")).To(gomega.BeTemporally("~", time.Now(), 20*time.Millisecond))
+		})
+
+		It("returns the zero time for methods that weren't invoked since the last reset", func() {
+			Expect(GetLastCallTime(display, "Show").IsZero()).To(BeTrue())
+
+			display.Show("Hello")
+			display.ResetForNextTest()
+
+			Expect(GetLastCallTime(display, "Show").IsZero()).To(BeTrue())
+		})
+
+		It("takes the time from the mock's clock", func() {
+			clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+			display := NewMockDisplay(WithClock(clock))
+			clock.Advance(time.Minute)
+			display.Show("Hello")
+
+			Expect(GetLastCallTime(display, "Show")).To(Equal(time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC)))
+		})
+	})
+
+	Describe("VerifyWasCalledWithin", func() {
+		It("waits for the call until the timeout is over", func() {
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				display.Show("hello")
+			}()
+
+			Expect(func() { display.VerifyWasCalledWithin(2 * time.Second).Show("hello") }).NotTo(Panic())
+		})
+
+		It("fails for calls made after the timeout", func() {
+			time.Sleep(100 * time.Millisecond)
+			display.Show("hello")
+
+			Expect(func() { display.VerifyWasCalledWithin(50 * time.Millisecond).Show("hello") }).To(PanicWithMessageTo(SatisfyAll(
+				ContainSubstring("Mock invocation count for test_interface.Display.Show(\"hello\") does not match expectation"),
+				ContainSubstring("Expected to be called at least once within 50ms of the mock's start but was called 0 times"),
+			)))
+		})
+
+		It("measures the timeout from the last reset", func() {
+			time.Sleep(100 * time.Millisecond)
+			display.ResetForNextTest()
+			display.Show("hello")
+
+			Expect(func() { display.VerifyWasCalledWithin(50 * time.Millisecond).Show("hello") }).NotTo(Panic())
+		})
+
+		It("measures the timeout with the mock's clock", func() {
+			clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+			display := NewMockDisplay(WithClock(clock))
+			clock.Advance(time.Second)
+			display.Show("in time")
+			clock.Advance(time.Second)
+			display.Show("too late")
+
+			Expect(func() { display.VerifyWasCalledWithin(time.Second).Show("in time") }).NotTo(Panic())
+			Expect(func() { display.VerifyWasCalledWithin(time.Second).Show("too late") }).To(PanicWithMessageTo(
+				ContainSubstring("Expected to be called at least once within 1s of the mock's start but was called 0 times")))
+		})
+
+		It("panics on negative timeouts", func() {
+			Expect(func() { Within(-time.Second) }).To(PanicWith("Within requires a non-negative timeout, but got -1s"))
+		})
+	})
+})
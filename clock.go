@@ -0,0 +1,30 @@
+package pegomock
+
+import "time"
+
+// Clock tells mocks the time of invocations. Tests that verify timing, e.g.
+// with VerifyRetrySchedule, can pass a fake Clock with WithClock to avoid
+// depending on the system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithClock makes the mock take the time of invocations from clock instead of
+// the system clock. The mock's start, see WithinInvocationCountMatcher, becomes
+// clock's current time.
+func WithClock(clock Clock) Option {
+	return OptionFunc(func(mock Mock) {
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		defer genericMock.Unlock()
+		genericMock.clock = clock
+		genericMock.startedAt = clock.Now()
+	})
+}
+
+func now(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
@@ -0,0 +1,32 @@
+package pegomock
+
+import (
+	"sync"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var (
+	globalCmpOptionsMutex sync.Mutex
+	globalCmpOptions      []cmp.Option
+)
+
+// RegisterCmpOptions makes all Eq matchers compare with cmp.Equal and opts
+// instead of reflect.DeepEqual. This includes the matchers params are wrapped in
+// when stubbing or verifying without matchers. Failure messages then show the
+// cmp.Diff of expected and actual values. Options replace the ones registered
+// before; calling it without options restores comparing with reflect.DeepEqual.
+//
+// Note that cmp.Equal panics on unexported struct fields, unless told how to
+// handle them, e.g. with cmpopts.IgnoreUnexported or cmp.AllowUnexported.
+func RegisterCmpOptions(opts ...cmp.Option) {
+	globalCmpOptionsMutex.Lock()
+	defer globalCmpOptionsMutex.Unlock()
+	globalCmpOptions = opts
+}
+
+func registeredCmpOptions() []cmp.Option {
+	globalCmpOptionsMutex.Lock()
+	defer globalCmpOptionsMutex.Unlock()
+	return append([]cmp.Option(nil), globalCmpOptions...)
+}
@@ -0,0 +1,92 @@
+package pegomock
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConcurrentRunResult is what RunConcurrent returns. It verifies the
+// invocations all goroutines made together.
+type ConcurrentRunResult struct {
+	goroutines int
+	iterations int
+}
+
+// goroutinePanic is a panic recovered from a goroutine started by RunConcurrent.
+type goroutinePanic struct {
+	goroutine, iteration int
+	value                interface{}
+	stack                []byte
+}
+
+// RunConcurrent calls fn(goroutine, iteration) iterations times in each of
+// goroutines goroutines and waits for all of them to finish, e.g. to check
+// that code under test is safe for concurrent use:
+//
+//	result := pegomock.RunConcurrent(t, 10, 100, func(i, j int) { service.Handle(request) })
+//	result.VerifyTotalInvocations(store, "Save", result.Calls())
+//
+// A goroutine that panics stops. Once all goroutines finished, their panics
+// are reported to the GlobalFailHandler or, if there is none, to t, which is
+// usually a *testing.T.
+func RunConcurrent(t testingT, goroutines int, iterations int, fn func(i, j int)) *ConcurrentRunResult {
+	var (
+		wg      sync.WaitGroup
+		mutex   sync.Mutex
+		panics  []goroutinePanic
+		started = make(chan struct{})
+	)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			j := 0
+			defer func() {
+				if value := recover(); value != nil {
+					mutex.Lock()
+					defer mutex.Unlock()
+					panics = append(panics, goroutinePanic{goroutine: i, iteration: j, value: value, stack: debug.Stack()})
+				}
+			}()
+			// Start all goroutines at once to make them overlap as much as possible.
+			<-started
+			for ; j < iterations; j++ {
+				fn(i, j)
+			}
+		}(i)
+	}
+	close(started)
+	wg.Wait()
+
+	if len(panics) > 0 {
+		fail := GlobalFailHandler
+		if fail == nil {
+			fail = BuildTestingTFailHandler(t)
+		}
+		fail(formatGoroutinePanics(panics), 1)
+	}
+	return &ConcurrentRunResult{goroutines: goroutines, iterations: iterations}
+}
+
+func formatGoroutinePanics(panics []goroutinePanic) string {
+	sort.Slice(panics, func(i, j int) bool { return panics[i].goroutine < panics[j].goroutine })
+	messages := make([]string, len(panics))
+	for i, p := range panics {
+		messages[i] = fmt.Sprintf("Goroutine %v panicked in iteration %v: %v\n%s", p.goroutine, p.iteration, p.value, p.stack)
+	}
+	return fmt.Sprintf("%v of the goroutines run by RunConcurrent panicked:\n\n%v", len(panics), strings.Join(messages, "\n"))
+}
+
+// Calls returns how often RunConcurrent called fn, i.e. goroutines * iterations.
+func (result *ConcurrentRunResult) Calls() int {
+	return result.goroutines * result.iterations
+}
+
+// VerifyTotalInvocations verifies that methodName of mock was invoked
+// expectedTotal times by all goroutines together, whatever the params.
+func (result *ConcurrentRunResult) VerifyTotalInvocations(mock Mock, methodName string, expectedTotal int) {
+	GetGenericMockFrom(mock).VerifyAnyParams(nil, Times(expectedTotal), methodName)
+}
@@ -0,0 +1,63 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// Container is what mocks generated with --provide register themselves in.
+// Adapt your dependency injection container to it to wire mocks into it.
+type Container interface {
+	Register(iface reflect.Type, value interface{})
+}
+
+var (
+	mockProvidersMutex sync.Mutex
+	mockProviders      = make(map[reflect.Type]func(Container) Mock)
+)
+
+// RegisterMockProvider makes ProvideAllMocksFor use provide for fields of
+// type iface. Mocks generated with --provide register their Provide function
+// this way.
+func RegisterMockProvider(iface reflect.Type, provide func(Container) Mock) {
+	verify.Argument(iface.Kind() == reflect.Interface, "%v is not an interface type", iface)
+	mockProvidersMutex.Lock()
+	defer mockProvidersMutex.Unlock()
+	mockProviders[iface] = provide
+}
+
+// ProvideAllMocksFor creates a mock for every interface field of the struct
+// type depsType and registers it in c:
+//
+//	pegomock.ProvideAllMocksFor(c, reflect.TypeOf((*Deps)(nil)).Elem())
+//
+// Fields of other types are left to the caller. It panics if there's no mock
+// provider for one of the interface types, without registering any mocks.
+func ProvideAllMocksFor(c Container, depsType reflect.Type) {
+	verify.Argument(depsType.Kind() == reflect.Struct, "%v is not a struct type", depsType)
+	mockProvidersMutex.Lock()
+	var providers []func(Container) Mock
+	var missing []string
+	for i := 0; i < depsType.NumField(); i++ {
+		field := depsType.Field(i)
+		if field.Type.Kind() != reflect.Interface {
+			continue
+		}
+		if provide, exists := mockProviders[field.Type]; exists {
+			providers = append(providers, provide)
+		} else {
+			missing = append(missing, fmt.Sprintf("%v (field %v)", field.Type, field.Name))
+		}
+	}
+	mockProvidersMutex.Unlock()
+	if len(missing) > 0 {
+		panic(fmt.Sprintf("No mock provider registered for %v. Generate these mocks with --provide.", strings.Join(missing, ", ")))
+	}
+	for _, provide := range providers {
+		provide(c)
+	}
+}
@@ -0,0 +1,260 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// SliceContaining registers and returns a matcher that matches slices and
+// arrays containing all of elems, in any order, compared with reflect.DeepEqual.
+// It's meant for interface{} parameters; for slice-typed parameters, use
+// SliceContainingOf on Go 1.18 and newer.
+func SliceContaining(elems ...interface{}) Matcher {
+	matcher := &SliceContainingMatcher{Elems: elems}
+	RegisterMatcher(matcher)
+	return matcher
+}
+
+// MapContaining registers and returns a matcher that matches maps with an entry
+// for key whose value is reflect.DeepEqual to value. It's meant for interface{}
+// parameters; for map-typed parameters, use MapContainingOf on Go 1.18 and newer.
+func MapContaining(key, value interface{}) Matcher {
+	matcher := &MapContainingMatcher{Key: key, Value: value}
+	RegisterMatcher(matcher)
+	return matcher
+}
+
+// StringMatching matches strings that contain a match of the regular expression
+// pattern. Anchor the pattern to match whole strings, e.g.
+// StringMatching("^user-[0-9]+$").
+func StringMatching(pattern string) string {
+	compiled, e := regexp.Compile(pattern)
+	verify.Argument(e == nil, "StringMatching needs a valid regular expression: %v", e)
+	RegisterMatcher(&StringMatchingMatcher{Regexp: compiled})
+	return ""
+}
+
+// FieldEqual registers and returns a matcher that matches structs, or pointers
+// to structs, whose field at fieldPath is reflect.DeepEqual to value. fieldPath
+// names exported fields separated by dots, e.g. "Address.City", and follows
+// pointers on the way. It's meant for interface{} parameters; for struct-typed
+// parameters, use FieldEqualOf on Go 1.18 and newer.
+func FieldEqual(fieldPath string, value interface{}) Matcher {
+	verify.Argument(fieldPath != "", "FieldEqual needs a field path, e.g. \"Address.City\"")
+	matcher := &FieldEqualMatcher{FieldPath: fieldPath, Value: value}
+	RegisterMatcher(matcher)
+	return matcher
+}
+
+type SliceContainingMatcher struct {
+	Elems   []interface{}
+	actual  Param
+	missing []interface{}
+	sync.Mutex
+}
+
+func (matcher *SliceContainingMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	matcher.missing = nil
+	value := reflect.ValueOf(param)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		matcher.missing = matcher.Elems
+		return false
+	}
+	for _, elem := range matcher.Elems {
+		if !containsElem(value, elem) {
+			matcher.missing = append(matcher.missing, elem)
+		}
+	}
+	return len(matcher.missing) == 0
+}
+
+func containsElem(value reflect.Value, elem interface{}) bool {
+	for i := 0; i < value.Len(); i++ {
+		if reflect.DeepEqual(value.Index(i).Interface(), elem) {
+			return true
+		}
+	}
+	return false
+}
+
+func (matcher *SliceContainingMatcher) FailureMessage() string {
+	kind := reflect.ValueOf(matcher.actual).Kind()
+	if kind != reflect.Slice && kind != reflect.Array {
+		return fmt.Sprintf("Expected: slice containing %v; but got: %v (%T), which is not a slice",
+			formatElems(matcher.Elems), formatCycleSafe("%v", matcher.actual), matcher.actual)
+	}
+	return fmt.Sprintf("Expected: slice containing %v; but got: %v, which is missing %v",
+		formatElems(matcher.Elems), formatCycleSafe("%v", matcher.actual), formatElems(matcher.missing))
+}
+
+func (matcher *SliceContainingMatcher) String() string {
+	return fmt.Sprintf("SliceContaining(%v)", formatElems(matcher.Elems))
+}
+
+func formatElems(elems []interface{}) string {
+	formatted := make([]string, len(elems))
+	for i, elem := range elems {
+		formatted[i] = formatCycleSafe("%#v", elem)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+type MapContainingMatcher struct {
+	Key    Param
+	Value  Param
+	actual Param
+	sync.Mutex
+}
+
+func (matcher *MapContainingMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	actualValue, found := matcher.lookup()
+	return found && reflect.DeepEqual(actualValue.Interface(), matcher.Value)
+}
+
+// lookup returns the value actual has for Key, if actual is a map that has an
+// entry for it.
+func (matcher *MapContainingMatcher) lookup() (reflect.Value, bool) {
+	mapValue := reflect.ValueOf(matcher.actual)
+	if mapValue.Kind() != reflect.Map || mapValue.IsNil() {
+		return reflect.Value{}, false
+	}
+	keyType := mapValue.Type().Key()
+	key := reflect.ValueOf(matcher.Key)
+	if !key.IsValid() {
+		switch keyType.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Chan:
+			key = reflect.Zero(keyType)
+		default:
+			return reflect.Value{}, false
+		}
+	}
+	if !key.Type().AssignableTo(keyType) {
+		return reflect.Value{}, false
+	}
+	value := mapValue.MapIndex(key)
+	return value, value.IsValid()
+}
+
+func (matcher *MapContainingMatcher) FailureMessage() string {
+	expected := fmt.Sprintf("map containing %v: %v", formatCycleSafe("%#v", matcher.Key), formatCycleSafe("%#v", matcher.Value))
+	if reflect.ValueOf(matcher.actual).Kind() != reflect.Map {
+		return fmt.Sprintf("Expected: %v; but got: %v (%T), which is not a map", expected, formatCycleSafe("%v", matcher.actual), matcher.actual)
+	}
+	actualValue, found := matcher.lookup()
+	if !found {
+		return fmt.Sprintf("Expected: %v; but got: %v, which has no such key", expected, formatCycleSafe("%v", matcher.actual))
+	}
+	return fmt.Sprintf("Expected: %v; but got: %v, which maps the key to %v",
+		expected, formatCycleSafe("%v", matcher.actual), formatCycleSafe("%#v", actualValue.Interface()))
+}
+
+func (matcher *MapContainingMatcher) String() string {
+	return fmt.Sprintf("MapContaining(%v, %v)", formatCycleSafe("%#v", matcher.Key), formatCycleSafe("%#v", matcher.Value))
+}
+
+type StringMatchingMatcher struct {
+	Regexp *regexp.Regexp
+	actual Param
+	sync.Mutex
+}
+
+func (matcher *StringMatchingMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	value := reflect.ValueOf(param)
+	return value.Kind() == reflect.String && matcher.Regexp.MatchString(value.String())
+}
+
+func (matcher *StringMatchingMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: string matching /%v/; but got: %#v", matcher.Regexp, matcher.actual)
+}
+
+func (matcher *StringMatchingMatcher) String() string {
+	return fmt.Sprintf("StringMatching(%q)", matcher.Regexp)
+}
+
+type FieldEqualMatcher struct {
+	FieldPath string
+	Value     Param
+	actual    Param
+	sync.Mutex
+}
+
+func (matcher *FieldEqualMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	field, problem := matcher.field()
+	return problem == "" && reflect.DeepEqual(field.Interface(), matcher.Value)
+}
+
+// field follows FieldPath through actual and returns the field it leads to, or
+// why it doesn't lead to any.
+func (matcher *FieldEqualMatcher) field() (reflect.Value, string) {
+	value := reflect.ValueOf(matcher.actual)
+	path := ""
+	for _, name := range strings.Split(matcher.FieldPath, ".") {
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return reflect.Value{}, fmt.Sprintf("%v is nil", describePath(path))
+			}
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Sprintf("%v is not a struct", describePath(path))
+		}
+		structField, found := value.Type().FieldByName(name)
+		if !found || structField.PkgPath != "" {
+			return reflect.Value{}, fmt.Sprintf("%v has no exported field %v", describePath(path), name)
+		}
+		path = strings.TrimPrefix(path+"."+name, ".")
+		// Fields promoted from embedded structs can be reached through nil pointers.
+		for i, index := range structField.Index {
+			if i > 0 && value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					return reflect.Value{}, fmt.Sprintf("the struct embedding %v is nil", path)
+				}
+				value = value.Elem()
+			}
+			value = value.Field(index)
+		}
+	}
+	return value, ""
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "the value"
+	}
+	return "field " + path
+}
+
+func (matcher *FieldEqualMatcher) FailureMessage() string {
+	expected := fmt.Sprintf("%v == %v", matcher.FieldPath, formatCycleSafe("%#v", matcher.Value))
+	field, problem := matcher.field()
+	if problem != "" {
+		return fmt.Sprintf("Expected: %v; but got: %v, where %v", expected, formatCycleSafe("%+v", matcher.actual), problem)
+	}
+	return fmt.Sprintf("Expected: %v; but got: %v, where %v == %v",
+		expected, formatCycleSafe("%+v", matcher.actual), matcher.FieldPath, formatCycleSafe("%#v", field.Interface()))
+}
+
+func (matcher *FieldEqualMatcher) String() string {
+	return fmt.Sprintf("FieldEqual(%q, %v)", matcher.FieldPath, formatCycleSafe("%#v", matcher.Value))
+}
@@ -0,0 +1,162 @@
+package pegomock_test
+
+import (
+	"regexp"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+type customerAddress struct {
+	City string
+}
+
+type customer struct {
+	Name    string
+	Address *customerAddress
+	*customerContact
+	secret string
+}
+
+type customerContact struct {
+	Email string
+}
+
+var _ = Describe("Container matchers", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	Describe("SliceContaining", func() {
+		It("matches slices and arrays containing all elements in any order", func() {
+			display.InterfaceParam([]string{"a", "b", "c"})
+			display.InterfaceParam([2]string{"c", "a"})
+			display.InterfaceParam([]string{"b"})
+			display.InterfaceParam("a")
+			display.InterfaceParam(nil)
+
+			display.VerifyWasCalled(Times(2)).InterfaceParam(SliceContaining("c", "a"))
+			display.VerifyWasCalled(Times(2)).InterfaceParam(SliceContaining("b"))
+			display.VerifyWasCalled(Times(3)).InterfaceParam(SliceContaining())
+		})
+
+		It("can be used for stubbing", func() {
+			When(func() { display.InterfaceParam(SliceContaining(2)) }).Then(func([]Param) ReturnValues { panic("stubbed") })
+
+			Expect(func() { display.InterfaceParam([]int{1, 2}) }).To(Panic())
+			Expect(func() { display.InterfaceParam([]int{1, 3}) }).NotTo(Panic())
+		})
+
+		It("reports the missing elements in its failure message", func() {
+			matcher := &SliceContainingMatcher{Elems: []interface{}{"a", "b"}}
+
+			Expect(matcher.Matches([]string{"b", "c"})).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: slice containing "a", "b"; but got: [b c], which is missing "a"`))
+			Expect(matcher.Matches(nil)).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: slice containing "a", "b"; but got: <nil> (<nil>), which is not a slice`))
+			Expect(matcher.String()).To(Equal(`SliceContaining("a", "b")`))
+		})
+	})
+
+	Describe("MapContaining", func() {
+		It("matches maps with an entry of the given key and value", func() {
+			display.InterfaceParam(map[string]int{"a": 1, "b": 2})
+			display.InterfaceParam(map[string]int{"a": 2})
+			display.InterfaceParam(map[int]int{1: 1})
+			display.InterfaceParam(map[string]int(nil))
+			display.InterfaceParam(nil)
+
+			display.VerifyWasCalledOnce().InterfaceParam(MapContaining("a", 1))
+			display.VerifyWasCalled(Never()).InterfaceParam(MapContaining("c", 0))
+		})
+
+		It("reports the value found for the key in its failure message", func() {
+			matcher := &MapContainingMatcher{Key: "a", Value: 1}
+
+			Expect(matcher.Matches(map[string]int{"a": 2})).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: map containing "a": 1; but got: map[a:2], which maps the key to 2`))
+			Expect(matcher.Matches(map[string]int{"b": 1})).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: map containing "a": 1; but got: map[b:1], which has no such key`))
+			Expect(matcher.Matches(nil)).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: map containing "a": 1; but got: <nil> (<nil>), which is not a map`))
+		})
+	})
+
+	Describe("StringMatching", func() {
+		It("matches strings containing a match of the regular expression", func() {
+			display.Show("user-42")
+			display.Show("admin-user-7")
+			display.Show("guest")
+
+			display.VerifyWasCalled(Times(2)).Show(StringMatching("user-[0-9]+"))
+			display.VerifyWasCalledOnce().Show(StringMatching("^user-[0-9]+$"))
+		})
+
+		It("can be mixed with raw values for stubbing", func() {
+			When(display.MultipleParamsAndReturnValue(StringMatching("^a"), 1)).ThenReturn("stubbed")
+
+			Expect(display.MultipleParamsAndReturnValue("abc", 1)).To(Equal("stubbed"))
+			Expect(display.MultipleParamsAndReturnValue("bc", 1)).To(Equal(""))
+		})
+
+		It("reports the actual value in its failure message", func() {
+			matcher := &StringMatchingMatcher{Regexp: regexp.MustCompile("^a")}
+
+			Expect(matcher.Matches("b")).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: string matching /^a/; but got: "b"`))
+			Expect(matcher.Matches(nil)).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: string matching /^a/; but got: <nil>`))
+		})
+
+		It("panics when given an invalid regular expression", func() {
+			Expect(func() { StringMatching("(") }).To(Panic())
+		})
+	})
+
+	Describe("FieldEqual", func() {
+		It("matches structs and pointers to structs by a field", func() {
+			display.InterfaceParam(customer{Name: "Alice"})
+			display.InterfaceParam(&customer{Name: "Alice"})
+			display.InterfaceParam(&customer{Name: "Bob"})
+			display.InterfaceParam((*customer)(nil))
+			display.InterfaceParam(nil)
+
+			display.VerifyWasCalled(Times(2)).InterfaceParam(FieldEqual("Name", "Alice"))
+		})
+
+		It("follows dotted paths through nested structs, pointers and embedded structs", func() {
+			display.InterfaceParam(&customer{Address: &customerAddress{City: "Berlin"}, customerContact: &customerContact{Email: "a@example.com"}})
+			display.InterfaceParam(&customer{Address: &customerAddress{City: "Paris"}})
+			display.InterfaceParam(&customer{})
+
+			display.VerifyWasCalledOnce().InterfaceParam(FieldEqual("Address.City", "Berlin"))
+			display.VerifyWasCalledOnce().InterfaceParam(FieldEqual("Email", "a@example.com"))
+			display.VerifyWasCalled(Never()).InterfaceParam(FieldEqual("secret", ""))
+		})
+
+		It("reports the actual field value or why the path can't be followed in its failure message", func() {
+			matcher := &FieldEqualMatcher{FieldPath: "Address.City", Value: "Berlin"}
+
+			Expect(matcher.Matches(customer{Name: "Bob", Address: &customerAddress{City: "Paris"}})).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(HavePrefix(`Expected: Address.City == "Berlin"; but got: {Name:Bob Address:`))
+			Expect(matcher.FailureMessage()).To(gomega.HaveSuffix(`, where Address.City == "Paris"`))
+			Expect(matcher.Matches(customer{Name: "Bob"})).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(gomega.HaveSuffix(", where field Address is nil"))
+			Expect(matcher.Matches("Bob")).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: Address.City == "Berlin"; but got: Bob, where the value is not a struct`))
+			Expect(matcher.Matches(customerAddress{})).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(gomega.HaveSuffix("where the value has no exported field Address"))
+			Expect(matcher.Matches(nil)).To(gomega.BeFalse())
+		})
+
+		It("doesn't panic on fields promoted from nil embedded structs", func() {
+			matcher := &FieldEqualMatcher{FieldPath: "Email", Value: ""}
+
+			Expect(matcher.Matches(customer{})).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(gomega.HaveSuffix("where the struct embedding Email is nil"))
+		})
+	})
+})
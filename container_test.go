@@ -0,0 +1,79 @@
+package pegomock_test
+
+import (
+	"reflect"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/petergtz/pegomock/test_interface"
+)
+
+type mapContainer map[reflect.Type]interface{}
+
+func (c mapContainer) Register(iface reflect.Type, value interface{}) { c[iface] = value }
+
+type statusDisplay interface{ test_interface.Display }
+
+type errorDisplay interface{ test_interface.Display }
+
+type dependencies struct {
+	Display test_interface.Display
+	Status  statusDisplay
+	Errors  errorDisplay
+	Name    string
+}
+
+func provideMockDisplayAs(iface reflect.Type) func(Container) Mock {
+	return func(c Container) Mock {
+		mock := NewMockDisplay()
+		c.Register(iface, mock)
+		return mock
+	}
+}
+
+var _ = Describe("Providing mocks to a dependency container", func() {
+	var c mapContainer
+
+	BeforeEach(func() {
+		c = make(mapContainer)
+	})
+
+	It("registers a generated mock with Provide<MockName>", func() {
+		display := ProvideMockDisplay(c)
+
+		Expect(c).To(gomega.HaveKeyWithValue(reflect.TypeOf((*test_interface.Display)(nil)).Elem(), gomega.BeIdenticalTo(display)))
+	})
+
+	It("provides mocks for all interface fields of a struct", func() {
+		statusType := reflect.TypeOf((*statusDisplay)(nil)).Elem()
+		errorType := reflect.TypeOf((*errorDisplay)(nil)).Elem()
+		RegisterMockProvider(statusType, provideMockDisplayAs(statusType))
+		RegisterMockProvider(errorType, provideMockDisplayAs(errorType))
+
+		ProvideAllMocksFor(c, reflect.TypeOf((*dependencies)(nil)).Elem())
+
+		Expect(c).To(HaveLen(3))
+		deps := dependencies{
+			Display: c[reflect.TypeOf((*test_interface.Display)(nil)).Elem()].(test_interface.Display),
+			Status:  c[statusType].(statusDisplay),
+			Errors:  c[errorType].(errorDisplay),
+		}
+		deps.Status.Show("Hello")
+		deps.Status.(*MockDisplay).VerifyWasCalledOnce().Show("Hello")
+		deps.Display.(*MockDisplay).VerifyWasNeverCalled().Show("Hello")
+		Expect(deps.Errors).NotTo(gomega.BeIdenticalTo(deps.Status))
+	})
+
+	It("panics without registering anything if a field's interface has no mock provider", func() {
+		type incompleteDependencies struct {
+			Display test_interface.Display
+			Other   interface{ Other() }
+		}
+
+		Expect(func() {
+			ProvideAllMocksFor(c, reflect.TypeOf((*incompleteDependencies)(nil)).Elem())
+		}).To(gomega.PanicWith("No mock provider registered for interface { Other() } (field Other). Generate these mocks with --provide."))
+		Expect(c).To(gomega.BeEmpty())
+	})
+})
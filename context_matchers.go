@@ -0,0 +1,20 @@
+package pegomock
+
+import (
+	"context"
+	"reflect"
+)
+
+// AnyContext matches any context.Context, including derived contexts such as
+// those returned by context.WithCancel.
+func AnyContext() context.Context {
+	RegisterMatcher(NewAnyMatcher(reflect.TypeOf((*context.Context)(nil)).Elem()))
+	return nil
+}
+
+// EqContext matches only the given context itself. Contexts are compared by
+// identity, because deep equality is meaningless for them.
+func EqContext(value context.Context) context.Context {
+	RegisterMatcher(&SameMatcher{Value: value})
+	return nil
+}
@@ -0,0 +1,114 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// DeepEqualMode configures how Eq matchers compare expected and actual values.
+// Modes are combined with |, see SetDeepEqualMode.
+type DeepEqualMode int
+
+const (
+	// StrictDeepEqual compares with reflect.DeepEqual, or with cmp.Equal if cmp
+	// options apply. Used on its own, a panicking comparison panics the test.
+	StrictDeepEqual DeepEqualMode = 1 << iota
+	// RecoverOnPanic makes a comparison that panics not match instead of
+	// panicking the test, e.g. cmp.Equal on structs with unexported fields.
+	RecoverOnPanic
+	// FmtFallback makes values of the same type that are not deeply equal match
+	// nonetheless if they format the same with %v. Mocks created WithLogger log
+	// a warning when a stubbing or verification matched this way. This helps
+	// with values that are equal, but not deeply equal, e.g.
+	// structs holding the same non-nil func, at the risk of matching values that
+	// only look alike.
+	FmtFallback
+)
+
+// DefaultDeepEqualMode is the mode Eq matchers compare with unless changed with
+// SetDeepEqualMode.
+const DefaultDeepEqualMode = StrictDeepEqual | RecoverOnPanic
+
+var (
+	globalDeepEqualModeMutex sync.Mutex
+	globalDeepEqualMode      = DefaultDeepEqualMode
+)
+
+// SetDeepEqualMode sets how all Eq matchers compare, including the matchers
+// params are wrapped in when stubbing or verifying without matchers, e.g.
+//
+//	pegomock.SetDeepEqualMode(pegomock.StrictDeepEqual | pegomock.RecoverOnPanic | pegomock.FmtFallback)
+//
+// Call it with DefaultDeepEqualMode to restore the default.
+func SetDeepEqualMode(mode DeepEqualMode) {
+	globalDeepEqualModeMutex.Lock()
+	defer globalDeepEqualModeMutex.Unlock()
+	globalDeepEqualMode = mode
+}
+
+func deepEqualMode() DeepEqualMode {
+	globalDeepEqualModeMutex.Lock()
+	defer globalDeepEqualModeMutex.Unlock()
+	return globalDeepEqualMode
+}
+
+// equalInMode reports whether expected and actual are equal according to equal
+// and mode. If they are only because they format the same, see FmtFallback,
+// fmtFallbackWarning says so.
+func equalInMode(mode DeepEqualMode, expected, actual Param, equal func() bool) (result bool, fmtFallbackWarning string) {
+	if safelyEqual(mode, equal) {
+		return true, ""
+	}
+	if mode&FmtFallback == 0 || reflect.TypeOf(expected) != reflect.TypeOf(actual) {
+		return false, ""
+	}
+	formattedExpected, formattedActual := formatCycleSafe("%v", expected), formatCycleSafe("%v", actual)
+	if formattedExpected != formattedActual {
+		return false, ""
+	}
+	return true, fmt.Sprintf("pegomock matched %v and %v of type %T, which are not deeply equal, because they format the same",
+		formattedExpected, formattedActual, actual)
+}
+
+// logFmtFallbackWarnings logs a warning to logger for every Eq matcher among
+// matchers that matches its param in params only because it formats the same.
+// Matchers of variadic params are not checked.
+func logFmtFallbackWarnings(logger invocationLogger, methodName string, matchers []Matcher, params []Param) {
+	if logger == nil || deepEqualMode()&FmtFallback == 0 {
+		return
+	}
+	for i, matcher := range matchers {
+		eqMatcher, isEqMatcher := matcher.(*EqMatcher)
+		if !isEqMatcher || i >= len(params) {
+			continue
+		}
+		if _, warning := eqMatcher.matchesWithFmtFallbackWarning(params[i]); warning != "" {
+			logger.logWarning(methodName, warning)
+		}
+	}
+}
+
+func safelyEqual(mode DeepEqualMode, equal func() bool) (result bool) {
+	if mode&RecoverOnPanic != 0 {
+		defer func() {
+			if recover() != nil {
+				result = false
+			}
+		}()
+	}
+	return equal()
+}
+
+// recoveredDifferences is like calling differences, but turns a panic into a
+// note about it if mode recovers from panics.
+func recoveredDifferences(mode DeepEqualMode, differences func() string) (result string) {
+	if mode&RecoverOnPanic != 0 {
+		defer func() {
+			if r := recover(); r != nil {
+				result = fmt.Sprintf("Comparing panicked: %v", r)
+			}
+		}()
+	}
+	return differences()
+}
@@ -0,0 +1,195 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// valueDifference is a single discrepancy found by diffValues. path is empty if
+// the values differ as a whole, otherwise it names the differing struct field,
+// slice or array index, or map key, e.g. "Address.Lines[1]".
+type valueDifference struct {
+	path     string
+	expected string
+	actual   string
+}
+
+func (difference valueDifference) String() string {
+	if difference.path == "" {
+		return fmt.Sprintf("expected %v, got %v", difference.expected, difference.actual)
+	}
+	return fmt.Sprintf("%v: expected %v, got %v", difference.path, difference.expected, difference.actual)
+}
+
+// maxDiffDepth and maxDiffValues bound how deep and how many values diffValues
+// walks, so e.g. long linked lists don't make failure messages take forever.
+const (
+	maxDiffDepth  = 100
+	maxDiffValues = 100000
+)
+
+// diffValues walks expected and actual like reflect.DeepEqual does and returns
+// the differences between them. Unlike %v, it also takes unexported fields into
+// account, which is where seemingly identical structs usually differ. If the
+// walk exceeded maxDiffDepth or maxDiffValues, abortNote says so; differences
+// then only covers the part walked so far.
+func diffValues(expected, actual interface{}) (differences []valueDifference, abortNote string) {
+	differ := &differ{visited: make(map[[2]uintptr]bool)}
+	differ.diff("", reflect.ValueOf(expected), reflect.ValueOf(actual))
+	return differ.differences, differ.abortNote
+}
+
+type differ struct {
+	differences []valueDifference
+	// visited holds pairs of pointers already being compared, so cyclic
+	// structures don't make diff recurse forever.
+	visited   map[[2]uintptr]bool
+	depth     int
+	walked    int
+	abortNote string
+}
+
+func (differ *differ) diff(path string, expected, actual reflect.Value) {
+	if differ.abortNote != "" {
+		return
+	}
+	if differ.depth >= maxDiffDepth {
+		differ.abortNote = fmt.Sprintf("comparison aborted at depth %v; use a custom matcher", maxDiffDepth)
+		return
+	}
+	if differ.walked >= maxDiffValues {
+		differ.abortNote = fmt.Sprintf("comparison aborted after %v values; use a custom matcher", maxDiffValues)
+		return
+	}
+	differ.depth++
+	differ.walked++
+	defer func() { differ.depth-- }()
+
+	if !expected.IsValid() || !actual.IsValid() || expected.Type() != actual.Type() {
+		if expected.IsValid() || actual.IsValid() {
+			differ.add(path, expected, actual)
+		}
+		return
+	}
+	switch expected.Kind() {
+	case reflect.Struct:
+		for i := 0; i < expected.NumField(); i++ {
+			differ.diff(joinPath(path, expected.Type().Field(i).Name), expected.Field(i), actual.Field(i))
+		}
+	case reflect.Array:
+		differ.diffElements(path, expected, actual)
+	case reflect.Slice:
+		if expected.IsNil() != actual.IsNil() {
+			differ.add(path, expected, actual)
+			return
+		}
+		if expected.Len() != actual.Len() {
+			differ.differences = append(differ.differences, valueDifference{
+				path:     path,
+				expected: fmt.Sprintf("length %v", expected.Len()),
+				actual:   fmt.Sprintf("length %v", actual.Len()),
+			})
+		}
+		differ.diffElements(path, expected, actual)
+	case reflect.Map:
+		if expected.IsNil() != actual.IsNil() {
+			differ.add(path, expected, actual)
+			return
+		}
+		differ.diffMaps(path, expected, actual)
+	case reflect.Ptr:
+		if expected.Pointer() == actual.Pointer() {
+			return
+		}
+		if expected.IsNil() || actual.IsNil() {
+			differ.add(path, expected, actual)
+			return
+		}
+		pointers := [2]uintptr{expected.Pointer(), actual.Pointer()}
+		if differ.visited[pointers] {
+			return
+		}
+		differ.visited[pointers] = true
+		differ.diff(path, expected.Elem(), actual.Elem())
+	case reflect.Interface:
+		if expected.IsNil() || actual.IsNil() {
+			if expected.IsNil() != actual.IsNil() {
+				differ.add(path, expected, actual)
+			}
+			return
+		}
+		differ.diff(path, expected.Elem(), actual.Elem())
+	default:
+		if !leafValuesEqual(expected, actual) {
+			differ.add(path, expected, actual)
+		}
+	}
+}
+
+func (differ *differ) diffElements(path string, expected, actual reflect.Value) {
+	for i := 0; i < expected.Len() && i < actual.Len(); i++ {
+		differ.diff(fmt.Sprintf("%v[%v]", path, i), expected.Index(i), actual.Index(i))
+	}
+}
+
+func (differ *differ) diffMaps(path string, expected, actual reflect.Value) {
+	keys := expected.MapKeys()
+	for _, key := range actual.MapKeys() {
+		if !expected.MapIndex(key).IsValid() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprintf("%#v", keys[i]) < fmt.Sprintf("%#v", keys[j]) })
+	for _, key := range keys {
+		differ.diff(fmt.Sprintf("%v[%#v]", path, key), expected.MapIndex(key), actual.MapIndex(key))
+	}
+}
+
+func (differ *differ) add(path string, expected, actual reflect.Value) {
+	differ.differences = append(differ.differences, valueDifference{
+		path:     path,
+		expected: formatValue(expected),
+		actual:   formatValue(actual),
+	})
+}
+
+func formatValue(value reflect.Value) string {
+	if !value.IsValid() {
+		return "nothing"
+	}
+	return formatReflectValueCycleSafe("%#v", value)
+}
+
+func joinPath(path, fieldName string) string {
+	if path == "" {
+		return fieldName
+	}
+	return path + "." + fieldName
+}
+
+// leafValuesEqual compares values of the remaining kinds via the kind-specific
+// getters, since Interface() panics for values obtained from unexported fields.
+func leafValuesEqual(expected, actual reflect.Value) bool {
+	switch expected.Kind() {
+	case reflect.Bool:
+		return expected.Bool() == actual.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return expected.Int() == actual.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return expected.Uint() == actual.Uint()
+	case reflect.Float32, reflect.Float64:
+		return expected.Float() == actual.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return expected.Complex() == actual.Complex()
+	case reflect.String:
+		return expected.String() == actual.String()
+	default:
+		// Chan, Func and UnsafePointer. Like reflect.DeepEqual, consider
+		// functions equal only if both are nil.
+		if expected.Kind() == reflect.Func {
+			return expected.IsNil() && actual.IsNil()
+		}
+		return expected.Pointer() == actual.Pointer()
+	}
+}
@@ -17,9 +17,13 @@ package pegomock
 import (
 	"bytes"
 	"fmt"
+	"path"
 	"reflect"
+	"runtime"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,20 +35,144 @@ var GlobalFailHandler FailHandler
 
 func RegisterMockFailHandler(handler FailHandler) {
 	GlobalFailHandler = handler
+	setCurrentFailureBuffer(nil)
 }
 func RegisterMockTestingT(t *testing.T) {
 	RegisterMockFailHandler(BuildTestingTFailHandler(t))
 }
 
+// The last invocation and the registered argument matchers are tracked per
+// goroutine. This way, tests running in parallel (e.g. using t.Parallel())
+// cannot corrupt each other's stubbings and verifications.
 var (
-	lastInvocation      *invocation
-	lastInvocationMutex sync.Mutex
+	lastInvocations      = make(map[int64]*invocation)
+	lastInvocationsMutex sync.Mutex
 )
 
-var globalArgMatchers Matchers
+// An invocation can't tell if When() is going to consume it, so goroutines that
+// call mocks leave their last invocation behind when they finish. Once more
+// goroutines than lastInvocationsPruneThreshold have one, the entries of
+// finished goroutines are deleted. The threshold grows with the goroutines
+// that are still running, so this stays rare.
+const minLastInvocationsPruneThreshold = 100
+
+var lastInvocationsPruneThreshold = minLastInvocationsPruneThreshold // guarded by lastInvocationsMutex
+
+var (
+	globalArgMatchers      = make(map[int64]Matchers)
+	globalArgMatchersMutex sync.Mutex
+)
 
 func RegisterMatcher(matcher Matcher) {
-	globalArgMatchers.append(matcher)
+	globalArgMatchersMutex.Lock()
+	defer globalArgMatchersMutex.Unlock()
+	id := currentGoroutineID()
+	globalArgMatchers[id] = append(globalArgMatchers[id], matcher)
+	recordArgMatcherCaller(id)
+}
+
+func argMatchersOfCurrentGoroutine() Matchers {
+	globalArgMatchersMutex.Lock()
+	defer globalArgMatchersMutex.Unlock()
+	return globalArgMatchers[currentGoroutineID()]
+}
+
+func clearArgMatchersOfCurrentGoroutine() {
+	globalArgMatchersMutex.Lock()
+	defer globalArgMatchersMutex.Unlock()
+	id := currentGoroutineID()
+	delete(globalArgMatchers, id)
+	clearArgMatcherCallers(id)
+}
+
+func setLastInvocationOfCurrentGoroutine(lastInvocation *invocation) {
+	lastInvocationsMutex.Lock()
+	lastInvocations[currentGoroutineID()] = lastInvocation
+	pruneDue := len(lastInvocations) > lastInvocationsPruneThreshold
+	lastInvocationsMutex.Unlock()
+	if pruneDue {
+		forgetFinishedGoroutines()
+	}
+}
+
+// setLastInvocationOfCurrentGoroutineCopyingParams is like
+// setLastInvocationOfCurrentGoroutine, but copies lastInvocation and its params,
+// reusing the copy made for the previous invocation on the goroutine if possible.
+func setLastInvocationOfCurrentGoroutineCopyingParams(lastInvocation invocation) {
+	lastInvocationsMutex.Lock()
+	id := currentGoroutineID()
+	params := lastInvocation.Params
+	reused := lastInvocations[id]
+	if reused == nil || !reused.ownsParams {
+		reused = &invocation{}
+		lastInvocations[id] = reused
+	}
+	reusedParams := reused.Params[:0]
+	*reused = lastInvocation
+	reused.Params = append(reusedParams, params...)
+	reused.ownsParams = true
+	pruneDue := len(lastInvocations) > lastInvocationsPruneThreshold
+	lastInvocationsMutex.Unlock()
+	if pruneDue {
+		forgetFinishedGoroutines()
+	}
+}
+
+func lastInvocationOfCurrentGoroutine() *invocation {
+	lastInvocationsMutex.Lock()
+	defer lastInvocationsMutex.Unlock()
+	return lastInvocations[currentGoroutineID()]
+}
+
+func clearLastInvocationOfCurrentGoroutine() {
+	lastInvocationsMutex.Lock()
+	defer lastInvocationsMutex.Unlock()
+	delete(lastInvocations, currentGoroutineID())
+}
+
+// forgetFinishedGoroutines deletes the last invocations and argument matchers
+// of goroutines that have finished.
+func forgetFinishedGoroutines() {
+	live := liveGoroutineIDs()
+	lastInvocationsMutex.Lock()
+	for id := range lastInvocations {
+		if !live[id] {
+			delete(lastInvocations, id)
+		}
+	}
+	lastInvocationsPruneThreshold = 2 * len(lastInvocations)
+	if lastInvocationsPruneThreshold < minLastInvocationsPruneThreshold {
+		lastInvocationsPruneThreshold = minLastInvocationsPruneThreshold
+	}
+	lastInvocationsMutex.Unlock()
+
+	globalArgMatchersMutex.Lock()
+	for id := range globalArgMatchers {
+		if !live[id] {
+			delete(globalArgMatchers, id)
+		}
+	}
+	globalArgMatchersMutex.Unlock()
+
+	argMatcherCallersMutex.Lock()
+	for id := range argMatcherCallers {
+		if !live[id] {
+			delete(argMatcherCallers, id)
+		}
+	}
+	argMatcherCallersMutex.Unlock()
+}
+
+// clearGoroutineStateOnPanic must be deferred. If the calling function panics,
+// e.g. because a method was stubbed with ThenPanic, it clears the last invocation
+// and argument matchers of the current goroutine before panicking on, so a test
+// that recovers doesn't stub or verify with what they were left at.
+func clearGoroutineStateOnPanic() {
+	if r := recover(); r != nil {
+		clearLastInvocationOfCurrentGoroutine()
+		clearArgMatchersOfCurrentGoroutine()
+		panic(r)
+	}
 }
 
 type invocation struct {
@@ -52,39 +180,237 @@ type invocation struct {
 	MethodName  string
 	Params      []Param
 	ReturnTypes []reflect.Type
+	// pendingArgMatchers is the number of argument matchers registered on the
+	// goroutine when the invocation happened.
+	pendingArgMatchers int
+	// stubbedAt is where When stubbed the invocation, or empty if it didn't.
+	stubbedAt string
+	// recorded is false if the invocation was not added to the invocation
+	// history, because recording was disabled with DisableRecording.
+	recorded bool
+	// ownsParams is set if Params is a copy that can be overwritten by the next
+	// invocation on the goroutine.
+	ownsParams bool
+	// stubberCall is set if the invocation only told Stubber.When which method
+	// to stub, so no stubbing answered it.
+	stubberCall bool
 }
 
 type GenericMock struct {
 	sync.Mutex
 	mockedMethods map[string]*mockedMethod
 	fail          FailHandler
+	mockTypeName  string
+	// label is how failure messages refer to the mock, e.g. "storage.Repository".
+	// It is empty for mocks that don't implement MockWithInterfaceName.
+	label string
+	// methodMetadata is nil for mocks that don't implement MockWithMethodMetadata.
+	methodMetadata map[string]MethodMetadata
+	fallback       func(methodName string, params []Param) ReturnValues
+	// mock is nil for GenericMocks not created by GetGenericMockFrom.
+	mock             Mock
+	invocationLogger invocationLogger
+	// invocationLimit is 0 for mocks that retain all invocations.
+	invocationLimit int
+	// clock is nil for mocks that take invocation times from the system clock.
+	clock Clock
+	// startedAt is when the mock was created or last reset, according to clock.
+	// See WithinInvocationCountMatcher.
+	startedAt time.Time
+	// recordingDisabled is set atomically by DisableRecording.
+	recordingDisabled int32
+	// returnsSelf is set by ReturnSelfByDefault.
+	returnsSelf bool
+	// smartNulls is set by ReturnSmartNullsByDefault.
+	smartNulls *smartNulls
+}
+
+// invocationLogger is notified of every invocation of a mock that isn't part of
+// stubbing, and of warnings about how they were matched. See WithLogger.
+type invocationLogger interface {
+	logInvocation(methodName string, params []Param, matchedStubbing bool, orderingNumber int)
+	logWarning(methodName string, message string)
 }
 
+// SetFallback makes Invoke call fallback for invocations that match no stubbing,
+// instead of returning zero values. Generated spies use it to pass such calls
+// through to the real implementation. Invocations made while stubbing with
+// argument matchers, i.e. inside When, never reach fallback.
+func (genericMock *GenericMock) SetFallback(fallback func(methodName string, params []Param) ReturnValues) {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	genericMock.fallback = fallback
+}
+
+var recordInvocationLocations int32
+
+// RecordInvocationLocations makes mocks record the file and line each of their
+// methods is called from, so verification failures can show where the code
+// under test called the mock. It is off by default, because looking up the
+// caller slows down every invocation.
+func RecordInvocationLocations(record bool) {
+	if record {
+		atomic.StoreInt32(&recordInvocationLocations, 1)
+	} else {
+		atomic.StoreInt32(&recordInvocationLocations, 0)
+	}
+}
+
+// Invoke and Verify are called from generated mock methods, which are called from
+// test code. Passing callerSkipToTestCode to fail handlers makes them report the
+// line in the test.
+const callerSkipToTestCode = 2
+
+// maxReportedStackFrames is how many frames of test code are shown where a
+// message reports a stack.
+const maxReportedStackFrames = 5
+
 func (genericMock *GenericMock) Invoke(methodName string, params []Param, returnTypes []reflect.Type) ReturnValues {
-	lastInvocationMutex.Lock()
-	lastInvocation = &invocation{
-		genericMock: genericMock,
-		MethodName:  methodName,
-		Params:      params,
-		ReturnTypes: returnTypes,
+	returnValues, _ := genericMock.invoke(nil, methodName, params, returnTypes, false)
+	return returnValues
+}
+
+// invoke is called by Invoke and MethodHandle.Invoke, so it is one call further
+// away from test code than they are. method is nil, unless the caller looked it
+// up already. If reusableParams is set, params are copied where they need to
+// outlive the call, if possible. paramsRetained tells if they still had to be
+// retained, e.g. because they were passed to a stubbed callback.
+func (genericMock *GenericMock) invoke(method *mockedMethod, methodName string, params []Param, returnTypes []reflect.Type, reusableParams bool) (returnValues ReturnValues, paramsRetained bool) {
+	defer clearGoroutineStateOnPanic()
+	if genericMock.methodMetadata != nil {
+		if message := genericMock.paramCountMismatchFor(methodName, params); message != "" {
+			genericMock.failHandler()(message, callerSkipToTestCode+1)
+			return nil, false
+		}
+	}
+	pendingArgMatchers := len(argMatchersOfCurrentGoroutine())
+	if pendingArgMatchers > 0 {
+		panicOnNestedInvocation(lastInvocationOfCurrentGoroutine(), genericMock, methodName)
+	}
+	stubberCall := isStubberCallOfCurrentGoroutine()
+	record := genericMock.isRecording() && !stubberCall
+	lastInvocation := invocation{
+		genericMock:        genericMock,
+		MethodName:         methodName,
+		Params:             params,
+		ReturnTypes:        returnTypes,
+		pendingArgMatchers: pendingArgMatchers,
+		recorded:           record,
+		stubberCall:        stubberCall,
+	}
+	if reusableParams && !record {
+		setLastInvocationOfCurrentGoroutineCopyingParams(lastInvocation)
+	} else {
+		setLastInvocationOfCurrentGoroutine(&lastInvocation)
+		paramsRetained = true
+	}
+	if stubberCall {
+		// The call only tells Stubber.When which method to stub.
+		return nil, paramsRetained
+	}
+	if metrics := currentMetricsRegisterer(); metrics != nil {
+		metrics.IncInvocationCount(genericMock.mockTypeName, methodName)
+	}
+	traceInvocation(genericMock)
+	genericMock.Lock()
+	logger := genericMock.invocationLogger
+	clock := genericMock.clock
+	genericMock.Unlock()
+	isStubbing := pendingArgMatchers > 0
+	if isStubbing {
+		logger = nil
+	}
+	var capturedStack []uintptr
+	if atomic.LoadInt32(&recordInvocationLocations) != 0 {
+		// skip runtime.Callers, invoke, Invoke and the mock's method
+		capturedStack = captureStack(4, maxReportedStackFrames)
+	}
+	if method == nil {
+		method = genericMock.getOrCreateMockedMethod(methodName)
+	}
+	returnValues, stubbed, paramsPassedOn := method.Invoke(params, capturedStack, now(clock), logger, record)
+	paramsRetained = paramsRetained || record || isStubbing || paramsPassedOn || logger != nil
+	if !stubbed {
+		genericMock.Lock()
+		fallback := genericMock.fallback
+		genericMock.Unlock()
+		if fallback != nil && !isStubbing {
+			return fallback(methodName, params), true
+		}
+		if selfReturnValues := genericMock.selfReturnValues(returnTypes); selfReturnValues != nil && !isStubbing {
+			return selfReturnValues, paramsRetained
+		}
+		if !isStubbing {
+			if smartNullReturnValues := genericMock.smartNullReturnValues(methodName, params, returnTypes); smartNullReturnValues != nil {
+				return smartNullReturnValues, paramsRetained
+			}
+		}
+	}
+	return returnValues, paramsRetained
+}
+
+// paramCountMismatchFor returns a failure message if params don't fit the number
+// of params methodName declares. Variadic arguments are passed as individual params.
+func (genericMock *GenericMock) paramCountMismatchFor(methodName string, params []Param) string {
+	metadata, known := genericMock.methodMetadata[methodName]
+	if !known {
+		return ""
+	}
+	var declaration string
+	if metadata.Variadic {
+		if len(params) >= metadata.NumParams-1 {
+			return ""
+		}
+		declaration = fmt.Sprintf("at least %v", metadata.NumParams-1)
+	} else {
+		if len(params) == metadata.NumParams {
+			return ""
+		}
+		declaration = fmt.Sprint(metadata.NumParams)
 	}
-	lastInvocationMutex.Unlock()
-	return genericMock.getOrCreateMockedMethod(methodName).Invoke(params)
+	// skip paramCountMismatchFor, invoke, Invoke and the mock's method
+	_, file, line, _ := runtime.Caller(4)
+	return fmt.Sprintf("%v.%v called with %v params but interface declares %v (at %v:%v)",
+		genericMock.mockTypeName, methodName, len(params), declaration, file, line)
+}
+
+func (genericMock *GenericMock) failHandler() FailHandler {
+	fail := genericMock.fail
+	// Options applied before WithFailHandler can create the GenericMock before
+	// the mock got its fail handler.
+	if fail == nil && genericMock.mock != nil {
+		fail = genericMock.mock.FailHandler()
+	}
+	if fail == nil && GlobalFailHandler == nil {
+		// skip runtime.Callers and failHandler
+		panic(fmt.Sprintf("No FailHandler set. Please use either RegisterMockFailHandler or RegisterMockTestingT, "+
+			"or create the mock with WithFailHandler or WithT to set a fail handler.\n\n"+
+			"The mock was used at:\n\t%v",
+			strings.Join(testCodeLocations(captureStack(2, maxReportedStackFrames+8), maxReportedStackFrames), "\n\t")))
+	}
+	if fail != nil {
+		return fail
+	}
+	return GlobalFailHandler
 }
 
 func (genericMock *GenericMock) stub(methodName string, paramMatchers []Matcher, returnValues ReturnValues) {
-	genericMock.stubWithCallback(methodName, paramMatchers, func([]Param) ReturnValues { return returnValues })
+	genericMock.getOrCreateMockedMethod(methodName).stub(paramMatchers, 1, func([]Param) ReturnValues { return returnValues }, false)
 }
 
 func (genericMock *GenericMock) stubWithCallback(methodName string, paramMatchers []Matcher, callback func([]Param) ReturnValues) {
-	genericMock.getOrCreateMockedMethod(methodName).stub(paramMatchers, callback)
+	genericMock.stubWithCallbackFor(methodName, paramMatchers, 1, callback)
+}
+
+func (genericMock *GenericMock) stubWithCallbackFor(methodName string, paramMatchers []Matcher, times int, callback func([]Param) ReturnValues) {
+	genericMock.getOrCreateMockedMethod(methodName).stub(paramMatchers, times, callback, true)
 }
 
 func (genericMock *GenericMock) getOrCreateMockedMethod(methodName string) *mockedMethod {
 	genericMock.Lock()
 	defer genericMock.Unlock()
 	if _, ok := genericMock.mockedMethods[methodName]; !ok {
-		genericMock.mockedMethods[methodName] = &mockedMethod{name: methodName}
+		genericMock.mockedMethods[methodName] = &mockedMethod{name: methodName, invocationLimit: genericMock.invocationLimit}
 	}
 	return genericMock.mockedMethods[methodName]
 }
@@ -100,62 +426,267 @@ func (genericMock *GenericMock) Verify(
 	params []Param,
 	options ...interface{},
 ) []MethodInvocation {
-	var timeout time.Duration
-	if len(options) == 1 {
-		timeout = options[0].(time.Duration)
-	}
-	if genericMock.fail == nil && GlobalFailHandler == nil {
-		panic("No FailHandler set. Please use either RegisterMockFailHandler or RegisterMockTestingT or TODO to set a fail handler.")
+	timeout, fail := verifyOptionsFrom(options)
+	return genericMock.verify(inOrderContext, invocationCountMatcher, methodName, params, false, timeout, fail)
+}
+
+// VerifyAnyParams is like Verify, but counts all invocations of methodName,
+// whatever their params. Generated verifiers use it to capture arguments
+// without matchers, e.g. mock.VerifyWasCalledOnce().Show_GetCapturedArguments().
+func (genericMock *GenericMock) VerifyAnyParams(
+	inOrderContext *InOrderContext,
+	invocationCountMatcher Matcher,
+	methodName string,
+	options ...interface{},
+) []MethodInvocation {
+	timeout, fail := verifyOptionsFrom(options)
+	return genericMock.verify(inOrderContext, invocationCountMatcher, methodName, nil, true, timeout, fail)
+}
+
+// verifyOptionsFrom takes the timeout and a FailHandler overriding the mock's
+// from options. Both are optional.
+func verifyOptionsFrom(options []interface{}) (timeout time.Duration, fail FailHandler) {
+	for _, option := range options {
+		switch option := option.(type) {
+		case time.Duration:
+			timeout = option
+		case FailHandler:
+			fail = option
+		default:
+			panic(fmt.Sprintf("Unsupported verify option %#v", option))
+		}
 	}
-	fail := GlobalFailHandler
-	if genericMock.fail != nil {
-		fail = genericMock.fail
+	return
+}
+
+// verify is called by Verify and VerifyAnyParams, so it is one call further
+// away from test code than they are.
+func (genericMock *GenericMock) verify(
+	inOrderContext *InOrderContext,
+	invocationCountMatcher Matcher,
+	methodName string,
+	params []Param,
+	anyParams bool,
+	timeout time.Duration,
+	fail FailHandler,
+) []MethodInvocation {
+	if fail == nil {
+		fail = genericMock.failHandler()
 	}
-	defer func() { globalArgMatchers = nil }() // We don't want a panic somewhere during verification screw our global argMatchers
+	inOrderContext = inOrderContextFor(inOrderContext)
+	argMatchers := argMatchersOfCurrentGoroutine()
+	defer clearArgMatchersOfCurrentGoroutine() // We don't want a panic somewhere during verification screw our global argMatchers
 
-	if len(globalArgMatchers) != 0 {
-		verifyArgMatcherUse(globalArgMatchers, params)
+	if len(argMatchers) != 0 {
+		if !anyParams {
+			panicOnOrphanArgMatchers(0, len(argMatchers)-len(params))
+			argMatchers = paramMatchersFromArgMatchersOrParams(argMatchers, params)
+		} else {
+			verifyArgMatcherUse(argMatchers, params)
+		}
 	}
+	// skip verify, Verify and the generated verifier method
+	_, file, line, _ := runtime.Caller(3)
+	verificationLocation := fmt.Sprintf("%v:%v", file, line)
+	if !genericMock.isRecording() {
+		fail(fmt.Sprintf("Cannot verify %v(%v): recording is disabled for %v by DisableRecording, so its invocations are not known.\n\tVerified at %v",
+			genericMock.labeled(methodName), formatParamsOrMatchers(params, argMatchers), genericMock.mockTypeName, verificationLocation),
+			callerSkipToTestCode+1)
+		return nil
+	}
+	within, isWithin := invocationCountMatcher.(*WithinInvocationCountMatcher)
+	var deadline time.Time
+	if isWithin {
+		genericMock.Lock()
+		deadline = genericMock.startedAt.Add(within.Timeout)
+		timeout = deadline.Sub(now(genericMock.clock))
+		genericMock.Unlock()
+	}
+	verified := false
+	defer func() {
+		traceVerification(func() string {
+			paramsOrMatchers := "<any>"
+			if !anyParams {
+				paramsOrMatchers = formatParamsOrMatchers(params, argMatchers)
+			}
+			return fmt.Sprintf("%v(%v) %v", genericMock.qualified(methodName), paramsOrMatchers, invocationCountMatcher)
+		}, verified)
+	}()
 	startTime := time.Now()
-	// timeoutLoop:
+	var methodInvocations []MethodInvocation
+	var evictedCount int
 	for {
 		genericMock.Lock()
-		methodInvocations := genericMock.methodInvocations(methodName, params, globalArgMatchers)
+		if anyParams {
+			methodInvocations = genericMock.allMethodInvocations(methodName)
+		} else {
+			methodInvocations = genericMock.methodInvocations(methodName, params, argMatchers)
+		}
+		evictedCount = genericMock.evictedInvocationCount(methodName)
 		genericMock.Unlock()
-		if inOrderContext != nil {
-			for _, methodInvocation := range methodInvocations {
-				if methodInvocation.orderingInvocationNumber <= inOrderContext.invocationCounter {
-					// TODO: should introduce the following, in case we decide support "inorder" and "eventually"
-					// if time.Since(startTime) < timeout {
-					// 	continue timeoutLoop
-					// }
-					fail(fmt.Sprintf("Expected function call %v(%v) before function call %v(%v)",
-						methodName, formatParams(params), inOrderContext.lastInvokedMethodName, formatParams(inOrderContext.lastInvokedMethodParams)))
+		if isWithin {
+			// Evicted invocations might have been made after the deadline.
+			methodInvocations, evictedCount = invocationsUntil(methodInvocations, deadline), 0
+		}
+		if evictedCount > 0 && !anyParams && !matchesAllInvocations(params, argMatchers) {
+			fail(fmt.Sprintf(
+				"Cannot verify %v(%v): invocation history truncated. "+
+					"Only the most recent %v invocations of %v are retained (see WithInvocationLimit) and %v older ones were evicted, "+
+					"so invocations with these params might be among them. "+
+					"Verify without params or with Any matchers only, or raise the limit.\n\tVerified at %v",
+				methodName, formatParamsOrMatchers(params, argMatchers), genericMock.invocationLimit, methodName, evictedCount, verificationLocation),
+				callerSkipToTestCode+1)
+			return nil
+		}
+		if invocationCountMatcher.Matches(len(methodInvocations)+evictedCount) || time.Since(startTime) >= timeout {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !anyParams {
+		genericMock.logFmtFallbackWarnings(methodName, params, argMatchers, methodInvocations)
+	}
+	// The order is only checked once polling is done, so a violation is reported once.
+	inOrderViolated := false
+	if inOrderContext != nil && len(methodInvocations) != 0 {
+		// Invocations get their numbers before they're recorded, so concurrent
+		// ones can be recorded out of order.
+		first, last := orderingNumberRange(methodInvocations)
+		if first <= inOrderContext.invocationCounter {
+			inOrderViolated = true
+			fail(fmt.Sprintf("Expected function call %v(%v) before function call %v(%v)",
+				methodName, formatParams(params), inOrderContext.lastInvokedMethodName, formatParams(inOrderContext.lastInvokedMethodParams)),
+				callerSkipToTestCode+1)
+		} else if inOrderContext.strict {
+			if interleaved := inOrderContext.interleavedInvocations(genericMock, first, last, methodInvocations); interleaved != "" {
+				inOrderViolated = true
+				if inOrderContext.invocationCounter == 0 {
+					fail(fmt.Sprintf("Expected the invocations of %v(%v) to directly follow each other, but there were other interactions in between:\n%v",
+						methodName, formatParamsOrMatchers(params, argMatchers), interleaved),
+						callerSkipToTestCode+1)
+				} else {
+					fail(fmt.Sprintf("Expected function call %v(%v) to directly follow function call %v(%v), but there were other interactions in between:\n%v",
+						methodName, formatParamsOrMatchers(params, argMatchers), inOrderContext.lastInvokedMethodName, formatParams(inOrderContext.lastInvokedMethodParams), interleaved),
+						callerSkipToTestCode+1)
 				}
-				inOrderContext.invocationCounter = methodInvocation.orderingInvocationNumber
-				inOrderContext.lastInvokedMethodName = methodName
-				inOrderContext.lastInvokedMethodParams = params
 			}
 		}
-		if !invocationCountMatcher.Matches(len(methodInvocations)) {
-			if time.Since(startTime) < timeout {
-				time.Sleep(10 * time.Millisecond)
-				continue
-			}
-			var paramsOrMatchers interface{} = formatParams(params)
-			if len(globalArgMatchers) != 0 {
-				paramsOrMatchers = formatMatchers(globalArgMatchers)
-			}
-			timeoutInfo := ""
-			if timeout > 0 {
-				timeoutInfo = fmt.Sprintf(" after timeout of %v", timeout)
+		inOrderContext.invocationCounter = last
+		inOrderContext.lastInvokedMethodName = methodName
+		inOrderContext.lastInvokedMethodParams = params
+	}
+	if !invocationCountMatcher.Matches(len(methodInvocations) + evictedCount) {
+		paramsOrMatchers := formatParamsOrMatchers(params, argMatchers)
+		mismatches := ""
+		if anyParams {
+			paramsOrMatchers = "<any>"
+		} else {
+			mismatches = genericMock.formatMismatches(methodName, params, argMatchers)
+		}
+		timeoutInfo := ""
+		if timeout > 0 && !isWithin {
+			timeoutInfo = fmt.Sprintf(" after timeout of %v", timeout)
+		}
+		fail(fmt.Sprintf(
+			"Mock invocation count for %v(%v) does not match expectation%v.\n\n\t%v%v\n\tVerified at %v\n\n\t%v%v",
+			genericMock.labeled(methodName), paramsOrMatchers, timeoutInfo, invocationCountMatcher.FailureMessage(),
+			formatOffendingInvocations(invocationCountMatcher, methodName, methodInvocations), verificationLocation, formatInteractions(genericMock.allInteractions()),
+			mismatches),
+			callerSkipToTestCode+1)
+	} else {
+		verified = !inOrderViolated
+		captureArguments(argMatchers, methodInvocations)
+		genericMock.markVerified(methodName, methodInvocations)
+	}
+	return methodInvocations
+}
+
+// labeled qualifies methodName with the mock's label, if it has one.
+func (genericMock *GenericMock) labeled(methodName string) string {
+	if genericMock.label == "" {
+		return methodName
+	}
+	return genericMock.label + "." + methodName
+}
+
+// qualified qualifies methodName with the mock's label or, if it has none, its
+// type name.
+func (genericMock *GenericMock) qualified(methodName string) string {
+	if genericMock.label == "" {
+		return genericMock.mockTypeName + "." + methodName
+	}
+	return genericMock.label + "." + methodName
+}
+
+func (genericMock *GenericMock) evictedInvocationCount(methodName string) int {
+	method, exists := genericMock.mockedMethods[methodName]
+	if !exists {
+		return 0
+	}
+	method.Lock()
+	defer method.Unlock()
+	return method.evictedCount
+}
+
+// matchesAllInvocations is true if a verification with params or matchers
+// counts every invocation of a method, so evicted invocations can be counted too.
+func matchesAllInvocations(params []Param, matchers []Matcher) bool {
+	if len(matchers) == 0 {
+		return len(params) == 0
+	}
+	for _, matcher := range matchers {
+		if _, isAnyMatcher := matcher.(*AnyMatcher); !isAnyMatcher {
+			return false
+		}
+	}
+	return true
+}
+
+func (genericMock *GenericMock) markVerified(methodName string, verifiedInvocations []MethodInvocation) {
+	if len(verifiedInvocations) == 0 {
+		return
+	}
+	verifiedNumbers := make(map[int]bool, len(verifiedInvocations))
+	for _, invocation := range verifiedInvocations {
+		verifiedNumbers[invocation.orderingInvocationNumber] = true
+	}
+	method := genericMock.getOrCreateMockedMethod(methodName)
+	method.Lock()
+	defer method.Unlock()
+	for i := range method.invocations {
+		if verifiedNumbers[method.invocations[i].orderingInvocationNumber] {
+			method.invocations[i].verified = true
+		}
+	}
+}
+
+// GetUnverifiedInvocations returns the invocations no successful verification
+// matched so far, keyed by method name. Methods without unverified invocations
+// are left out. It is meant for custom assertion helpers.
+func (genericMock *GenericMock) GetUnverifiedInvocations() map[string][]MethodInvocation {
+	unverified := make(map[string][]MethodInvocation)
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	for methodName, method := range genericMock.mockedMethods {
+		method.Lock()
+		for _, invocation := range method.invocations {
+			if !invocation.verified {
+				unverified[methodName] = append(unverified[methodName], invocation)
 			}
-			fail(fmt.Sprintf(
-				"Mock invocation count for %v(%v) does not match expectation%v.\n\n\t%v\n\n\t%v",
-				methodName, paramsOrMatchers, timeoutInfo, invocationCountMatcher.FailureMessage(), formatInteractions(genericMock.allInteractions())))
 		}
-		return methodInvocations
+		method.Unlock()
 	}
+	return unverified
+}
+
+// ReportNothingCaptured fails the test, because captured arguments of methodName
+// were requested from a verification that matched no invocations.
+func (genericMock *GenericMock) ReportNothingCaptured(methodName string) {
+	genericMock.failHandler()(fmt.Sprintf(
+		"Cannot get captured arguments of %v: the verification matched no invocations of %v. "+
+			"Verify with an invocation count that requires at least one invocation, e.g. Once() or AtLeast(1).",
+		methodName, methodName),
+		callerSkipToTestCode)
 }
 
 // TODO this doesn't need to be a method, can be a free function
@@ -175,8 +706,42 @@ func (genericMock *GenericMock) GetInvocationParams(methodInvocations []MethodIn
 	return result
 }
 
+// logFmtFallbackWarnings logs a warning through the mock's logger for every
+// param of the verified invocations that matched only because it formats the
+// same, see FmtFallback. Each warning is logged once per verification.
+func (genericMock *GenericMock) logFmtFallbackWarnings(methodName string, params []Param, matchers []Matcher, invocations []MethodInvocation) {
+	genericMock.Lock()
+	logger := genericMock.invocationLogger
+	genericMock.Unlock()
+	if logger == nil || deepEqualMode()&FmtFallback == 0 {
+		return
+	}
+	if len(matchers) == 0 {
+		matchers = transformParamsIntoEqMatchers(params)
+	}
+	deduplicatingLogger := &deduplicatingWarningLogger{invocationLogger: logger, logged: make(map[string]bool)}
+	for _, invocation := range invocations {
+		logFmtFallbackWarnings(deduplicatingLogger, methodName, matchers, invocation.params)
+	}
+}
+
+type deduplicatingWarningLogger struct {
+	invocationLogger
+	logged map[string]bool
+}
+
+func (logger *deduplicatingWarningLogger) logWarning(methodName string, message string) {
+	if !logger.logged[message] {
+		logger.logged[message] = true
+		logger.invocationLogger.logWarning(methodName, message)
+	}
+}
+
 func (genericMock *GenericMock) methodInvocations(methodName string, params []Param, matchers []Matcher) []MethodInvocation {
 	var invocations []MethodInvocation
+	if len(matchers) == 0 && len(params) != 0 && (len(registeredCmpOptions()) != 0 || deepEqualMode() != DefaultDeepEqualMode) {
+		matchers = transformParamsIntoEqMatchers(params)
+	}
 	if method, exists := genericMock.mockedMethods[methodName]; exists {
 		method.Lock()
 		for _, invocation := range method.invocations {
@@ -185,8 +750,7 @@ func (genericMock *GenericMock) methodInvocations(methodName string, params []Pa
 					invocations = append(invocations, invocation)
 				}
 			} else {
-				if reflect.DeepEqual(params, invocation.params) ||
-					(len(params) == 0 && len(invocation.params) == 0) {
+				if paramsEqual(params, invocation.params) {
 					invocations = append(invocations, invocation)
 				}
 			}
@@ -196,6 +760,70 @@ func (genericMock *GenericMock) methodInvocations(methodName string, params []Pa
 	return invocations
 }
 
+// paramsEqual compares params like Eq matchers do by default: with
+// reflect.DeepEqual, except for function params, which are compared by pointer
+// identity.
+func paramsEqual(a, b []Param) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if isNonNilFunc(a[i]) {
+			if !sameFunc(a[i], b[i]) {
+				return false
+			}
+		} else if !reflect.DeepEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (genericMock *GenericMock) allMethodInvocations(methodName string) []MethodInvocation {
+	method, exists := genericMock.mockedMethods[methodName]
+	if !exists {
+		return nil
+	}
+	method.Lock()
+	defer method.Unlock()
+	return append([]MethodInvocation(nil), method.invocations...)
+}
+
+// formatMismatches lists the differences between expected and actual params of
+// invocations of methodName that were compared for equality. This is for params
+// like structs, whose differences are often hard to spot in their formatting.
+func (genericMock *GenericMock) formatMismatches(methodName string, params []Param, matchers []Matcher) string {
+	method, exists := genericMock.mockedMethods[methodName]
+	if !exists {
+		return ""
+	}
+	if len(matchers) == 0 {
+		matchers = make([]Matcher, len(params))
+		for i, param := range params {
+			matchers[i] = &EqMatcher{Value: param}
+		}
+	}
+	result := ""
+	method.Lock()
+	defer method.Unlock()
+	for _, invocation := range method.invocations {
+		if len(invocation.params) != len(matchers) {
+			continue
+		}
+		for i, param := range invocation.params {
+			eqMatcher, isEqMatcher := matchers[i].(*EqMatcher)
+			if isEqMatcher && !eqMatcher.Matches(param) && recoveredDifferences(deepEqualMode(), eqMatcher.differences) != "" {
+				result += fmt.Sprintf("\t%v(%v), param %v:\n\t\t%v\n", methodName, formatParams(invocation.params), i,
+					strings.Replace(eqMatcher.FailureMessage(), "\n", "\n\t\t", -1))
+			}
+		}
+	}
+	if result == "" {
+		return ""
+	}
+	return "\n\tMismatches with invocations of " + methodName + ":\n" + result
+}
+
 func formatInteractions(interactions map[string][]MethodInvocation) string {
 	if len(interactions) == 0 {
 		return "There were no other interactions with this mock"
@@ -207,9 +835,23 @@ func formatInteractions(interactions map[string][]MethodInvocation) string {
 	return result
 }
 
+// formatOffendingInvocations lists the invocations a verification with Never()
+// found, since each of them violates it.
+func formatOffendingInvocations(invocationCountMatcher Matcher, methodName string, invocations []MethodInvocation) string {
+	times, isTimes := invocationCountMatcher.(*TimesInvocationCountMatcher)
+	if !isTimes || times.Value != 0 || len(invocations) == 0 {
+		return ""
+	}
+	return ":\n\t" + strings.ReplaceAll(strings.TrimSuffix(formatInvocations(methodName, invocations), "\n"), "\n", "\n\t")
+}
+
 func formatInvocations(methodName string, invocations []MethodInvocation) (result string) {
 	for _, invocation := range invocations {
-		result += "\t" + methodName + "(" + formatParams(invocation.params) + ")\n"
+		result += "\t" + methodName + "(" + formatParams(invocation.params) + ")"
+		if location := invocation.location(); location != "" {
+			result += " called from " + location
+		}
+		result += "\n"
 	}
 	return
 }
@@ -219,11 +861,18 @@ func formatParams(params []Param) (result string) {
 		if i > 0 {
 			result += ", "
 		}
-		result += fmt.Sprintf("%#v", param)
+		result += formatCycleSafe("%#v", param)
 	}
 	return
 }
 
+func formatParamsOrMatchers(params []Param, matchers []Matcher) string {
+	if len(matchers) != 0 {
+		return formatMatchers(matchers)
+	}
+	return formatParams(params)
+}
+
 func formatMatchers(matchers []Matcher) (result string) {
 	for i, matcher := range matchers {
 		if i > 0 {
@@ -260,33 +909,115 @@ type mockedMethod struct {
 	name        string
 	invocations []MethodInvocation
 	stubbings   Stubbings
+	// invocationLimit is 0 for methods that retain all invocations.
+	invocationLimit int
+	evictedCount    int
+	// lastEvicted is the invocation evicted by the last invocation. If the last
+	// invocation turns out to be part of stubbing, removeLastInvocation restores it.
+	lastEvicted *MethodInvocation
+	// unrecordedCount counts the invocations made while recording was disabled.
+	unrecordedCount int
 }
 
-func (method *mockedMethod) Invoke(params []Param) ReturnValues {
+// Invoke returns in paramsPassedOn if params were passed to a stubbed callback,
+// which might retain them.
+func (method *mockedMethod) Invoke(params []Param, capturedStack []uintptr, invokedAt time.Time, logger invocationLogger, record bool) (returnValues ReturnValues, stubbed bool, paramsPassedOn bool) {
+	var orderingNumber int
+	if record || logger != nil {
+		orderingNumber = globalInvocationCounter.nextNumber()
+	}
 	method.Lock()
-	method.invocations = append(method.invocations, MethodInvocation{params, globalInvocationCounter.nextNumber()})
-	method.Unlock()
+	if record {
+		method.invocations = append(method.invocations, MethodInvocation{params: params, orderingInvocationNumber: orderingNumber, capturedStack: capturedStack, time: invokedAt})
+		method.lastEvicted = nil
+		if method.invocationLimit > 0 && len(method.invocations) > method.invocationLimit {
+			method.lastEvicted = &method.invocations[0]
+			method.invocations = method.invocations[1:]
+			method.evictedCount++
+		}
+	} else {
+		method.unrecordedCount++
+	}
 	stubbing := method.stubbings.find(params)
+	var callback func([]Param) ReturnValues
+	if stubbing != nil {
+		callback, paramsPassedOn = stubbing.nextCallback()
+	}
+	// The callback runs unlocked, so it can call other mocks or this one.
+	method.Unlock()
+	if logger != nil {
+		logger.logInvocation(method.name, params, stubbing != nil, orderingNumber)
+	}
 	if stubbing == nil {
-		return ReturnValues{}
+		return ReturnValues{}, false, false
+	}
+	logFmtFallbackWarnings(logger, method.name, stubbing.paramMatchers, params)
+	if callback == nil {
+		return ReturnValues{}, false, false
 	}
-	return stubbing.Invoke(params)
+	return callback(params), true, paramsPassedOn
 }
 
-func (method *mockedMethod) stub(paramMatchers Matchers, callback func([]Param) ReturnValues) {
+// stub adds callback to the stubbing for paramMatchers. usesParams tells if
+// callback uses the params it gets, and might retain them.
+func (method *mockedMethod) stub(paramMatchers Matchers, times int, callback func([]Param) ReturnValues, usesParams bool) {
+	method.Lock()
+	defer method.Unlock()
 	stubbing := method.stubbings.findByMatchers(paramMatchers)
 	if stubbing == nil {
 		stubbing = &Stubbing{paramMatchers: paramMatchers}
 		method.stubbings = append(method.stubbings, stubbing)
 	}
 	stubbing.callbackSequence = append(stubbing.callbackSequence, callback)
+	stubbing.repeatCounts = append(stubbing.repeatCounts, times)
+	stubbing.callbacksUseParams = append(stubbing.callbacksUseParams, usesParams)
+}
+
+// stubOnCall makes callback answer the callNumber-th call of the stubbing for
+// paramMatchers.
+func (method *mockedMethod) stubOnCall(paramMatchers Matchers, callNumber int, callback func([]Param) ReturnValues) {
+	method.Lock()
+	defer method.Unlock()
+	stubbing := method.stubbings.findByMatchers(paramMatchers)
+	if stubbing == nil {
+		stubbing = &Stubbing{paramMatchers: paramMatchers}
+		method.stubbings = append(method.stubbings, stubbing)
+	}
+	if stubbing.onCall == nil {
+		stubbing.onCall = make(map[int]func([]Param) ReturnValues)
+	}
+	stubbing.onCall[callNumber] = callback
 }
 
 func (method *mockedMethod) removeLastInvocation() {
+	method.Lock()
+	defer method.Unlock()
 	method.invocations = method.invocations[:len(method.invocations)-1]
+	if method.lastEvicted != nil {
+		method.invocations = append([]MethodInvocation{*method.lastEvicted}, method.invocations...)
+		method.lastEvicted = nil
+		method.evictedCount--
+	}
+}
+
+// uncountAnswer takes back counting the call with params as answered by a
+// stubbing, because the call was made to stub the method.
+func (method *mockedMethod) uncountAnswer(params []Param) {
+	method.Lock()
+	defer method.Unlock()
+	if stubbing := method.stubbings.find(params); stubbing != nil {
+		if stubbing.calls > 0 {
+			stubbing.calls--
+		}
+		if stubbing.timesAnswered > 0 {
+			stubbing.timesAnswered--
+		}
+	}
 }
 
 func (method *mockedMethod) reset(paramMatchers Matchers) {
+	method.Lock()
+	defer method.Unlock()
 	method.stubbings.removeByMatchers(paramMatchers)
 }
 
@@ -309,6 +1040,31 @@ var globalInvocationCounter = Counter{count: 1}
 type MethodInvocation struct {
 	params                   []Param
 	orderingInvocationNumber int
+	verified                 bool
+	// capturedStack is the stack the method was invoked from, starting with the
+	// caller of the mock's method. It is only set if RecordInvocationLocations
+	// is on.
+	capturedStack []uintptr
+	time          time.Time
+}
+
+// location returns the file and line the method was invoked from, or "" if it
+// is unknown.
+func (invocation MethodInvocation) location() string {
+	if locations := testCodeLocations(invocation.capturedStack, 1); len(locations) > 0 {
+		return locations[0]
+	}
+	return ""
+}
+
+// Params returns the params the method was invoked with.
+func (invocation MethodInvocation) Params() []Param {
+	return invocation.params
+}
+
+// Time returns when the method was invoked, according to the mock's clock.
+func (invocation MethodInvocation) Time() time.Time {
+	return invocation.time
 }
 
 type Stubbings []*Stubbing
@@ -354,21 +1110,76 @@ func matchersEqual(a, b Matchers) bool {
 type Stubbing struct {
 	paramMatchers    Matchers
 	callbackSequence []func([]Param) ReturnValues
-	sequencePointer  int
+	// repeatCounts holds for each callback how many calls it answers before
+	// the next one takes over. The last callback answers all remaining calls.
+	repeatCounts []int
+	// callbacksUseParams holds for each callback if it uses its params.
+	callbacksUseParams []bool
+	sequencePointer    int
+	callsAnswered      int
+	// timesAnswered counts all calls the stubbing answered, except the ones
+	// made to stub methods.
+	timesAnswered int
+	// onCall holds the callbacks stubbed with ThenReturnOnCall by the number of
+	// the call they answer, starting at 1. They answer their calls instead of
+	// callbackSequence, which answers all other calls.
+	onCall map[int]func([]Param) ReturnValues
+	// calls counts the calls since the stubbing was made or rewound.
+	calls int
 }
 
 func (stubbing *Stubbing) Invoke(params []Param) ReturnValues {
-	defer func() {
-		if stubbing.sequencePointer < len(stubbing.callbackSequence)-1 {
-			stubbing.sequencePointer++
-		}
-	}()
-	return stubbing.callbackSequence[stubbing.sequencePointer](params)
+	callback, _ := stubbing.nextCallback()
+	if callback == nil {
+		return nil
+	}
+	return callback(params)
+}
+
+// nextCallback counts a call and returns the callback that answers it, and if
+// that callback uses its params. callback is nil if the stubbing doesn't answer
+// the call because it was only stubbed for other calls with ThenReturnOnCall.
+// Callers must hold the lock of the stubbing's mockedMethod, but must not call
+// callback while holding it.
+func (stubbing *Stubbing) nextCallback() (callback func([]Param) ReturnValues, usesParams bool) {
+	stubbing.calls++
+	if callback, exists := stubbing.onCall[stubbing.calls]; exists {
+		stubbing.timesAnswered++
+		return callback, false
+	}
+	if len(stubbing.callbackSequence) == 0 {
+		return nil, false
+	}
+	callback, usesParams = stubbing.callbackSequence[stubbing.sequencePointer], stubbing.callbacksUseParams[stubbing.sequencePointer]
+	stubbing.timesAnswered++
+	stubbing.callsAnswered++
+	if stubbing.sequencePointer < len(stubbing.callbackSequence)-1 &&
+		stubbing.callsAnswered >= stubbing.repeatCounts[stubbing.sequencePointer] {
+		stubbing.sequencePointer++
+		stubbing.callsAnswered = 0
+	}
+	return callback, usesParams
+}
+
+// rewind makes the stubbing answer calls as if it was just made. Callers must
+// hold the lock of the stubbing's mockedMethod.
+func (stubbing *Stubbing) rewind() {
+	stubbing.sequencePointer = 0
+	stubbing.callsAnswered = 0
+	stubbing.calls = 0
 }
 
 type Matchers []Matcher
 
 func (matchers Matchers) Matches(params []Param) bool {
+	if len(matchers) > 0 {
+		if variadicMatcher, isVariadic := matchers[len(matchers)-1].(*VariadicDefaultingMatcher); isVariadic {
+			leading := len(matchers) - 1
+			return len(params) >= leading &&
+				matchers[:leading].Matches(params[:leading]) &&
+				variadicMatcher.matchesVariadic(params[leading:])
+		}
+	}
 	if len(matchers) != len(params) { // Technically, this is not an error. Variadic arguments can cause this
 		return false
 	}
@@ -381,10 +1192,6 @@ func (matchers Matchers) Matches(params []Param) bool {
 	return true
 }
 
-func (matchers *Matchers) append(matcher Matcher) {
-	*matchers = append(*matchers, matcher)
-}
-
 type ongoingStubbing struct {
 	genericMock   *GenericMock
 	MethodName    string
@@ -393,20 +1200,76 @@ type ongoingStubbing struct {
 }
 
 func When(invocation ...interface{}) *ongoingStubbing {
+	return when(invocation)
+}
+
+// WhenFunc is like When(func() { mock.Method(...) }). It calls call, which must
+// make the call on a mock to stub, and stubs that call. Unlike with
+// When(mock.Method(...)), the call happens under WhenFunc's control: argument
+// matchers registered before, which no call on a mock consumed, make it panic
+// instead of being mixed up with the ones of the call, and calls on mocks made
+// before are never stubbed.
+func WhenFunc(call func()) *ongoingStubbing {
+	verify.Argument(call != nil, "WhenFunc() requires a function that makes a call on a mock, but got nil.")
+	panicOnArgMatchersRegisteredBefore()
+	return when([]interface{}{call})
+}
+
+// panicOnArgMatchersRegisteredBefore panics if argument matchers are pending
+// before WhenFunc or Stubber.When make the call on a mock, and clears them.
+func panicOnArgMatchersRegisteredBefore() {
+	if orphans := len(argMatchersOfCurrentGoroutine()); orphans > 0 {
+		defer clearArgMatchersOfCurrentGoroutine()
+		defer clearLastInvocationOfCurrentGoroutine()
+		panicOnOrphanArgMatchers(0, orphans)
+	}
+}
+
+// when must be called by When, WhenFunc or Stubber.When directly, so it can tell where the
+// stubbing happened.
+func when(invocation []interface{}) *ongoingStubbing {
+	var markStubbed func()
+	defer func() {
+		clearLastInvocationOfCurrentGoroutine()
+		clearArgMatchersOfCurrentGoroutine()
+		if markStubbed != nil {
+			markStubbed()
+		}
+	}()
 	callIfIsFunc(invocation)
+	lastInvocation := lastInvocationOfCurrentGoroutine()
+	argMatchers := argMatchersOfCurrentGoroutine()
+	if lastInvocation == nil || lastInvocation.stubbedAt != "" {
+		panicOnOrphanArgMatchers(0, len(argMatchers))
+	}
 	verify.Argument(lastInvocation != nil,
 		"When() requires an argument which has to be 'a method call on a mock'.")
-	defer func() {
-		lastInvocationMutex.Lock()
-		lastInvocation = nil
-		lastInvocationMutex.Unlock()
-
-		globalArgMatchers = nil
-	}()
-	lastInvocation.genericMock.mockedMethods[lastInvocation.MethodName].removeLastInvocation()
+	verify.Argument(lastInvocation.stubbedAt == "",
+		"When() requires an argument which has to be 'a method call on a mock', but got none. "+
+			"The last call on a mock, %v, was already stubbed at %v. Each When() needs its own call on a mock.",
+		lastInvocation.genericMock.qualified(lastInvocation.MethodName), lastInvocation.stubbedAt)
+	panicOnOrphanArgMatchers(lastInvocation.pendingArgMatchers, len(argMatchers))
+	panicOnOrphanArgMatchers(0, len(argMatchers)-len(lastInvocation.Params))
+	if !isFunc(invocation) {
+		verify.Argument(len(invocation) == len(lastInvocation.ReturnTypes),
+			"When() requires an argument which has to be 'a method call on a mock', but got %v values. "+
+				"The last call on a mock, %v, returns %v. Maybe When() got a call on a real object?",
+			len(invocation), lastInvocation.genericMock.qualified(lastInvocation.MethodName), len(lastInvocation.ReturnTypes))
+	}
+	if lastInvocation.recorded {
+		lastInvocation.genericMock.getOrCreateMockedMethod(lastInvocation.MethodName).removeLastInvocation()
+	}
+	if !lastInvocation.stubberCall {
+		lastInvocation.genericMock.getOrCreateMockedMethod(lastInvocation.MethodName).uncountAnswer(lastInvocation.Params)
+	}
 
-	paramMatchers := paramMatchersFromArgMatchersOrParams(globalArgMatchers, lastInvocation.Params)
+	paramMatchers := paramMatchersFromArgMatchersOrParams(argMatchers, lastInvocation.Params)
 	lastInvocation.genericMock.reset(lastInvocation.MethodName, paramMatchers)
+	_, file, line, _ := runtime.Caller(2)
+	stubbedAt := fmt.Sprintf("%v:%v", file, line)
+	trackStubbing(lastInvocation.genericMock, func() string { return stubbedAt })
+	trackStubbingUse(lastInvocation.genericMock, lastInvocation.MethodName, paramMatchers, func() string { return stubbedAt })
+	markStubbed = func() { markLastInvocationStubbed(lastInvocation, stubbedAt) }
 	return &ongoingStubbing{
 		genericMock:   lastInvocation.genericMock,
 		MethodName:    lastInvocation.MethodName,
@@ -415,43 +1278,61 @@ func When(invocation ...interface{}) *ongoingStubbing {
 	}
 }
 
+// markLastInvocationStubbed remembers that stubbedInvocation was stubbed, so a
+// second When() without a new call on a mock can tell where that happened.
+func markLastInvocationStubbed(stubbedInvocation *invocation, stubbedAt string) {
+	setLastInvocationOfCurrentGoroutine(&invocation{
+		genericMock: stubbedInvocation.genericMock,
+		MethodName:  stubbedInvocation.MethodName,
+		stubbedAt:   stubbedAt,
+	})
+}
+
 func callIfIsFunc(invocation []interface{}) {
-	if len(invocation) == 1 {
+	if isFunc(invocation) {
 		actualType := actualTypeOf(invocation[0])
-		if actualType != nil && actualType.Kind() == reflect.Func && !reflect.ValueOf(invocation[0]).IsNil() {
-			if !(actualType.NumIn() == 0 && actualType.NumOut() == 0) {
-				panic("When using 'When' with function that does not return a value, " +
-					"it expects a function with no arguments and no return value.")
-			}
-			reflect.ValueOf(invocation[0]).Call([]reflect.Value{})
+		if !(actualType.NumIn() == 0 && actualType.NumOut() == 0) {
+			panic("When using 'When' with function that does not return a value, " +
+				"it expects a function with no arguments and no return value.")
 		}
+		// Only a call on a mock made by the function may be stubbed, not one
+		// made before When().
+		clearLastInvocationOfCurrentGoroutine()
+		reflect.ValueOf(invocation[0]).Call([]reflect.Value{})
 	}
 }
 
+func isFunc(invocation []interface{}) bool {
+	if len(invocation) != 1 {
+		return false
+	}
+	actualType := actualTypeOf(invocation[0])
+	return actualType != nil && actualType.Kind() == reflect.Func && !reflect.ValueOf(invocation[0]).IsNil()
+}
+
 // Deals with nils without panicking
 func actualTypeOf(iface interface{}) reflect.Type {
 	defer func() { recover() }()
 	return reflect.TypeOf(iface)
 }
 
+// paramMatchersFromArgMatchersOrParams returns a matcher for each of params.
+// Params not given by argMatchers are matched with EqMatchers.
 func paramMatchersFromArgMatchersOrParams(argMatchers []Matcher, params []Param) []Matcher {
-	if len(argMatchers) != 0 {
-		verifyArgMatcherUse(argMatchers, params)
-		return argMatchers
+	if len(argMatchers) == 0 {
+		return transformParamsIntoEqMatchers(params)
+	}
+	if len(argMatchers) < len(params) {
+		return paramMatchersMixedWithParams(argMatchers, params)
 	}
-	return transformParamsIntoEqMatchers(params)
+	verifyArgMatcherUse(argMatchers, params)
+	return argMatchers
 }
 
 func verifyArgMatcherUse(argMatchers []Matcher, params []Param) {
 	verify.Argument(len(argMatchers) == len(params),
 		"Invalid use of matchers!\n\n %v matchers expected, %v recorded.\n\n"+
-			"This error may occur if matchers are combined with raw values:\n"+
-			"    //incorrect:\n"+
-			"    someFunc(AnyInt(), \"raw String\")\n"+
-			"When using matchers, all arguments have to be provided by matchers.\n"+
-			"For example:\n"+
-			"    //correct:\n"+
-			"    someFunc(AnyInt(), EqString(\"String by matcher\"))",
+			"This error may occur if matchers are registered without being passed to the call on the mock.",
 		len(params), len(argMatchers),
 	)
 }
@@ -470,26 +1351,189 @@ var (
 )
 
 func GetGenericMockFrom(mock Mock) *GenericMock {
+	flushBufferedFailuresIfOnTestGoroutine()
 	genericMocksMutex.Lock()
 	defer genericMocksMutex.Unlock()
 	if genericMocks[mock] == nil {
 		genericMocks[mock] = &GenericMock{
 			mockedMethods: make(map[string]*mockedMethod),
 			fail:          mock.FailHandler(),
+			mockTypeName:  mockTypeNameOf(mock),
+			mock:          mock,
+			startedAt:     time.Now(),
+		}
+		if mockWithMethodMetadata, ok := mock.(MockWithMethodMetadata); ok {
+			genericMocks[mock].methodMetadata = mockWithMethodMetadata.MethodMetadata()
+		}
+		if mockWithInterfaceName, ok := mock.(MockWithInterfaceName); ok {
+			genericMocks[mock].label = path.Base(mockWithInterfaceName.PegomockInterfaceName())
 		}
 	}
 	return genericMocks[mock]
 }
 
+// MockTypeName returns the type name of the mock being stubbed, e.g. MockDisplay.
+func (stubbing *ongoingStubbing) MockTypeName() string {
+	return stubbing.genericMock.mockTypeName
+}
+
 func (stubbing *ongoingStubbing) ThenReturn(values ...ReturnValue) *ongoingStubbing {
-	checkAssignabilityOf(values, stubbing.returnTypes)
+	values = checkAssignabilityOf(values, stubbing.returnTypes)
 	stubbing.genericMock.stub(stubbing.MethodName, stubbing.ParamMatchers, values)
 	return stubbing
 }
 
-func checkAssignabilityOf(stubbedReturnValues []ReturnValue, expectedReturnTypes []reflect.Type) {
+// ThenReturnFor stubs the method to return values for the next times calls.
+// After that, the entry stubbed next takes over, e.g.
+//
+//	When(client.Fetch()).ThenReturnFor(2, nil, ErrNotReady).ThenReturn(data, nil)
+//
+// fails the first two calls and succeeds on all later ones. Like with ThenReturn,
+// the last entry keeps being returned once all calls before it are used up, even
+// if it was stubbed with ThenReturnFor.
+func (stubbing *ongoingStubbing) ThenReturnFor(times int, values ...ReturnValue) *ongoingStubbing {
+	verify.Argument(times > 0, "ThenReturnFor requires times to be at least 1, but got %v", times)
+	values = checkAssignabilityOf(values, stubbing.returnTypes)
+	stubbing.genericMock.stubWithCallbackFor(
+		stubbing.MethodName,
+		stubbing.ParamMatchers,
+		times,
+		func([]Param) ReturnValues { return values })
+	return stubbing
+}
+
+// ThenReturnOnCall stubs the method to return values on its callNumber-th call,
+// counting from 1, e.g.
+//
+//	When(client.Fetch()).ThenReturn(data, nil).ThenReturnOnCall(3, nil, ErrTimeout)
+//
+// fails the third call and succeeds on all others. The other entries of the
+// chain answer the other calls, as if the callNumber-th call didn't happen. If
+// there are no other entries, the other calls are answered as if the method
+// wasn't stubbed: spies pass them through to their delegate, mocks created with
+// ReturnSelfByDefault or ReturnSmartNullsByDefault return what these provide,
+// and all other mocks return zero values.
+func (stubbing *ongoingStubbing) ThenReturnOnCall(callNumber int, values ...ReturnValue) *ongoingStubbing {
+	verify.Argument(callNumber > 0, "ThenReturnOnCall requires callNumber to be at least 1, but got %v", callNumber)
+	values = checkAssignabilityOf(values, stubbing.returnTypes)
+	stubbing.genericMock.getOrCreateMockedMethod(stubbing.MethodName).stubOnCall(
+		stubbing.ParamMatchers,
+		callNumber,
+		func([]Param) ReturnValues { return values })
+	return stubbing
+}
+
+// AnswerProvider is implemented by the answer types generated for each mocked
+// method, e.g. MockDisplay_SomeValue_Answer.
+type AnswerProvider interface {
+	Answer(params []Param) ReturnValues
+}
+
+// ThenAnswer stubs the method to answer calls with answer. answer is either a
+// function with the signature of the method, e.g.
+//
+//	When(store.Get(AnyString())).ThenAnswer(func(key string) (int, error) { return len(key), nil })
+//
+// whose signature is checked when stubbing, or a generated answer type, e.g.
+// MockStore_Get_Answer(func(key string) (int, error) { ... }), whose signature
+// is checked at compile time. Unlike with Then, params and results need no casts.
+// It panics if answer is neither or its signature doesn't match.
+func (stubbing *ongoingStubbing) ThenAnswer(answer interface{}) *ongoingStubbing {
+	if answerProvider, isAnswerProvider := answer.(AnswerProvider); isAnswerProvider {
+		return stubbing.Then(answerProvider.Answer)
+	}
+	methodType := stubbing.methodType()
+	answerValue := reflect.ValueOf(answer)
+	verify.Argument(answerValue.Kind() == reflect.Func && !answerValue.IsNil() && sameSignature(answerValue.Type(), methodType),
+		"ThenAnswer requires a function of type %v for %v, but got %T", methodType, stubbing.MethodName, answer)
+	return stubbing.Then(func(params []Param) ReturnValues {
+		return callWithParams(answerValue, params)
+	})
+}
+
+// methodType returns the type of the stubbed method, without receiver.
+func (stubbing *ongoingStubbing) methodType() reflect.Type {
+	verify.Argument(stubbing.genericMock.mock != nil,
+		"ThenAnswer with a plain function requires a generated mock, but %v is none", stubbing.genericMock.mockTypeName)
+	method := reflect.ValueOf(stubbing.genericMock.mock).MethodByName(stubbing.MethodName)
+	verify.Argument(method.IsValid(), "%v has no method %v", stubbing.genericMock.mockTypeName, stubbing.MethodName)
+	return method.Type()
+}
+
+func sameSignature(a, b reflect.Type) bool {
+	if a.NumIn() != b.NumIn() || a.NumOut() != b.NumOut() || a.IsVariadic() != b.IsVariadic() {
+		return false
+	}
+	for i := 0; i < a.NumIn(); i++ {
+		if a.In(i) != b.In(i) {
+			return false
+		}
+	}
+	for i := 0; i < a.NumOut(); i++ {
+		if a.Out(i) != b.Out(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// callWithParams calls function with params, as passed to Invoke, i.e. with
+// variadic arguments passed individually.
+func callWithParams(function reflect.Value, params []Param) ReturnValues {
+	functionType := function.Type()
+	args := make([]reflect.Value, functionType.NumIn())
+	for i := range args {
+		if functionType.IsVariadic() && i == len(args)-1 {
+			args[i] = reflect.MakeSlice(functionType.In(i), len(params)-i, len(params)-i)
+			for j, param := range params[i:] {
+				if param != nil {
+					args[i].Index(j).Set(reflect.ValueOf(param))
+				}
+			}
+		} else if params[i] == nil {
+			args[i] = reflect.Zero(functionType.In(i))
+		} else {
+			args[i] = reflect.ValueOf(params[i])
+		}
+	}
+	var results []reflect.Value
+	if functionType.IsVariadic() {
+		results = function.CallSlice(args)
+	} else {
+		results = function.Call(args)
+	}
+	returnValues := make(ReturnValues, len(results))
+	for i, result := range results {
+		returnValues[i] = result.Interface()
+	}
+	return returnValues
+}
+
+// ReturnValuesProvider is implemented by the returns structs generated for each
+// mocked method that has return values, e.g. MockDisplay_SomeValue_Returns.
+type ReturnValuesProvider interface {
+	ReturnValues() ReturnValues
+}
+
+// ThenReturnStruct is like ThenReturn, but takes the return values as the
+// generated returns struct of the method, so their number and types are checked
+// at compile time. Several structs stub consecutive return values.
+func (stubbing *ongoingStubbing) ThenReturnStruct(returns ...ReturnValuesProvider) *ongoingStubbing {
+	verify.Argument(len(returns) > 0, "ThenReturnStruct requires at least one returns struct")
+	for _, r := range returns {
+		stubbing.ThenReturn(r.ReturnValues()...)
+	}
+	return stubbing
+}
+
+// checkAssignabilityOf panics unless stubbedReturnValues can be returned as
+// expectedReturnTypes. It returns the values converted to the expected types
+// where they differ, i.e. bidirectional channels returned as directional ones.
+func checkAssignabilityOf(stubbedReturnValues []ReturnValue, expectedReturnTypes []reflect.Type) []ReturnValue {
 	verify.Argument(len(stubbedReturnValues) == len(expectedReturnTypes),
 		"Different number of return values")
+	converted := make([]ReturnValue, len(stubbedReturnValues))
+	copy(converted, stubbedReturnValues)
 	for i := range stubbedReturnValues {
 		if stubbedReturnValues[i] == nil {
 			switch expectedReturnTypes[i].Kind() {
@@ -499,11 +1543,104 @@ func checkAssignabilityOf(stubbedReturnValues []ReturnValue, expectedReturnTypes
 				reflect.Struct:
 				panic("Return value 'nil' not assignable to return type " + expectedReturnTypes[i].Kind().String())
 			}
+		} else if expectedReturnTypes[i] == errorType {
+			verify.Argument(reflect.TypeOf(stubbedReturnValues[i]).Implements(errorType),
+				"Return value of type %T not assignable to return type error%v",
+				stubbedReturnValues[i], errorAssignabilityHint(reflect.TypeOf(stubbedReturnValues[i]), i, expectedReturnTypes))
 		} else {
 			verify.Argument(reflect.TypeOf(stubbedReturnValues[i]).AssignableTo(expectedReturnTypes[i]),
 				"Return value of type %T not assignable to return type %v", stubbedReturnValues[i], expectedReturnTypes[i])
+			if expectedReturnTypes[i].Kind() == reflect.Chan {
+				converted[i] = reflect.ValueOf(stubbedReturnValues[i]).Convert(expectedReturnTypes[i]).Interface()
+			}
 		}
 	}
+	return converted
+}
+
+// errorAssignabilityHint explains why a value of type t can't be returned as the
+// error at index i of returnTypes: its Error method might have a pointer
+// receiver, or the error might not be the last return value, which suggests the
+// values were passed in the wrong order.
+func errorAssignabilityHint(t reflect.Type, i int, returnTypes []reflect.Type) string {
+	if t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(errorType) {
+		return fmt.Sprintf(", because its Error method has a pointer receiver. Return a *%v instead", t)
+	}
+	if i < len(returnTypes)-1 {
+		return fmt.Sprintf(". Note that the method returns (%v), with the error not being the last return value. "+
+			"Maybe the values are in the wrong order?", formatTypes(returnTypes))
+	}
+	return ""
+}
+
+// ThenReturnError stubs the method to return err as its last return value and
+// zero values for all others. It panics if the method's last return value is not
+// an error.
+func (stubbing *ongoingStubbing) ThenReturnError(err error) *ongoingStubbing {
+	numReturns := len(stubbing.returnTypes)
+	verify.Argument(numReturns > 0 && stubbing.returnTypes[numReturns-1] == errorType,
+		"ThenReturnError requires a method whose last return value is an error, but %v returns (%v)",
+		stubbing.MethodName, formatTypes(stubbing.returnTypes))
+	values := make(ReturnValues, numReturns)
+	for i, returnType := range stubbing.returnTypes[:numReturns-1] {
+		values[i] = zeroReturnValueOf(returnType)
+	}
+	values[numReturns-1] = err
+	stubbing.genericMock.stub(stubbing.MethodName, stubbing.ParamMatchers, values)
+	return stubbing
+}
+
+// DoNothing stubs the method to return zero values for all its return values,
+// which is mostly useful for methods without return values, e.g.
+//
+//	When(func() { repository.Save(AnyString()) }).DoNothing().ThenPanic("second call fails")
+func (stubbing *ongoingStubbing) DoNothing() *ongoingStubbing {
+	values := make(ReturnValues, len(stubbing.returnTypes))
+	for i, returnType := range stubbing.returnTypes {
+		values[i] = zeroReturnValueOf(returnType)
+	}
+	stubbing.genericMock.stub(stubbing.MethodName, stubbing.ParamMatchers, values)
+	return stubbing
+}
+
+// DoReturn is a synonym for ThenReturn, for those used to Mockito's doReturn.
+func (stubbing *ongoingStubbing) DoReturn(values ...ReturnValue) *ongoingStubbing {
+	return stubbing.ThenReturn(values...)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func zeroReturnValueOf(returnType reflect.Type) ReturnValue {
+	switch returnType.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return nil
+	default:
+		return reflect.Zero(returnType).Interface()
+	}
+}
+
+func formatTypes(types []reflect.Type) string {
+	formatted := make([]string, len(types))
+	for i, t := range types {
+		formatted[i] = t.String()
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// ThenCallRealMethod stubs the method to pass calls through to the delegate of
+// a spy. It panics if the mock is not a spy.
+func (stubbing *ongoingStubbing) ThenCallRealMethod() *ongoingStubbing {
+	stubbing.genericMock.Lock()
+	fallback := stubbing.genericMock.fallback
+	stubbing.genericMock.Unlock()
+	verify.Argument(fallback != nil,
+		"ThenCallRealMethod requires a spy, i.e. a mock created with NewSpy..., but %v is no spy", stubbing.genericMock.mockTypeName)
+	methodName := stubbing.MethodName
+	stubbing.genericMock.stubWithCallback(
+		methodName,
+		stubbing.ParamMatchers,
+		func(params []Param) ReturnValues { return fallback(methodName, params) })
+	return stubbing
 }
 
 func (stubbing *ongoingStubbing) ThenPanic(v interface{}) *ongoingStubbing {
@@ -522,14 +1659,28 @@ func (stubbing *ongoingStubbing) Then(callback func([]Param) ReturnValues) *ongo
 	return stubbing
 }
 
+// Mock returns the mock whose method is being stubbed. It is meant for packages
+// that build other stubbing APIs on top of When, like gomockadapter.
+func (stubbing *ongoingStubbing) Mock() Mock {
+	return stubbing.genericMock.mock
+}
+
 type InOrderContext struct {
 	invocationCounter       int
 	lastInvokedMethodName   string
 	lastInvokedMethodParams []Param
+	// strict and mocks are set by NewStrictInOrderContext.
+	strict bool
+	mocks  []*GenericMock
 }
 
 // Matcher ... it is guaranteed that FailureMessage will always be called after Matches
 // so an implementation can save state
+//
+// Matches is on the hot path of every stubbed and verified invocation, while
+// FailureMessage and String are only called when something needs to be reported.
+// Implementations should therefore store raw values and do all formatting lazily
+// in FailureMessage and String, as the built-in matchers do.
 type Matcher interface {
 	Matches(param Param) bool
 	FailureMessage() string
@@ -559,14 +1710,8 @@ func SDumpInvocationsFor(mock Mock) string {
 //
 // This is accomplished by temporarily replacing the *global* fail handler
 // with a fail handler that simply annotates failures.  The original fail handler
-// is reset when InterceptMockFailures returns.
+// is reset when InterceptMockFailures returns, even if the callback panics.
+// It is short for WithGlobalFailHandler(CollectFailures, f).
 func InterceptMockFailures(f func()) []string {
-	originalHandler := GlobalFailHandler
-	failures := []string{}
-	RegisterMockFailHandler(func(message string, callerSkip ...int) {
-		failures = append(failures, message)
-	})
-	f()
-	RegisterMockFailHandler(originalHandler)
-	return failures
+	return WithGlobalFailHandler(CollectFailures, f)
 }
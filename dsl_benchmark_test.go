@@ -0,0 +1,53 @@
+package pegomock_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+)
+
+// BenchmarkStubbingAndInvokingWithEqMatchers simulates a table test with 10k
+// rows, each of which stubs and invokes a fresh mock using Eq matchers.
+func BenchmarkStubbingAndInvokingWithEqMatchers(b *testing.B) {
+	failHandler := WithFailHandler(func(message string, callerSkip ...int) { b.Fatal(message) })
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for row := 0; row < 10000; row++ {
+			display := NewMockDisplay(failHandler)
+			When(display.MultipleParamsAndReturnValue(EqString("Hello"), EqInt(row))).ThenReturn("World")
+			if display.MultipleParamsAndReturnValue("Hello", row) != "World" {
+				b.Fatal("Unexpected return value")
+			}
+		}
+	}
+}
+
+// BenchmarkInvoking invokes a stubbed mock in a hot loop, recording every
+// invocation. Compare with BenchmarkInvokingWithRecordingDisabled.
+func BenchmarkInvoking(b *testing.B) {
+	display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) { b.Fatal(message) }))
+	When(display.MultipleParamsAndReturnValue(EqString("Hello"), AnyInt())).ThenReturn("World")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if display.MultipleParamsAndReturnValue("Hello", i) != "World" {
+			b.Fatal("Unexpected return value")
+		}
+	}
+}
+
+// BenchmarkInvokingWithRecordingDisabled is BenchmarkInvoking with
+// DisableRecording, which lets the mock skip the invocation history and reuse
+// param slices.
+func BenchmarkInvokingWithRecordingDisabled(b *testing.B) {
+	display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) { b.Fatal(message) }))
+	When(display.MultipleParamsAndReturnValue(EqString("Hello"), AnyInt())).ThenReturn("World")
+	DisableRecording(display)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if display.MultipleParamsAndReturnValue("Hello", i) != "World" {
+			b.Fatal("Unexpected return value")
+		}
+	}
+}
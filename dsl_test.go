@@ -15,10 +15,17 @@
 package pegomock_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"expvar"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
 	"reflect"
+	"regexp"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -26,14 +33,17 @@ import (
 	. "github.com/petergtz/pegomock"
 	. "github.com/petergtz/pegomock/matchers"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
 	"github.com/petergtz/pegomock"
 	"github.com/petergtz/pegomock/test_interface"
 )
 
 var (
 	BeforeEach       = ginkgo.BeforeEach
+	AfterEach        = ginkgo.AfterEach
 	It               = ginkgo.It
 	FIt              = ginkgo.FIt
 	Describe         = ginkgo.Describe
@@ -43,6 +53,7 @@ var (
 	ConsistOf        = gomega.ConsistOf
 	ContainSubstring = gomega.ContainSubstring
 	MatchError       = gomega.MatchError
+	MatchRegexp      = gomega.MatchRegexp
 	Equal            = gomega.Equal
 	Expect           = gomega.Expect
 	HaveLen          = gomega.HaveLen
@@ -107,7 +118,7 @@ var _ = Describe("MockDisplay", func() {
 
 		It("fails during verification when mock was not called", func() {
 			Expect(func() { display.VerifyWasCalledOnce().MultipleParamsAndReturnValue("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
-				"Mock invocation count for MultipleParamsAndReturnValue(\"Hello\", 333) does not match expectation.\n\n\tExpected: 1; but got: 0",
+				"Mock invocation count for test_interface.Display.MultipleParamsAndReturnValue(\"Hello\", 333) does not match expectation.\n\n\tExpected to be called once but was called 0 times",
 			)))
 		})
 
@@ -118,7 +129,7 @@ var _ = Describe("MockDisplay", func() {
 
 		It("succeeds verification when verification and invocation are mixed", func() {
 			Expect(func() { display.VerifyWasCalledOnce().MultipleParamsAndReturnValue("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "MultipleParamsAndReturnValue(\"Hello\", 333)", expected: "1", actual: "0"}.string(),
+				expectation{method: "MultipleParamsAndReturnValue(\"Hello\", 333)", expected: "once", actual: "0 times"}.string(),
 			)))
 			display.MultipleParamsAndReturnValue("Hello", 333)
 			Expect(func() { display.VerifyWasCalledOnce().MultipleParamsAndReturnValue("Hello", 333) }).NotTo(Panic())
@@ -130,7 +141,7 @@ var _ = Describe("MockDisplay", func() {
 			When(display.MultipleParamsAndReturnValue(AnyString(), EqInt(333))).ThenReturn("Bla")
 
 			Expect(func() { display.VerifyWasCalledOnce().MultipleParamsAndReturnValue("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "MultipleParamsAndReturnValue(\"Hello\", 333)", expected: "1", actual: "0"}.string(),
+				expectation{method: "MultipleParamsAndReturnValue(\"Hello\", 333)", expected: "once", actual: "0 times"}.string(),
 			)))
 
 			display.MultipleParamsAndReturnValue("Hello", 333)
@@ -142,23 +153,48 @@ var _ = Describe("MockDisplay", func() {
 			Expect(func() { display.VerifyWasCalledOnce().MultipleParamsAndReturnValue("And again", 333) }).NotTo(Panic())
 
 			Expect(func() { display.VerifyWasCalledOnce().MultipleParamsAndReturnValue("And again", 444) }).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "MultipleParamsAndReturnValue(\"And again\", 444)", expected: "1", actual: "0"}.string(),
+				expectation{method: "MultipleParamsAndReturnValue(\"And again\", 444)", expected: "once", actual: "0 times"}.string(),
 			)))
 
 		})
 	})
 
 	Context("Calling MultipleParamsAndReturnValue() only with matchers on some parameters", func() {
-		It("panics", func() {
-			Expect(func() { When(display.MultipleParamsAndReturnValue(EqString("Hello"), 333)) }).To(PanicWithMessageTo(HavePrefix(
-				"Invalid use of matchers!\n\n 2 matchers expected, 1 recorded.\n\n" +
-					"This error may occur if matchers are combined with raw values:\n" +
-					"    //incorrect:\n" +
+		It("matches the other parameters with Eq matchers", func() {
+			When(display.MultipleParamsAndReturnValue(EqString("Hello"), 333)).ThenReturn("Bla")
+			When(display.MultipleParamsAndReturnValue("Hi", AnyInt())).ThenReturn("Blub")
+
+			Expect(display.MultipleParamsAndReturnValue("Hello", 333)).To(Equal("Bla"))
+			Expect(display.MultipleParamsAndReturnValue("Hello", 444)).To(Equal(""))
+			Expect(display.MultipleParamsAndReturnValue("Hi", 444)).To(Equal("Blub"))
+			Expect(display.MultipleParamsAndReturnValue("Hey", 444)).To(Equal(""))
+		})
+
+		It("tells matchers from raw zero values of other types", func() {
+			When(display.MultipleParamsAndReturnValue("", AnyInt())).ThenReturn("Bla")
+
+			Expect(display.MultipleParamsAndReturnValue("", 444)).To(Equal("Bla"))
+			Expect(display.MultipleParamsAndReturnValue("Hello", 444)).To(Equal(""))
+		})
+
+		It("matches the variadic parameters with Eq matchers", func() {
+			display.NormalAndVariadicParam("Hello", 333, "a", "b")
+
+			Expect(func() { display.VerifyWasCalledOnce().NormalAndVariadicParam(AnyString(), 333, "a", "b") }).NotTo(Panic())
+			Expect(func() { display.VerifyWasCalledOnce().NormalAndVariadicParam("Hello", 333, "a", AnyString()) }).NotTo(Panic())
+			Expect(func() { display.VerifyWasCalledOnce().NormalAndVariadicParam("Hello", AnyInt(), "a", "c") }).To(PanicWithMessageTo(HavePrefix(
+				expectation{method: "NormalAndVariadicParam(Eq(Hello), Any(int), Eq(a), Eq(c))", expected: "once", actual: "0 times"}.string(),
+			)))
+		})
+
+		It("panics if the matchers can't be told apart from raw zero values", func() {
+			Expect(func() { display.VerifyWasCalledOnce().NormalAndVariadicParam("", 333, AnyString()) }).To(PanicWithMessageTo(HavePrefix(
+				"Invalid use of matchers!\n\n 3 matchers expected, 1 recorded.\n\n" +
+					"Matchers can be combined with raw values, e.g.:\n" +
 					"    someFunc(AnyInt(), \"raw String\")\n" +
-					"When using matchers, all arguments have to be provided by matchers.\n" +
-					"For example:\n" +
-					"    //correct:\n" +
-					"    someFunc(AnyInt(), EqString(\"String by matcher\"))",
+					"but the matchers Any(string) can't be told apart from the raw values in (\"\", 333, \"\"), " +
+					"because there are several arguments they could have been passed as.\n" +
+					"Matcher functions return zero values, so wrap raw zero values, e.g. \"\", 0 or nil, in matchers, too.",
 			)))
 		})
 	})
@@ -189,7 +225,7 @@ var _ = Describe("MockDisplay", func() {
 
 		It("fails if verify is called on mock that was not invoked.", func() {
 			Expect(func() { display.VerifyWasCalledOnce().Show("Some parameter") }).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "Show(\"Some parameter\")", expected: "1", actual: "0"}.string(),
+				expectation{method: "Show(\"Some parameter\")", expected: "once", actual: "0 times"}.string(),
 			)))
 		})
 
@@ -197,9 +233,198 @@ var _ = Describe("MockDisplay", func() {
 			display.Show("param")
 			display.Show("param")
 			Expect(func() { display.VerifyWasCalledOnce().Show("param") }).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "Show(\"param\")", expected: "1", actual: "2"}.string(),
+				expectation{method: "Show(\"param\")", expected: "once", actual: "twice"}.string(),
+			)))
+
+		})
+	})
+
+	Context("Stubbing with ThenReturnFor", func() {
+		It("returns the values for the given number of calls before falling through to the next entry", func() {
+			When(display.ErrorReturnValue()).ThenReturnFor(2, errors.New("not ready")).ThenReturn(nil)
+
+			Expect(display.ErrorReturnValue()).To(MatchError("not ready"))
+			Expect(display.ErrorReturnValue()).To(MatchError("not ready"))
+			Expect(display.ErrorReturnValue()).To(BeNil())
+			Expect(display.ErrorReturnValue()).To(BeNil())
+		})
+
+		It("combines with other entries in the order they were stubbed", func() {
+			When(display.SomeValue()).ThenReturn("first").ThenReturnFor(3, "middle").ThenReturn("last")
+
+			var values []string
+			for i := 0; i < 6; i++ {
+				values = append(values, display.SomeValue())
+			}
+			Expect(values).To(Equal([]string{"first", "middle", "middle", "middle", "last", "last"}))
+		})
+
+		It("keeps returning the values of the last entry after its calls are used up", func() {
+			When(display.SomeValue()).ThenReturn("first").ThenReturnFor(2, "last")
+
+			var values []string
+			for i := 0; i < 5; i++ {
+				values = append(values, display.SomeValue())
+			}
+			Expect(values).To(Equal([]string{"first", "last", "last", "last", "last"}))
+		})
+
+		It("does not affect verification counts", func() {
+			When(display.SomeValue()).ThenReturnFor(2, "Hello").ThenReturn("again")
+
+			display.SomeValue()
+			display.SomeValue()
+			display.SomeValue()
+
+			display.VerifyWasCalled(Times(3)).SomeValue()
+		})
+
+		It("panics when times is less than 1", func() {
+			Expect(func() { When(display.SomeValue()).ThenReturnFor(0, "Hello") }).To(PanicWithMessageTo(HavePrefix(
+				"ThenReturnFor requires times to be at least 1, but got 0",
+			)))
+		})
+
+		It("panics when the values are not assignable to the return types", func() {
+			Expect(func() { When(display.SomeValue()).ThenReturnFor(2, 0) }).To(PanicWithMessageTo(HavePrefix(
+				"Return value of type int not assignable to return type string",
+			)))
+		})
+	})
+
+	Context("Stubbing with ThenReturnOnCall", func() {
+		It("returns the values on the given call and the other entries on all other calls", func() {
+			When(display.ErrorReturnValue()).ThenReturn(nil).ThenReturnOnCall(3, errors.New("timeout"))
+
+			Expect(display.ErrorReturnValue()).To(BeNil())
+			Expect(display.ErrorReturnValue()).To(BeNil())
+			Expect(display.ErrorReturnValue()).To(MatchError("timeout"))
+			Expect(display.ErrorReturnValue()).To(BeNil())
+		})
+
+		It("doesn't use up the other entries on the given calls", func() {
+			When(display.SomeValue()).ThenReturn("first").ThenReturn("second").ThenReturnOnCall(1, "on call 1").ThenReturnOnCall(3, "on call 3")
+
+			var values []string
+			for i := 0; i < 5; i++ {
+				values = append(values, display.SomeValue())
+			}
+			Expect(values).To(Equal([]string{"on call 1", "first", "on call 3", "second", "second"}))
+		})
+
+		It("returns zero values on all other calls without other entries", func() {
+			When(display.SomeValue()).ThenReturnOnCall(2, "second")
+
+			Expect(display.SomeValue()).To(Equal(""))
+			Expect(display.SomeValue()).To(Equal("second"))
+			Expect(display.SomeValue()).To(Equal(""))
+		})
+
+		It("answers all other calls of spies by their delegate without other entries", func() {
+			delegate := NewMockDisplay()
+			When(delegate.MultipleParamsAndReturnValue("Hello", 1)).ThenReturn("real value")
+			spy := NewSpyDisplay(delegate)
+			When(spy.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenReturnOnCall(2, "second")
+
+			Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("real value"))
+			Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("second"))
+			Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("real value"))
+		})
+
+		It("returns smart nulls on all other calls without other entries", func() {
+			query := NewMockQuery(ReturnSmartNullsByDefault(NewMockQuery))
+			other := NewMockQuery()
+			When(query.Where("x")).ThenReturnOnCall(2, other)
+
+			Expect(query.Where("x")).To(SatisfyAll(gomega.Not(BeNil()), gomega.Not(gomega.BeIdenticalTo(other))))
+			Expect(query.Where("x")).To(gomega.BeIdenticalTo(other))
+		})
+
+		It("answers each given call exactly once when called from parallel goroutines", func() {
+			stubbing := When(display.SomeValue()).ThenReturn("other")
+			expected := []string{}
+			for callNumber := 1; callNumber <= 10; callNumber++ {
+				stubbing.ThenReturnOnCall(callNumber, fmt.Sprint("on call ", callNumber))
+				expected = append(expected, fmt.Sprint("on call ", callNumber), "other")
+			}
+
+			var values []string
+			var valuesMutex sync.Mutex
+			wg := sync.WaitGroup{}
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					value := display.SomeValue()
+					valuesMutex.Lock()
+					values = append(values, value)
+					valuesMutex.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			Expect(values).To(gomega.ConsistOf(expected))
+		})
+
+		It("counts calls again after ResetForNextTest with KeepStubbings", func() {
+			When(display.SomeValue()).ThenReturn("other").ThenReturnOnCall(1, "first")
+			display.SomeValue()
+			display.ResetForNextTest(KeepStubbings)
+
+			Expect(display.SomeValue()).To(Equal("first"))
+		})
+
+		It("panics when callNumber is less than 1 or the values are not assignable to the return types", func() {
+			Expect(func() { When(display.SomeValue()).ThenReturnOnCall(0, "Hello") }).To(PanicWithMessageTo(HavePrefix(
+				"ThenReturnOnCall requires callNumber to be at least 1, but got 0",
+			)))
+			Expect(func() { When(display.SomeValue()).ThenReturnOnCall(2, 0) }).To(PanicWithMessageTo(HavePrefix(
+				"Return value of type int not assignable to return type string",
 			)))
+		})
+	})
+
+	Context("Stubbing with ThenAnswer", func() {
+		It("answers with a plain function that has the method's signature", func() {
+			When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenAnswer(func(s string, i int) string {
+				return fmt.Sprintf("%v %v", s, i)
+			})
+
+			Expect(display.MultipleParamsAndReturnValue("Hello", 3)).To(Equal("Hello 3"))
+		})
+
+		It("answers with a generated answer type", func() {
+			When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenAnswer(
+				MockDisplay_MultipleParamsAndReturnValue_Answer(func(s string, i int) string { return s + "!" }))
+
+			Expect(display.MultipleParamsAndReturnValue("Hello", 3)).To(Equal("Hello!"))
+		})
+
+		It("passes variadic arguments and nil params", func() {
+			var plain, generated []string
+			When(func() { display.NormalAndVariadicParam(AnyString(), AnyInt(), AnyString(), AnyString()) }).
+				ThenAnswer(func(s string, i int, v ...string) { plain = append([]string{s}, v...) })
+			When(func() { display.VariadicParam(AnyString()) }).
+				ThenAnswer(MockDisplay_VariadicParam_Answer(func(v ...string) { generated = v }))
+			var interfaceParam interface{} = "not nil"
+			When(func() { display.InterfaceParam(AnyInterface()) }).
+				ThenAnswer(func(param interface{}) { interfaceParam = param })
+
+			display.NormalAndVariadicParam("Hello", 1, "a", "b")
+			display.VariadicParam("c")
+			display.InterfaceParam(nil)
+
+			Expect(plain).To(Equal([]string{"Hello", "a", "b"}))
+			Expect(generated).To(Equal([]string{"c"}))
+			Expect(interfaceParam).To(BeNil())
+		})
 
+		It("panics when the function's signature doesn't match the method's", func() {
+			Expect(func() {
+				When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenAnswer(func(s string) string { return s })
+			}).To(PanicWith("ThenAnswer requires a function of type func(string, int) string for MultipleParamsAndReturnValue, but got func(string) string"))
+			Expect(func() { When(display.SomeValue()).ThenAnswer("Hello") }).To(PanicWith(
+				"ThenAnswer requires a function of type func() string for SomeValue, but got string"))
 		})
 	})
 
@@ -211,6 +436,105 @@ var _ = Describe("MockDisplay", func() {
 		})
 	})
 
+	Context("Stubbing with returns structs", func() {
+		It("returns the values of the struct", func() {
+			for _, entry := range []struct {
+				s       string
+				returns MockDisplay_ContextAwareCall_Returns
+			}{
+				{"found", MockDisplay_ContextAwareCall_Returns{Ret0: "result"}},
+				{"missing", MockDisplay_ContextAwareCall_Returns{Ret1: errors.New("not found")}},
+			} {
+				When(display.ContextAwareCall(AnyContext(), EqString(entry.s))).ThenReturnStruct(entry.returns)
+			}
+
+			Expect(display.ContextAwareCall(context.Background(), "found")).To(Equal("result"))
+			_, err := display.ContextAwareCall(context.Background(), "missing")
+			Expect(err).To(MatchError("not found"))
+		})
+
+		It("returns consecutive values for several structs", func() {
+			When(display.MultipleValues()).ThenReturnStruct(
+				MockDisplay_MultipleValues_Returns{"one", 1, 1.5},
+				MockDisplay_MultipleValues_Returns{"two", 2, 2.5})
+
+			s, i, f := display.MultipleValues()
+			Expect([]interface{}{s, i, f}).To(Equal([]interface{}{"one", 1, float32(1.5)}))
+			s, i, f = display.MultipleValues()
+			Expect([]interface{}{s, i, f}).To(Equal([]interface{}{"two", 2, float32(2.5)}))
+		})
+
+		It("panics when given the returns struct of another method", func() {
+			Expect(func() {
+				When(display.SomeValue()).ThenReturnStruct(MockDisplay_ErrorReturnValue_Returns{})
+			}).To(PanicWithMessageTo(HavePrefix("Return value 'nil' not assignable to return type string")))
+		})
+	})
+
+	Context("Stubbing with ThenReturnError", func() {
+		It("returns the error and zero values for all other return values", func() {
+			When(display.ContextAwareCall(AnyContext(), AnyString())).ThenReturnError(errors.New("Ouch"))
+
+			s, err := display.ContextAwareCall(context.Background(), "Hello")
+
+			Expect(s).To(Equal(""))
+			Expect(err).To(MatchError("Ouch"))
+		})
+
+		It("returns the error for methods with an error as the only return value", func() {
+			When(display.ErrorReturnValue()).ThenReturnError(errors.New("Ouch"))
+
+			Expect(display.ErrorReturnValue()).To(MatchError("Ouch"))
+		})
+
+		It("panics when the method's last return value is not an error", func() {
+			Expect(func() { When(display.MultipleValues()).ThenReturnError(errors.New("Ouch")) }).To(PanicWithMessageTo(HavePrefix(
+				"ThenReturnError requires a method whose last return value is an error, but MultipleValues returns (string, int, float32)",
+			)))
+		})
+	})
+
+	Context("Stubbing with DoNothing", func() {
+		It("returns zero values for primitives, pointers and interfaces", func() {
+			When(display.MultipleValues()).DoNothing()
+			When(display.PointerReturnValue()).DoNothing()
+			When(display.InterfaceReturnValue()).DoNothing()
+			When(display.ErrorReturnValue()).DoNothing()
+
+			s, i, f := display.MultipleValues()
+			Expect([]interface{}{s, i, f}).To(Equal([]interface{}{"", 0, float32(0)}))
+			Expect(display.PointerReturnValue()).To(BeNil())
+			Expect(display.InterfaceReturnValue()).To(BeNil())
+			Expect(display.ErrorReturnValue()).To(BeNil())
+		})
+
+		It("stubs methods without return values and chains", func() {
+			When(func() { display.Show(AnyString()) }).DoNothing().ThenPanic("second call fails")
+
+			display.Show("one")
+			Expect(func() { display.Show("two") }).To(PanicWith("second call fails"))
+		})
+
+		It("overrides a previous stubbing", func() {
+			When(display.SomeValue()).ThenReturn("Hello")
+			When(display.SomeValue()).DoNothing().ThenReturn("again")
+
+			Expect(display.SomeValue()).To(Equal(""))
+			Expect(display.SomeValue()).To(Equal("again"))
+		})
+	})
+
+	Context("Stubbing with DoReturn", func() {
+		It("works like ThenReturn", func() {
+			When(display.MultipleValues()).DoReturn("one", 1, float32(1.5)).DoReturn("two", 2, float32(2.5))
+
+			s, i, f := display.MultipleValues()
+			Expect([]interface{}{s, i, f}).To(Equal([]interface{}{"one", 1, float32(1.5)}))
+			s, i, f = display.MultipleValues()
+			Expect([]interface{}{s, i, f}).To(Equal([]interface{}{"two", 2, float32(2.5)}))
+		})
+	})
+
 	Describe("https://github.com/petergtz/pegomock/issues/24", func() {
 		Context("Stubbing with nil value", func() {
 			It("does not panic when return type is interface{}", func() {
@@ -246,6 +570,15 @@ var _ = Describe("MockDisplay", func() {
 			})
 		})
 
+		Context("Stubbing with value whose Error method has a pointer receiver", func() {
+			It("panics with a hint to return a pointer", func() {
+				Expect(func() { When(display.ErrorReturnValue()).ThenReturn(pointerReceiverError{}) }).To(PanicWith(
+					"Return value of type pegomock_test.pointerReceiverError not assignable to return type error, " +
+						"because its Error method has a pointer receiver. Return a *pegomock_test.pointerReceiverError instead",
+				))
+			})
+		})
+
 		Context("Stubbing string return type with nil value", func() {
 			It("panics", func() {
 				Expect(func() { When(display.SomeValue()).ThenReturn(nil) }).To(PanicWith(
@@ -260,7 +593,7 @@ var _ = Describe("MockDisplay", func() {
 		It("fails during verification", func() {
 			When(display.SomeValue()).ThenReturn("Hello")
 			Expect(func() { display.VerifyWasCalledOnce().SomeValue() }).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "SomeValue()", expected: "1", actual: "0"}.string(),
+				expectation{method: "SomeValue()", expected: "once", actual: "0 times"}.string(),
 			)))
 		})
 	})
@@ -275,7 +608,7 @@ var _ = Describe("MockDisplay", func() {
 
 		It("fails during verification if values are not matching", func() {
 			Expect(func() { display.VerifyWasCalledOnce().Flash("Hello", 666) }).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "Flash(\"Hello\", 666)", expected: "1", actual: "0"}.string(),
+				expectation{method: "Flash(\"Hello\", 666)", expected: "once", actual: "0 times"}.string(),
 			)))
 		})
 
@@ -289,21 +622,15 @@ var _ = Describe("MockDisplay", func() {
 
 		It("fails during verification when using invalid Eq-matchers ", func() {
 			Expect(func() { display.VerifyWasCalledOnce().Flash(EqString("Invalid"), EqInt(-1)) }).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "Flash(Eq(Invalid), Eq(-1))", expected: "1", actual: "0"}.string(),
+				expectation{method: "Flash(Eq(Invalid), Eq(-1))", expected: "once", actual: "0 times"}.string(),
 			)))
 		})
 
-		It("fails when not using matchers for all params", func() {
-			Expect(func() { display.VerifyWasCalledOnce().Flash("Hello", AnyInt()) }).To(PanicWith(
-				"Invalid use of matchers!\n\n 2 matchers expected, 1 recorded.\n\n" +
-					"This error may occur if matchers are combined with raw values:\n" +
-					"    //incorrect:\n" +
-					"    someFunc(AnyInt(), \"raw String\")\n" +
-					"When using matchers, all arguments have to be provided by matchers.\n" +
-					"For example:\n" +
-					"    //correct:\n" +
-					"    someFunc(AnyInt(), EqString(\"String by matcher\"))",
-			))
+		It("succeeds when using matchers only for some params", func() {
+			Expect(func() { display.VerifyWasCalledOnce().Flash("Hello", AnyInt()) }).NotTo(Panic())
+			Expect(func() { display.VerifyWasCalledOnce().Flash("Invalid", AnyInt()) }).To(PanicWithMessageTo(HavePrefix(
+				expectation{method: "Flash(Eq(Invalid), Any(int))", expected: "once", actual: "0 times"}.string(),
+			)))
 		})
 	})
 
@@ -322,13 +649,13 @@ var _ = Describe("MockDisplay", func() {
 
 			It("fails during verification if verifying with VerifyWasCalledOnce", func() {
 				Expect(func() { display.VerifyWasCalledOnce().Flash("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
-					expectation{method: "Flash(\"Hello\", 333)", expected: "1", actual: "2"}.string(),
+					expectation{method: "Flash(\"Hello\", 333)", expected: "once", actual: "twice"}.string(),
 				)))
 			})
 
 			It("fails during verification if verifying with Times(1)", func() {
 				Expect(func() { display.VerifyWasCalled(Times(1)).Flash("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
-					expectation{method: "Flash(\"Hello\", 333)", expected: "1", actual: "2"}.string(),
+					expectation{method: "Flash(\"Hello\", 333)", expected: "once", actual: "twice"}.string(),
 				)))
 			})
 
@@ -342,7 +669,7 @@ var _ = Describe("MockDisplay", func() {
 
 			It("fails during verification when using AtLeast(3)", func() {
 				Expect(func() { display.VerifyWasCalled(AtLeast(3)).Flash("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
-					expectation{method: "Flash(\"Hello\", 333)", expected: "at least 3", actual: "2"}.string(),
+					expectation{method: "Flash(\"Hello\", 333)", expected: "at least 3 times", actual: "twice"}.string(),
 				)))
 			})
 
@@ -352,17 +679,84 @@ var _ = Describe("MockDisplay", func() {
 
 			It("fails during verification when using Never()", func() {
 				Expect(func() { display.VerifyWasCalled(Never()).Flash("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
-					expectation{method: "Flash(\"Hello\", 333)", expected: "0", actual: "2"}.string(),
+					expectation{method: "Flash(\"Hello\", 333)", expected: "never", actual: "twice"}.string(),
+				)))
+			})
+		})
+
+		Context("Calling Flash twice and verifying with shorthands", func() {
+			BeforeEach(func() {
+				display.Flash("Hello", 333)
+				display.Flash("Hello", 333)
+			})
+
+			It("succeeds during verification when using VerifyWasCalledAtLeastOnce", func() {
+				Expect(func() { display.VerifyWasCalledAtLeastOnce().Flash("Hello", 333) }).NotTo(Panic())
+			})
+
+			It("fails during verification when using VerifyWasCalledAtLeastOnce and the mock was not called", func() {
+				Expect(func() { display.VerifyWasCalledAtLeastOnce().Flash("Other value", 333) }).To(PanicWithMessageTo(HavePrefix(
+					expectation{method: "Flash(\"Other value\", 333)", expected: "at least once", actual: "0 times"}.string(),
+				)))
+			})
+
+			It("succeeds during verification when using VerifyWasNeverCalled", func() {
+				Expect(func() { display.VerifyWasNeverCalled().Flash("Other value", 333) }).NotTo(Panic())
+			})
+
+			It("fails during verification when using VerifyWasNeverCalled", func() {
+				Expect(func() { display.VerifyWasNeverCalled().Flash("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
+					expectation{method: "Flash(\"Hello\", 333)", expected: "never", actual: "twice"}.string(),
+				)))
+			})
+
+			It("succeeds during verification when using VerifyWasCalledExactly(2)", func() {
+				Expect(func() { display.VerifyWasCalledExactly(2).Flash("Hello", 333) }).NotTo(Panic())
+			})
+
+			It("fails during verification when using VerifyWasCalledExactly(3)", func() {
+				Expect(func() { display.VerifyWasCalledExactly(3).Flash("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
+					expectation{method: "Flash(\"Hello\", 333)", expected: "3 times", actual: "twice"}.string(),
+				)))
+			})
+
+			It("succeeds during verification when using VerifyWasCalledAtLeast(2)", func() {
+				Expect(func() { display.VerifyWasCalledAtLeast(2).Flash("Hello", 333) }).NotTo(Panic())
+			})
+
+			It("fails during verification when using VerifyWasCalledAtLeast(3)", func() {
+				Expect(func() { display.VerifyWasCalledAtLeast(3).Flash("Hello", 333) }).To(PanicWithMessageTo(HavePrefix(
+					expectation{method: "Flash(\"Hello\", 333)", expected: "at least 3 times", actual: "twice"}.string(),
 				)))
 			})
 		})
 
+		It("describes invocation count matchers in a human-readable way", func() {
+			Expect(Never().String()).To(Equal("never"))
+			Expect(Once().String()).To(Equal("once"))
+			Expect(Twice().String()).To(Equal("twice"))
+			Expect(Times(3).String()).To(Equal("3 times"))
+			Expect(AtLeast(1).String()).To(Equal("at least once"))
+			Expect(AtMost(5).String()).To(Equal("at most 5 times"))
+			Expect(Exactly(4).String()).To(Equal("4 times"))
+			Expect(Between(2, 4).String()).To(Equal("between 2 and 4 times"))
+		})
+
 		Context("Never calling Flash", func() {
 			It("succeeds during verification when using Never() and argument matchers", func() {
 				// https://github.com/petergtz/pegomock/issues/34
 				Expect(func() { display.VerifyWasCalled(Never()).Flash(AnyString(), AnyInt()) }).NotTo(Panic())
 			})
 		})
+
+		It("lists the offending invocations when verifying with Never()", func() {
+			display.Flash("Hello", 333)
+			display.Show("Hello")
+			display.Flash("Other", 1)
+
+			Expect(func() { display.VerifyWasNeverCalled().Flash(AnyString(), AnyInt()) }).To(PanicWithMessageTo(ContainSubstring(
+				"Expected not to be called but was called twice:\n\t\tFlash(\"Hello\", 333)\n\t\tFlash(\"Other\", 1)\n\tVerified at")))
+		})
 	})
 
 	Context("Calling MultipleParamsAndReturnValue()", func() {
@@ -375,6 +769,40 @@ var _ = Describe("MockDisplay", func() {
 			}).To(PanicWith("I'm panicking"))
 		})
 
+		Context("recovering from a call stubbed to panic", func() {
+			BeforeEach(func() {
+				When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).
+					ThenPanic("I'm panicking")
+			})
+
+			callRecovering := func(call func()) {
+				defer func() { recover() }()
+				call()
+			}
+
+			It("does not leave the panicking call behind for When", func() {
+				callRecovering(func() { display.MultipleParamsAndReturnValue("Some string", 123) })
+
+				Expect(func() { When("not a call on a mock") }).To(PanicWith(
+					"When() requires an argument which has to be 'a method call on a mock'."))
+			})
+
+			It("does not leave argument matchers behind for the next stubbing", func() {
+				callRecovering(func() { display.MultipleParamsAndReturnValue(AnyString(), AnyInt()) })
+
+				When(display.SomeValue()).ThenReturn("Hello")
+
+				Expect(display.SomeValue()).To(Equal("Hello"))
+			})
+
+			It("does not leave argument matchers behind for the next verification", func() {
+				callRecovering(func() { display.MultipleParamsAndReturnValue(AnyString(), AnyInt()) })
+				display.Show("Hello")
+
+				display.VerifyWasCalledOnce().Show("Hello")
+			})
+		})
+
 		It("calls back when stubbed to call back", func() {
 			When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).Then(
 				func(params []Param) ReturnValues {
@@ -387,6 +815,75 @@ var _ = Describe("MockDisplay", func() {
 
 	})
 
+	Context("Misusing the DSL", func() {
+		var other *MockDisplay
+
+		BeforeEach(func() { other = NewMockDisplay() })
+
+		realFunc := func(s string) string { return s }
+
+		It("panics naming both methods when calls on mocks with matchers are nested", func() {
+			Expect(func() {
+				When(display.MultipleParamsAndReturnValue(other.ParamNamedLikeItsType(AnyString()), AnyInt()))
+			}).To(PanicWithMessageTo(HavePrefix("Invalid use of matchers!\n\n" +
+				"test_interface.Display.MultipleParamsAndReturnValue was called while argument matchers used for a call to " +
+				"test_interface.Display.ParamNamedLikeItsType were still pending.")))
+		})
+
+		It("stubs the outer call and records the inner one when calls on mocks without matchers are nested", func() {
+			When(display.MultipleParamsAndReturnValue(other.SomeValue(), 1)).ThenReturn("stubbed")
+
+			Expect(display.MultipleParamsAndReturnValue("", 1)).To(Equal("stubbed"))
+			other.VerifyWasCalledOnce().SomeValue()
+		})
+
+		It("panics telling where matchers were registered that were not passed to a call on a mock", func() {
+			Expect(func() { When(realFunc(AnyString())) }).To(PanicWithMessageTo(SatisfyAll(
+				HavePrefix("Invalid use of matchers!\n\nArgument matchers were not passed to a call on a mock."),
+				MatchRegexp(`registered at:\n\t.*dsl_test.go:\d+\n`))))
+		})
+
+		It("panics telling where matchers were registered that were followed by a call on a mock without them", func() {
+			realFunc(AnyString())
+
+			Expect(func() { When(display.SomeValue()) }).To(PanicWithMessageTo(SatisfyAll(
+				HavePrefix("Invalid use of matchers!\n\nArgument matchers were not passed to a call on a mock."),
+				MatchRegexp(`registered at:\n\t.*dsl_test.go:\d+\n`))))
+		})
+
+		It("panics telling where matchers were registered that were followed by a verification", func() {
+			realFunc(AnyString())
+
+			Expect(func() { display.VerifyWasCalledOnce().Flash(AnyString(), AnyInt()) }).To(PanicWithMessageTo(
+				HavePrefix("Invalid use of matchers!\n\nArgument matchers were not passed to a call on a mock.")))
+		})
+
+		It("panics when When() gets a call on a real object after a call on a mock", func() {
+			display.Show("Hello")
+
+			Expect(func() { When(realFunc("Hello")) }).To(PanicWith(
+				"When() requires an argument which has to be 'a method call on a mock', but got 1 values. " +
+					"The last call on a mock, test_interface.Display.Show, returns 0. Maybe When() got a call on a real object?"))
+		})
+
+		It("panics when When() gets a function that does not call a mock after a call on a mock", func() {
+			display.Show("Hello")
+
+			Expect(func() { When(func() {}) }).To(PanicWith(
+				"When() requires an argument which has to be 'a method call on a mock'."))
+		})
+
+		It("panics telling where the call on a mock was stubbed when When() reuses it", func() {
+			value := display.SomeValue()
+			When(value).ThenReturn("Hello")
+
+			Expect(func() { When(value) }).To(PanicWithMessageTo(MatchRegexp(
+				`^When\(\) requires an argument which has to be 'a method call on a mock', but got none. ` +
+					`The last call on a mock, test_interface.Display.SomeValue, was already stubbed at .*dsl_test.go:\d+. ` +
+					`Each When\(\) needs its own call on a mock.$`)))
+		})
+	})
+
 	Context("Making calls in a specific order", func() {
 
 		BeforeEach(func() {
@@ -426,69 +923,302 @@ var _ = Describe("MockDisplay", func() {
 
 	})
 
-	Context("Capturing arguments", func() {
-		It("Returns arguments when verifying with argument capture", func() {
-			display.Flash("Hello", 111)
-
-			arg1, arg2 := display.VerifyWasCalledOnce().Flash(AnyString(), AnyInt()).GetCapturedArguments()
+	Context("Making calls in a specific order without passing an InOrderContext", func() {
+		var t *fakeInOrderT
 
-			Expect(arg1).To(Equal("Hello"))
-			Expect(arg2).To(Equal(111))
+		BeforeEach(func() {
+			t = &fakeInOrderT{}
+			display.Flash("Hello", 111)
+			display.Flash("again", 222)
+			display.Flash("and again", 333)
 		})
 
-		It("Returns arguments of last invocation when verifying with argument capture", func() {
-			display.Flash("Hello", 111)
-			display.Flash("Again", 222)
+		AfterEach(func() { t.runCleanups() })
 
-			arg1, arg2 := display.VerifyWasCalled(AtLeast(1)).Flash(AnyString(), AnyInt()).GetCapturedArguments()
+		flashedNumbers := map[string]int{"Hello": 111, "again": 222, "and again": 333}
+		verifyFlashedInOrder := func(first string, second string) {
+			display.VerifyWasCalledOnce().Flash(first, flashedNumbers[first])
+			display.VerifyWasCalledOnce().Flash(second, flashedNumbers[second])
+		}
 
-			Expect(arg1).To(Equal("Again"))
-			Expect(arg2).To(Equal(222))
-		})
+		It("succeeds when order is correct", func() {
+			BeginInOrder(t)
+			verifyFlashedInOrder("Hello", "and again")
+			EndInOrder(t)
 
-		It("Returns arguments of all invocations when verifying with \"all\" argument capture", func() {
-			display.Flash("Hello", 111)
-			display.Flash("Again", 222)
+			Expect(t.errors).To(gomega.BeEmpty())
+		})
 
-			args1, args2 := display.VerifyWasCalled(AtLeast(1)).Flash(AnyString(), AnyInt()).GetAllCapturedArguments()
+		It("fails when order is not correct", func() {
+			BeginInOrder(t)
 
-			Expect(args1).To(ConsistOf("Hello", "Again"))
-			Expect(args2).To(ConsistOf(111, 222))
+			Expect(func() { verifyFlashedInOrder("again", "Hello") }).To(PanicWithMessageTo(HavePrefix(
+				"Expected function call Flash(\"Hello\", 111) before function call Flash(\"again\", 222)",
+			)))
 		})
 
-		It("Returns *array* arguments of all invocations when verifying with \"all\" argument capture", func() {
-			display.ArrayParam([]string{"one", "two"})
-			display.ArrayParam([]string{"4", "5", "3"})
+		It("does not verify in order after EndInOrder", func() {
+			BeginInOrder(t)
+			verifyFlashedInOrder("Hello", "again")
+			EndInOrder(t)
 
-			args := display.VerifyWasCalled(AtLeast(1)).ArrayParam(AnyStringSlice()).GetAllCapturedArguments()
+			Expect(func() { verifyFlashedInOrder("again", "Hello") }).NotTo(Panic())
+			Expect(t.errors).To(gomega.BeEmpty())
+		})
 
-			Expect(flattenStringSliceOfSlices(args)).To(ConsistOf("one", "two", "3", "4", "5"))
+		It("ends in-order verification when the test finishes", func() {
+			BeginInOrder(t)
+			verifyFlashedInOrder("Hello", "again")
+			t.runCleanups()
+
+			Expect(func() { verifyFlashedInOrder("again", "Hello") }).NotTo(Panic())
+			Expect(t.errors).To(gomega.BeEmpty())
 		})
 
-	})
+		It("fails when in-order verification was never used", func() {
+			BeginInOrder(t)
+			EndInOrder(t)
 
-	Context("Stubbing using string slice", func() {
-		It("does not panic when comparing the slices in the matcher", func() {
-			When(func() { display.ArrayParam([]string{"one", "two"}) }).Then(func([]Param) ReturnValues {
-				// do nothing, because that's not our focus here.
-				return nil
-			})
-			display.ArrayParam([]string{"one", "two"})
+			Expect(t.errors).To(ConsistOf(ContainSubstring("no verification used it")))
 		})
-	})
 
-	Describe("Different \"Any\" matcher scenarios", func() {
-		It("Succeeds when int-parameter is passed as int but veryfied as float", func() {
-			display.FloatParam(1)
-			display.VerifyWasCalledOnce().FloatParam(AnyFloat32())
+		It("lets an explicit InOrderContext take precedence, but reports mixing both", func() {
+			BeginInOrder(t)
+			display.VerifyWasCalledInOrder(Once(), new(InOrderContext)).Flash("again", 222)
+			display.VerifyWasCalledOnce().Flash("Hello", 111)
+			EndInOrder(t)
+
+			Expect(t.errors).To(ConsistOf(ContainSubstring("1 verification(s) used an explicit InOrderContext")))
 		})
 
-		It("Panics when interface{}-parameter is passed as int, but verified as float", func() {
-			Expect(func() {
-				display.InterfaceParam(3)
-				display.VerifyWasCalledOnce().InterfaceParam(AnyFloat32())
-			}).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "InterfaceParam(Any(float32))", expected: "1", actual: "0"}.string(),
+		It("verifies in order while polling with VerifyWasCalledEventually", func() {
+			BeginInOrder(t)
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				display.Flash("Hello", 111)
+			}()
+
+			Expect(func() { display.VerifyWasCalledEventually(Twice(), 2*time.Second).Flash("Hello", 111) }).NotTo(Panic())
+			Expect(func() { display.VerifyWasCalledOnce().Flash("again", 222) }).To(PanicWithMessageTo(HavePrefix(
+				"Expected function call Flash(\"again\", 222) before function call Flash(\"Hello\", 111)",
+			)))
+		})
+
+		It("reports an order violation once while polling with VerifyWasCalledEventually", func() {
+			var failures []string
+			display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) {
+				failures = append(failures, message)
+			}))
+			display.Flash("Hello", 111)
+			display.Flash("again", 222)
+			BeginInOrder(t)
+
+			display.VerifyWasCalledOnce().Flash("again", 222)
+			display.VerifyWasCalledEventually(Twice(), 100*time.Millisecond).Flash("Hello", 111)
+
+			Expect(failures).To(ConsistOf(
+				HavePrefix("Expected function call Flash(\"Hello\", 111) before function call Flash(\"again\", 222)"),
+				HavePrefix("Mock invocation count for test_interface.Display.Flash(\"Hello\", 111) does not match expectation after timeout of 100ms."),
+			))
+		})
+
+		It("fails when beginning twice or ending without beginning", func() {
+			BeginInOrder(t)
+			BeginInOrder(t)
+			display.VerifyWasCalledOnce().Flash("Hello", 111)
+			EndInOrder(t)
+			EndInOrder(t)
+
+			Expect(t.errors).To(ConsistOf(
+				ContainSubstring("already active"),
+				ContainSubstring("without a preceding BeginInOrder"),
+			))
+		})
+	})
+
+	Context("Spying on a real implementation", func() {
+		var delegate, spy *MockDisplay
+
+		BeforeEach(func() {
+			delegate = NewMockDisplay()
+			spy = NewSpyDisplay(delegate)
+		})
+
+		It("passes calls through to the delegate and returns its values", func() {
+			When(delegate.MultipleParamsAndReturnValue("Hello", 1)).ThenReturn("real value")
+			When(delegate.MultipleValues()).ThenReturn("one", 2, float32(3))
+
+			Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("real value"))
+			s, i, f := spy.MultipleValues()
+			Expect([]interface{}{s, i, f}).To(Equal([]interface{}{"one", 2, float32(3)}))
+			delegate.VerifyWasCalledOnce().MultipleParamsAndReturnValue("Hello", 1)
+		})
+
+		It("passes variadic and nil params through to the delegate", func() {
+			spy.NormalAndVariadicParam("Hello", 1, "a", "b")
+			spy.ErrorParam(nil)
+
+			delegate.VerifyWasCalledOnce().NormalAndVariadicParam("Hello", 1, "a", "b")
+			delegate.VerifyWasCalledOnce().ErrorParam(nil)
+		})
+
+		It("records passed through calls for verification", func() {
+			spy.Show("Hello")
+
+			spy.VerifyWasCalledOnce().Show("Hello")
+			Expect(func() { spy.VerifyWasCalledOnce().Show("Bye") }).To(Panic())
+		})
+
+		It("uses stubbings instead of the delegate for stubbed calls", func() {
+			When(spy.MultipleParamsAndReturnValue(EqString("Hello"), AnyInt())).ThenReturn("stubbed value")
+
+			Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("stubbed value"))
+			Expect(spy.MultipleParamsAndReturnValue("Bye", 1)).To(Equal(""))
+			delegate.VerifyWasCalledOnce().MultipleParamsAndReturnValue(AnyString(), AnyInt())
+			delegate.VerifyWasCalledOnce().MultipleParamsAndReturnValue("Bye", 1)
+		})
+
+		It("acts as a partial mock, overriding only stubbed methods for all their calls", func() {
+			When(delegate.SomeValue()).ThenReturn("real value")
+			When(spy.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenReturn("overridden")
+			When(func() { spy.Show(AnyString()) }).DoNothing()
+
+			Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("overridden"))
+			Expect(spy.MultipleParamsAndReturnValue("Bye", 2)).To(Equal("overridden"))
+			spy.Show("Hello")
+			Expect(spy.SomeValue()).To(Equal("real value"))
+			spy.Flash("Hello", 1)
+
+			delegate.VerifyWasCalled(Never()).MultipleParamsAndReturnValue(AnyString(), AnyInt())
+			delegate.VerifyWasCalled(Never()).Show(AnyString())
+			delegate.VerifyWasCalledOnce().SomeValue()
+			delegate.VerifyWasCalledOnce().Flash("Hello", 1)
+			spy.VerifyWasCalledOnce().Show("Hello")
+		})
+
+		It("calls through to the delegate for calls stubbed with ThenCallRealMethod", func() {
+			When(delegate.MultipleParamsAndReturnValue("Hello", 1)).ThenReturn("real value")
+			When(spy.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenReturn("stubbed value")
+			When(spy.MultipleParamsAndReturnValue(EqString("Hello"), AnyInt())).ThenCallRealMethod()
+
+			Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("real value"))
+			Expect(spy.MultipleParamsAndReturnValue("Bye", 1)).To(Equal("stubbed value"))
+			delegate.VerifyWasCalledOnce().MultipleParamsAndReturnValue(AnyString(), AnyInt())
+		})
+
+		It("panics when stubbing ThenCallRealMethod on a mock that is no spy", func() {
+			Expect(func() { When(delegate.SomeValue()).ThenCallRealMethod() }).To(PanicWithMessageTo(HavePrefix(
+				"ThenCallRealMethod requires a spy, i.e. a mock created with NewSpy..., but MockDisplay is no spy",
+			)))
+		})
+
+		It("panics when creating a spy without a delegate", func() {
+			Expect(func() { NewSpyDisplay(nil) }).To(PanicWithMessageTo(Equal(
+				"NewSpyDisplay requires a delegate to pass calls through to, but got nil",
+			)))
+		})
+	})
+
+	Context("Capturing arguments", func() {
+		It("Returns arguments when verifying with argument capture", func() {
+			display.Flash("Hello", 111)
+
+			arg1, arg2 := display.VerifyWasCalledOnce().Flash(AnyString(), AnyInt()).GetCapturedArguments()
+
+			Expect(arg1).To(Equal("Hello"))
+			Expect(arg2).To(Equal(111))
+		})
+
+		It("Returns arguments of last invocation when verifying with argument capture", func() {
+			display.Flash("Hello", 111)
+			display.Flash("Again", 222)
+
+			arg1, arg2 := display.VerifyWasCalled(AtLeast(1)).Flash(AnyString(), AnyInt()).GetCapturedArguments()
+
+			Expect(arg1).To(Equal("Again"))
+			Expect(arg2).To(Equal(222))
+		})
+
+		It("Returns arguments of all invocations when verifying with \"all\" argument capture", func() {
+			display.Flash("Hello", 111)
+			display.Flash("Again", 222)
+
+			args1, args2 := display.VerifyWasCalled(AtLeast(1)).Flash(AnyString(), AnyInt()).GetAllCapturedArguments()
+
+			Expect(args1).To(ConsistOf("Hello", "Again"))
+			Expect(args2).To(ConsistOf(111, 222))
+		})
+
+		It("Returns *array* arguments of all invocations when verifying with \"all\" argument capture", func() {
+			display.ArrayParam([]string{"one", "two"})
+			display.ArrayParam([]string{"4", "5", "3"})
+
+			args := display.VerifyWasCalled(AtLeast(1)).ArrayParam(AnyStringSlice()).GetAllCapturedArguments()
+
+			Expect(flattenStringSliceOfSlices(args)).To(ConsistOf("one", "two", "3", "4", "5"))
+		})
+
+		It("Returns nil interface arguments as nil when verifying with argument capture", func() {
+			display.RegisterHandler(nil)
+
+			handler := display.VerifyWasCalledOnce().RegisterHandler(AnyHttpHandler()).GetCapturedArguments()
+
+			Expect(handler).To(BeNil())
+		})
+
+		It("Returns arguments when capturing directly off the verification", func() {
+			display.Flash("Hello", 111)
+			display.Flash("Again", 222)
+
+			arg1, arg2 := display.VerifyWasCalledExactly(2).Flash_GetCapturedArguments()
+			args1, args2 := display.VerifyWasCalledExactly(2).Flash_GetAllCapturedArguments()
+
+			Expect(arg1).To(Equal("Again"))
+			Expect(arg2).To(Equal(222))
+			Expect(args1).To(Equal([]string{"Hello", "Again"}))
+			Expect(args2).To(Equal([]int{111, 222}))
+		})
+
+		It("Counts invocations with any arguments when capturing directly off the verification", func() {
+			display.Show("Hello")
+			display.Show("Again")
+
+			Expect(InterceptMockFailures(func() {
+				display.VerifyWasCalledOnce().Show_GetCapturedArguments()
+			})).To(ConsistOf(HavePrefix("Mock invocation count for test_interface.Display.Show(<any>) does not match expectation.")))
+		})
+
+		It("Fails with a hint when the verification matched no invocations to capture arguments from", func() {
+			Expect(func() {
+				display.VerifyWasCalled(AtLeast(0)).RegisterHandler(AnyHttpHandler()).GetCapturedArguments()
+			}).To(PanicWithMessageTo(HavePrefix(
+				"Cannot get captured arguments of RegisterHandler: the verification matched no invocations of RegisterHandler.",
+			)))
+		})
+	})
+
+	Context("Stubbing using string slice", func() {
+		It("does not panic when comparing the slices in the matcher", func() {
+			When(func() { display.ArrayParam([]string{"one", "two"}) }).Then(func([]Param) ReturnValues {
+				// do nothing, because that's not our focus here.
+				return nil
+			})
+			display.ArrayParam([]string{"one", "two"})
+		})
+	})
+
+	Describe("Different \"Any\" matcher scenarios", func() {
+		It("Succeeds when int-parameter is passed as int but veryfied as float", func() {
+			display.FloatParam(1)
+			display.VerifyWasCalledOnce().FloatParam(AnyFloat32())
+		})
+
+		It("Panics when interface{}-parameter is passed as int, but verified as float", func() {
+			Expect(func() {
+				display.InterfaceParam(3)
+				display.VerifyWasCalledOnce().InterfaceParam(AnyFloat32())
+			}).To(PanicWithMessageTo(HavePrefix(
+				expectation{method: "InterfaceParam(Any(float32))", expected: "once", actual: "0 times"}.string(),
 			)))
 		})
 
@@ -497,7 +1227,7 @@ var _ = Describe("MockDisplay", func() {
 				display.InterfaceParam(3.141)
 				display.VerifyWasCalledOnce().InterfaceParam(AnyInt())
 			}).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "InterfaceParam(Any(int))", expected: "1", actual: "0"}.string(),
+				expectation{method: "InterfaceParam(Any(int))", expected: "once", actual: "0 times"}.string(),
 			)))
 		})
 
@@ -516,7 +1246,7 @@ var _ = Describe("MockDisplay", func() {
 				display.InterfaceParam(nil)
 				display.VerifyWasCalledOnce().InterfaceParam(AnyInt())
 			}).To(PanicWithMessageTo(HavePrefix(
-				expectation{method: "InterfaceParam(Any(int))", expected: "1", actual: "0"}.string(),
+				expectation{method: "InterfaceParam(Any(int))", expected: "once", actual: "0 times"}.string(),
 			)))
 		})
 
@@ -559,6 +1289,191 @@ var _ = Describe("MockDisplay", func() {
 		})
 	})
 
+	Describe("Context matchers", func() {
+		It("matches any context with AnyContext, including derived ones", func() {
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "key", "value"))
+			defer cancel()
+
+			display.ContextParam(ctx, "Hello")
+			display.ContextParam(context.TODO(), "Hello")
+			display.ContextParam(nil, "Hello")
+
+			display.VerifyWasCalled(Times(3)).ContextParam(AnyContext(), EqString("Hello"))
+		})
+
+		It("compares contexts by identity with EqContext", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			sameLookingCtx, cancelSameLookingCtx := context.WithCancel(context.Background())
+			defer cancelSameLookingCtx()
+
+			display.ContextParam(ctx, "Hello")
+
+			display.VerifyWasCalledOnce().ContextParam(EqContext(ctx), EqString("Hello"))
+			display.VerifyWasCalled(Never()).ContextParam(EqContext(sameLookingCtx), EqString("Hello"))
+		})
+	})
+
+	Describe("Context-aware methods", func() {
+		// The mock is generated with --context-aware.
+		It("returns the context's error without consulting stubbings when the context is done", func() {
+			When(display.ContextAwareCall(AnyContext(), AnyString())).ThenReturn("stubbed", nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			value, err := display.ContextAwareCall(ctx, "Hello")
+
+			Expect(value).To(gomega.BeEmpty())
+			Expect(err).To(Equal(context.Canceled))
+			display.VerifyWasNeverCalled().ContextAwareCall(AnyContext(), AnyString())
+		})
+
+		It("uses stubbings as usual while the context is live", func() {
+			When(display.ContextAwareCall(AnyContext(), EqString("Hello"))).ThenReturn("stubbed", nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			value, err := display.ContextAwareCall(ctx, "Hello")
+
+			Expect(value).To(Equal("stubbed"))
+			Expect(err).NotTo(gomega.HaveOccurred())
+			display.VerifyWasCalledOnce().ContextAwareCall(EqContext(ctx), EqString("Hello"))
+		})
+
+		It("treats a nil context as live", func() {
+			When(display.ContextAwareCall(AnyContext(), EqString("Hello"))).ThenReturn("stubbed", nil)
+
+			Expect(display.ContextAwareCall(nil, "Hello")).To(Equal("stubbed"))
+		})
+	})
+
+	Describe("IsA matcher", func() {
+		It("matches values of exactly the given type", func() {
+			display.InterfaceParam(&MyEvent{})
+			display.InterfaceParam(&OtherEvent{})
+			display.InterfaceParam(MyEvent{})
+			display.InterfaceParam(nil)
+
+			display.VerifyWasCalledOnce().InterfaceParam(IsA((*MyEvent)(nil)))
+			display.VerifyWasCalledOnce().InterfaceParam(IsA(MyEvent{}))
+			display.VerifyWasCalledOnce().InterfaceParam(IsA((*OtherEvent)(nil)))
+		})
+
+		It("matches values implementing the given interface", func() {
+			display.InterfaceParam(&MyEvent{})
+			display.InterfaceParam(errors.New("some error"))
+			display.InterfaceParam("not a stringer")
+
+			display.VerifyWasCalledOnce().InterfaceParam(IsA((*fmt.Stringer)(nil)))
+			display.VerifyWasCalledOnce().InterfaceParam(IsA((*error)(nil)))
+			display.VerifyWasCalled(Times(3)).InterfaceParam(IsA((*interface{})(nil)))
+		})
+
+		It("can be used for stubbing", func() {
+			When(display.InterfaceReturnValue()).ThenReturn("irrelevant")
+			When(func() { display.InterfaceParam(IsA((*MyEvent)(nil))) }).Then(func([]Param) ReturnValues { panic("stubbed") })
+
+			Expect(func() { display.InterfaceParam(&MyEvent{}) }).To(Panic())
+			Expect(func() { display.InterfaceParam(&OtherEvent{}) }).NotTo(Panic())
+		})
+
+		It("reports expected and actual type in its failure message", func() {
+			matcher := &IsAMatcher{Type: reflect.TypeOf(&MyEvent{})}
+
+			Expect(matcher.Matches(&OtherEvent{})).To(gomega.BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal("expected value of type *pegomock_test.MyEvent, got *pegomock_test.OtherEvent"))
+		})
+
+		It("panics when given an untyped nil", func() {
+			Expect(func() { IsA(nil) }).To(Panic())
+		})
+	})
+
+	Describe("Function matchers", func() {
+		It("matches any non-nil function with AnyFunc", func() {
+			display.InterfaceParam(namedFunc)
+			display.InterfaceParam(func() {})
+			display.InterfaceParam((&MyEvent{}).String)
+			display.InterfaceParam((func())(nil))
+			display.InterfaceParam(nil)
+			display.InterfaceParam("not a function")
+
+			display.VerifyWasCalled(Times(3)).InterfaceParam(AnyFunc())
+		})
+
+		It("matches the same function by identity with SameFuncAs", func() {
+			anonymousFunc := func(s string) error { return nil }
+			otherAnonymousFunc := func(s string) error { return nil }
+			display.InterfaceParam(anonymousFunc)
+			display.InterfaceParam(namedFunc)
+
+			display.VerifyWasCalledOnce().InterfaceParam(SameFuncAs(anonymousFunc))
+			display.VerifyWasCalledOnce().InterfaceParam(SameFuncAs(namedFunc))
+			display.VerifyWasCalled(Never()).InterfaceParam(SameFuncAs(otherAnonymousFunc))
+		})
+
+		It("matches method values of the same method with SameFuncAs", func() {
+			event := &MyEvent{}
+			display.InterfaceParam(event.String)
+
+			display.VerifyWasCalledOnce().InterfaceParam(SameFuncAs(event.String))
+			display.VerifyWasCalled(Never()).InterfaceParam(SameFuncAs(namedFunc))
+		})
+
+		It("does not match nil functions with SameFuncAs and panics when given one", func() {
+			display.InterfaceParam((func(string) error)(nil))
+
+			display.VerifyWasCalled(Never()).InterfaceParam(SameFuncAs(namedFunc))
+			Expect(func() { SameFuncAs((func(string) error)(nil)) }).To(Panic())
+		})
+
+		It("matches function params by identity without matchers", func() {
+			anonymousFunc := func(s string) error { return nil }
+			display.FuncParam(anonymousFunc)
+
+			display.VerifyWasCalledOnce().FuncParam(anonymousFunc)
+			display.VerifyWasCalled(Never()).FuncParam(func(s string) error { return nil })
+		})
+
+		It("stubs methods with function params by identity", func() {
+			handler := func(test_interface.Event) {}
+			When(display.OnEvent(handler)).ThenReturn(true)
+
+			Expect(display.OnEvent(handler)).To(BeTrue())
+			Expect(display.OnEvent(func(test_interface.Event) {})).To(gomega.BeFalse())
+		})
+	})
+
+	Describe("Formatting of matchers", func() {
+		var formatCount int
+
+		formatCountingEqString := func(value string) string {
+			RegisterMatcher(formatCountingMatcher{&EqMatcher{Value: value}, &formatCount})
+			return ""
+		}
+
+		BeforeEach(func() { formatCount = 0 })
+
+		It("does not happen when stubbing, invoking and verifying succeed", func() {
+			for i := 0; i < 100; i++ {
+				When(display.MultipleParamsAndReturnValue(formatCountingEqString("Hello"), EqInt(i))).ThenReturn("World")
+				Expect(display.MultipleParamsAndReturnValue("Hello", i)).To(Equal("World"))
+				display.VerifyWasCalledOnce().MultipleParamsAndReturnValue(formatCountingEqString("Hello"), EqInt(i))
+			}
+
+			Expect(formatCount).To(gomega.BeZero())
+		})
+
+		It("happens when verification fails", func() {
+			display.MultipleParamsAndReturnValue("Hello", 1)
+
+			Expect(func() {
+				display.VerifyWasCalledOnce().MultipleParamsAndReturnValue(formatCountingEqString("Bye"), EqInt(1))
+			}).To(Panic())
+			Expect(formatCount).NotTo(gomega.BeZero())
+		})
+	})
+
 	Describe("Generated matchers", func() {
 		It("Succeeds when map-parameter is passed to interface{} and verified as any map", func() {
 			display.InterfaceParam(map[string]http.Request{"foo": http.Request{}})
@@ -584,13 +1499,11 @@ var _ = Describe("MockDisplay", func() {
 		It("Fails when http.Request-parameter is passed as null value and verified as never matching http.Request", func() {
 			display.NetHttpRequestParam(http.Request{})
 			Expect(func() { display.VerifyWasCalledOnce().NetHttpRequestParam(NeverMatchingRequest()) }).
-				To(PanicWithMessageTo(Equal(`Mock invocation count for NetHttpRequestParam(NeverMatching) does not match expectation.
-
-	Expected: 1; but got: 0
+				To(PanicWithVerificationFailure(`Mock invocation count for test_interface.Display.NetHttpRequestParam(NeverMatching) does not match expectation.
 
-	But other interactions with this mock were:
+	Expected to be called once but was called 0 times`, `	But other interactions with this mock were:
 	NetHttpRequestParam(http.Request{Method:"", URL:(*url.URL)(nil), Proto:"", ProtoMajor:0, ProtoMinor:0, Header:http.Header(nil), Body:io.ReadCloser(nil), GetBody:(func() (io.ReadCloser, error))(nil), ContentLength:0, TransferEncoding:[]string(nil), Close:false, Host:"", Form:url.Values(nil), PostForm:url.Values(nil), MultipartForm:(*multipart.Form)(nil), Trailer:http.Header(nil), RemoteAddr:"", RequestURI:"", TLS:(*tls.ConnectionState)(nil), Cancel:(<-chan struct {})(nil), Response:(*http.Response)(nil), ctx:context.Context(nil)})
-`)))
+`))
 		})
 	})
 
@@ -620,11 +1533,11 @@ var _ = Describe("MockDisplay", func() {
 			display.Flash("Hello", 123)
 			display.Flash("Again", 456)
 
-			Expect(func() { display.VerifyWasCalledOnce().Flash("wrong string", -987) }).To(PanicWith(
-				"Mock invocation count for Flash(\"wrong string\", -987) " +
-					"does not match expectation.\n\n\tExpected: 1; but got: 0\n\n" +
-					"\tBut other interactions with this mock were:\n" +
-					"\tFlash(\"Hello\", 123)\n" +
+			Expect(func() { display.VerifyWasCalledOnce().Flash("wrong string", -987) }).To(PanicWithVerificationFailure(
+				"Mock invocation count for test_interface.Display.Flash(\"wrong string\", -987) "+
+					"does not match expectation.\n\n\tExpected to be called once but was called 0 times",
+				"\tBut other interactions with this mock were:\n"+
+					"\tFlash(\"Hello\", 123)\n"+
 					"\tFlash(\"Again\", 456)\n",
 			))
 		})
@@ -633,33 +1546,80 @@ var _ = Describe("MockDisplay", func() {
 			display.Show("Again")
 			display.Flash("Hello", 123)
 
-			Expect(func() { display.VerifyWasCalledOnce().Flash("wrong string", -987) }).To(PanicWith(
-				"Mock invocation count for Flash(\"wrong string\", -987) " +
-					"does not match expectation.\n\n\tExpected: 1; but got: 0\n\n" +
-					"\tBut other interactions with this mock were:\n" +
-					"\tFlash(\"Hello\", 123)\n" +
+			Expect(func() { display.VerifyWasCalledOnce().Flash("wrong string", -987) }).To(PanicWithVerificationFailure(
+				"Mock invocation count for test_interface.Display.Flash(\"wrong string\", -987) "+
+					"does not match expectation.\n\n\tExpected to be called once but was called 0 times",
+				"\tBut other interactions with this mock were:\n"+
+					"\tFlash(\"Hello\", 123)\n"+
 					"\tShow(\"Again\")\n"),
 			)
 		})
 
 		It("formats params in interactions with Go syntax for better readability", func() {
 			display.NetHttpRequestParam(http.Request{Host: "x.com"})
-			Expect(func() { display.VerifyWasCalledOnce().NetHttpRequestParam(http.Request{Host: "y.com"}) }).To(PanicWith(
-				`Mock invocation count for NetHttpRequestParam(http.Request{Method:"", URL:(*url.URL)(nil), Proto:"", ProtoMajor:0, ProtoMinor:0, Header:http.Header(nil), Body:io.ReadCloser(nil), GetBody:(func() (io.ReadCloser, error))(nil), ContentLength:0, TransferEncoding:[]string(nil), Close:false, Host:"y.com", Form:url.Values(nil), PostForm:url.Values(nil), MultipartForm:(*multipart.Form)(nil), Trailer:http.Header(nil), RemoteAddr:"", RequestURI:"", TLS:(*tls.ConnectionState)(nil), Cancel:(<-chan struct {})(nil), Response:(*http.Response)(nil), ctx:context.Context(nil)}) does not match expectation.
+			Expect(func() { display.VerifyWasCalledOnce().NetHttpRequestParam(http.Request{Host: "y.com"}) }).To(PanicWithMessageTo(SatisfyAll(
+				HavePrefix(`Mock invocation count for test_interface.Display.NetHttpRequestParam(http.Request{Method:"", URL:(*url.URL)(nil), Proto:"", ProtoMajor:0, ProtoMinor:0, Header:http.Header(nil), Body:io.ReadCloser(nil), GetBody:(func() (io.ReadCloser, error))(nil), ContentLength:0, TransferEncoding:[]string(nil), Close:false, Host:"y.com", Form:url.Values(nil), PostForm:url.Values(nil), MultipartForm:(*multipart.Form)(nil), Trailer:http.Header(nil), RemoteAddr:"", RequestURI:"", TLS:(*tls.ConnectionState)(nil), Cancel:(<-chan struct {})(nil), Response:(*http.Response)(nil), ctx:context.Context(nil)}) does not match expectation.
 
-	Expected: 1; but got: 0
+	Expected to be called once but was called 0 times
+	Verified at `),
+				ContainSubstring(`
 
 	But other interactions with this mock were:
 	NetHttpRequestParam(http.Request{Method:"", URL:(*url.URL)(nil), Proto:"", ProtoMajor:0, ProtoMinor:0, Header:http.Header(nil), Body:io.ReadCloser(nil), GetBody:(func() (io.ReadCloser, error))(nil), ContentLength:0, TransferEncoding:[]string(nil), Close:false, Host:"x.com", Form:url.Values(nil), PostForm:url.Values(nil), MultipartForm:(*multipart.Form)(nil), Trailer:http.Header(nil), RemoteAddr:"", RequestURI:"", TLS:(*tls.ConnectionState)(nil), Cancel:(<-chan struct {})(nil), Response:(*http.Response)(nil), ctx:context.Context(nil)})
-`,
-			))
+`),
+				ContainSubstring(`Host: expected "y.com", got "x.com"`),
+			)))
+		})
+
+		It("shows which struct fields differ, including unexported ones", func() {
+			display.InterfaceParam(person{Name: "Alice", age: 30, Tags: []string{"a", "b"}})
+
+			Expect(func() {
+				display.VerifyWasCalledOnce().InterfaceParam(person{Name: "Alice", age: 31, Tags: []string{"a", "b"}})
+			}).To(PanicWithMessageTo(SatisfyAll(
+				ContainSubstring("Mismatches with invocations of InterfaceParam:\n"),
+				gomega.HaveSuffix("Differences:\n\t\t\tage: expected 31, got 30\n"),
+			)))
+		})
+
+		It("shows which slice indices differ", func() {
+			display.InterfaceParam([]person{{Name: "Alice"}, {Name: "Bob"}})
+
+			Expect(func() {
+				display.VerifyWasCalledOnce().InterfaceParam([]person{{Name: "Alice"}, {Name: "Carol"}})
+			}).To(PanicWithMessageTo(SatisfyAll(
+				ContainSubstring(`[1].Name: expected "Carol", got "Bob"`),
+				gomega.Not(ContainSubstring("[0]")),
+			)))
+		})
+
+		It("does not show mismatches for values that only differ as a whole", func() {
+			display.Flash("Hello", 123)
+
+			Expect(func() { display.VerifyWasCalledOnce().Flash("Hello", 124) }).To(PanicWithMessageTo(gomega.Not(ContainSubstring("Mismatches"))))
+		})
+
+		It("shows where the failed verification is", func() {
+			_, file, line, _ := runtime.Caller(0)
+			Expect(func() { display.VerifyWasCalledOnce().Show("Hello") }).To(PanicWithMessageTo(
+				ContainSubstring(fmt.Sprintf("\n\tVerified at %v:%v\n", file, line+1))))
+		})
+
+		It("shows where the mock was called if invocation locations are recorded", func() {
+			RecordInvocationLocations(true)
+			defer RecordInvocationLocations(false)
+			_, file, line, _ := runtime.Caller(0)
+			display.Show("Hello")
+
+			Expect(func() { display.VerifyWasCalledOnce().Show("Bye") }).To(PanicWithMessageTo(
+				ContainSubstring(fmt.Sprintf("\tShow(\"Hello\") called from %v:%v\n", file, line+1))))
 		})
 
 		It("shows no interactions if there were none", func() {
-			Expect(func() { display.VerifyWasCalledOnce().Flash("wrong string", -987) }).To(PanicWith(
-				"Mock invocation count for Flash(\"wrong string\", -987) " +
-					"does not match expectation.\n\n\tExpected: 1; but got: 0\n\n" +
-					"\tThere were no other interactions with this mock",
+			Expect(func() { display.VerifyWasCalledOnce().Flash("wrong string", -987) }).To(PanicWithVerificationFailure(
+				"Mock invocation count for test_interface.Display.Flash(\"wrong string\", -987) "+
+					"does not match expectation.\n\n\tExpected to be called once but was called 0 times",
+				"\tThere were no other interactions with this mock",
 			))
 		})
 	})
@@ -846,48 +1806,687 @@ var _ = Describe("MockDisplay", func() {
 
 	})
 
-	Describe("Using VerifyWasCalledEventually when object under test calls goroutine", func() {
-		It("correctly fails when timeout is shorter than mock invocation, and succeeds, when timeout is longer", func() {
-			go func() {
-				time.Sleep(1 * time.Second)
-				display.Show("hello")
-			}()
-			Expect(func() { display.VerifyWasCalledEventually(Once(), 100*time.Millisecond).Show("hello") }).
-				To(PanicWithMessageTo(SatisfyAll(
-					ContainSubstring("Mock invocation count for Show(\"hello\") does not match expectation"),
-					ContainSubstring("after timeout of 100ms"),
-					ContainSubstring("Expected: 1; but got: 0"),
-				)))
+	Describe("Stubbing and verifying with matchers from parallel goroutines", func() {
+		It("does not mix up matchers and invocations of different goroutines", func() {
+			wg := sync.WaitGroup{}
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer ginkgo.GinkgoRecover()
+					defer wg.Done()
 
-			Expect(func() { display.VerifyWasCalledEventually(Once(), 2*time.Second).Show("hello") }).NotTo(Panic())
-		})
+					display := NewMockDisplay()
+					for j := 0; j < 20; j++ {
+						When(display.MultipleParamsAndReturnValue(AnyString(), EqInt(i))).ThenReturn(fmt.Sprint(i))
 
-	})
+						Expect(display.MultipleParamsAndReturnValue("Hello", i)).To(Equal(fmt.Sprint(i)))
 
-	Describe("Manipulating out args (using pointers) in Then blocks", func() {
-		It("correctly manipulates the out args", func() {
-			type Entity struct{ i int }
-			var input = []Entity{}
-			When(func() { display.InterfaceParam(AnyInterface()) }).Then(func(params []pegomock.Param) pegomock.ReturnValues {
-				*params[0].(*[]Entity) = append(*params[0].(*[]Entity), Entity{3})
-				return nil
-			})
+						display.VerifyWasCalled(Times(j+1)).MultipleParamsAndReturnValue(AnyString(), EqInt(i))
+						display.VerifyWasCalled(Never()).MultipleParamsAndReturnValue(AnyString(), EqInt(i+1))
+					}
+				}(i)
+			}
+			wg.Wait()
+		})
+	})
 
-			display.InterfaceParam(&input)
+	Describe("Validating the number of params passed to Invoke", func() {
+		It("fails with method, mock and call site when the number of params does not match", func() {
+			mock := &HandRolledDisplay{}
 
-			Expect(input).To(HaveLen(1))
-			Expect(input[0].i).To(Equal(3))
+			Expect(func() { mock.Flash("Hello") }).To(PanicWithMessageTo(MatchRegexp(
+				`^HandRolledDisplay\.Flash called with 1 params but interface declares 2 \(at .*dsl_test\.go:\d+\)$`)))
 		})
-	})
 
-	Context("Mock created with custom fail handler", func() {
-		It("calls custom fail handler instead of global one", func() {
-			failHandlerCalled := false
-			display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) {
-				failHandlerCalled = true
-			}))
+		It("accounts for variadic params", func() {
+			mock := &HandRolledDisplay{}
 
-			display.VerifyWasCalledOnce().Show("This was never called")
+			Expect(func() { mock.NormalAndVariadicParam("Hello", 1, "a", "b") }).NotTo(Panic())
+			Expect(func() { mock.NormalAndVariadicParam("Hello", 1) }).NotTo(Panic())
+			Expect(func() { mock.NormalAndVariadicParamWithTooFewParams("Hello") }).To(PanicWithMessageTo(HavePrefix(
+				"HandRolledDisplay.NormalAndVariadicParam called with 1 params but interface declares at least 2")))
+		})
+
+		It("does not validate mocks without method metadata", func() {
+			mock := &LegacyMock{}
+
+			Expect(func() {
+				GetGenericMockFrom(mock).Invoke("AnyMethod", []Param{"a", "b", "c"}, nil)
+			}).NotTo(Panic())
+		})
+	})
+
+	Describe("Referring to mocks by their interface in failure messages", func() {
+		It("tells the mocked interface qualified with its package path", func() {
+			Expect(display.PegomockInterfaceName()).To(Equal("github.com/petergtz/pegomock/test_interface.Display"))
+		})
+
+		It("refers to mocks without interface name by the method only", func() {
+			mock := &LegacyMock{}
+
+			Expect(func() { GetGenericMockFrom(mock).Verify(nil, Once(), "AnyMethod", nil) }).To(PanicWithMessageTo(HavePrefix(
+				"Mock invocation count for AnyMethod() does not match expectation.")))
+		})
+	})
+
+	Describe("Reporting the location of failed verifications", func() {
+		It("passes a callerSkip to the fail handler that points at the line in the test", func() {
+			var file string
+			var line int
+			display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) {
+				_, file, line, _ = runtime.Caller(callerSkip[0] + 1)
+			}))
+
+			_, expectedFile, expectedLine, _ := runtime.Caller(0)
+			display.VerifyWasCalledOnce().Show("Hello")
+
+			Expect(file).To(Equal(expectedFile))
+			Expect(line).To(Equal(expectedLine + 1))
+		})
+
+		It("reports the line in the test with a testify fail handler", func() {
+			t := &fakeTestifyT{}
+			display := NewMockDisplay(WithTestifyT(t))
+
+			_, file, line, _ := runtime.Caller(0)
+			display.VerifyWasCalledOnce().Show("Hello")
+
+			Expect(t.helperCalled).To(BeTrue())
+			Expect(t.message).To(HavePrefix(fmt.Sprintf(
+				"\n\tError Trace:\t%v:%v\n\tError:      \tMock invocation count for test_interface.Display.Show(\"Hello\") does not match expectation.", file, line+1)))
+		})
+
+		It("starts the stack trace at the line in the test with a testing.T fail handler", func() {
+			t := &fakeTestingT{}
+			display := NewMockDisplay(WithT(t))
+
+			_, file, line, _ := runtime.Caller(0)
+			display.VerifyWasCalledOnce().Show("Hello")
+
+			Expect(t.message).To(HavePrefix(fmt.Sprintf("\n\t%v:%v ", file, line+1)))
+		})
+
+		It("shows where the mock was used if there is no fail handler", func() {
+			display := NewMockDisplay()
+			var file string
+			var line int
+			var message interface{}
+
+			WithGlobalFailHandler(nil, func() {
+				defer func() { message = recover() }()
+				_, file, line, _ = runtime.Caller(0)
+				display.VerifyWasCalledOnce().Show("Hello")
+			})
+
+			Expect(message).To(SatisfyAll(
+				HavePrefix("No FailHandler set."),
+				ContainSubstring("The mock was used at:\n\t"),
+				ContainSubstring(fmt.Sprintf("\n\t%v:%v\n", file, line+1)),
+				gomega.Not(ContainSubstring("/pegomock/dsl.go")),
+			))
+		})
+	})
+
+	Describe("Buffering failures from other goroutines", func() {
+		var (
+			t                 *fakeBufferingT
+			originalHandler   FailHandler
+			verifyOnGoroutine = func(verification func()) {
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					verification()
+				}()
+				<-done
+			}
+		)
+
+		BeforeEach(func() {
+			originalHandler = GlobalFailHandler
+			t = &fakeBufferingT{}
+			RegisterBufferedMockTestingT(t)
+		})
+
+		AfterEach(func() { RegisterMockFailHandler(originalHandler) })
+
+		It("reports failures on the test goroutine immediately", func() {
+			display.VerifyWasCalledOnce().Show("Hello")
+
+			Expect(t.messages).To(ConsistOf(ContainSubstring(`Mock invocation count for test_interface.Display.Show("Hello") does not match expectation.`)))
+		})
+
+		It("reports failures from other goroutines the next time a mock is used on the test goroutine", func() {
+			verifyOnGoroutine(func() { display.VerifyWasCalledOnce().Show("Hello") })
+			verifyOnGoroutine(func() { display.VerifyWasCalledOnce().Show("Bye") })
+			Expect(t.messages).To(gomega.BeEmpty())
+
+			display.Flash("irrelevant", 0)
+
+			Expect(t.messages).To(ConsistOf(SatisfyAll(
+				HavePrefix("2 failure(s) on other goroutines:"),
+				ContainSubstring(`Show("Hello") does not match expectation.`),
+				ContainSubstring(`Show("Bye") does not match expectation.`),
+			)))
+		})
+
+		It("reports failures from other goroutines when the test finishes", func() {
+			verifyOnGoroutine(func() { display.VerifyWasCalledOnce().Show("Hello") })
+
+			t.runCleanups()
+
+			Expect(t.messages).To(ConsistOf(ContainSubstring(`Show("Hello") does not match expectation.`)))
+		})
+
+		It("reports failures from other goroutines before the failure on the test goroutine", func() {
+			verifyOnGoroutine(func() { display.VerifyWasCalledOnce().Show("Hello") })
+
+			display.VerifyWasCalledOnce().Show("Bye")
+
+			Expect(t.messages).To(HaveLen(2))
+			Expect(t.messages[0]).To(ContainSubstring(`Show("Hello") does not match expectation.`))
+			Expect(t.messages[1]).To(ContainSubstring(`Show("Bye") does not match expectation.`))
+		})
+
+		It("stops buffering when another fail handler is registered", func() {
+			RegisterMockFailHandler(originalHandler)
+			verifyOnGoroutine(func() {
+				Expect(func() { display.VerifyWasCalledOnce().Show("Hello") }).To(Panic())
+			})
+
+			t.runCleanups()
+
+			Expect(t.messages).To(gomega.BeEmpty())
+		})
+	})
+
+	Describe("Exposing invocation metrics", func() {
+		AfterEach(func() { ExposeMetrics(nil) })
+
+		It("does not count invocations by default", func() {
+			metrics := &countingMetrics{counts: make(map[string]int)}
+			display.Show("Hello")
+
+			ExposeMetrics(metrics)
+			ExposeMetrics(nil)
+			display.Show("Hello")
+
+			Expect(metrics.counts).To(gomega.BeEmpty())
+		})
+
+		It("counts invocations per mock and method, also from parallel goroutines", func() {
+			metrics := &countingMetrics{counts: make(map[string]int)}
+			ExposeMetrics(metrics)
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					display.Show("Hello")
+					display.Flash("Hello", 1)
+				}()
+			}
+			wg.Wait()
+
+			Expect(metrics.counts).To(Equal(map[string]int{"MockDisplay.Show": 20, "MockDisplay.Flash": 20}))
+		})
+
+		It("publishes invocation counts via expvar", func() {
+			ExposeMetrics(ExpvarMetrics("pegomock_test_invocations"))
+
+			display.Show("Hello")
+			display.Show("Hello")
+
+			Expect(expvar.Get("pegomock_test_invocations").(*expvar.Map).Get("MockDisplay.Show").String()).To(Equal("2"))
+			Expect(func() { ExpvarMetrics("pegomock_test_invocations") }).NotTo(Panic())
+		})
+	})
+
+	Context("Getting unverified invocations", func() {
+		It("returns the invocations that no verification matched", func() {
+			display.Show("Hello")
+			display.Show("Bye")
+
+			display.VerifyWasCalled(Times(1)).Show("Hello")
+
+			unverified := GetGenericMockFrom(display).GetUnverifiedInvocations()
+			Expect(unverified).To(HaveLen(1))
+			Expect(unverified["Show"]).To(HaveLen(1))
+			Expect(unverified["Show"][0].Params()).To(Equal([]Param{"Bye"}))
+		})
+
+		It("keeps invocations unverified when the verification fails", func() {
+			display.Show("Hello")
+			display.Show("Hello")
+
+			Expect(func() { display.VerifyWasCalled(Times(1)).Show("Hello") }).To(Panic())
+
+			Expect(GetGenericMockFrom(display).GetUnverifiedInvocations()["Show"]).To(HaveLen(2))
+		})
+	})
+
+	Context("Comparing with registered cmp options", func() {
+		approxTime := cmp.Comparer(func(a, b time.Time) bool { return a.Sub(b) < time.Second && b.Sub(a) < time.Second })
+		newYear := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		AfterEach(func() { RegisterCmpOptions() })
+
+		It("compares params with cmp.Equal and the registered options", func() {
+			RegisterCmpOptions(approxTime)
+			display.UseTime(newYear.Add(500 * time.Millisecond))
+
+			display.VerifyWasCalledOnce().UseTime(newYear)
+		})
+
+		It("shows cmp's diff for near misses", func() {
+			RegisterCmpOptions(approxTime)
+			display.UseTime(newYear)
+
+			Expect(func() { display.VerifyWasCalledOnce().UseTime(newYear.Add(time.Hour)) }).To(
+				PanicWithMessageTo(ContainSubstring("Diff (-expected +actual):\n")))
+		})
+
+		It("compares with reflect.DeepEqual again once the options are cleared", func() {
+			RegisterCmpOptions(approxTime)
+			RegisterCmpOptions()
+			display.UseTime(newYear.Add(500 * time.Millisecond))
+
+			display.VerifyWasCalled(Never()).UseTime(newYear)
+		})
+	})
+
+	Context("Running code concurrently with RunConcurrent", func() {
+		It("waits for all goroutines and verifies the invocations they made together", func() {
+			result := RunConcurrent(ginkgo.GinkgoT(), 10, 50, func(i, j int) { display.Flash("tick", j) })
+
+			Expect(result.Calls()).To(Equal(500))
+			result.VerifyTotalInvocations(display, "Flash", result.Calls())
+			Expect(func() { result.VerifyTotalInvocations(display, "Flash", 499) }).To(
+				PanicWithMessageTo(ContainSubstring("Mock invocation count for test_interface.Display.Flash(<any>) does not match expectation")))
+		})
+
+		It("reports panics of goroutines to the global fail handler once all goroutines finished", func() {
+			Expect(func() {
+				RunConcurrent(ginkgo.GinkgoT(), 4, 3, func(i, j int) {
+					if i == 1 && j == 2 {
+						panic("boom")
+					}
+					display.Flash("tick", j)
+				})
+			}).To(PanicWithMessageTo(SatisfyAll(
+				HavePrefix("1 of the goroutines run by RunConcurrent panicked:\n\nGoroutine 1 panicked in iteration 2: boom\n"),
+				ContainSubstring("dsl_test.go"),
+			)))
+
+			Expect(display.InvocationCount("Flash")).To(Equal(11))
+		})
+
+		It("reports panics of goroutines to t without global fail handler", func() {
+			t := &fakeTestingT{}
+			WithGlobalFailHandler(nil, func() {
+				RunConcurrent(t, 2, 1, func(i, j int) { panic("boom") })
+			})
+
+			Expect(t.message).To(ContainSubstring("2 of the goroutines run by RunConcurrent panicked"))
+		})
+	})
+
+	Context("Comparing in different deep equal modes", func() {
+		approxTime := cmp.Comparer(func(a, b time.Time) bool { return a.Sub(b) < time.Second && b.Sub(a) < time.Second })
+		var logs *bytes.Buffer
+
+		BeforeEach(func() {
+			logs = new(bytes.Buffer)
+			log.SetOutput(logs)
+		})
+
+		AfterEach(func() {
+			SetDeepEqualMode(DefaultDeepEqualMode)
+			RegisterCmpOptions()
+			log.SetOutput(os.Stderr)
+		})
+
+		It("does not match when comparing panics", func() {
+			RegisterCmpOptions(approxTime)
+			display.InterfaceParam(person{Name: "Alice"})
+
+			Expect(func() { display.VerifyWasCalledOnce().InterfaceParam(person{Name: "Alice"}) }).To(
+				PanicWithMessageTo(ContainSubstring("Comparing panicked: ")))
+		})
+
+		It("propagates the panic in StrictDeepEqual mode", func() {
+			SetDeepEqualMode(StrictDeepEqual)
+			RegisterCmpOptions(approxTime)
+			display.InterfaceParam(person{Name: "Alice"})
+
+			Expect(func() { display.VerifyWasCalledOnce().InterfaceParam(person{Name: "Alice"}) }).To(
+				PanicWithMessageTo(ContainSubstring("unexported field")))
+		})
+
+		It("matches values that format the same in FmtFallback mode without writing to the global log", func() {
+			withFunc := struct{ F func() }{F: func() {}}
+			display.InterfaceParam(withFunc)
+
+			display.VerifyWasCalled(Never()).InterfaceParam(withFunc)
+
+			SetDeepEqualMode(StrictDeepEqual | RecoverOnPanic | FmtFallback)
+			display.VerifyWasCalledOnce().InterfaceParam(withFunc)
+			Expect(logs.String()).To(gomega.BeEmpty())
+		})
+
+		It("does not match values of different types that format the same in FmtFallback mode", func() {
+			SetDeepEqualMode(StrictDeepEqual | FmtFallback)
+			display.InterfaceParam(1)
+
+			display.VerifyWasCalled(Never()).InterfaceParam("1")
+			Expect(logs.String()).To(gomega.BeEmpty())
+		})
+	})
+
+	Context("Limiting the retained invocations with WithInvocationLimit", func() {
+		var limitedDisplay *MockDisplay
+
+		BeforeEach(func() {
+			limitedDisplay = NewMockDisplay(WithInvocationLimit(2))
+		})
+
+		It("retains only the most recent invocations per method", func() {
+			for i := 0; i < 5; i++ {
+				limitedDisplay.Flash("Hello", i)
+			}
+			limitedDisplay.Show("Bye")
+
+			unverified := GetGenericMockFrom(limitedDisplay).GetUnverifiedInvocations()
+			Expect(unverified["Flash"]).To(HaveLen(2))
+			Expect(unverified["Flash"][0].Params()).To(Equal([]Param{"Hello", 3}))
+			Expect(unverified["Show"]).To(HaveLen(1))
+		})
+
+		It("still counts evicted invocations when verifying without params or with Any matchers", func() {
+			for i := 0; i < 5; i++ {
+				limitedDisplay.Flash("Hello", i)
+				limitedDisplay.SomeValue()
+			}
+
+			limitedDisplay.VerifyWasCalled(Times(5)).SomeValue()
+			limitedDisplay.VerifyWasCalled(Times(5)).Flash(AnyString(), AnyInt())
+			limitedDisplay.VerifyWasCalled(AtLeast(1)).Flash(AnyString(), AnyInt())
+		})
+
+		It("fails verifications with specific params once invocations were evicted", func() {
+			for i := 0; i < 3; i++ {
+				limitedDisplay.Flash("Hello", i)
+			}
+
+			failures := InterceptMockFailures(func() {
+				limitedDisplay.VerifyWasCalledOnce().Flash("Hello", 2)
+				limitedDisplay.VerifyWasCalledOnce().Flash(EqString("Hello"), AnyInt())
+			})
+
+			Expect(failures).To(ConsistOf(
+				HavePrefix("Cannot verify Flash(\"Hello\", 2): invocation history truncated. "+
+					"Only the most recent 2 invocations of Flash are retained (see WithInvocationLimit) and 1 older ones were evicted"),
+				HavePrefix("Cannot verify Flash(Eq(Hello), Any(int)): invocation history truncated.")))
+		})
+
+		It("verifies specific params as usual while nothing was evicted", func() {
+			limitedDisplay.Flash("Hello", 1)
+			limitedDisplay.Flash("Hello", 2)
+
+			limitedDisplay.VerifyWasCalledOnce().Flash("Hello", 2)
+		})
+
+		It("doesn't evict invocations for stubbing", func() {
+			limitedDisplay.MultipleParamsAndReturnValue("one", 1)
+			limitedDisplay.MultipleParamsAndReturnValue("two", 2)
+
+			When(limitedDisplay.MultipleParamsAndReturnValue("three", 3)).ThenReturn("stubbed")
+
+			limitedDisplay.VerifyWasCalledOnce().MultipleParamsAndReturnValue("one", 1)
+		})
+
+		It("panics on a non-positive limit", func() {
+			Expect(func() { WithInvocationLimit(0) }).To(PanicWith("Invocation limit must be positive, but is 0"))
+		})
+	})
+
+	Context("Disabling recording with DisableRecording", func() {
+		BeforeEach(func() {
+			DisableRecording(display)
+		})
+
+		It("keeps answering stubbed calls", func() {
+			When(display.MultipleParamsAndReturnValue("Hello", AnyInt())).ThenReturn("World")
+
+			for i := 0; i < 3; i++ {
+				Expect(display.MultipleParamsAndReturnValue("Hello", i)).To(Equal("World"))
+			}
+			Expect(display.MultipleParamsAndReturnValue("Bye", 1)).To(gomega.BeEmpty())
+		})
+
+		It("fails verifications with a recording disabled error", func() {
+			display.Show("Hello")
+
+			failures := InterceptMockFailures(func() {
+				display.VerifyWasCalledOnce().Show("Hello")
+			})
+
+			Expect(failures).To(ConsistOf(ContainSubstring("Show(\"Hello\"): recording is disabled")))
+			Expect(display.InvocationsOf("Show")).To(gomega.BeEmpty())
+		})
+
+		It("doesn't reuse params passed to stubbed callbacks", func() {
+			var retained [][]Param
+			When(func() { display.Flash(AnyString(), AnyInt()) }).Then(func(params []Param) ReturnValues {
+				retained = append(retained, params)
+				return nil
+			})
+
+			display.Flash("one", 1)
+			display.Flash("two", 2)
+
+			Expect(retained).To(Equal([][]Param{{"one", 1}, {"two", 2}}))
+		})
+
+		It("stubs with params of calls made after previous calls", func() {
+			display.MultipleParamsAndReturnValue("one", 1)
+			When(display.MultipleParamsAndReturnValue("two", 2)).ThenReturn("stubbed")
+			display.MultipleParamsAndReturnValue("three", 3)
+
+			Expect(display.MultipleParamsAndReturnValue("two", 2)).To(Equal("stubbed"))
+		})
+	})
+
+	Context("Counting invocations with InvocationCount", func() {
+		It("returns how often a method was invoked", func() {
+			calls := 0
+			for i := 0; i < 3; i++ {
+				display.Flash("Hello", i)
+				calls++
+				Expect(display.InvocationCount("Flash")).To(Equal(calls))
+			}
+		})
+
+		It("counts only invocations with params equal to the given ones", func() {
+			display.Flash("Hello", 1)
+			display.Flash("Hello", 2)
+			display.Flash("Hello", 1)
+
+			Expect(GetGenericMockFrom(display).InvocationCount("Flash", "Hello", 1)).To(Equal(2))
+			Expect(GetGenericMockFrom(display).InvocationCount("Flash", "Bye", 1)).To(Equal(0))
+		})
+
+		It("returns 0 for methods that were never invoked", func() {
+			When(display.SomeValue()).ThenReturn("Hello")
+
+			Expect(display.InvocationCount("SomeValue")).To(Equal(0))
+			Expect(display.InvocationCount("Show")).To(Equal(0))
+		})
+
+		It("counts invocations evicted due to WithInvocationLimit", func() {
+			limitedDisplay := NewMockDisplay(WithInvocationLimit(1))
+			limitedDisplay.Show("one")
+			limitedDisplay.Show("two")
+
+			Expect(limitedDisplay.InvocationCount("Show")).To(Equal(2))
+		})
+	})
+
+	Describe("Reporting interactions", func() {
+		BeforeEach(func() {
+			display.Show("one")
+			display.Flash("two", 2)
+			display.Show("three")
+			display.Flash("four", 4)
+		})
+
+		It("lists all invocations in the order they were made", func() {
+			Expect(AllInvocations(display)).To(gomega.HaveLen(4))
+			Expect(InteractionsReport(display)).To(Equal("Interactions with this mock were:\n" +
+				"\tShow(\"one\")\n\tFlash(\"two\", 2)\n\tShow(\"three\")\n\tFlash(\"four\", 4)\n"))
+		})
+
+		It("filters by method", func() {
+			invocations := AllInvocations(display, InteractionsReportOptions{Methods: []string{"Flash"}})
+
+			Expect(invocations).To(gomega.HaveLen(2))
+			Expect(invocations[0].MethodName).To(Equal("Flash"))
+			Expect(invocations[0].Params).To(Equal([]Param{"two", 2}))
+		})
+
+		It("filters by matchers, by checkpoint and by limit, and composes these filters", func() {
+			checkpoint := NewCheckpoint()
+			display.Flash("five", 5)
+			display.Show("six")
+			display.Flash("seven", 7)
+			display.Flash("eight", 8)
+
+			Expect(InteractionsReport(display, InteractionsReportOptions{
+				Methods:  []string{"Flash"},
+				Matching: map[string]Matchers{"Flash": {NewAnyMatcher(reflect.TypeOf("")), &EqMatcher{Value: 7}}},
+				Since:    checkpoint,
+				Limit:    1,
+			})).To(Equal("Interactions with this mock were:\n\tFlash(\"seven\", 7)\n\n" +
+				"Filtered by methods Flash; matchers Flash(Any(string), Eq(7)); since checkpoint; limit of 1. " +
+				"7 of 8 invocation(s) hidden."))
+		})
+
+		It("states that filters hide all invocations", func() {
+			Expect(InteractionsReport(display, InteractionsReportOptions{Methods: []string{"MultipleParamsAndReturnValue"}})).To(Equal(
+				"There were no interactions with this mock\n" +
+					"Filtered by methods MultipleParamsAndReturnValue. 4 of 4 invocation(s) hidden."))
+		})
+
+		It("returns the invocations of a method with their ordering numbers", func() {
+			shows := display.InvocationsOf("Show")
+			flashes := display.InvocationsOf("Flash")
+
+			Expect(shows).To(gomega.HaveLen(2))
+			Expect(shows[0].Params).To(Equal([]Param{"one"}))
+			Expect(shows[1].Params).To(Equal([]Param{"three"}))
+			Expect(flashes).To(gomega.HaveLen(2))
+			Expect(shows[0].OrderingNumber).To(gomega.BeNumerically("<", flashes[0].OrderingNumber))
+			Expect(flashes[0].OrderingNumber).To(gomega.BeNumerically("<", shows[1].OrderingNumber))
+			Expect(display.InvocationsOf("SomeValue")).To(gomega.BeEmpty())
+		})
+
+		It("orders invocations across mocks", func() {
+			other := NewMockDisplay()
+			other.Show("in between")
+			display.Show("last")
+
+			Expect(other.InvocationsOf("Show")[0].OrderingNumber).To(SatisfyAll(
+				gomega.BeNumerically(">", display.InvocationsOf("Flash")[1].OrderingNumber),
+				gomega.BeNumerically("<", display.InvocationsOf("Show")[2].OrderingNumber)))
+		})
+
+		It("returns copies that don't affect the mock", func() {
+			display.InvocationsOf("Show")[0].Params[0] = "changed"
+
+			Expect(display.InvocationsOf("Show")[0].Params).To(Equal([]Param{"one"}))
+			display.VerifyWasCalledOnce().Show("one")
+		})
+
+		It("includes where the method was invoked from when recording invocation locations", func() {
+			RecordInvocationLocations(true)
+			defer RecordInvocationLocations(false)
+			display.Show("located")
+
+			Expect(display.InvocationsOf("Show")[0].Location).To(gomega.BeEmpty())
+			Expect(display.InvocationsOf("Show")[2].Location).To(MatchRegexp(`dsl_test\.go:\d+$`))
+		})
+	})
+
+	Describe("Calling mocks from goroutines that finish", func() {
+		It("forgets the last invocations and argument matchers of these goroutines", func() {
+			goroutineIDs := make([]int64, 200)
+			wg := sync.WaitGroup{}
+			for i := range goroutineIDs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					goroutineIDs[i] = CurrentGoroutineID()
+					display.Show("Hello")
+					AnyString()
+				}(i)
+			}
+			wg.Wait()
+
+			gomega.Eventually(func() []int64 {
+				ForgetFinishedGoroutines()
+				var withState []int64
+				for _, id := range goroutineIDs {
+					if HasGoroutineState(id) {
+						withState = append(withState, id)
+					}
+				}
+				return withState
+			}).Should(gomega.BeEmpty())
+		})
+	})
+
+	Describe("Using VerifyWasCalledEventually when object under test calls goroutine", func() {
+		It("correctly fails when timeout is shorter than mock invocation, and succeeds, when timeout is longer", func() {
+			go func() {
+				time.Sleep(1 * time.Second)
+				display.Show("hello")
+			}()
+			Expect(func() { display.VerifyWasCalledEventually(Once(), 100*time.Millisecond).Show("hello") }).
+				To(PanicWithMessageTo(SatisfyAll(
+					ContainSubstring("Mock invocation count for test_interface.Display.Show(\"hello\") does not match expectation"),
+					ContainSubstring("after timeout of 100ms"),
+					ContainSubstring("Expected to be called once but was called 0 times"),
+				)))
+
+			Expect(func() { display.VerifyWasCalledEventually(Once(), 2*time.Second).Show("hello") }).NotTo(Panic())
+		})
+
+	})
+
+	Describe("Manipulating out args (using pointers) in Then blocks", func() {
+		It("correctly manipulates the out args", func() {
+			type Entity struct{ i int }
+			var input = []Entity{}
+			When(func() { display.InterfaceParam(AnyInterface()) }).Then(func(params []pegomock.Param) pegomock.ReturnValues {
+				*params[0].(*[]Entity) = append(*params[0].(*[]Entity), Entity{3})
+				return nil
+			})
+
+			display.InterfaceParam(&input)
+
+			Expect(input).To(HaveLen(1))
+			Expect(input[0].i).To(Equal(3))
+		})
+	})
+
+	Context("Mock created with custom fail handler", func() {
+		It("calls custom fail handler instead of global one", func() {
+			failHandlerCalled := false
+			display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) {
+				failHandlerCalled = true
+			}))
+
+			display.VerifyWasCalledOnce().Show("This was never called")
 
 			Expect(failHandlerCalled).To(BeTrue())
 		})
@@ -922,12 +2521,133 @@ var _ = Describe("MockDisplay", func() {
 			})
 		})
 
+		Context("stubbing methods returning receive-only channels with ThenReturn", func() {
+			It("returns a bidirectional channel as receive-only channel", func() {
+				events := make(chan test_interface.Event, 1)
+				When(display.Subscribe()).ThenReturn(events)
+
+				events <- test_interface.Event{Name: "started"}
+
+				Expect(<-display.Subscribe()).To(Equal(test_interface.Event{Name: "started"}))
+			})
+
+			It("returns a receive-only channel", func() {
+				var events <-chan test_interface.Event = make(chan test_interface.Event)
+				When(display.Subscribe()).ThenReturn(events)
+
+				Expect(display.Subscribe()).To(Equal(events))
+			})
+
+			It("returns a nil channel when stubbed with nil or not stubbed", func() {
+				Expect(display.Subscribe()).To(BeNil())
+
+				When(display.Subscribe()).ThenReturn(nil)
+
+				Expect(display.Subscribe()).To(BeNil())
+			})
+
+			It("returns a bidirectional channel and a function together", func() {
+				events := make(chan test_interface.Event, 1)
+				cancelled := false
+				When(display.SubscribeWithCancel()).ThenReturn(events, func() { cancelled = true })
+
+				received, cancel := display.SubscribeWithCancel()
+				events <- test_interface.Event{Name: "started"}
+				cancel()
+
+				Expect(<-received).To(Equal(test_interface.Event{Name: "started"}))
+				Expect(cancelled).To(BeTrue())
+			})
+
+			It("returns a bidirectional channel stubbed with ThenReturnFor as receive-only channel", func() {
+				events := make(chan test_interface.Event)
+				When(display.SubscribeWithCancel()).ThenReturnFor(1, events, func() {})
+
+				received, _ := display.SubscribeWithCancel()
+
+				Expect(received).To(gomega.BeAssignableToTypeOf((<-chan test_interface.Event)(nil)))
+			})
+
+			It("rejects a channel with the wrong direction", func() {
+				Expect(func() {
+					When(display.Subscribe()).ThenReturn(make(chan<- test_interface.Event))
+				}).To(PanicWith("Return value of type chan<- test_interface.Event not assignable to return type <-chan test_interface.Event"))
+			})
+		})
+
 		Context("using send-/receive-only channels", func() {
 			It("generates the mock method with correct channel directions", func() {
 				var stringReadChan <-chan string
 				var errorWriteChan chan<- error
 				display.ChanParams(stringReadChan, errorWriteChan)
 			})
+
+			It("verifies send-only channel params with the channel passed", func() {
+				events := make(chan test_interface.Event)
+
+				display.Publish(events)
+
+				display.VerifyWasCalledOnce().Publish(events)
+			})
+		})
+	})
+
+	Context("parameters with unusual names and types", func() {
+		It("stubs and verifies methods with unnamed params", func() {
+			When(display.UnnamedParams("Hello", 1)).ThenReturn(errors.New("failed"))
+
+			Expect(display.UnnamedParams("Hello", 1)).To(MatchError("failed"))
+			display.VerifyWasCalledOnce().UnnamedParams("Hello", 1)
+		})
+
+		It("stubs and verifies methods with params named like their type", func() {
+			When(display.ParamNamedLikeItsType("Hello")).ThenReturn("Bye")
+
+			Expect(display.ParamNamedLikeItsType("Hello")).To(Equal("Bye"))
+			display.VerifyWasCalledOnce().ParamNamedLikeItsType("Hello")
+		})
+
+		It("verifies methods with params named like identifiers of the generated code", func() {
+			display.ParamsNamedLikeGeneratedIdentifiers("a", 1, true, "b", "c")
+
+			display.VerifyWasCalledOnce().ParamsNamedLikeGeneratedIdentifiers("a", 1, true, "b", "c")
+			mock, _, _, _, ret0 := display.VerifyWasCalledOnce().ParamsNamedLikeGeneratedIdentifiers_GetCapturedArguments()
+			Expect(mock).To(Equal("a"))
+			Expect(ret0).To(Equal("c"))
+		})
+
+		It("verifies methods with blank params", func() {
+			display.BlankParams("Hello", 1)
+
+			display.VerifyWasCalledOnce().BlankParams(AnyString(), EqInt(1))
+		})
+
+		It("verifies methods with channels of channels", func() {
+			sendChan := make(chan chan int)
+			recvChan := make(chan (<-chan string))
+
+			display.ChanOfChanParams(sendChan, recvChan)
+
+			display.VerifyWasCalledOnce().ChanOfChanParams(sendChan, recvChan)
+		})
+
+		It("captures func params with their own variadic params", func() {
+			display.FuncWithOwnParams(func(s string, n ...int) (bool, error) { return len(n) == 2, nil })
+
+			f := display.VerifyWasCalledOnce().FuncWithOwnParams_GetCapturedArguments()
+			Expect(f("Hello", 1, 2)).To(BeTrue())
+		})
+
+		It("stubs and verifies methods with anonymous struct params and return values", func() {
+			type person = struct {
+				Name string
+				Age  int `json:"age"`
+			}
+			When(display.AnonymousStructParam(person{Name: "Tom", Age: 3})).ThenReturn(struct{ OK bool }{OK: true})
+
+			Expect(display.AnonymousStructParam(person{Name: "Tom", Age: 3}).OK).To(BeTrue())
+			Expect(display.AnonymousStructParam(person{Name: "Tim", Age: 3}).OK).To(gomega.BeFalse())
+			display.VerifyWasCalledOnce().AnonymousStructParam(person{Name: "Tom", Age: 3})
 		})
 	})
 })
@@ -939,6 +2659,138 @@ func flattenStringSliceOfSlices(sliceOfSlices [][]string) (result []string) {
 	return
 }
 
+type pointerReceiverError struct{}
+
+func (*pointerReceiverError) Error() string { return "pointer receiver" }
+
+type countingMetrics struct {
+	sync.Mutex
+	counts map[string]int
+}
+
+func (metrics *countingMetrics) IncInvocationCount(mock, method string) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.counts[mock+"."+method]++
+}
+
+// HandRolledDisplay pretends to be a hand-written mock with method metadata
+// that passes the wrong number of params to Invoke.
+type HandRolledDisplay struct{ MockDisplay }
+
+func (mock *HandRolledDisplay) Flash(s string) {
+	GetGenericMockFrom(mock).Invoke("Flash", []Param{s}, nil)
+}
+
+func (mock *HandRolledDisplay) NormalAndVariadicParam(s string, i int, v ...string) {
+	params := []Param{s, i}
+	for _, param := range v {
+		params = append(params, param)
+	}
+	GetGenericMockFrom(mock).Invoke("NormalAndVariadicParam", params, nil)
+}
+
+func (mock *HandRolledDisplay) NormalAndVariadicParamWithTooFewParams(s string) {
+	GetGenericMockFrom(mock).Invoke("NormalAndVariadicParam", []Param{s}, nil)
+}
+
+type LegacyMock struct{ fail FailHandler }
+
+func (mock *LegacyMock) SetFailHandler(fh FailHandler) { mock.fail = fh }
+func (mock *LegacyMock) FailHandler() FailHandler      { return mock.fail }
+
+type fakeTestifyT struct {
+	helperCalled bool
+	message      string
+}
+
+func (t *fakeTestifyT) Helper() { t.helperCalled = true }
+func (t *fakeTestifyT) Fatalf(format string, args ...interface{}) {
+	t.message = fmt.Sprintf(format, args...)
+}
+
+type fakeBufferingT struct {
+	messages []string
+	cleanups []func()
+}
+
+func (t *fakeBufferingT) Fatalf(format string, args ...interface{}) {
+	t.messages = append(t.messages, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeBufferingT) Cleanup(cleanup func()) { t.cleanups = append(t.cleanups, cleanup) }
+
+func (t *fakeBufferingT) runCleanups() {
+	for _, cleanup := range t.cleanups {
+		cleanup()
+	}
+}
+
+type fakeInOrderT struct {
+	errors   []string
+	logs     []string
+	cleanups []func()
+}
+
+func (t *fakeInOrderT) Helper() {}
+
+func (t *fakeInOrderT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeInOrderT) Log(args ...interface{}) { t.logs = append(t.logs, fmt.Sprint(args...)) }
+
+func (t *fakeInOrderT) Cleanup(cleanup func()) { t.cleanups = append(t.cleanups, cleanup) }
+
+func (t *fakeInOrderT) runCleanups() {
+	for _, cleanup := range t.cleanups {
+		cleanup()
+	}
+	t.cleanups = nil
+}
+
+type fakeTestingT struct{ message string }
+
+func (t *fakeTestingT) Errorf(format string, args ...interface{}) {
+	t.message = fmt.Sprintf(format, args...)
+}
+
+func namedFunc(s string) error { return nil }
+
+type person struct {
+	Name string
+	age  int
+	Tags []string
+}
+
+type formatCountingMatcher struct {
+	Matcher
+	formatCount *int
+}
+
+func (matcher formatCountingMatcher) FailureMessage() string {
+	*matcher.formatCount++
+	return matcher.Matcher.FailureMessage()
+}
+
+func (matcher formatCountingMatcher) String() string {
+	*matcher.formatCount++
+	return matcher.Matcher.String()
+}
+
+type MyEvent struct{}
+
+func (*MyEvent) String() string { return "MyEvent" }
+
+type OtherEvent struct{}
+
+// PanicWithVerificationFailure matches the message of a failed verification in
+// this file, which has the verification's location between expectation and interactions.
+func PanicWithVerificationFailure(expectation, interactions string) types.GomegaMatcher {
+	return PanicWithMessageTo(MatchRegexp("^" + regexp.QuoteMeta(expectation) +
+		`\n\tVerified at .+/dsl_test\.go:\d+\n\n` + regexp.QuoteMeta(interactions) + "$"))
+}
+
 type expectation struct {
 	method   string
 	expected string
@@ -946,6 +2798,10 @@ type expectation struct {
 }
 
 func (e expectation) string() string {
-	return fmt.Sprintf("Mock invocation count for %v does not match expectation.\n\n\tExpected: %v; but got: %v",
+	if e.expected == "never" {
+		return fmt.Sprintf("Mock invocation count for test_interface.Display.%v does not match expectation.\n\n\tExpected not to be called but was called %v",
+			e.method, e.actual)
+	}
+	return fmt.Sprintf("Mock invocation count for test_interface.Display.%v does not match expectation.\n\n\tExpected to be called %v but was called %v",
 		e.method, e.expected, e.actual)
 }
@@ -0,0 +1,19 @@
+package examples_test
+
+import (
+	"testing"
+
+	"github.com/petergtz/pegomock"
+)
+
+// RunConcurrent runs a function in many goroutines at once and waits for all of
+// them, so the invocations they made can be verified together afterwards.
+func TestCountingConcurrently(t *testing.T) {
+	counter := NewMockCounter(pegomock.WithT(t))
+
+	result := pegomock.RunConcurrent(t, 8, 100, func(i, j int) { counter.Increment(1) })
+
+	result.VerifyTotalInvocations(counter, "Increment", result.Calls())
+	counter.VerifyWasCalled(pegomock.Times(800)).Increment(1)
+	counter.VerifyWasNeverCalled().Value()
+}
@@ -0,0 +1,9 @@
+package examples
+
+//go:generate pegomock generate --use-experimental-model-gen --output mock_counter_test.go --package examples_test github.com/petergtz/pegomock/examples Counter
+
+// Counter is the interface the examples of concurrent code mock.
+type Counter interface {
+	Increment(by int)
+	Value() int
+}
@@ -0,0 +1,52 @@
+package examples_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/petergtz/pegomock"
+)
+
+func Example_collectingFailures() {
+	book := NewMockPhoneBook()
+	book.AddPhoneNumber("alice", "555-0100")
+
+	// WithGlobalFailHandler with CollectFailures returns failures instead of
+	// failing the test, e.g. to test test helpers.
+	failures := pegomock.WithGlobalFailHandler(pegomock.CollectFailures, func() {
+		book.VerifyWasCalledOnce().AddPhoneNumber("bob", pegomock.AnyString())
+		book.VerifyWasCalledOnce().AddPhoneNumber("alice", "555-0100")
+	})
+	fmt.Println("failures:", len(failures))
+	// Output: failures: 1
+}
+
+// Mocks created WithT report failures to t, so they work with plain go test
+// and no global fail handler.
+func TestVerifyingWithT(t *testing.T) {
+	book := NewMockPhoneBook(pegomock.WithT(t))
+	pegomock.When(book.GetPhoneNumber("alice")).ThenReturn("555-0100", nil)
+
+	if number, _ := book.GetPhoneNumber("alice"); number != "555-0100" {
+		t.Errorf("Expected 555-0100, got %v", number)
+	}
+
+	book.VerifyWasCalledOnce().GetPhoneNumber("alice")
+}
+
+// One mock serves all cases of a table-driven test: ResetForNextTest forgets
+// the invocations of the previous case, KeepStubbings keeps the stubbings
+// shared by all cases.
+func TestTableDrivenWithResetForNextTest(t *testing.T) {
+	book := NewMockPhoneBook(pegomock.WithFailHandler(pegomock.BuildTestingTFailHandler(t)))
+	pegomock.When(book.GetPhoneNumber("alice")).ThenReturn("555-0100", nil)
+
+	for _, name := range []string{"alice", "bob"} {
+		book.ResetForNextTest(pegomock.KeepStubbings)
+
+		book.GetPhoneNumber(name)
+
+		book.VerifyWasCalledOnce().GetPhoneNumber(pegomock.AnyString())
+		book.VerifyWasCalledOnce().GetPhoneNumber(name)
+	}
+}
@@ -0,0 +1,16 @@
+// Package examples shows how to use Pegomock with runnable examples, one for
+// each feature of its DSL. They live in this package's tests, mock PhoneBook or
+// Counter and are run and checked by go test like any other test.
+//
+// The mocks are generated with go generate. Run it before go test.
+package examples
+
+//go:generate pegomock generate --use-experimental-model-gen --output mock_phonebook_test.go --package examples_test github.com/petergtz/pegomock/examples PhoneBook
+
+// PhoneBook is the interface the examples mock.
+type PhoneBook interface {
+	GetPhoneNumber(name string) (string, error)
+	AddPhoneNumber(name string, number string)
+	Notify(message string, recipients ...string) int
+	Subscribe(onChange func(name string))
+}
@@ -0,0 +1,126 @@
+package examples_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/petergtz/pegomock"
+)
+
+func Example_stubbing() {
+	book := NewMockPhoneBook()
+	pegomock.When(book.GetPhoneNumber("alice")).ThenReturn("555-0100", nil)
+
+	number, err := book.GetPhoneNumber("alice")
+	fmt.Println(number, err)
+	// Calls without matching stubbing return zero values.
+	number, err = book.GetPhoneNumber("bob")
+	fmt.Printf("%q %v\n", number, err)
+	// Output:
+	// 555-0100 <nil>
+	// "" <nil>
+}
+
+func Example_stubbingSequences() {
+	book := NewMockPhoneBook()
+	pegomock.When(book.GetPhoneNumber("alice")).
+		ThenReturnFor(2, "", errors.New("busy")).
+		ThenReturn("555-0100", nil).
+		ThenReturn("555-0199", nil)
+
+	for i := 0; i < 5; i++ {
+		number, err := book.GetPhoneNumber("alice")
+		fmt.Printf("%q %v\n", number, err)
+	}
+	// Output:
+	// "" busy
+	// "" busy
+	// "555-0100" <nil>
+	// "555-0199" <nil>
+	// "555-0199" <nil>
+}
+
+func Example_stubbingErrors() {
+	book := NewMockPhoneBook()
+	pegomock.When(book.GetPhoneNumber("alice")).ThenReturnError(errors.New("not found"))
+
+	number, err := book.GetPhoneNumber("alice")
+	fmt.Printf("%q %v\n", number, err)
+	// Output: "" not found
+}
+
+func Example_stubbingWithCallbacks() {
+	book := NewMockPhoneBook()
+	pegomock.When(book.GetPhoneNumber(pegomock.AnyString())).Then(func(params []pegomock.Param) pegomock.ReturnValues {
+		return pegomock.ReturnValues{"555-" + strings.ToUpper(params[0].(string)), nil}
+	})
+
+	fmt.Println(book.GetPhoneNumber("alice"))
+	// Output: 555-ALICE <nil>
+}
+
+func Example_stubbingPanics() {
+	book := NewMockPhoneBook()
+	pegomock.When(func() { book.AddPhoneNumber(pegomock.AnyString(), pegomock.EqString("")) }).ThenPanic("empty number")
+
+	defer func() { fmt.Println("recovered:", recover()) }()
+	book.AddPhoneNumber("alice", "555-0100")
+	fmt.Println("added alice")
+	book.AddPhoneNumber("bob", "")
+	// Output:
+	// added alice
+	// recovered: empty number
+}
+
+func Example_stubbingVariadicMethods() {
+	book := NewMockPhoneBook()
+	// Variadic arguments are matched one by one, so this matches exactly two recipients.
+	pegomock.When(book.Notify("hello", "alice", "bob")).ThenReturn(2)
+	pegomock.When(book.Notify(pegomock.AnyString(), pegomock.AnyString())).ThenReturn(1)
+
+	fmt.Println(book.Notify("hello", "alice", "bob"))
+	fmt.Println(book.Notify("hi", "carol"))
+	fmt.Println(book.Notify("hi", "carol", "dave", "erin"))
+	// Output:
+	// 2
+	// 1
+	// 0
+}
+
+func Example_argumentMatchers() {
+	book := NewMockPhoneBook()
+	pegomock.When(book.GetPhoneNumber(pegomock.AnyString())).ThenReturn("555-0000", nil)
+	// Later stubbings take precedence over earlier ones they overlap with.
+	pegomock.When(book.GetPhoneNumber(pegomock.EqString("alice"))).ThenReturn("555-0100", nil)
+	pegomock.When(book.GetPhoneNumber(startsWith("office-"))).ThenReturn("555-0199", nil)
+
+	fmt.Println(book.GetPhoneNumber("alice"))
+	fmt.Println(book.GetPhoneNumber("office-berlin"))
+	fmt.Println(book.GetPhoneNumber("bob"))
+	// Output:
+	// 555-0100 <nil>
+	// 555-0199 <nil>
+	// 555-0000 <nil>
+}
+
+// startsWith is a custom argument matcher for strings starting with prefix.
+func startsWith(prefix string) string {
+	pegomock.RegisterMatcher(&prefixMatcher{prefix: prefix})
+	return ""
+}
+
+type prefixMatcher struct{ prefix string }
+
+func (matcher *prefixMatcher) Matches(param pegomock.Param) bool {
+	s, isString := param.(string)
+	return isString && strings.HasPrefix(s, matcher.prefix)
+}
+
+func (matcher *prefixMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected a string starting with %q", matcher.prefix)
+}
+
+func (matcher *prefixMatcher) String() string {
+	return fmt.Sprintf("StartsWith(%q)", matcher.prefix)
+}
@@ -0,0 +1,115 @@
+package examples_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/petergtz/pegomock"
+)
+
+func Example_verification() {
+	book := NewMockPhoneBook()
+	book.AddPhoneNumber("alice", "555-0100")
+	book.AddPhoneNumber("bob", "555-0199")
+	book.AddPhoneNumber("bob", "555-0199")
+
+	// Without a fail handler, failed verifications panic. InterceptMockFailures
+	// collects them instead, so this example can show them.
+	failures := pegomock.InterceptMockFailures(func() {
+		book.VerifyWasCalledOnce().AddPhoneNumber("alice", "555-0100")
+		book.VerifyWasCalled(pegomock.Times(2)).AddPhoneNumber("bob", "555-0199")
+		book.VerifyWasCalled(pegomock.AtLeast(1)).AddPhoneNumber(pegomock.AnyString(), pegomock.AnyString())
+		book.VerifyWasCalled(pegomock.Between(1, 3)).AddPhoneNumber("bob", pegomock.AnyString())
+		book.VerifyWasCalled(pegomock.Never()).AddPhoneNumber("carol", pegomock.AnyString())
+	})
+	fmt.Println("failures:", len(failures))
+
+	failures = pegomock.InterceptMockFailures(func() {
+		book.VerifyWasCalledOnce().AddPhoneNumber("bob", "555-0199")
+	})
+	fmt.Println("failures:", len(failures))
+	// Output:
+	// failures: 0
+	// failures: 1
+}
+
+func Example_verificationInOrder() {
+	book := NewMockPhoneBook()
+	book.AddPhoneNumber("alice", "555-0100")
+	book.Notify("added", "alice")
+
+	inOrderContext := new(pegomock.InOrderContext)
+	failures := pegomock.InterceptMockFailures(func() {
+		book.VerifyWasCalledInOrder(pegomock.Once(), inOrderContext).AddPhoneNumber("alice", "555-0100")
+		book.VerifyWasCalledInOrder(pegomock.Once(), inOrderContext).Notify("added", "alice")
+	})
+	fmt.Println("in order:", len(failures) == 0)
+
+	inOrderContext = new(pegomock.InOrderContext)
+	failures = pegomock.InterceptMockFailures(func() {
+		book.VerifyWasCalledInOrder(pegomock.Once(), inOrderContext).Notify("added", "alice")
+		book.VerifyWasCalledInOrder(pegomock.Once(), inOrderContext).AddPhoneNumber("alice", "555-0100")
+	})
+	fmt.Println("in order:", len(failures) == 0)
+	// Output:
+	// in order: true
+	// in order: false
+}
+
+func Example_argumentCaptors() {
+	book := NewMockPhoneBook()
+	book.AddPhoneNumber("alice", "555-0100")
+	book.AddPhoneNumber("bob", "555-0199")
+
+	pegomock.InterceptMockFailures(func() {
+		// GetCapturedArguments returns the arguments of the last matching invocation.
+		name, number := book.VerifyWasCalled(pegomock.Times(2)).AddPhoneNumber(pegomock.AnyString(), pegomock.AnyString()).GetCapturedArguments()
+		fmt.Println(name, number)
+
+		// GetAllCapturedArguments returns those of all matching invocations.
+		names, numbers := book.VerifyWasCalled(pegomock.Times(2)).AddPhoneNumber(pegomock.AnyString(), pegomock.AnyString()).GetAllCapturedArguments()
+		fmt.Println(names, numbers)
+	})
+	// Output:
+	// bob 555-0199
+	// [alice bob] [555-0100 555-0199]
+}
+
+func Example_capturingCallbacks() {
+	book := NewMockPhoneBook()
+	// The code under test subscribes to changes of the phone book.
+	book.Subscribe(func(name string) { fmt.Println("changed:", name) })
+
+	pegomock.InterceptMockFailures(func() {
+		// The test captures the callback and simulates a change.
+		onChange := book.VerifyWasCalledOnce().Subscribe_GetCapturedArguments()
+		pegomock.InvokeCaptured(onChange, func(onChange func(name string)) { onChange("alice") })
+	})
+	// Output: changed: alice
+}
+
+func Example_verifyingAsynchronousCalls() {
+	book := NewMockPhoneBook()
+	go book.AddPhoneNumber("alice", "555-0100")
+
+	// VerifyWasCalledEventually polls until the verification succeeds or the
+	// timeout expires.
+	failures := pegomock.InterceptMockFailures(func() {
+		book.VerifyWasCalledEventually(pegomock.Once(), time.Second).AddPhoneNumber("alice", "555-0100")
+	})
+	fmt.Println("failures:", len(failures))
+	// Output: failures: 0
+}
+
+func Example_countingInvocations() {
+	book := NewMockPhoneBook()
+	book.Notify("hello", "alice")
+	book.Notify("hello", "bob")
+
+	// Unlike verifications, InvocationCount never fails.
+	fmt.Println(book.InvocationCount("Notify"))
+	fmt.Println(book.InvocationCount("GetPhoneNumber"))
+	// Output:
+	// 2
+	// 0
+}
@@ -0,0 +1,36 @@
+package pegomock
+
+// Exported for tests in package pegomock_test only.
+
+var CurrentGoroutineID = currentGoroutineID
+
+var ForgetFinishedGoroutines = forgetFinishedGoroutines
+
+// HasGoroutineState tells if a last invocation or argument matchers are kept
+// for the goroutine with the given ID.
+func HasGoroutineState(goroutineID int64) bool {
+	lastInvocationsMutex.Lock()
+	_, hasLastInvocation := lastInvocations[goroutineID]
+	lastInvocationsMutex.Unlock()
+	globalArgMatchersMutex.Lock()
+	_, hasArgMatchers := globalArgMatchers[goroutineID]
+	globalArgMatchersMutex.Unlock()
+	argMatcherCallersMutex.Lock()
+	_, hasArgMatcherCallers := argMatcherCallers[goroutineID]
+	argMatcherCallersMutex.Unlock()
+	return hasLastInvocation || hasArgMatchers || hasArgMatcherCallers
+}
+
+// StubbingsTimesAnswered tells how many calls each stubbing of methodName
+// answered, not counting calls made to stub methods, in the order the
+// stubbings were made in.
+func StubbingsTimesAnswered(mock Mock, methodName string) []int {
+	method := GetGenericMockFrom(mock).getOrCreateMockedMethod(methodName)
+	method.Lock()
+	defer method.Unlock()
+	timesAnswered := make([]int, len(method.stubbings))
+	for i, stubbing := range method.stubbings {
+		timesAnswered[i] = stubbing.timesAnswered
+	}
+	return timesAnswered
+}
@@ -0,0 +1,91 @@
+package pegomock
+
+import (
+	"reflect"
+	"sync"
+)
+
+// CollectFailures is the built-in collecting fail handler for
+// WithGlobalFailHandler. Failures reported to it don't fail the test, but are
+// returned by WithGlobalFailHandler. It must not be used on its own.
+func CollectFailures(message string, callerSkip ...int) {
+	panic("CollectFailures can only be used with WithGlobalFailHandler, but got failure: " + message)
+}
+
+var (
+	failHandlerScopesMutex sync.Mutex
+	failHandlerScopes      []*failHandlerScope
+)
+
+type failHandlerScope struct {
+	previousHandler FailHandler
+	previousBuffer  *failureBuffer
+}
+
+// WithGlobalFailHandler installs handler as GlobalFailHandler while fn runs, and
+// restores the previous fail handler afterwards, even if fn panics. It's meant
+// for tests that expect verifications to fail, e.g. tests of test helpers or of
+// matcher libraries. Mocks with their own fail handler, e.g. created with WithT
+// or WithFailHandler, keep using it.
+//
+// With CollectFailures as handler, failures don't fail the test, but are
+// returned instead:
+//
+//	failures := pegomock.WithGlobalFailHandler(pegomock.CollectFailures, func() {
+//		display.VerifyWasCalledOnce().Show("Hello")
+//	})
+//
+// With any other handler, it returns nil.
+//
+// Calls may be nested, but must return in reverse order of being made.
+// WithGlobalFailHandler panics otherwise, e.g. when calls on different
+// goroutines overlap.
+func WithGlobalFailHandler(handler FailHandler, fn func()) (failures []string) {
+	if isCollectFailures(handler) {
+		var mutex sync.Mutex
+		failures = []string{}
+		handler = func(message string, callerSkip ...int) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			failures = append(failures, message)
+		}
+	}
+	scope := beginFailHandlerScope(handler)
+	defer endFailHandlerScope(scope)
+	fn()
+	return
+}
+
+func isCollectFailures(handler FailHandler) bool {
+	return handler != nil && reflect.ValueOf(handler).Pointer() == reflect.ValueOf(CollectFailures).Pointer()
+}
+
+func beginFailHandlerScope(handler FailHandler) *failHandlerScope {
+	failHandlerScopesMutex.Lock()
+	defer failHandlerScopesMutex.Unlock()
+	currentFailureBufferMutex.Lock()
+	scope := &failHandlerScope{previousHandler: GlobalFailHandler, previousBuffer: currentFailureBuffer}
+	currentFailureBufferMutex.Unlock()
+	failHandlerScopes = append(failHandlerScopes, scope)
+	RegisterMockFailHandler(handler)
+	return scope
+}
+
+func endFailHandlerScope(scope *failHandlerScope) {
+	failHandlerScopesMutex.Lock()
+	defer failHandlerScopesMutex.Unlock()
+	GlobalFailHandler = scope.previousHandler
+	setCurrentFailureBuffer(scope.previousBuffer)
+	innermost := failHandlerScopes[len(failHandlerScopes)-1] == scope
+	for i := range failHandlerScopes {
+		if failHandlerScopes[i] == scope {
+			failHandlerScopes = append(failHandlerScopes[:i], failHandlerScopes[i+1:]...)
+			break
+		}
+	}
+	if !innermost {
+		panic("WithGlobalFailHandler calls must return in reverse order of being made, " +
+			"but one returned while a call made after it was still running. " +
+			"Don't call WithGlobalFailHandler from several goroutines at the same time.")
+	}
+}
@@ -0,0 +1,102 @@
+package pegomock_test
+
+import (
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("WithGlobalFailHandler", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("returns the failures with CollectFailures instead of failing", func() {
+		failures := WithGlobalFailHandler(CollectFailures, func() {
+			display.VerifyWasCalledOnce().Show("Hello")
+			display.VerifyWasCalledOnce().Show("Bye")
+		})
+
+		Expect(failures).To(ConsistOf(
+			ContainSubstring(`Show("Hello") does not match expectation.`),
+			ContainSubstring(`Show("Bye") does not match expectation.`)))
+	})
+
+	It("returns an empty list with CollectFailures if nothing failed", func() {
+		display.Show("Hello")
+
+		Expect(WithGlobalFailHandler(CollectFailures, func() {
+			display.VerifyWasCalledOnce().Show("Hello")
+		})).To(gomega.BeEmpty())
+	})
+
+	It("installs the given handler and returns nil", func() {
+		var messages []string
+		failures := WithGlobalFailHandler(func(message string, callerSkip ...int) { messages = append(messages, message) }, func() {
+			display.VerifyWasCalledOnce().Show("Hello")
+		})
+
+		Expect(failures).To(BeNil())
+		Expect(messages).To(ConsistOf(ContainSubstring(`Show("Hello") does not match expectation.`)))
+	})
+
+	It("restores the previous handler afterwards", func() {
+		WithGlobalFailHandler(CollectFailures, func() {})
+
+		Expect(func() { display.VerifyWasCalledOnce().Show("Hello") }).To(Panic())
+	})
+
+	It("restores the previous handler when fn panics", func() {
+		Expect(func() {
+			WithGlobalFailHandler(CollectFailures, func() { panic("Ouch") })
+		}).To(PanicWith("Ouch"))
+
+		Expect(func() { display.VerifyWasCalledOnce().Show("Hello") }).To(Panic())
+	})
+
+	It("supports nesting", func() {
+		var inner []string
+		outer := WithGlobalFailHandler(CollectFailures, func() {
+			inner = WithGlobalFailHandler(CollectFailures, func() {
+				display.VerifyWasCalledOnce().Show("inner")
+			})
+			display.VerifyWasCalledOnce().Show("outer")
+		})
+
+		Expect(inner).To(ConsistOf(ContainSubstring(`Show("inner")`)))
+		Expect(outer).To(ConsistOf(ContainSubstring(`Show("outer")`)))
+	})
+
+	It("panics when calls overlap instead of nesting", func() {
+		var overlapPanic interface{}
+		WithGlobalFailHandler(CollectFailures, func() {
+			firstStarted, secondStarted, firstReturned := make(chan struct{}), make(chan struct{}), make(chan struct{})
+			go func() {
+				defer close(firstReturned)
+				defer func() { overlapPanic = recover() }()
+				WithGlobalFailHandler(CollectFailures, func() {
+					close(firstStarted)
+					<-secondStarted
+				})
+			}()
+			<-firstStarted
+			WithGlobalFailHandler(CollectFailures, func() {
+				close(secondStarted)
+				<-firstReturned
+			})
+		})
+
+		Expect(overlapPanic).To(HavePrefix("WithGlobalFailHandler calls must return in reverse order of being made"))
+		Expect(func() { display.VerifyWasCalledOnce().Show("Hello") }).To(Panic())
+	})
+
+	It("makes InterceptMockFailures restore the previous handler when its callback panics", func() {
+		Expect(func() {
+			InterceptMockFailures(func() { panic("Ouch") })
+		}).To(PanicWith("Ouch"))
+
+		Expect(func() { display.VerifyWasCalledOnce().Show("Hello") }).To(Panic())
+	})
+})
@@ -0,0 +1,75 @@
+package pegomock
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// DisableRecording makes mock stop recording its invocations, for benchmarks
+// that use mocks as cheap fakes. Stubbing keeps working, but verifications fail
+// with a "recording disabled" error, and InvocationsOf and AllInvocations don't
+// return invocations made from then on. It also lets generated mocks reuse the
+// slices they pass params in.
+func DisableRecording(mock Mock) {
+	atomic.StoreInt32(&GetGenericMockFrom(mock).recordingDisabled, 1)
+}
+
+func (genericMock *GenericMock) isRecording() bool {
+	return atomic.LoadInt32(&genericMock.recordingDisabled) == 0
+}
+
+// MethodHandle caches what Invoke looks up on every invocation of a method.
+// Generated mocks have one for each method. The zero value is ready to use.
+type MethodHandle struct {
+	once        sync.Once
+	genericMock *GenericMock
+	method      *mockedMethod
+
+	spareParamsMutex sync.Mutex
+	spareParams      [][]Param
+}
+
+func (handle *MethodHandle) init(mock Mock, methodName string) {
+	handle.once.Do(func() {
+		handle.genericMock = GetGenericMockFrom(mock)
+		handle.method = handle.genericMock.getOrCreateMockedMethod(methodName)
+	})
+}
+
+// Params returns a slice for the n params of an invocation of methodName. For
+// mocks with recording disabled, it reuses slices that Invoke didn't need to
+// retain.
+func (handle *MethodHandle) Params(mock Mock, methodName string, n int) []Param {
+	handle.init(mock, methodName)
+	if n == 0 || handle.genericMock.isRecording() {
+		return make([]Param, n)
+	}
+	handle.spareParamsMutex.Lock()
+	defer handle.spareParamsMutex.Unlock()
+	for len(handle.spareParams) > 0 {
+		params := handle.spareParams[len(handle.spareParams)-1]
+		handle.spareParams = handle.spareParams[:len(handle.spareParams)-1]
+		if cap(params) >= n {
+			return params[:n]
+		}
+	}
+	return make([]Param, n)
+}
+
+// Invoke is like GenericMock.Invoke, but skips looking up the GenericMock of
+// mock and the method.
+func (handle *MethodHandle) Invoke(mock Mock, methodName string, params []Param, returnTypes []reflect.Type) ReturnValues {
+	handle.init(mock, methodName)
+	flushBufferedFailuresIfOnTestGoroutine()
+	returnValues, paramsRetained := handle.genericMock.invoke(handle.method, methodName, params, returnTypes, true)
+	if !paramsRetained && len(params) > 0 {
+		for i := range params {
+			params[i] = nil
+		}
+		handle.spareParamsMutex.Lock()
+		handle.spareParams = append(handle.spareParams, params)
+		handle.spareParamsMutex.Unlock()
+	}
+	return returnValues
+}
@@ -0,0 +1,172 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// formatCycleSafe formats value with verb like fmt does. fmt recurses forever
+// on values that contain themselves through maps, slices or interfaces, e.g. a
+// slice stored in one of its own elements. Such values are rendered in Go
+// syntax instead, with a back-reference marker in place of the repeated value.
+func formatCycleSafe(verb string, value interface{}) string {
+	if value == nil {
+		return fmt.Sprintf(verb, value)
+	}
+	return formatReflectValueCycleSafe(verb, reflect.ValueOf(value))
+}
+
+func formatReflectValueCycleSafe(verb string, value reflect.Value) string {
+	if !containsFormattingCycle(value, make(map[formattingCycleKey]bool), true) {
+		// fmt prints the value held by a reflect.Value, including unexported fields.
+		return fmt.Sprintf(verb, value)
+	}
+	var builder strings.Builder
+	writeGoSyntax(&builder, value, make(map[formattingCycleKey]bool), true)
+	return builder.String()
+}
+
+// formattingCycleKey identifies the maps and slices fmt descends into. Slices
+// are identified by their underlying array together with their length, since
+// slicing creates new slice values.
+type formattingCycleKey struct {
+	typ     reflect.Type
+	pointer uintptr
+	length  int
+}
+
+func formattingCycleKeyOf(value reflect.Value) formattingCycleKey {
+	return formattingCycleKey{typ: value.Type(), pointer: value.Pointer(), length: value.Len()}
+}
+
+// containsFormattingCycle reports whether fmt would come across a map or slice
+// again while it is still formatting it. Like fmt, it descends into pointers
+// only at the top level; deeper pointers are printed as addresses.
+func containsFormattingCycle(value reflect.Value, onPath map[formattingCycleKey]bool, topLevel bool) bool {
+	switch value.Kind() {
+	case reflect.Interface:
+		return !value.IsNil() && containsFormattingCycle(value.Elem(), onPath, topLevel)
+	case reflect.Ptr:
+		return topLevel && !value.IsNil() && containsFormattingCycle(value.Elem(), onPath, false)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if containsFormattingCycle(value.Field(i), onPath, false) {
+				return true
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if containsFormattingCycle(value.Index(i), onPath, false) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Map:
+		if value.IsNil() || value.Len() == 0 {
+			return false
+		}
+		key := formattingCycleKeyOf(value)
+		if onPath[key] {
+			return true
+		}
+		onPath[key] = true
+		defer delete(onPath, key)
+		if value.Kind() == reflect.Slice {
+			for i := 0; i < value.Len(); i++ {
+				if containsFormattingCycle(value.Index(i), onPath, false) {
+					return true
+				}
+			}
+			return false
+		}
+		iter := value.MapRange()
+		for iter.Next() {
+			if containsFormattingCycle(iter.Key(), onPath, false) || containsFormattingCycle(iter.Value(), onPath, false) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeGoSyntax(builder *strings.Builder, value reflect.Value, onPath map[formattingCycleKey]bool, topLevel bool) {
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			fmt.Fprintf(builder, "%#v", value)
+			return
+		}
+		writeGoSyntax(builder, value.Elem(), onPath, topLevel)
+	case reflect.Ptr:
+		if !topLevel || value.IsNil() {
+			fmt.Fprintf(builder, "%#v", value)
+			return
+		}
+		builder.WriteString("&")
+		writeGoSyntax(builder, value.Elem(), onPath, false)
+	case reflect.Struct:
+		builder.WriteString(value.Type().String() + "{")
+		for i := 0; i < value.NumField(); i++ {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(value.Type().Field(i).Name + ":")
+			writeGoSyntax(builder, value.Field(i), onPath, false)
+		}
+		builder.WriteString("}")
+	case reflect.Array:
+		writeGoSyntaxElements(builder, value, onPath)
+	case reflect.Slice, reflect.Map:
+		if value.IsNil() || value.Len() == 0 {
+			fmt.Fprintf(builder, "%#v", value)
+			return
+		}
+		key := formattingCycleKeyOf(value)
+		if onPath[key] {
+			fmt.Fprintf(builder, "<back-reference to %v>", value.Type())
+			return
+		}
+		onPath[key] = true
+		defer delete(onPath, key)
+		if value.Kind() == reflect.Slice {
+			writeGoSyntaxElements(builder, value, onPath)
+			return
+		}
+		writeGoSyntaxMap(builder, value, onPath)
+	default:
+		fmt.Fprintf(builder, "%#v", value)
+	}
+}
+
+func writeGoSyntaxElements(builder *strings.Builder, value reflect.Value, onPath map[formattingCycleKey]bool) {
+	builder.WriteString(value.Type().String() + "{")
+	for i := 0; i < value.Len(); i++ {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		writeGoSyntax(builder, value.Index(i), onPath, false)
+	}
+	builder.WriteString("}")
+}
+
+func writeGoSyntaxMap(builder *strings.Builder, value reflect.Value, onPath map[formattingCycleKey]bool) {
+	type entry struct{ key, value string }
+	var entries []entry
+	iter := value.MapRange()
+	for iter.Next() {
+		var key, element strings.Builder
+		writeGoSyntax(&key, iter.Key(), onPath, false)
+		writeGoSyntax(&element, iter.Value(), onPath, false)
+		entries = append(entries, entry{key.String(), element.String()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	builder.WriteString(value.Type().String() + "{")
+	for i, entry := range entries {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(entry.key + ":" + entry.value)
+	}
+	builder.WriteString("}")
+}
@@ -0,0 +1,81 @@
+package pegomock_test
+
+import (
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+type treeNode struct {
+	Name     string
+	Parent   *treeNode
+	Children []*treeNode
+}
+
+func twoNodeCycle(parentName, childName string) *treeNode {
+	parent := &treeNode{Name: parentName}
+	child := &treeNode{Name: childName, Parent: parent}
+	parent.Children = []*treeNode{child}
+	return parent
+}
+
+type listNode struct {
+	Value int
+	Next  *listNode
+}
+
+func linkedList(values ...int) *listNode {
+	var head *listNode
+	for i := len(values) - 1; i >= 0; i-- {
+		head = &listNode{Value: values[i], Next: head}
+	}
+	return head
+}
+
+var _ = Describe("Failure messages for self-referential params", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("reports differences between structures with parent pointers", func() {
+		display.InterfaceParam(twoNodeCycle("parent", "child"))
+
+		Expect(func() { display.VerifyWasCalledOnce().InterfaceParam(twoNodeCycle("parent", "other child")) }).To(PanicWithMessageTo(
+			ContainSubstring(`Children[0].Name: expected "other child", got "child"`)))
+	})
+
+	It("renders a back-reference for slices that contain themselves", func() {
+		slice := []interface{}{"first", nil}
+		slice[1] = slice
+		display.InterfaceParam(slice)
+
+		Expect(func() { display.VerifyWasCalledOnce().InterfaceParam("other") }).To(PanicWithMessageTo(
+			ContainSubstring(`InterfaceParam([]interface {}{"first", <back-reference to []interface {}>})`)))
+	})
+
+	It("renders a back-reference for maps that contain themselves", func() {
+		m := map[string]interface{}{"key": "value"}
+		m["self"] = m
+		display.InterfaceParam(m)
+
+		Expect(func() { display.VerifyWasCalledOnce().InterfaceParam("other") }).To(PanicWithMessageTo(
+			ContainSubstring(`InterfaceParam(map[string]interface {}{"key":"value", "self":<back-reference to map[string]interface {}>})`)))
+	})
+
+	It("aborts comparing long linked lists instead of hanging", func() {
+		values := make([]int, 10000)
+		for i := range values {
+			values[i] = i
+		}
+		display.InterfaceParam(linkedList(values...))
+		values[len(values)-1] = -1
+		expected := linkedList(values...)
+
+		start := time.Now()
+		Expect(func() { display.VerifyWasCalledOnce().InterfaceParam(expected) }).To(PanicWithMessageTo(
+			ContainSubstring("comparison aborted at depth 100; use a custom matcher")))
+		Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second))
+	})
+})
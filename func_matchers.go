@@ -0,0 +1,95 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// AnyFunc registers and returns a matcher that matches any non-nil function.
+// Eq matchers, which params are wrapped in by default, match functions only by
+// pointer identity, like SameFuncAs.
+func AnyFunc() Matcher {
+	matcher := &AnyFuncMatcher{}
+	RegisterMatcher(matcher)
+	return matcher
+}
+
+// SameFuncAs registers and returns a matcher that matches the function f by
+// pointer identity. This works when the code under test passes on the very
+// function f, e.g. one stored in a variable.
+//
+// Note that the pointer identifies the function's code: all closures created by
+// the same function literal, and method values of the same method on different
+// receivers, are considered the same.
+func SameFuncAs(f interface{}) Matcher {
+	verify.Argument(isNonNilFunc(f), "SameFuncAs needs a non-nil function")
+	matcher := &SameFuncMatcher{Func: f}
+	RegisterMatcher(matcher)
+	return matcher
+}
+
+func isNonNilFunc(param Param) bool {
+	value := reflect.ValueOf(param)
+	return value.Kind() == reflect.Func && !value.IsNil()
+}
+
+type AnyFuncMatcher struct {
+	actual Param
+	sync.Mutex
+}
+
+func (matcher *AnyFuncMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	return isNonNilFunc(param)
+}
+
+func (matcher *AnyFuncMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: non-nil function; but got: %T", matcher.actual)
+}
+
+func (matcher *AnyFuncMatcher) String() string {
+	return "AnyFunc()"
+}
+
+type SameFuncMatcher struct {
+	Func   interface{}
+	actual Param
+	sync.Mutex
+}
+
+func (matcher *SameFuncMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	return isNonNilFunc(param) && reflect.ValueOf(param).Pointer() == reflect.ValueOf(matcher.Func).Pointer()
+}
+
+// sameFunc reports whether expected and actual are non-nil functions of the same
+// type with the same pointer.
+func sameFunc(expected, actual Param) bool {
+	return isNonNilFunc(actual) && reflect.TypeOf(expected) == reflect.TypeOf(actual) &&
+		reflect.ValueOf(expected).Pointer() == reflect.ValueOf(actual).Pointer()
+}
+
+func (matcher *SameFuncMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: same function as %v; but got: %v", funcName(matcher.Func), funcName(matcher.actual))
+}
+
+func (matcher *SameFuncMatcher) String() string {
+	return fmt.Sprintf("SameFuncAs(%v)", funcName(matcher.Func))
+}
+
+func funcName(f interface{}) string {
+	if !isNonNilFunc(f) {
+		return fmt.Sprintf("%#v", f)
+	}
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
@@ -15,7 +15,6 @@
 package mockgen_test
 
 import (
-	"os"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -27,6 +26,11 @@ func TestMockGeneration(t *testing.T) { RunSpecs(t, "Generating mocks with GoMoc
 var _ = It("Generate mocks", func() {
 	filehandling.GenerateMockFile(
 		[]string{"../../test_interface/display.go"},
-		"../../mock_display_test.go", "MockDisplay", "pegomock_test",
-		"", false, os.Stdout, false, true, "")
+		"../../mock_display_test.go",
+		filehandling.GenerateOptions{MockName: "MockDisplay", NamePrefix: "Mock", PackageOut: "pegomock_test",
+			GenerateMatchers: true, ContextAware: true, Provide: true})
+	filehandling.GenerateMockFile(
+		[]string{"../../test_interface/query.go"},
+		"../../mock_query_test.go",
+		filehandling.GenerateOptions{MockName: "MockQuery", NamePrefix: "Mock", PackageOut: "pegomock_test"})
 })
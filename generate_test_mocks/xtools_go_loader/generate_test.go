@@ -15,7 +15,6 @@
 package mockgen_test
 
 import (
-	"os"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -29,6 +28,19 @@ func TestMockGeneration(t *testing.T) {
 var _ = It("Generate mocks", func() {
 	filehandling.GenerateMockFile(
 		[]string{"github.com/petergtz/pegomock/test_interface", "Display"},
-		"../../mock_display_test.go", "MockDisplay", "pegomock_test",
-		"", false, os.Stdout, true, true, "")
+		"../../mock_display_test.go",
+		filehandling.GenerateOptions{MockName: "MockDisplay", NamePrefix: "Mock", PackageOut: "pegomock_test",
+			UseExperimentalModelGen: true, GenerateMatchers: true, ContextAware: true, Provide: true})
+	filehandling.GenerateMockFile(
+		[]string{"github.com/petergtz/pegomock/test_interface", "Query"},
+		"../../mock_query_test.go",
+		filehandling.GenerateOptions{MockName: "MockQuery", NamePrefix: "Mock", PackageOut: "pegomock_test", UseExperimentalModelGen: true})
+	filehandling.GenerateMockFile(
+		[]string{"github.com/petergtz/pegomock/examples", "PhoneBook"},
+		"../../examples/mock_phonebook_test.go",
+		filehandling.GenerateOptions{MockName: "MockPhoneBook", NamePrefix: "Mock", PackageOut: "examples_test", UseExperimentalModelGen: true})
+	filehandling.GenerateMockFile(
+		[]string{"github.com/petergtz/pegomock/examples", "Counter"},
+		"../../examples/mock_counter_test.go",
+		filehandling.GenerateOptions{MockName: "MockCounter", NamePrefix: "Mock", PackageOut: "examples_test", UseExperimentalModelGen: true})
 })
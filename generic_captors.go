@@ -0,0 +1,63 @@
+//go:build go1.18
+// +build go1.18
+
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// InvokeCaptured calls call with captured, e.g. a callback interface or function
+// the code under test passed to a mock and that was obtained via
+// GetCapturedArguments. This way, tests can simulate events the code under test
+// subscribed to. It fails the test if captured is nil.
+func InvokeCaptured[T any](captured T, call func(T)) {
+	if isNilValue(reflect.ValueOf(&captured).Elem()) {
+		message := fmt.Sprintf("Cannot invoke captured %v: it is nil.", reflect.TypeOf(&captured).Elem())
+		if GlobalFailHandler == nil {
+			panic(message)
+		}
+		GlobalFailHandler(message, 1)
+		return
+	}
+	call(captured)
+}
+
+// TypedArgumentCaptor is an ArgumentCaptor for arguments of type T.
+type TypedArgumentCaptor[T any] struct{ *ArgumentCaptor }
+
+// CaptorOf returns a captor for arguments of type T, for types that have no
+// generated captor.
+func CaptorOf[T any]() *TypedArgumentCaptor[T] {
+	return &TypedArgumentCaptor[T]{NewArgumentCaptor(reflect.TypeOf((*T)(nil)).Elem())}
+}
+
+func (captor *TypedArgumentCaptor[T]) Capture() T {
+	RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue T
+	return nullValue
+}
+
+func (captor *TypedArgumentCaptor[T]) GetValue() T {
+	value, _ := captor.Value().(T)
+	return value
+}
+
+func (captor *TypedArgumentCaptor[T]) GetAllValues() []T {
+	capturedValues := captor.Values()
+	values := make([]T, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(T)
+	}
+	return values
+}
+
+func isNilValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return value.IsNil()
+	default:
+		return false
+	}
+}
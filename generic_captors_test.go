@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package pegomock_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/petergtz/pegomock"
+	. "github.com/petergtz/pegomock/matchers"
+)
+
+// greetingServer is a sample system under test that registers a handler with a Display.
+type greetingServer struct {
+	greeted []string
+}
+
+func (server *greetingServer) Start(display *MockDisplay) {
+	display.RegisterHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		server.greeted = append(server.greeted, name)
+		w.Write([]byte("Hello " + name))
+	}))
+}
+
+var _ = Describe("Generic captors", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("drives a captured handler with InvokeCaptured", func() {
+		server := &greetingServer{}
+		server.Start(display)
+
+		handler := display.VerifyWasCalledOnce().RegisterHandler(AnyHttpHandler()).GetCapturedArguments()
+		for _, name := range []string{"Alice", "Bob"} {
+			recorder := httptest.NewRecorder()
+			InvokeCaptured(handler, func(handler http.Handler) {
+				handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/?name="+name, nil))
+			})
+			Expect(recorder.Body.String()).To(Equal("Hello " + name))
+		}
+
+		Expect(server.greeted).To(Equal([]string{"Alice", "Bob"}))
+	})
+
+	It("fails when the captured argument is nil", func() {
+		display.RegisterHandler(nil)
+
+		handler := display.VerifyWasCalledOnce().RegisterHandler(AnyHttpHandler()).GetCapturedArguments()
+
+		Expect(func() { InvokeCaptured(handler, func(http.Handler) {}) }).To(PanicWithMessageTo(Equal(
+			"Cannot invoke captured http.Handler: it is nil.",
+		)))
+	})
+
+	It("captures arguments with CaptorOf", func() {
+		display.MultipleParamsAndReturnValue("Hello", 1)
+		display.MultipleParamsAndReturnValue("World", 2)
+
+		captor := CaptorOf[int]()
+		display.VerifyWasCalled(Times(2)).MultipleParamsAndReturnValue(AnyString(), captor.Capture())
+
+		Expect(captor.GetValue()).To(Equal(2))
+		Expect(captor.GetAllValues()).To(Equal([]int{1, 2}))
+	})
+})
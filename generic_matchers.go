@@ -0,0 +1,82 @@
+//go:build go1.18
+// +build go1.18
+
+package pegomock
+
+import (
+	"reflect"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// IsAOf is the type-parameterized form of IsA, e.g. IsAOf[*MyEvent]() or
+// IsAOf[io.Reader]().
+func IsAOf[T any]() Matcher {
+	return registerIsAMatcher(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// AnyFuncOf registers the same matcher as AnyFunc, but returns a value usable as
+// argument for a function-typed parameter, e.g. AnyFuncOf[func(string) error]().
+func AnyFuncOf[T any]() T {
+	AnyFunc()
+	var nullValue T
+	return nullValue
+}
+
+// SameFuncOf is the type-parameterized form of SameFuncAs, usable as argument
+// for a function-typed parameter.
+func SameFuncOf[T any](f T) T {
+	SameFuncAs(f)
+	var nullValue T
+	return nullValue
+}
+
+// EqUsing matches params equal to value according to cmp.Equal with opts, e.g.
+// EqUsing(deadline, cmpopts.EquateApproxTime(time.Second)). On mismatch, the
+// failure message shows the cmp.Diff.
+func EqUsing[T any](value T, opts ...cmp.Option) T {
+	RegisterMatcher(NewEqMatcherUsing(value, opts...))
+	var nullValue T
+	return nullValue
+}
+
+// VariadicDefaultingOf registers the same matcher as VariadicDefaulting, but
+// returns a value usable in place of the variadic arguments of type T, e.g.
+// file.VerifyWasCalledOnce().Open(EqString("f.txt"), VariadicDefaultingOf([]string{"utf-8"}, &EqMatcher{Value: "utf-8"})).
+func VariadicDefaultingOf[T any](defaults []T, matchers ...Matcher) T {
+	defaultParams := make([]interface{}, len(defaults))
+	for i, defaultValue := range defaults {
+		defaultParams[i] = defaultValue
+	}
+	VariadicDefaulting(defaultParams, matchers...)
+	var nullValue T
+	return nullValue
+}
+
+// SliceContainingOf registers the same matcher as SliceContaining, but returns a
+// value usable as argument for a slice-typed parameter, e.g.
+// display.VerifyWasCalledOnce().ArrayParam(SliceContainingOf("a", "b")).
+func SliceContainingOf[E any](elems ...E) []E {
+	elemParams := make([]interface{}, len(elems))
+	for i, elem := range elems {
+		elemParams[i] = elem
+	}
+	SliceContaining(elemParams...)
+	return nil
+}
+
+// MapContainingOf registers the same matcher as MapContaining, but returns a
+// value usable as argument for a map-typed parameter.
+func MapContainingOf[K comparable, V any](key K, value V) map[K]V {
+	MapContaining(key, value)
+	return nil
+}
+
+// FieldEqualOf registers the same matcher as FieldEqual, but returns a value
+// usable as argument for a parameter of type T, e.g.
+// FieldEqualOf[*User]("Address.City", "Berlin").
+func FieldEqualOf[T any](fieldPath string, value interface{}) T {
+	FieldEqual(fieldPath, value)
+	var nullValue T
+	return nullValue
+}
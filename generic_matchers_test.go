@@ -0,0 +1,121 @@
+//go:build go1.18
+// +build go1.18
+
+package pegomock_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("Generic matchers", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("matches values of exactly the given type with IsAOf", func() {
+		display.InterfaceParam(&MyEvent{})
+		display.InterfaceParam(&OtherEvent{})
+
+		display.VerifyWasCalledOnce().InterfaceParam(IsAOf[*MyEvent]())
+		display.VerifyWasCalled(Never()).InterfaceParam(IsAOf[MyEvent]())
+	})
+
+	It("matches values implementing the given interface with IsAOf", func() {
+		display.InterfaceParam(&MyEvent{})
+		display.InterfaceParam(&OtherEvent{})
+
+		display.VerifyWasCalledOnce().InterfaceParam(IsAOf[fmt.Stringer]())
+		display.VerifyWasCalled(Times(2)).InterfaceParam(IsAOf[any]())
+	})
+
+	It("matches function-typed params with AnyFuncOf and SameFuncOf", func() {
+		display.FuncParam(namedFunc)
+		display.FuncParam(func(string) error { return nil })
+		display.FuncParam(nil)
+
+		display.VerifyWasCalled(Times(2)).FuncParam(AnyFuncOf[func(string) error]())
+		display.VerifyWasCalledOnce().FuncParam(SameFuncOf(namedFunc))
+	})
+
+	It("compares with cmp.Equal and the given options with EqUsing, and shows the diff on mismatch", func() {
+		approxTime := cmp.Comparer(func(a, b time.Time) bool { return a.Sub(b) < time.Second && b.Sub(a) < time.Second })
+		newYear := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		display.UseTime(newYear.Add(500 * time.Millisecond))
+
+		display.VerifyWasCalledOnce().UseTime(EqUsing(newYear, approxTime))
+		Expect(func() { display.VerifyWasCalledOnce().UseTime(EqUsing(newYear.Add(time.Hour), approxTime)) }).To(
+			PanicWithMessageTo(ContainSubstring("param 0:\n\t\tExpected: 2020-01-01 01:00:00 +0000 UTC; but got: 2020-01-01 00:00:00.5 +0000 UTC\n\t\tDiff (-expected +actual):\n")))
+	})
+
+	It("matches slice-, map- and struct-typed params with SliceContainingOf, MapContainingOf and FieldEqualOf", func() {
+		display.ArrayParam([]string{"a", "b"})
+		display.ArrayParam([]string{"b"})
+		display.MapOfStringToInterfaceParam(map[string]interface{}{"id": 1})
+		display.MapOfStringToInterfaceParam(nil)
+		display.AnonymousStructParam(struct {
+			Name string
+			Age  int `json:"age"`
+		}{Name: "Alice"})
+
+		display.VerifyWasCalledOnce().ArrayParam(SliceContainingOf("b", "a"))
+		display.VerifyWasCalledOnce().MapOfStringToInterfaceParam(MapContainingOf[string, interface{}]("id", 1))
+		display.VerifyWasCalledOnce().AnonymousStructParam(FieldEqualOf[struct {
+			Name string
+			Age  int `json:"age"`
+		}]("Name", "Alice"))
+	})
+
+	Context("VariadicDefaultingOf", func() {
+		It("verifies calls that omit variadic arguments and ones that pass their defaults alike", func() {
+			display.NormalAndVariadicParam("a", 1)
+			display.NormalAndVariadicParam("a", 1, "default")
+			display.NormalAndVariadicParam("a", 1, "other")
+
+			display.VerifyWasCalled(Twice()).NormalAndVariadicParam(EqString("a"), EqInt(1),
+				VariadicDefaultingOf([]string{"default"}, &EqMatcher{Value: "default"}))
+			display.VerifyWasCalledOnce().NormalAndVariadicParam(EqString("a"), EqInt(1),
+				VariadicDefaultingOf([]string{"default"}, &EqMatcher{Value: "other"}))
+		})
+
+		It("drops trailing variadic arguments equal to their defaults beyond the number of matchers", func() {
+			display.VariadicParam("x", "y")
+			display.VariadicParam("x", "z")
+
+			display.VerifyWasCalledOnce().VariadicParam(VariadicDefaultingOf([]string{"x", "y"}, &EqMatcher{Value: "x"}))
+		})
+
+		It("doesn't match calls with more variadic arguments than defaults", func() {
+			display.VariadicParam("x", "y")
+
+			display.VerifyWasCalled(Never()).VariadicParam(VariadicDefaultingOf([]string{"x"}, &EqMatcher{Value: "x"}))
+		})
+
+		It("stubs calls that omit variadic arguments and ones that pass their defaults alike", func() {
+			When(func() {
+				display.NormalAndVariadicParam(AnyString(), AnyInt(), VariadicDefaultingOf([]string{"default"}, &EqMatcher{Value: "default"}))
+			}).ThenPanic("stubbed")
+
+			Expect(func() { display.NormalAndVariadicParam("a", 1) }).To(PanicWith("stubbed"))
+			Expect(func() { display.NormalAndVariadicParam("a", 1, "default") }).To(PanicWith("stubbed"))
+			Expect(func() { display.NormalAndVariadicParam("a", 1, "other") }).NotTo(Panic())
+		})
+
+		It("takes over matchers that register themselves", func() {
+			display.VariadicParam()
+
+			display.VerifyWasCalledOnce().VariadicParam(VariadicDefaultingOf([]string{"default"}, IsA("")))
+		})
+
+		It("panics when a matcher has no default", func() {
+			Expect(func() { VariadicDefaultingOf([]string{}, &EqMatcher{Value: "x"}) }).To(PanicWith(
+				"VariadicDefaulting needs a default for each matcher, but got 0 defaults for 1 matchers"))
+		})
+	})
+})
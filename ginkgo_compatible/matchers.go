@@ -56,10 +56,17 @@ var (
 	EqString           = pegomock.EqString
 	AnyString          = pegomock.AnyString
 	AnyStringSlice     = pegomock.AnyStringSlice
+	EqContext          = pegomock.EqContext
+	AnyContext         = pegomock.AnyContext
+	IsA                = pegomock.IsA
+	AnyFunc            = pegomock.AnyFunc
+	SameFuncAs         = pegomock.SameFuncAs
 
 	Times   = pegomock.Times
+	Exactly = pegomock.Exactly
 	AtLeast = pegomock.AtLeast
 	AtMost  = pegomock.AtMost
+	Between = pegomock.Between
 	Never   = pegomock.Never
 	Once    = pegomock.Once
 	Twice   = pegomock.Twice
@@ -0,0 +1,313 @@
+package pegomock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// updateGoldenEnvVar names the environment variable that makes
+// InteractionsMatchGolden write golden files instead of comparing with them.
+const updateGoldenEnvVar = "PEGOMOCK_UPDATE_GOLDEN"
+
+type goldenT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// InteractionRecord is a serializable entry of the timeline returned by
+// DumpInteractions.
+type InteractionRecord struct {
+	MethodName string `json:"method" yaml:"method"`
+	// Params are the params the method was invoked with, formatted
+	// deterministically and sanitized, see DumpInteractions.
+	Params []string `json:"params,omitempty" yaml:"params,omitempty"`
+	// OrderingNumber tells the order of invocations across all mocks, like
+	// Invocation.OrderingNumber.
+	OrderingNumber int `json:"orderingNumber" yaml:"orderingNumber"`
+}
+
+func (record InteractionRecord) String() string {
+	return record.MethodName + "(" + strings.Join(record.Params, ", ") + ")"
+}
+
+// ParamFormatter formats params of the type it is registered for, see
+// RegisterParamFormatter.
+type ParamFormatter func(param Param) string
+
+// ParamSanitizer returns formatted, the formatted paramIndex-th param of an
+// invocation of methodName, with nondeterministic parts like timestamps or ids
+// redacted, see RegisterParamSanitizer.
+type ParamSanitizer func(methodName string, paramIndex int, formatted string) string
+
+var (
+	recordFormattingMutex sync.RWMutex
+	paramFormatters       = make(map[reflect.Type]ParamFormatter)
+	paramSanitizers       []ParamSanitizer
+)
+
+// RegisterParamFormatter makes DumpInteractions format values of typ with
+// formatter, also where they are nested in other values. Registering typ again
+// replaces its formatter.
+func RegisterParamFormatter(typ reflect.Type, formatter ParamFormatter) {
+	verify.Argument(typ != nil, "RegisterParamFormatter needs a type")
+	verify.Argument(formatter != nil, "RegisterParamFormatter needs a formatter")
+	recordFormattingMutex.Lock()
+	defer recordFormattingMutex.Unlock()
+	paramFormatters[typ] = formatter
+}
+
+// RegisterParamSanitizer makes DumpInteractions pass all formatted params
+// through sanitizer, after the sanitizers registered before.
+func RegisterParamSanitizer(sanitizer ParamSanitizer) {
+	verify.Argument(sanitizer != nil, "RegisterParamSanitizer needs a sanitizer")
+	recordFormattingMutex.Lock()
+	defer recordFormattingMutex.Unlock()
+	paramSanitizers = append(paramSanitizers, sanitizer)
+}
+
+// DumpInteractions returns the invocations of mock in the order they were made,
+// with params formatted for golden files: in Go syntax like %#v, but with map
+// entries sorted, pointers followed instead of printed as addresses, and values
+// of types registered with RegisterParamFormatter formatted by their formatter.
+// The formatted params are then passed through the sanitizers registered with
+// RegisterParamSanitizer. Invocations evicted due to WithInvocationLimit are
+// left out.
+func DumpInteractions(mock Mock) []InteractionRecord {
+	formatter, sanitizers := registeredRecordFormatting()
+	invocations := GetGenericMockFrom(mock).sortedInvocations()
+	records := make([]InteractionRecord, len(invocations))
+	for i, invocation := range invocations {
+		records[i] = InteractionRecord{MethodName: invocation.MethodName, OrderingNumber: invocation.OrderingNumber}
+		for j, param := range invocation.Params {
+			formatted := formatter.format(param)
+			for _, sanitize := range sanitizers {
+				formatted = sanitize(invocation.MethodName, j, formatted)
+			}
+			records[i].Params = append(records[i].Params, formatted)
+		}
+	}
+	return records
+}
+
+// registeredRecordFormatting returns copies of the registered formatters and
+// sanitizers, so they can register others while being used.
+func registeredRecordFormatting() (recordFormatter, []ParamSanitizer) {
+	recordFormattingMutex.RLock()
+	defer recordFormattingMutex.RUnlock()
+	formatter := recordFormatter{formatters: make(map[reflect.Type]ParamFormatter, len(paramFormatters))}
+	for typ, paramFormatter := range paramFormatters {
+		formatter.formatters[typ] = paramFormatter
+	}
+	return formatter, append([]ParamSanitizer(nil), paramSanitizers...)
+}
+
+// InteractionsMatchGolden compares the interactions of mock, as returned by
+// DumpInteractions, with the golden file at path, which has one invocation per
+// line. It fails t with a diff if they differ, or if the golden file doesn't
+// exist. If the environment variable PEGOMOCK_UPDATE_GOLDEN is set to a true
+// value, e.g. "1", it writes the golden file instead.
+//
+// Golden files don't contain OrderingNumbers, since they depend on other mocks
+// and tests.
+func InteractionsMatchGolden(t goldenT, mock Mock, path string) {
+	t.Helper()
+	var actual strings.Builder
+	for _, record := range DumpInteractions(mock) {
+		actual.WriteString(record.String() + "\n")
+	}
+
+	if update, _ := strconv.ParseBool(os.Getenv(updateGoldenEnvVar)); update {
+		if e := os.MkdirAll(filepath.Dir(path), 0755); e != nil {
+			t.Fatalf("Could not write golden file %v: %v", path, e)
+			return
+		}
+		if e := ioutil.WriteFile(path, []byte(actual.String()), 0644); e != nil {
+			t.Fatalf("Could not write golden file %v: %v", path, e)
+		}
+		return
+	}
+
+	golden, e := ioutil.ReadFile(path)
+	if os.IsNotExist(e) {
+		t.Fatalf("Golden file %v does not exist. Run with %v=1 to create it.", path, updateGoldenEnvVar)
+		return
+	}
+	if e != nil {
+		t.Fatalf("Could not read golden file %v: %v", path, e)
+		return
+	}
+	if string(golden) != actual.String() {
+		t.Errorf("Interactions differ from golden file %v (-golden +actual). Run with %v=1 to update it.\n%v",
+			path, updateGoldenEnvVar, diffLines(splitLines(string(golden)), splitLines(actual.String())))
+	}
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines renders the lines only in expected with "-", the lines only in
+// actual with "+" and the lines they have in common with two spaces.
+func diffLines(expected, actual []string) string {
+	// commonAfter[i][j] is the length of the longest common subsequence of
+	// expected[i:] and actual[j:].
+	commonAfter := make([][]int, len(expected)+1)
+	for i := range commonAfter {
+		commonAfter[i] = make([]int, len(actual)+1)
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				commonAfter[i][j] = commonAfter[i+1][j+1] + 1
+			} else if commonAfter[i+1][j] >= commonAfter[i][j+1] {
+				commonAfter[i][j] = commonAfter[i+1][j]
+			} else {
+				commonAfter[i][j] = commonAfter[i][j+1]
+			}
+		}
+	}
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(expected) || j < len(actual) {
+		switch {
+		case i < len(expected) && j < len(actual) && expected[i] == actual[j]:
+			diff.WriteString("  " + expected[i] + "\n")
+			i, j = i+1, j+1
+		case j == len(actual) || (i < len(expected) && commonAfter[i+1][j] >= commonAfter[i][j+1]):
+			diff.WriteString("- " + expected[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+ " + actual[j] + "\n")
+			j++
+		}
+	}
+	return diff.String()
+}
+
+// recordFormatter writes values in Go syntax like writeGoSyntax does, but
+// follows pointers at all levels, since addresses differ between test runs, and
+// uses formatters for the types they are registered for. Pointers, maps and
+// slices that lead back to values it is still writing are rendered as
+// back-reference markers.
+type recordFormatter struct {
+	formatters map[reflect.Type]ParamFormatter
+}
+
+func (formatter recordFormatter) format(param Param) string {
+	if param == nil {
+		return "nil"
+	}
+	var builder strings.Builder
+	formatter.write(&builder, reflect.ValueOf(param), make(map[formattingCycleKey]bool))
+	return builder.String()
+}
+
+func (formatter recordFormatter) write(builder *strings.Builder, value reflect.Value, onPath map[formattingCycleKey]bool) {
+	if paramFormatter, exists := formatter.formatters[value.Type()]; exists && value.CanInterface() {
+		builder.WriteString(paramFormatter(value.Interface()))
+		return
+	}
+	if value.Kind() != reflect.Interface && !(value.Kind() == reflect.Ptr && value.IsNil()) && value.CanInterface() {
+		if goStringer, isGoStringer := value.Interface().(fmt.GoStringer); isGoStringer {
+			builder.WriteString(goStringer.GoString())
+			return
+		}
+	}
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			builder.WriteString("nil")
+			return
+		}
+		formatter.write(builder, value.Elem(), onPath)
+	case reflect.Ptr:
+		if value.IsNil() {
+			fmt.Fprintf(builder, "(%v)(nil)", value.Type())
+			return
+		}
+		key := formattingCycleKey{typ: value.Type(), pointer: value.Pointer()}
+		if onPath[key] {
+			fmt.Fprintf(builder, "<back-reference to %v>", value.Type())
+			return
+		}
+		onPath[key] = true
+		defer delete(onPath, key)
+		builder.WriteString("&")
+		formatter.write(builder, value.Elem(), onPath)
+	case reflect.Struct:
+		builder.WriteString(value.Type().String() + "{")
+		for i := 0; i < value.NumField(); i++ {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(value.Type().Field(i).Name + ":")
+			formatter.write(builder, value.Field(i), onPath)
+		}
+		builder.WriteString("}")
+	case reflect.Array:
+		formatter.writeElements(builder, value, onPath)
+	case reflect.Slice, reflect.Map:
+		if value.IsNil() {
+			fmt.Fprintf(builder, "%v(nil)", value.Type())
+			return
+		}
+		key := formattingCycleKeyOf(value)
+		if onPath[key] {
+			fmt.Fprintf(builder, "<back-reference to %v>", value.Type())
+			return
+		}
+		onPath[key] = true
+		defer delete(onPath, key)
+		if value.Kind() == reflect.Slice {
+			formatter.writeElements(builder, value, onPath)
+			return
+		}
+		formatter.writeMap(builder, value, onPath)
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if value.IsNil() {
+			fmt.Fprintf(builder, "(%v)(nil)", value.Type())
+			return
+		}
+		fmt.Fprintf(builder, "(%v)(non-nil)", value.Type())
+	default:
+		fmt.Fprintf(builder, "%#v", value)
+	}
+}
+
+func (formatter recordFormatter) writeElements(builder *strings.Builder, value reflect.Value, onPath map[formattingCycleKey]bool) {
+	builder.WriteString(value.Type().String() + "{")
+	for i := 0; i < value.Len(); i++ {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		formatter.write(builder, value.Index(i), onPath)
+	}
+	builder.WriteString("}")
+}
+
+func (formatter recordFormatter) writeMap(builder *strings.Builder, value reflect.Value, onPath map[formattingCycleKey]bool) {
+	var entries []string
+	iter := value.MapRange()
+	for iter.Next() {
+		var entry strings.Builder
+		formatter.write(&entry, iter.Key(), onPath)
+		entry.WriteString(":")
+		formatter.write(&entry, iter.Value(), onPath)
+		entries = append(entries, entry.String())
+	}
+	sort.Strings(entries)
+	builder.WriteString(value.Type().String() + "{" + strings.Join(entries, ", ") + "}")
+}
@@ -0,0 +1,149 @@
+package pegomock_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+type fakeGoldenT struct {
+	errors   []string
+	failures []string
+}
+
+func (t *fakeGoldenT) Helper() {}
+
+func (t *fakeGoldenT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeGoldenT) Fatalf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+type goldenOrder struct {
+	ID      string
+	Items   map[string]int
+	Parent  *goldenOrder
+	Created time.Time
+}
+
+type goldenTemperature float64
+
+var _ = Describe("Golden interactions", func() {
+	var (
+		display *MockDisplay
+		dir     string
+	)
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+		var e error
+		dir, e = ioutil.TempDir("", "golden")
+		Expect(e).NotTo(gomega.HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("PEGOMOCK_UPDATE_GOLDEN")
+		os.RemoveAll(dir)
+	})
+
+	It("dumps interactions in order with params formatted deterministically", func() {
+		parent := &goldenOrder{ID: "parent"}
+		order := &goldenOrder{ID: "child", Items: map[string]int{"b": 2, "a": 1, "c": 3}, Parent: parent}
+		parent.Parent = parent
+		display.Show("Hello")
+		display.InterfaceParam(order)
+		display.VariadicParam("x", "y")
+		display.InterfaceParam(nil)
+
+		records := DumpInteractions(display)
+
+		Expect(records).To(HaveLen(4))
+		Expect(records[0].MethodName).To(Equal("Show"))
+		Expect(records[0].Params).To(Equal([]string{`"Hello"`}))
+		Expect(records[1].Params).To(Equal([]string{`&pegomock_test.goldenOrder{ID:"child", Items:map[string]int{"a":1, "b":2, "c":3}, ` +
+			`Parent:&pegomock_test.goldenOrder{ID:"parent", Items:map[string]int(nil), Parent:<back-reference to *pegomock_test.goldenOrder>, ` +
+			`Created:time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)}, Created:time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)}`}))
+		Expect(records[2].String()).To(Equal(`VariadicParam("x", "y")`))
+		Expect(records[3].String()).To(Equal(`InterfaceParam(nil)`))
+		Expect(records[0].OrderingNumber).To(gomega.BeNumerically("<", records[1].OrderingNumber))
+		Expect(records[1].OrderingNumber).To(gomega.BeNumerically("<", records[2].OrderingNumber))
+	})
+
+	It("formats params with registered formatters and redacts them with sanitizers", func() {
+		RegisterParamFormatter(reflect.TypeOf(goldenTemperature(0)), func(param Param) string {
+			return fmt.Sprintf("%.1f°C", param)
+		})
+		orderID := regexp.MustCompile(`ID:"order-[0-9]+"`)
+		RegisterParamSanitizer(func(methodName string, paramIndex int, formatted string) string {
+			if methodName != "MapOfStringToInterfaceParam" {
+				return formatted
+			}
+			return orderID.ReplaceAllString(formatted, `ID:"<id>"`)
+		})
+
+		display.MapOfStringToInterfaceParam(map[string]interface{}{
+			"temperature": goldenTemperature(21.53),
+			"order":       goldenOrder{ID: fmt.Sprintf("order-%v", time.Now().UnixNano())},
+		})
+
+		Expect(DumpInteractions(display)[0].Params).To(Equal([]string{`map[string]interface {}{` +
+			`"order":pegomock_test.goldenOrder{ID:"<id>", Items:map[string]int(nil), Parent:(*pegomock_test.goldenOrder)(nil), ` +
+			`Created:time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)}, "temperature":21.5°C}`}))
+	})
+
+	It("writes the golden file when updating and compares with it otherwise", func() {
+		path := filepath.Join(dir, "testdata", "display.golden")
+		display.Show("Hello")
+		display.Flash("world", 42)
+		t := &fakeGoldenT{}
+
+		InteractionsMatchGolden(t, display, path)
+		Expect(t.failures).To(Equal([]string{
+			"Golden file " + path + " does not exist. Run with PEGOMOCK_UPDATE_GOLDEN=1 to create it.",
+		}))
+
+		t = &fakeGoldenT{}
+		os.Setenv("PEGOMOCK_UPDATE_GOLDEN", "1")
+		InteractionsMatchGolden(t, display, path)
+		Expect(t.failures).To(gomega.BeEmpty())
+		golden, e := ioutil.ReadFile(path)
+		Expect(e).NotTo(gomega.HaveOccurred())
+		Expect(string(golden)).To(Equal("Show(\"Hello\")\nFlash(\"world\", 42)\n"))
+
+		os.Unsetenv("PEGOMOCK_UPDATE_GOLDEN")
+		InteractionsMatchGolden(t, display, path)
+		Expect(t.errors).To(gomega.BeEmpty())
+		Expect(t.failures).To(gomega.BeEmpty())
+	})
+
+	It("reports how interactions differ from the golden file", func() {
+		path := filepath.Join(dir, "display.golden")
+		Expect(ioutil.WriteFile(path, []byte("Show(\"Hello\")\nFlash(\"world\", 42)\nSomeValue()\n"), 0644)).To(gomega.Succeed())
+		display.Show("Hello")
+		display.Flash("world", 43)
+		display.SomeValue()
+		display.Show("Bye")
+		t := &fakeGoldenT{}
+
+		InteractionsMatchGolden(t, display, path)
+
+		Expect(t.errors).To(Equal([]string{
+			"Interactions differ from golden file " + path + " (-golden +actual). Run with PEGOMOCK_UPDATE_GOLDEN=1 to update it.\n" +
+				"  Show(\"Hello\")\n" +
+				"- Flash(\"world\", 42)\n" +
+				"+ Flash(\"world\", 43)\n" +
+				"  SomeValue()\n" +
+				"+ Show(\"Bye\")\n",
+		}))
+	})
+})
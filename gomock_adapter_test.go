@@ -0,0 +1,117 @@
+package pegomock_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/petergtz/pegomock/gomockadapter"
+)
+
+// The Test functions are written the way gomock tests are, to show that such
+// tests need only few changes to run with pegomock-generated mocks.
+
+func TestGomockStyleReturnAndTimes(t *testing.T) {
+	ctrl := gomockadapter.NewController(t)
+	defer ctrl.Finish()
+	display := NewMockDisplay(WithT(t))
+
+	ctrl.EXPECT(display.MultipleParamsAndReturnValue("Hello", 1)).Return("one").Times(2)
+	ctrl.EXPECT(display.MultipleParamsAndReturnValue(AnyString(), EqInt(2))).Return("two")
+	ctrl.EXPECT(display.SomeValue()).AnyTimes()
+
+	if result := display.MultipleParamsAndReturnValue("Hello", 1); result != "one" {
+		t.Errorf("Expected one, but got %v", result)
+	}
+	display.MultipleParamsAndReturnValue("Hello", 1)
+	if result := display.MultipleParamsAndReturnValue("Bye", 2); result != "two" {
+		t.Errorf("Expected two, but got %v", result)
+	}
+}
+
+func TestGomockStyleDoAndDoAndReturn(t *testing.T) {
+	ctrl := gomockadapter.NewController(t)
+	defer ctrl.Finish()
+	display := NewMockDisplay(WithT(t))
+	var shown []string
+
+	ctrl.EXPECT(func() { display.Show(AnyString()) }).Do(func(s string) { shown = append(shown, s) }).Times(2)
+	ctrl.EXPECT(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).DoAndReturn(func(s string, i int) string {
+		return fmt.Sprint(s, i)
+	})
+	ctrl.EXPECT(func() { display.VariadicParam(AnyString(), AnyString()) }).Do(func(v ...string) { shown = append(shown, v...) })
+
+	display.Show("Hello")
+	display.Show("Bye")
+	if result := display.MultipleParamsAndReturnValue("Hello", 3); result != "Hello3" {
+		t.Errorf("Expected Hello3, but got %v", result)
+	}
+	display.VariadicParam("a", "b")
+
+	if fmt.Sprint(shown) != "[Hello Bye a b]" {
+		t.Errorf("Expected [Hello Bye a b], but got %v", shown)
+	}
+}
+
+type recordingTestReporter struct{ errors []string }
+
+func (reporter *recordingTestReporter) Errorf(format string, args ...interface{}) {
+	reporter.errors = append(reporter.errors, fmt.Sprintf(format, args...))
+}
+
+func (reporter *recordingTestReporter) Fatalf(format string, args ...interface{}) {
+	reporter.Errorf(format, args...)
+}
+
+var _ = Describe("gomockadapter.Controller", func() {
+	var (
+		reporter *recordingTestReporter
+		ctrl     *gomockadapter.Controller
+	)
+
+	BeforeEach(func() {
+		reporter = &recordingTestReporter{}
+		ctrl = gomockadapter.NewController(reporter)
+	})
+
+	It("reports expected calls invoked too few or too many times", func() {
+		display := NewMockDisplay()
+		ctrl.EXPECT(func() { display.Show("Hello") })
+		ctrl.EXPECT(display.SomeValue()).Times(1)
+
+		display.SomeValue()
+		display.SomeValue()
+		ctrl.Finish()
+
+		Expect(reporter.errors).To(ConsistOf(
+			"Expected call *pegomock_test.MockDisplay.Show(Eq(Hello)) has wrong number of calls: expected 1, but got 0",
+			"Expected call *pegomock_test.MockDisplay.SomeValue() has wrong number of calls: expected 1, but got 2"))
+	})
+
+	It("reports unexpected calls of mocks with expected calls only", func() {
+		display := NewMockDisplay()
+		otherDisplay := NewMockDisplay()
+		ctrl.EXPECT(func() { display.Show("Hello") })
+
+		display.Show("Hello")
+		display.Show("Bye")
+		otherDisplay.Show("Bye")
+		ctrl.Finish()
+
+		Expect(reporter.errors).To(ConsistOf(`Unexpected call to *pegomock_test.MockDisplay.Show("Bye")`))
+	})
+
+	It("accepts calls allowed with AnyTimes any number of times", func() {
+		display := NewMockDisplay()
+		ctrl.EXPECT(display.SomeValue()).Return("Hello").AnyTimes()
+		ctrl.EXPECT(func() { display.Show("Hello") }).AnyTimes()
+
+		Expect(display.SomeValue()).To(Equal("Hello"))
+		Expect(display.SomeValue()).To(Equal("Hello"))
+		ctrl.Finish()
+
+		Expect(reporter.errors).To(gomega.BeEmpty())
+	})
+})
@@ -0,0 +1,227 @@
+// Package gomockadapter eases migrating tests from gomock to pegomock. It
+// provides a Controller with gomock's expectation API, i.e. EXPECT, Return,
+// Times, AnyTimes, Do and DoAndReturn, on top of pegomock-generated mocks:
+//
+//	ctrl := gomockadapter.NewController(t)
+//	defer ctrl.Finish()
+//	display := NewMockDisplay(pegomock.WithT(t))
+//
+//	ctrl.EXPECT(display.SomeValue()).Return("Hello").Times(2)
+//	ctrl.EXPECT(func() { display.Show("Hello") })
+//
+// Unlike in gomock, the expected call is passed to EXPECT, like to pegomock.When.
+// Methods without return values are passed inside a func. Argument matchers
+// work as with When.
+package gomockadapter
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/petergtz/pegomock"
+)
+
+// TestReporter is the part of *testing.T a Controller reports to, as in gomock.
+type TestReporter interface {
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// Controller records expected calls and checks them in Finish.
+type Controller struct {
+	t     TestReporter
+	mutex sync.Mutex
+	calls []*Call
+}
+
+func NewController(t TestReporter) *Controller {
+	return &Controller{t: t}
+}
+
+// EXPECT expects the given call once, unless configured otherwise with Times
+// or AnyTimes. Invoking the call returns zero values, unless configured
+// otherwise with Return or DoAndReturn.
+func (ctrl *Controller) EXPECT(invocation ...interface{}) *Call {
+	stubbing := pegomock.When(invocation...)
+	call := &Call{
+		mock:       stubbing.Mock(),
+		methodName: stubbing.MethodName,
+		matchers:   stubbing.ParamMatchers,
+		minTimes:   1,
+		maxTimes:   1,
+	}
+	stubbing.Then(call.invoke)
+	ctrl.mutex.Lock()
+	defer ctrl.mutex.Unlock()
+	ctrl.calls = append(ctrl.calls, call)
+	return call
+}
+
+// Finish reports expected calls that were invoked too few or too many times,
+// and invocations of the mocks that no expected call matched. Like
+// VerifyNoMoreInteractions, it only looks at mocks that have expected calls.
+func (ctrl *Controller) Finish() {
+	ctrl.mutex.Lock()
+	defer ctrl.mutex.Unlock()
+	var mocks []pegomock.Mock
+	for _, call := range ctrl.calls {
+		call.mutex.Lock()
+		if call.count < call.minTimes || (call.maxTimes >= 0 && call.count > call.maxTimes) {
+			ctrl.t.Errorf("Expected call %v has wrong number of calls: expected %v, but got %v",
+				call, call.formatTimes(), call.count)
+		}
+		call.mutex.Unlock()
+		if !containsMock(mocks, call.mock) {
+			mocks = append(mocks, call.mock)
+		}
+	}
+	for _, mock := range mocks {
+		for _, invocation := range pegomock.AllInvocations(mock) {
+			if !ctrl.expects(mock, invocation) {
+				ctrl.t.Errorf("Unexpected call to %T.%v", mock, invocation)
+			}
+		}
+	}
+}
+
+func (ctrl *Controller) expects(mock pegomock.Mock, invocation pegomock.Invocation) bool {
+	for _, call := range ctrl.calls {
+		if call.mock == mock && call.methodName == invocation.MethodName && call.matchers.Matches(invocation.Params) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMock(mocks []pegomock.Mock, mock pegomock.Mock) bool {
+	for _, m := range mocks {
+		if m == mock {
+			return true
+		}
+	}
+	return false
+}
+
+// Call is an expected call. Its methods can be chained in any order.
+type Call struct {
+	mock       pegomock.Mock
+	methodName string
+	matchers   pegomock.Matchers
+
+	mutex        sync.Mutex
+	minTimes     int
+	maxTimes     int // -1 means unlimited
+	returnValues pegomock.ReturnValues
+	do           reflect.Value
+	doAndReturn  reflect.Value
+	count        int
+}
+
+// Return makes invocations of the call return values.
+func (call *Call) Return(values ...interface{}) *Call {
+	call.mutex.Lock()
+	defer call.mutex.Unlock()
+	call.returnValues = make(pegomock.ReturnValues, len(values))
+	for i, value := range values {
+		call.returnValues[i] = value
+	}
+	return call
+}
+
+// Times expects the call exactly n times.
+func (call *Call) Times(n int) *Call {
+	call.mutex.Lock()
+	defer call.mutex.Unlock()
+	call.minTimes, call.maxTimes = n, n
+	return call
+}
+
+// AnyTimes allows the call any number of times, including none.
+func (call *Call) AnyTimes() *Call {
+	call.mutex.Lock()
+	defer call.mutex.Unlock()
+	call.minTimes, call.maxTimes = 0, -1
+	return call
+}
+
+// Do makes invocations of the call invoke f with their arguments. f must
+// have the signature of the method, but its return values are ignored.
+func (call *Call) Do(f interface{}) *Call {
+	call.mutex.Lock()
+	defer call.mutex.Unlock()
+	call.do = funcValue("Do", f)
+	return call
+}
+
+// DoAndReturn makes invocations of the call invoke f with their arguments and
+// return its return values. f must have the signature of the method.
+func (call *Call) DoAndReturn(f interface{}) *Call {
+	call.mutex.Lock()
+	defer call.mutex.Unlock()
+	call.doAndReturn = funcValue("DoAndReturn", f)
+	return call
+}
+
+func funcValue(methodName string, f interface{}) reflect.Value {
+	value := reflect.ValueOf(f)
+	if value.Kind() != reflect.Func {
+		panic(fmt.Sprintf("%v requires a func, but got %#v", methodName, f))
+	}
+	return value
+}
+
+func (call *Call) invoke(params []pegomock.Param) pegomock.ReturnValues {
+	call.mutex.Lock()
+	call.count++
+	do, doAndReturn, returnValues := call.do, call.doAndReturn, call.returnValues
+	call.mutex.Unlock()
+	if do.IsValid() {
+		callFunc(do, params)
+	}
+	if doAndReturn.IsValid() {
+		return callFunc(doAndReturn, params)
+	}
+	return returnValues
+}
+
+func callFunc(f reflect.Value, params []pegomock.Param) pegomock.ReturnValues {
+	funcType := f.Type()
+	args := make([]reflect.Value, len(params))
+	for i, param := range params {
+		var paramType reflect.Type
+		if funcType.IsVariadic() && i >= funcType.NumIn()-1 {
+			paramType = funcType.In(funcType.NumIn() - 1).Elem()
+		} else {
+			paramType = funcType.In(i)
+		}
+		if param == nil {
+			args[i] = reflect.Zero(paramType)
+		} else {
+			args[i] = reflect.ValueOf(param)
+		}
+	}
+	var returnValues pegomock.ReturnValues
+	for _, result := range f.Call(args) {
+		returnValues = append(returnValues, result.Interface())
+	}
+	return returnValues
+}
+
+func (call *Call) formatTimes() string {
+	if call.maxTimes < 0 {
+		return fmt.Sprintf("at least %v", call.minTimes)
+	}
+	return fmt.Sprint(call.minTimes)
+}
+
+func (call *Call) String() string {
+	result := fmt.Sprintf("%T.%v(", call.mock, call.methodName)
+	for i, matcher := range call.matchers {
+		if i > 0 {
+			result += ", "
+		}
+		result += matcher.String()
+	}
+	return result + ")"
+}
@@ -0,0 +1,49 @@
+package pegomock
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+var goroutinePrefix = []byte("goroutine ")
+
+// currentGoroutineID extracts the ID of the calling goroutine from the
+// header of its stack trace, which looks like "goroutine 42 [running]:".
+func currentGoroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, goroutinePrefix)
+	buf = buf[:bytes.IndexByte(buf, ' ')]
+	id, err := strconv.ParseInt(string(buf), 10, 64)
+	if err != nil {
+		panic("Could not determine goroutine ID: " + err.Error())
+	}
+	return id
+}
+
+// liveGoroutineIDs returns the IDs of all goroutines that haven't finished.
+func liveGoroutineIDs() map[int64]bool {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	ids := make(map[int64]bool)
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		if !bytes.HasPrefix(line, goroutinePrefix) {
+			continue
+		}
+		line = bytes.TrimPrefix(line, goroutinePrefix)
+		if end := bytes.IndexByte(line, ' '); end > 0 {
+			if id, err := strconv.ParseInt(string(line[:end]), 10, 64); err == nil {
+				ids[id] = true
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,107 @@
+package pegomock
+
+import "sync"
+
+type inOrderT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// implicitInOrder is the ordering context activated by BeginInOrder.
+type implicitInOrder struct {
+	t       inOrderT
+	context InOrderContext
+	used    bool
+	// explicitUses counts verifications that passed their own InOrderContext
+	// while this one was active.
+	explicitUses int
+}
+
+// Like argument matchers, implicit ordering contexts are tracked per goroutine,
+// so that each test gets its own.
+var (
+	implicitInOrders      = make(map[int64]*implicitInOrder)
+	implicitInOrdersMutex sync.Mutex
+)
+
+// BeginInOrder activates an implicit InOrderContext for the calling test. Until
+// EndInOrder is called or the test finishes, all verifications on the test's
+// goroutine that don't pass an InOrderContext explicitly are verified in order
+// using this implicit context. This way, helpers can verify sequences of calls
+// across mocks without having to pass an InOrderContext around.
+func BeginInOrder(t inOrderT) {
+	t.Helper()
+	goroutineID := currentGoroutineID()
+	implicitInOrdersMutex.Lock()
+	if _, exists := implicitInOrders[goroutineID]; exists {
+		implicitInOrdersMutex.Unlock()
+		t.Errorf("BeginInOrder called while in-order verification is already active. Call EndInOrder first.")
+		return
+	}
+	implicitInOrders[goroutineID] = &implicitInOrder{t: t}
+	implicitInOrdersMutex.Unlock()
+
+	t.Cleanup(func() { endInOrder(goroutineID) })
+}
+
+// EndInOrder deactivates the implicit InOrderContext activated by BeginInOrder.
+// It fails the test if no verification used the implicit context, or if
+// verifications used an explicit InOrderContext in the meantime.
+func EndInOrder(t inOrderT) {
+	t.Helper()
+	if !endInOrder(currentGoroutineID()) {
+		t.Errorf("EndInOrder called without a preceding BeginInOrder.")
+	}
+}
+
+func endInOrder(goroutineID int64) (ended bool) {
+	implicitInOrdersMutex.Lock()
+	implicit, exists := implicitInOrders[goroutineID]
+	delete(implicitInOrders, goroutineID)
+	implicitInOrdersMutex.Unlock()
+	if !exists {
+		return false
+	}
+
+	implicit.t.Helper()
+	if !implicit.used {
+		implicit.t.Errorf("In-order verification was begun with BeginInOrder, but no verification used it.")
+	}
+	if implicit.explicitUses > 0 {
+		implicit.t.Errorf("%v verification(s) used an explicit InOrderContext while in-order verification "+
+			"begun with BeginInOrder was active. The explicit InOrderContext took precedence. "+
+			"Please don't mix both ways of verifying in order.", implicit.explicitUses)
+	}
+	return true
+}
+
+// inOrderContextFor returns the InOrderContext a verification should use: the
+// explicitly passed one, if any, else the one activated via BeginInOrder.
+func inOrderContextFor(explicit *InOrderContext) *InOrderContext {
+	implicitInOrdersMutex.Lock()
+	defer implicitInOrdersMutex.Unlock()
+	if len(implicitInOrders) == 0 {
+		return explicit
+	}
+	implicit, exists := implicitInOrders[currentGoroutineID()]
+	if !exists {
+		return explicit
+	}
+	if explicit != nil {
+		implicit.explicitUses++
+		return explicit
+	}
+	implicit.used = true
+	return &implicit.context
+}
+
+// resetImplicitInOrder makes the InOrderContext activated by BeginInOrder on
+// the calling goroutine start over, if there is one.
+func resetImplicitInOrder() {
+	implicitInOrdersMutex.Lock()
+	defer implicitInOrdersMutex.Unlock()
+	if implicit, exists := implicitInOrders[currentGoroutineID()]; exists {
+		implicit.context = InOrderContext{}
+	}
+}
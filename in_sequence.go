@@ -0,0 +1,93 @@
+package pegomock
+
+import (
+	"fmt"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+type inSequenceT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// VerifyCall is one step of a sequence verified with VerifyInSequence.
+type VerifyCall struct {
+	Mock       Mock
+	MethodName string
+	// Params are compared for equality, unless they are Matchers, e.g. &EqMatcher{Value: "Hello"}.
+	Params []Param
+	// Times is the expected invocation count. Defaults to Once().
+	Times Matcher
+}
+
+func (call VerifyCall) String() string {
+	params := ""
+	for i, param := range call.Params {
+		if i > 0 {
+			params += ", "
+		}
+		if matcher, isMatcher := param.(Matcher); isMatcher {
+			params += fmt.Sprintf("%v", matcher)
+		} else {
+			params += formatCycleSafe("%#v", param)
+		}
+	}
+	return fmt.Sprintf("%T.%v(%v)", call.Mock, call.MethodName, params)
+}
+
+// VerifyInSequence verifies that calls happened in the given order, like
+// VerifyWasCalledInOrder with a shared InOrderContext does. It reports the
+// first step that fails to t, naming it and the step before it, and skips the
+// remaining steps. The returned InOrderContext can be used to verify further
+// calls in order.
+func VerifyInSequence(t inSequenceT, calls []VerifyCall) *InOrderContext {
+	t.Helper()
+	inOrderContext := new(InOrderContext)
+	for i, call := range calls {
+		verify.Argument(call.Mock != nil, "Mock of step %v must not be nil", i+1)
+		times := call.Times
+		if times == nil {
+			times = Once()
+		}
+		var failure string
+		fail := FailHandler(func(message string, callerSkip ...int) {
+			if failure == "" {
+				failure = message
+			}
+		})
+		registerParamMatchers(call.Params)
+		GetGenericMockFrom(call.Mock).Verify(inOrderContext, times, call.MethodName, call.Params, fail)
+		if failure != "" {
+			if i == 0 {
+				t.Errorf("VerifyInSequence failed at step 1 of %v, %v:\n%v", len(calls), call, failure)
+			} else {
+				t.Errorf("VerifyInSequence failed at step %v of %v, %v, which must follow step %v, %v:\n%v",
+					i+1, len(calls), call, i, calls[i-1], failure)
+			}
+			break
+		}
+	}
+	return inOrderContext
+}
+
+// registerParamMatchers registers matchers for params if any of them is a
+// Matcher, because Verify requires either matchers for all params or none.
+func registerParamMatchers(params []Param) {
+	usesMatchers := false
+	for _, param := range params {
+		if _, isMatcher := param.(Matcher); isMatcher {
+			usesMatchers = true
+		}
+	}
+	if !usesMatchers {
+		return
+	}
+	for _, param := range params {
+		if matcher, isMatcher := param.(Matcher); isMatcher {
+			RegisterMatcher(matcher)
+		} else {
+			RegisterMatcher(&EqMatcher{Value: param})
+		}
+	}
+}
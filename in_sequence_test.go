@@ -0,0 +1,92 @@
+package pegomock_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+type recordingInSequenceT struct{ errors []string }
+
+func (t *recordingInSequenceT) Helper() {}
+
+func (t *recordingInSequenceT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestVerifyInSequence(t *testing.T) {
+	display1 := NewMockDisplay(WithT(t))
+	display2 := NewMockDisplay(WithT(t))
+
+	display1.Show("One")
+	display2.Flash("Two", 2)
+	display1.Show("Three")
+
+	inOrderContext := VerifyInSequence(t, []VerifyCall{
+		{Mock: display1, MethodName: "Show", Params: []Param{"One"}},
+		{Mock: display2, MethodName: "Flash", Params: []Param{"Two", &EqMatcher{Value: 2}}},
+	})
+	display1.VerifyWasCalledInOrder(Once(), inOrderContext).Show("Three")
+}
+
+var _ = Describe("VerifyInSequence", func() {
+	var (
+		t                  *recordingInSequenceT
+		display1, display2 *MockDisplay
+	)
+
+	BeforeEach(func() {
+		t = &recordingInSequenceT{}
+		display1 = NewMockDisplay()
+		display2 = NewMockDisplay()
+	})
+
+	It("passes when calls of interleaved mocks happened in sequence", func() {
+		display1.Show("One")
+		display2.Show("Two")
+		display1.Show("Three")
+		display2.Show("Four")
+
+		VerifyInSequence(t, []VerifyCall{
+			{Mock: display1, MethodName: "Show", Params: []Param{"One"}},
+			{Mock: display2, MethodName: "Show", Params: []Param{"Two"}},
+			{Mock: display1, MethodName: "Show", Params: []Param{"Three"}},
+			{Mock: display2, MethodName: "Show", Params: []Param{"Four"}},
+		})
+
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("names the pair of steps that happened out of order", func() {
+		display1.Show("One")
+		display2.Show("Three")
+		display1.Show("Two")
+
+		VerifyInSequence(t, []VerifyCall{
+			{Mock: display1, MethodName: "Show", Params: []Param{"One"}},
+			{Mock: display1, MethodName: "Show", Params: []Param{"Two"}},
+			{Mock: display2, MethodName: "Show", Params: []Param{"Three"}},
+		})
+
+		Expect(t.errors).To(ConsistOf(
+			"VerifyInSequence failed at step 3 of 3, *pegomock_test.MockDisplay.Show(\"Three\"), " +
+				"which must follow step 2, *pegomock_test.MockDisplay.Show(\"Two\"):\n" +
+				"Expected function call Show(\"Three\") before function call Show(\"Two\")"))
+	})
+
+	It("reports a step with an unexpected count and skips the remaining steps", func() {
+		display1.Show("One")
+
+		VerifyInSequence(t, []VerifyCall{
+			{Mock: display1, MethodName: "Show", Params: []Param{"One"}, Times: Times(2)},
+			{Mock: display2, MethodName: "Show", Params: []Param{"Two"}},
+		})
+
+		Expect(t.errors).To(ConsistOf(HavePrefix(
+			"VerifyInSequence failed at step 1 of 2, *pegomock_test.MockDisplay.Show(\"One\"):\n" +
+				"Mock invocation count for test_interface.Display.Show(\"One\") does not match expectation.")))
+	})
+})
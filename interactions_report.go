@@ -0,0 +1,197 @@
+package pegomock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// Checkpoint marks a point in time in the history of invocations of all mocks.
+// The zero value marks the very beginning.
+type Checkpoint struct {
+	invocationNumber int
+}
+
+// NewCheckpoint returns a Checkpoint marking the current point in time. Passed
+// as InteractionsReportOptions.Since, it hides all invocations made before.
+func NewCheckpoint() Checkpoint {
+	return Checkpoint{invocationNumber: globalInvocationCounter.nextNumber()}
+}
+
+// Invocation is an entry of the timeline returned by AllInvocations and
+// GenericMock.InvocationsOf. It is a copy, so changing it doesn't affect the mock.
+type Invocation struct {
+	MethodName string
+	Params     []Param
+	// OrderingNumber tells the order of invocations across all mocks: an
+	// invocation made after another one has a greater OrderingNumber.
+	OrderingNumber int
+	// Location is where the method was invoked from. It is only set if
+	// RecordInvocationLocations is on.
+	Location string
+}
+
+// InvocationsOf returns the invocations of methodName in the order they were
+// made, e.g. for custom assertions like "Save was called within 3 calls after
+// Begin". Invocations evicted due to WithInvocationLimit are left out.
+func (genericMock *GenericMock) InvocationsOf(methodName string) []Invocation {
+	return genericMock.sortedInvocations(methodName)
+}
+
+// InvocationCount returns how often methodName was invoked or, if params are
+// given, how often with params equal to them. Unlike a verification, it never
+// fails, e.g. for tests that branch on the number of calls. Without params, it
+// also counts invocations evicted due to WithInvocationLimit or not recorded due
+// to DisableRecording. With params, only the retained ones can be compared.
+func (genericMock *GenericMock) InvocationCount(methodName string, params ...Param) int {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	method, exists := genericMock.mockedMethods[methodName]
+	if !exists {
+		return 0
+	}
+	if len(params) != 0 {
+		return len(genericMock.methodInvocations(methodName, params, nil))
+	}
+	method.Lock()
+	defer method.Unlock()
+	return len(method.invocations) + method.evictedCount + method.unrecordedCount
+}
+
+func (invocation Invocation) String() string {
+	return invocation.MethodName + "(" + formatParams(invocation.Params) + ")"
+}
+
+// InteractionsReportOptions select the invocations AllInvocations and
+// InteractionsReport return. All filters that are set must apply for an
+// invocation to be selected.
+type InteractionsReportOptions struct {
+	// Methods restricts invocations to the methods with these names.
+	Methods []string
+	// Matching restricts invocations of the methods used as keys to the ones
+	// whose params match the respective Matchers. It doesn't affect other methods.
+	Matching map[string]Matchers
+	// Since restricts invocations to the ones made after the Checkpoint.
+	Since Checkpoint
+	// Limit restricts invocations to the first Limit ones. 0 means no limit.
+	Limit int
+}
+
+// AllInvocations returns the invocations of mock in the order they were made,
+// filtered by options if given.
+func AllInvocations(mock Mock, options ...InteractionsReportOptions) []Invocation {
+	selected, _ := GetGenericMockFrom(mock).filteredInvocations(reportOptionsFrom(options))
+	return selected
+}
+
+// InteractionsReport renders the invocations of mock in the order they were
+// made, filtered by options if given. If filters are applied, the report says
+// which ones and how many invocations they hide.
+func InteractionsReport(mock Mock, options ...InteractionsReportOptions) string {
+	reportOptions := reportOptionsFrom(options)
+	selected, total := GetGenericMockFrom(mock).filteredInvocations(reportOptions)
+
+	var report strings.Builder
+	if len(selected) == 0 {
+		report.WriteString("There were no interactions with this mock")
+	} else {
+		report.WriteString("Interactions with this mock were:\n")
+		for _, invocation := range selected {
+			report.WriteString("\t" + invocation.String() + "\n")
+		}
+	}
+	if filters := reportOptions.describe(); filters != "" {
+		fmt.Fprintf(&report, "\nFiltered by %v. %v of %v invocation(s) hidden.", filters, total-len(selected), total)
+	}
+	return report.String()
+}
+
+func reportOptionsFrom(options []InteractionsReportOptions) InteractionsReportOptions {
+	verify.Argument(len(options) <= 1, "Must provide at most one InteractionsReportOptions")
+	if len(options) == 0 {
+		return InteractionsReportOptions{}
+	}
+	return options[0]
+}
+
+// filteredInvocations returns the selected invocations and the total number of
+// invocations of genericMock.
+func (genericMock *GenericMock) filteredInvocations(options InteractionsReportOptions) (selected []Invocation, total int) {
+	invocations := genericMock.sortedInvocations()
+	for _, invocation := range invocations {
+		if options.selects(invocation) && (options.Limit == 0 || len(selected) < options.Limit) {
+			selected = append(selected, invocation)
+		}
+	}
+	return selected, len(invocations)
+}
+
+// sortedInvocations returns copies of the invocations of the methods with the
+// given names, or of all methods if none are given, ordered by OrderingNumber.
+func (genericMock *GenericMock) sortedInvocations(methodNames ...string) []Invocation {
+	var invocations []Invocation
+	genericMock.Lock()
+	for methodName, method := range genericMock.mockedMethods {
+		if len(methodNames) > 0 && !containsString(methodNames, methodName) {
+			continue
+		}
+		method.Lock()
+		for _, invocation := range method.invocations {
+			invocations = append(invocations, Invocation{
+				MethodName:     methodName,
+				Params:         append([]Param(nil), invocation.params...),
+				OrderingNumber: invocation.orderingInvocationNumber,
+				Location:       invocation.location(),
+			})
+		}
+		method.Unlock()
+	}
+	genericMock.Unlock()
+	sort.Slice(invocations, func(i, j int) bool {
+		return invocations[i].OrderingNumber < invocations[j].OrderingNumber
+	})
+	return invocations
+}
+
+func (options InteractionsReportOptions) selects(invocation Invocation) bool {
+	if len(options.Methods) > 0 && !containsString(options.Methods, invocation.MethodName) {
+		return false
+	}
+	if matchers, exists := options.Matching[invocation.MethodName]; exists && !matchers.Matches(invocation.Params) {
+		return false
+	}
+	return invocation.OrderingNumber > options.Since.invocationNumber
+}
+
+func (options InteractionsReportOptions) describe() string {
+	var filters []string
+	if len(options.Methods) > 0 {
+		filters = append(filters, "methods "+strings.Join(options.Methods, ", "))
+	}
+	if len(options.Matching) > 0 {
+		var matchings []string
+		for methodName, matchers := range options.Matching {
+			matchings = append(matchings, methodName+"("+formatMatchers(matchers)+")")
+		}
+		sort.Strings(matchings)
+		filters = append(filters, "matchers "+strings.Join(matchings, ", "))
+	}
+	if options.Since != (Checkpoint{}) {
+		filters = append(filters, "since checkpoint")
+	}
+	if options.Limit > 0 {
+		filters = append(filters, fmt.Sprintf("limit of %v", options.Limit))
+	}
+	return strings.Join(filters, "; ")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
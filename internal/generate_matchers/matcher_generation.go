@@ -39,7 +39,8 @@ func GenerateDefaultMatchers() string {
 	for _, kind := range primitiveKinds {
 		result += GenerateEqMatcherFactory(kind) +
 			GenerateAnyMatcherFactory(kind) +
-			GenerateAnySliceMatcherFactory(kind)
+			GenerateAnySliceMatcherFactory(kind) +
+			GenerateCaptorFactory(kind)
 	}
 	// hard-coding this for now as interface{} overall works slighly different than other types.
 	result += `func EqInterface(value interface{}) interface{} {
@@ -55,6 +56,30 @@ func AnyInterface() interface{} {
 func AnyInterfaceSlice() []interface{} {
 	RegisterMatcher(NewAnyMatcher(reflect.SliceOf(reflect.TypeOf((*(interface{}))(nil)).Elem())))
 	return nil
+}
+
+type InterfaceArgumentCaptor struct{ *ArgumentCaptor }
+
+func InterfaceCaptor() *InterfaceArgumentCaptor {
+	return &InterfaceArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((*(interface{}))(nil)).Elem())}
+}
+
+func (captor *InterfaceArgumentCaptor) Capture() interface{} {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return nil
+}
+
+func (captor *InterfaceArgumentCaptor) GetValue() interface{} {
+	return captor.Value()
+}
+
+func (captor *InterfaceArgumentCaptor) GetAllValues() []interface{} {
+	capturedValues := captor.Values()
+	values := make([]interface{}, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value
+	}
+	return values
 }`
 	return result
 }
@@ -106,6 +131,37 @@ func GenerateAnySliceMatcherFactory(kind reflect.Kind) string {
 `, strings.Title(kind.String()), kind.String(), kind.String(), nullOf(kind))
 }
 
+// GenerateCaptorFactory generates a captor for kind, see ArgumentCaptor, whose
+// methods take and return values of kind.
+func GenerateCaptorFactory(kind reflect.Kind) string {
+	return fmt.Sprintf(`type %[1]sArgumentCaptor struct{ *ArgumentCaptor }
+
+func %[1]sCaptor() *%[1]sArgumentCaptor {
+	return &%[1]sArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((%[2]s)(%[3]s)))}
+}
+
+func (captor *%[1]sArgumentCaptor) Capture() %[2]s {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return %[3]s
+}
+
+func (captor *%[1]sArgumentCaptor) GetValue() %[2]s {
+	value, _ := captor.Value().(%[2]s)
+	return value
+}
+
+func (captor *%[1]sArgumentCaptor) GetAllValues() []%[2]s {
+	capturedValues := captor.Values()
+	values := make([]%[2]s, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(%[2]s)
+	}
+	return values
+}
+
+`, strings.Title(kind.String()), kind.String(), nullOf(kind))
+}
+
 // TODO generate:
 // Eq Slice matchers
 // generate chan, func matchers
@@ -14,26 +14,196 @@
 
 package pegomock
 
-func Times(numDesiredInvocations int) *EqMatcher {
-	return &EqMatcher{Value: numDesiredInvocations}
+import (
+	"fmt"
+	"time"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// TimesInvocationCountMatcher matches an exact number of invocations. It is
+// what Times, Exactly, Once, Twice and Never return.
+type TimesInvocationCountMatcher struct {
+	Value  int
+	actual int
+}
+
+// Deprecated: Use TimesInvocationCountMatcher.
+type TimesMatcher = TimesInvocationCountMatcher
+
+func (matcher *TimesInvocationCountMatcher) Matches(param Param) bool {
+	matcher.actual = param.(int)
+	return matcher.actual == matcher.Value
+}
+
+func (matcher *TimesInvocationCountMatcher) FailureMessage() string {
+	if matcher.Value == 0 {
+		return "Expected not to be called but was called " + calledString(matcher.actual)
+	}
+	return invocationCountFailureMessage(matcher, matcher.actual)
+}
+
+func (matcher *TimesInvocationCountMatcher) String() string {
+	if matcher.Value == 0 {
+		return "never"
+	}
+	return timesString(matcher.Value)
+}
+
+// AtLeastInvocationCountMatcher matches a minimum number of invocations.
+type AtLeastInvocationCountMatcher struct {
+	Value  int
+	actual int
+}
+
+// Deprecated: Use AtLeastInvocationCountMatcher.
+type AtLeastIntMatcher = AtLeastInvocationCountMatcher
+
+func (matcher *AtLeastInvocationCountMatcher) Matches(param Param) bool {
+	matcher.actual = param.(int)
+	return matcher.actual >= matcher.Value
+}
+
+func (matcher *AtLeastInvocationCountMatcher) FailureMessage() string {
+	return invocationCountFailureMessage(matcher, matcher.actual)
+}
+
+func (matcher *AtLeastInvocationCountMatcher) String() string {
+	return "at least " + timesString(matcher.Value)
+}
+
+// AtMostInvocationCountMatcher matches a maximum number of invocations.
+type AtMostInvocationCountMatcher struct {
+	Value  int
+	actual int
+}
+
+// Deprecated: Use AtMostInvocationCountMatcher.
+type AtMostIntMatcher = AtMostInvocationCountMatcher
+
+func (matcher *AtMostInvocationCountMatcher) Matches(param Param) bool {
+	matcher.actual = param.(int)
+	return matcher.actual <= matcher.Value
+}
+
+func (matcher *AtMostInvocationCountMatcher) FailureMessage() string {
+	return invocationCountFailureMessage(matcher, matcher.actual)
+}
+
+func (matcher *AtMostInvocationCountMatcher) String() string {
+	return "at most " + timesString(matcher.Value)
+}
+
+// BetweenInvocationCountMatcher matches a number of invocations from Min to
+// Max, both inclusive.
+type BetweenInvocationCountMatcher struct {
+	Min, Max int
+	actual   int
+}
+
+func (matcher *BetweenInvocationCountMatcher) Matches(param Param) bool {
+	matcher.actual = param.(int)
+	return matcher.actual >= matcher.Min && matcher.actual <= matcher.Max
+}
+
+func (matcher *BetweenInvocationCountMatcher) FailureMessage() string {
+	return invocationCountFailureMessage(matcher, matcher.actual)
+}
+
+func (matcher *BetweenInvocationCountMatcher) String() string {
+	return fmt.Sprintf("between %v and %v times", matcher.Min, matcher.Max)
+}
+
+func invocationCountFailureMessage(expected fmt.Stringer, actual int) string {
+	return fmt.Sprintf("Expected to be called %v but was called %v", expected, calledString(actual))
+}
+
+func timesString(numInvocations int) string {
+	switch numInvocations {
+	case 1:
+		return "once"
+	case 2:
+		return "twice"
+	default:
+		return fmt.Sprintf("%v times", numInvocations)
+	}
+}
+
+func calledString(numInvocations int) string {
+	if numInvocations == 0 {
+		return "0 times"
+	}
+	return timesString(numInvocations)
+}
+
+// WithinInvocationCountMatcher matches at least one invocation made within
+// Timeout of the mock's start, i.e. its creation or its last ResetForNextTest.
+// Verifications with it only count invocations made until then and wait for
+// them until then, e.g. mock.VerifyWasCalledWithin(time.Second).Show("Hello").
+type WithinInvocationCountMatcher struct {
+	Timeout time.Duration
+	actual  int
+}
+
+func (matcher *WithinInvocationCountMatcher) Matches(param Param) bool {
+	matcher.actual = param.(int)
+	return matcher.actual >= 1
+}
+
+func (matcher *WithinInvocationCountMatcher) FailureMessage() string {
+	return invocationCountFailureMessage(matcher, matcher.actual)
+}
+
+func (matcher *WithinInvocationCountMatcher) String() string {
+	return fmt.Sprintf("at least once within %v of the mock's start", matcher.Timeout)
+}
+
+func Times(numDesiredInvocations int) *TimesInvocationCountMatcher {
+	return &TimesInvocationCountMatcher{Value: numDesiredInvocations}
+}
+
+// Exactly is an alias for Times, e.g. VerifyWasCalled(Exactly(3)).
+func Exactly(numDesiredInvocations int) *TimesInvocationCountMatcher {
+	return Times(numDesiredInvocations)
+}
+
+// AtLeast matches numDesiredInvocations invocations or more. It panics if
+// numDesiredInvocations is negative.
+func AtLeast(numDesiredInvocations int) *AtLeastInvocationCountMatcher {
+	verify.Argument(numDesiredInvocations >= 0, "AtLeast requires a non-negative number of invocations, but got %v", numDesiredInvocations)
+	return &AtLeastInvocationCountMatcher{Value: numDesiredInvocations}
+}
+
+// AtMost matches numDesiredInvocations invocations or less. It panics if
+// numDesiredInvocations is negative.
+func AtMost(numDesiredInvocations int) *AtMostInvocationCountMatcher {
+	verify.Argument(numDesiredInvocations >= 0, "AtMost requires a non-negative number of invocations, but got %v", numDesiredInvocations)
+	return &AtMostInvocationCountMatcher{Value: numDesiredInvocations}
 }
 
-func AtLeast(numDesiredInvocations int) *AtLeastIntMatcher {
-	return &AtLeastIntMatcher{Value: numDesiredInvocations}
+// Between matches from min to max invocations, both inclusive. It panics if min
+// is negative or greater than max.
+func Between(min, max int) *BetweenInvocationCountMatcher {
+	verify.Argument(min >= 0, "Between requires a non-negative min, but got %v", min)
+	verify.Argument(min <= max, "Between requires min <= max, but got min %v and max %v", min, max)
+	return &BetweenInvocationCountMatcher{Min: min, Max: max}
 }
 
-func AtMost(numDesiredInvocations int) *AtMostIntMatcher {
-	return &AtMostIntMatcher{Value: numDesiredInvocations}
+// Within matches at least one invocation made within timeout of the mock's
+// start, see WithinInvocationCountMatcher. It panics if timeout is negative.
+func Within(timeout time.Duration) *WithinInvocationCountMatcher {
+	verify.Argument(timeout >= 0, "Within requires a non-negative timeout, but got %v", timeout)
+	return &WithinInvocationCountMatcher{Timeout: timeout}
 }
 
-func Never() *EqMatcher {
-	return &EqMatcher{Value: 0}
+func Never() *TimesInvocationCountMatcher {
+	return Times(0)
 }
 
-func Once() *EqMatcher {
-	return &EqMatcher{Value: 1}
+func Once() *TimesInvocationCountMatcher {
+	return Times(1)
 }
 
-func Twice() *EqMatcher {
-	return &EqMatcher{Value: 2}
+func Twice() *TimesInvocationCountMatcher {
+	return Times(2)
 }
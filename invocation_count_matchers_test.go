@@ -0,0 +1,85 @@
+package pegomock_test
+
+import (
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("Invocation count matchers", func() {
+	matches := func(matcher Matcher, numInvocations int) bool {
+		return matcher.Matches(numInvocations)
+	}
+
+	It("matches exactly n invocations with Times and Exactly", func() {
+		for _, matcher := range []Matcher{Times(3), Exactly(3)} {
+			Expect(matches(matcher, 2)).To(gomega.BeFalse())
+			Expect(matches(matcher, 3)).To(BeTrue())
+			Expect(matches(matcher, 4)).To(gomega.BeFalse())
+		}
+	})
+
+	It("matches n invocations or more with AtLeast", func() {
+		Expect(matches(AtLeast(3), 2)).To(gomega.BeFalse())
+		Expect(matches(AtLeast(3), 3)).To(BeTrue())
+		Expect(matches(AtLeast(3), 4)).To(BeTrue())
+	})
+
+	It("matches n invocations or less with AtMost", func() {
+		Expect(matches(AtMost(3), 2)).To(BeTrue())
+		Expect(matches(AtMost(3), 3)).To(BeTrue())
+		Expect(matches(AtMost(3), 4)).To(gomega.BeFalse())
+	})
+
+	It("matches from min to max invocations with Between", func() {
+		Expect(matches(Between(2, 4), 1)).To(gomega.BeFalse())
+		Expect(matches(Between(2, 4), 2)).To(BeTrue())
+		Expect(matches(Between(2, 4), 3)).To(BeTrue())
+		Expect(matches(Between(2, 4), 4)).To(BeTrue())
+		Expect(matches(Between(2, 4), 5)).To(gomega.BeFalse())
+	})
+
+	It("matches exactly one count with Between when min equals max", func() {
+		Expect(matches(Between(2, 2), 1)).To(gomega.BeFalse())
+		Expect(matches(Between(2, 2), 2)).To(BeTrue())
+		Expect(matches(Between(2, 2), 3)).To(gomega.BeFalse())
+	})
+
+	It("panics when Between's min is greater than its max", func() {
+		Expect(func() { Between(3, 2) }).To(PanicWith("Between requires min <= max, but got min 3 and max 2"))
+	})
+
+	It("panics on negative numbers of invocations", func() {
+		Expect(func() { AtLeast(-1) }).To(PanicWith("AtLeast requires a non-negative number of invocations, but got -1"))
+		Expect(func() { AtMost(-1) }).To(PanicWith("AtMost requires a non-negative number of invocations, but got -1"))
+		Expect(func() { Between(-1, 2) }).To(PanicWith("Between requires a non-negative min, but got -1"))
+	})
+
+	It("describes the expected and the actual number of invocations in failure messages", func() {
+		for _, entry := range []struct {
+			matcher        Matcher
+			numInvocations int
+			message        string
+		}{
+			{Times(3), 5, "Expected to be called 3 times but was called 5 times"},
+			{Exactly(1), 0, "Expected to be called once but was called 0 times"},
+			{Never(), 2, "Expected not to be called but was called twice"},
+			{AtLeast(3), 2, "Expected to be called at least 3 times but was called twice"},
+			{AtMost(1), 2, "Expected to be called at most once but was called twice"},
+			{Between(2, 4), 5, "Expected to be called between 2 and 4 times but was called 5 times"},
+		} {
+			Expect(matches(entry.matcher, entry.numInvocations)).To(gomega.BeFalse())
+			Expect(entry.matcher.FailureMessage()).To(Equal(entry.message))
+		}
+	})
+
+	It("verifies with Between", func() {
+		display := NewMockDisplay()
+		display.Show("Hello")
+		display.Show("Hello")
+
+		display.VerifyWasCalled(Between(1, 2)).Show("Hello")
+		Expect(func() { display.VerifyWasCalled(Between(3, 4)).Show("Hello") }).To(PanicWithMessageTo(ContainSubstring(
+			"Expected to be called between 3 and 4 times but was called twice")))
+	})
+})
@@ -0,0 +1,52 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// IsA registers and returns a matcher that matches any value whose type is
+// assignable to the type of expectedType. It's meant for interface{}
+// parameters, e.g. When(mock.Process(IsA((*MyEvent)(nil)))).
+//
+// To match any value implementing an interface, pass a nil pointer to the
+// interface, e.g. IsA((*io.Reader)(nil)).
+func IsA(expectedType interface{}) Matcher {
+	verify.Argument(expectedType != nil, "IsA needs a typed value, e.g. (*MyStruct)(nil)")
+	typ := reflect.TypeOf(expectedType)
+	if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Interface {
+		typ = typ.Elem()
+	}
+	return registerIsAMatcher(typ)
+}
+
+func registerIsAMatcher(typ reflect.Type) Matcher {
+	matcher := &IsAMatcher{Type: typ}
+	RegisterMatcher(matcher)
+	return matcher
+}
+
+type IsAMatcher struct {
+	Type   reflect.Type
+	actual reflect.Type
+	sync.Mutex
+}
+
+func (matcher *IsAMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = reflect.TypeOf(param)
+	return matcher.actual != nil && matcher.actual.AssignableTo(matcher.Type)
+}
+
+func (matcher *IsAMatcher) FailureMessage() string {
+	return fmt.Sprintf("expected value of type %v, got %v", matcher.Type, matcher.actual)
+}
+
+func (matcher *IsAMatcher) String() string {
+	return fmt.Sprintf("IsA(%v)", matcher.Type)
+}
@@ -3,31 +3,99 @@ package pegomock
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/petergtz/pegomock/internal/verify"
 	"sync"
 )
 
+// EqMatcher matches params equal to Value. It compares with reflect.DeepEqual,
+// unless it was created with NewEqMatcherUsing or options were registered with
+// RegisterCmpOptions, in which case it compares with cmp.Equal. Functions, which
+// are never deeply equal, are compared by pointer identity instead. How it handles
+// comparisons that panic or values that are equal but not deeply equal is set
+// with SetDeepEqualMode.
 type EqMatcher struct {
 	Value  Param
 	actual Param
 	sync.Mutex
+	usesCmp    bool
+	cmpOptions []cmp.Option
+}
+
+// NewEqMatcherUsing creates an EqMatcher that compares with cmp.Equal and
+// opts, in addition to the options registered with RegisterCmpOptions.
+func NewEqMatcherUsing(value Param, opts ...cmp.Option) *EqMatcher {
+	return &EqMatcher{Value: value, usesCmp: true, cmpOptions: opts}
 }
 
 func (matcher *EqMatcher) Matches(param Param) bool {
+	matches, _ := matcher.matchesWithFmtFallbackWarning(param)
+	return matches
+}
+
+// matchesWithFmtFallbackWarning is Matches, but also returns a warning if param
+// only matches because it formats the same, see FmtFallback.
+func (matcher *EqMatcher) matchesWithFmtFallbackWarning(param Param) (bool, string) {
 	matcher.Lock()
 	defer matcher.Unlock()
 
 	matcher.actual = param
-	return reflect.DeepEqual(matcher.Value, param)
+	return equalInMode(deepEqualMode(), matcher.Value, param, func() bool {
+		if isNonNilFunc(matcher.Value) {
+			return sameFunc(matcher.Value, param)
+		}
+		if cmpOptions, usesCmp := matcher.effectiveCmpOptions(); usesCmp {
+			return cmp.Equal(matcher.Value, param, cmpOptions...)
+		}
+		return reflect.DeepEqual(matcher.Value, param)
+	})
+}
+
+func (matcher *EqMatcher) effectiveCmpOptions() ([]cmp.Option, bool) {
+	registered := registeredCmpOptions()
+	if !matcher.usesCmp && len(registered) == 0 {
+		return nil, false
+	}
+	return append(registered, matcher.cmpOptions...), true
 }
 
 func (matcher *EqMatcher) FailureMessage() string {
-	return fmt.Sprintf("Expected: %v; but got: %v", matcher.Value, matcher.actual)
+	message := fmt.Sprintf("Expected: %v; but got: %v", formatCycleSafe("%v", matcher.Value), formatCycleSafe("%v", matcher.actual))
+	if differences := recoveredDifferences(deepEqualMode(), matcher.differences); differences != "" {
+		message += "\n" + differences
+	}
+	return message
+}
+
+// differences describes how the expected and the actual value differ, or
+// returns "" if they differ only as a whole, in which case the message
+// "Expected: ...; but got: ..." says it all.
+func (matcher *EqMatcher) differences() string {
+	if cmpOptions, usesCmp := matcher.effectiveCmpOptions(); usesCmp {
+		diff := cmp.Diff(matcher.Value, matcher.actual, cmpOptions...)
+		if diff == "" {
+			return ""
+		}
+		return "Diff (-expected +actual):\n" + strings.TrimRight(diff, "\n")
+	}
+	structuralDifferences, abortNote := diffValues(matcher.Value, matcher.actual)
+	if len(structuralDifferences) == 1 && structuralDifferences[0].path == "" && abortNote == "" {
+		return ""
+	}
+	message := "Differences:"
+	for _, difference := range structuralDifferences {
+		message += "\n\t" + difference.String()
+	}
+	if abortNote != "" {
+		message += "\n\t" + abortNote
+	}
+	return message
 }
 
 func (matcher *EqMatcher) String() string {
-	return fmt.Sprintf("Eq(%v)", matcher.Value)
+	return fmt.Sprintf("Eq(%v)", formatCycleSafe("%v", matcher.Value))
 }
 
 type AnyMatcher struct {
@@ -66,38 +134,32 @@ func (matcher *AnyMatcher) String() string {
 	return fmt.Sprintf("Any(%v)", matcher.Type)
 }
 
-type AtLeastIntMatcher struct {
-	Value  int
-	actual int
-}
-
-func (matcher *AtLeastIntMatcher) Matches(param Param) bool {
-	matcher.actual = param.(int)
-	return param.(int) >= matcher.Value
-}
-
-func (matcher *AtLeastIntMatcher) FailureMessage() string {
-	return fmt.Sprintf("Expected: at least %v; but got: %v", matcher.Value, matcher.actual)
-}
-
-func (matcher *AtLeastIntMatcher) String() string {
-	return fmt.Sprintf("AtLeast(%v)", matcher.Value)
+// SameMatcher matches only the identical value, i.e. compares with == instead of
+// reflect.DeepEqual. Values of non-comparable types never match.
+type SameMatcher struct {
+	Value  Param
+	actual Param
+	sync.Mutex
 }
 
-type AtMostIntMatcher struct {
-	Value  int
-	actual int
-}
+func (matcher *SameMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
 
-func (matcher *AtMostIntMatcher) Matches(param Param) bool {
-	matcher.actual = param.(int)
-	return param.(int) <= matcher.Value
+	matcher.actual = param
+	if param == nil || matcher.Value == nil {
+		return param == matcher.Value
+	}
+	if reflect.TypeOf(param) != reflect.TypeOf(matcher.Value) || !reflect.TypeOf(param).Comparable() {
+		return false
+	}
+	return param == matcher.Value
 }
 
-func (matcher *AtMostIntMatcher) FailureMessage() string {
-	return fmt.Sprintf("Expected: at most %v; but got: %v", matcher.Value, matcher.actual)
+func (matcher *SameMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: same as %v; but got: %v", matcher.Value, matcher.actual)
 }
 
-func (matcher *AtMostIntMatcher) String() string {
-	return fmt.Sprintf("AtMost(%v)", matcher.Value)
+func (matcher *SameMatcher) String() string {
+	return fmt.Sprintf("Same(%v)", matcher.Value)
 }
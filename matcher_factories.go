@@ -19,6 +19,31 @@ func AnyBoolSlice() []bool {
 	return nil
 }
 
+type BoolArgumentCaptor struct{ *ArgumentCaptor }
+
+func BoolCaptor() *BoolArgumentCaptor {
+	return &BoolArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((bool)(false)))}
+}
+
+func (captor *BoolArgumentCaptor) Capture() bool {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return false
+}
+
+func (captor *BoolArgumentCaptor) GetValue() bool {
+	value, _ := captor.Value().(bool)
+	return value
+}
+
+func (captor *BoolArgumentCaptor) GetAllValues() []bool {
+	capturedValues := captor.Values()
+	values := make([]bool, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(bool)
+	}
+	return values
+}
+
 func EqInt(value int) int {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -34,6 +59,31 @@ func AnyIntSlice() []int {
 	return nil
 }
 
+type IntArgumentCaptor struct{ *ArgumentCaptor }
+
+func IntCaptor() *IntArgumentCaptor {
+	return &IntArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((int)(0)))}
+}
+
+func (captor *IntArgumentCaptor) Capture() int {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *IntArgumentCaptor) GetValue() int {
+	value, _ := captor.Value().(int)
+	return value
+}
+
+func (captor *IntArgumentCaptor) GetAllValues() []int {
+	capturedValues := captor.Values()
+	values := make([]int, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(int)
+	}
+	return values
+}
+
 func EqInt8(value int8) int8 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -49,6 +99,31 @@ func AnyInt8Slice() []int8 {
 	return nil
 }
 
+type Int8ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Int8Captor() *Int8ArgumentCaptor {
+	return &Int8ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((int8)(0)))}
+}
+
+func (captor *Int8ArgumentCaptor) Capture() int8 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Int8ArgumentCaptor) GetValue() int8 {
+	value, _ := captor.Value().(int8)
+	return value
+}
+
+func (captor *Int8ArgumentCaptor) GetAllValues() []int8 {
+	capturedValues := captor.Values()
+	values := make([]int8, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(int8)
+	}
+	return values
+}
+
 func EqInt16(value int16) int16 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -64,6 +139,31 @@ func AnyInt16Slice() []int16 {
 	return nil
 }
 
+type Int16ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Int16Captor() *Int16ArgumentCaptor {
+	return &Int16ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((int16)(0)))}
+}
+
+func (captor *Int16ArgumentCaptor) Capture() int16 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Int16ArgumentCaptor) GetValue() int16 {
+	value, _ := captor.Value().(int16)
+	return value
+}
+
+func (captor *Int16ArgumentCaptor) GetAllValues() []int16 {
+	capturedValues := captor.Values()
+	values := make([]int16, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(int16)
+	}
+	return values
+}
+
 func EqInt32(value int32) int32 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -79,6 +179,31 @@ func AnyInt32Slice() []int32 {
 	return nil
 }
 
+type Int32ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Int32Captor() *Int32ArgumentCaptor {
+	return &Int32ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((int32)(0)))}
+}
+
+func (captor *Int32ArgumentCaptor) Capture() int32 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Int32ArgumentCaptor) GetValue() int32 {
+	value, _ := captor.Value().(int32)
+	return value
+}
+
+func (captor *Int32ArgumentCaptor) GetAllValues() []int32 {
+	capturedValues := captor.Values()
+	values := make([]int32, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(int32)
+	}
+	return values
+}
+
 func EqInt64(value int64) int64 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -94,6 +219,31 @@ func AnyInt64Slice() []int64 {
 	return nil
 }
 
+type Int64ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Int64Captor() *Int64ArgumentCaptor {
+	return &Int64ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((int64)(0)))}
+}
+
+func (captor *Int64ArgumentCaptor) Capture() int64 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Int64ArgumentCaptor) GetValue() int64 {
+	value, _ := captor.Value().(int64)
+	return value
+}
+
+func (captor *Int64ArgumentCaptor) GetAllValues() []int64 {
+	capturedValues := captor.Values()
+	values := make([]int64, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(int64)
+	}
+	return values
+}
+
 func EqUint(value uint) uint {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -109,6 +259,31 @@ func AnyUintSlice() []uint {
 	return nil
 }
 
+type UintArgumentCaptor struct{ *ArgumentCaptor }
+
+func UintCaptor() *UintArgumentCaptor {
+	return &UintArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((uint)(0)))}
+}
+
+func (captor *UintArgumentCaptor) Capture() uint {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *UintArgumentCaptor) GetValue() uint {
+	value, _ := captor.Value().(uint)
+	return value
+}
+
+func (captor *UintArgumentCaptor) GetAllValues() []uint {
+	capturedValues := captor.Values()
+	values := make([]uint, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(uint)
+	}
+	return values
+}
+
 func EqUint8(value uint8) uint8 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -124,6 +299,31 @@ func AnyUint8Slice() []uint8 {
 	return nil
 }
 
+type Uint8ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Uint8Captor() *Uint8ArgumentCaptor {
+	return &Uint8ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((uint8)(0)))}
+}
+
+func (captor *Uint8ArgumentCaptor) Capture() uint8 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Uint8ArgumentCaptor) GetValue() uint8 {
+	value, _ := captor.Value().(uint8)
+	return value
+}
+
+func (captor *Uint8ArgumentCaptor) GetAllValues() []uint8 {
+	capturedValues := captor.Values()
+	values := make([]uint8, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(uint8)
+	}
+	return values
+}
+
 func EqUint16(value uint16) uint16 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -139,6 +339,31 @@ func AnyUint16Slice() []uint16 {
 	return nil
 }
 
+type Uint16ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Uint16Captor() *Uint16ArgumentCaptor {
+	return &Uint16ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((uint16)(0)))}
+}
+
+func (captor *Uint16ArgumentCaptor) Capture() uint16 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Uint16ArgumentCaptor) GetValue() uint16 {
+	value, _ := captor.Value().(uint16)
+	return value
+}
+
+func (captor *Uint16ArgumentCaptor) GetAllValues() []uint16 {
+	capturedValues := captor.Values()
+	values := make([]uint16, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(uint16)
+	}
+	return values
+}
+
 func EqUint32(value uint32) uint32 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -154,6 +379,31 @@ func AnyUint32Slice() []uint32 {
 	return nil
 }
 
+type Uint32ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Uint32Captor() *Uint32ArgumentCaptor {
+	return &Uint32ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((uint32)(0)))}
+}
+
+func (captor *Uint32ArgumentCaptor) Capture() uint32 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Uint32ArgumentCaptor) GetValue() uint32 {
+	value, _ := captor.Value().(uint32)
+	return value
+}
+
+func (captor *Uint32ArgumentCaptor) GetAllValues() []uint32 {
+	capturedValues := captor.Values()
+	values := make([]uint32, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(uint32)
+	}
+	return values
+}
+
 func EqUint64(value uint64) uint64 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -169,6 +419,31 @@ func AnyUint64Slice() []uint64 {
 	return nil
 }
 
+type Uint64ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Uint64Captor() *Uint64ArgumentCaptor {
+	return &Uint64ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((uint64)(0)))}
+}
+
+func (captor *Uint64ArgumentCaptor) Capture() uint64 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Uint64ArgumentCaptor) GetValue() uint64 {
+	value, _ := captor.Value().(uint64)
+	return value
+}
+
+func (captor *Uint64ArgumentCaptor) GetAllValues() []uint64 {
+	capturedValues := captor.Values()
+	values := make([]uint64, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(uint64)
+	}
+	return values
+}
+
 func EqUintptr(value uintptr) uintptr {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -184,6 +459,31 @@ func AnyUintptrSlice() []uintptr {
 	return nil
 }
 
+type UintptrArgumentCaptor struct{ *ArgumentCaptor }
+
+func UintptrCaptor() *UintptrArgumentCaptor {
+	return &UintptrArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((uintptr)(0)))}
+}
+
+func (captor *UintptrArgumentCaptor) Capture() uintptr {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *UintptrArgumentCaptor) GetValue() uintptr {
+	value, _ := captor.Value().(uintptr)
+	return value
+}
+
+func (captor *UintptrArgumentCaptor) GetAllValues() []uintptr {
+	capturedValues := captor.Values()
+	values := make([]uintptr, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(uintptr)
+	}
+	return values
+}
+
 func EqFloat32(value float32) float32 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -199,6 +499,31 @@ func AnyFloat32Slice() []float32 {
 	return nil
 }
 
+type Float32ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Float32Captor() *Float32ArgumentCaptor {
+	return &Float32ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((float32)(0)))}
+}
+
+func (captor *Float32ArgumentCaptor) Capture() float32 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Float32ArgumentCaptor) GetValue() float32 {
+	value, _ := captor.Value().(float32)
+	return value
+}
+
+func (captor *Float32ArgumentCaptor) GetAllValues() []float32 {
+	capturedValues := captor.Values()
+	values := make([]float32, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(float32)
+	}
+	return values
+}
+
 func EqFloat64(value float64) float64 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -214,6 +539,31 @@ func AnyFloat64Slice() []float64 {
 	return nil
 }
 
+type Float64ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Float64Captor() *Float64ArgumentCaptor {
+	return &Float64ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((float64)(0)))}
+}
+
+func (captor *Float64ArgumentCaptor) Capture() float64 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Float64ArgumentCaptor) GetValue() float64 {
+	value, _ := captor.Value().(float64)
+	return value
+}
+
+func (captor *Float64ArgumentCaptor) GetAllValues() []float64 {
+	capturedValues := captor.Values()
+	values := make([]float64, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(float64)
+	}
+	return values
+}
+
 func EqComplex64(value complex64) complex64 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -229,6 +579,31 @@ func AnyComplex64Slice() []complex64 {
 	return nil
 }
 
+type Complex64ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Complex64Captor() *Complex64ArgumentCaptor {
+	return &Complex64ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((complex64)(0)))}
+}
+
+func (captor *Complex64ArgumentCaptor) Capture() complex64 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Complex64ArgumentCaptor) GetValue() complex64 {
+	value, _ := captor.Value().(complex64)
+	return value
+}
+
+func (captor *Complex64ArgumentCaptor) GetAllValues() []complex64 {
+	capturedValues := captor.Values()
+	values := make([]complex64, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(complex64)
+	}
+	return values
+}
+
 func EqComplex128(value complex128) complex128 {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return 0
@@ -244,6 +619,31 @@ func AnyComplex128Slice() []complex128 {
 	return nil
 }
 
+type Complex128ArgumentCaptor struct{ *ArgumentCaptor }
+
+func Complex128Captor() *Complex128ArgumentCaptor {
+	return &Complex128ArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((complex128)(0)))}
+}
+
+func (captor *Complex128ArgumentCaptor) Capture() complex128 {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return 0
+}
+
+func (captor *Complex128ArgumentCaptor) GetValue() complex128 {
+	value, _ := captor.Value().(complex128)
+	return value
+}
+
+func (captor *Complex128ArgumentCaptor) GetAllValues() []complex128 {
+	capturedValues := captor.Values()
+	values := make([]complex128, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(complex128)
+	}
+	return values
+}
+
 func EqString(value string) string {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return ""
@@ -259,6 +659,31 @@ func AnyStringSlice() []string {
 	return nil
 }
 
+type StringArgumentCaptor struct{ *ArgumentCaptor }
+
+func StringCaptor() *StringArgumentCaptor {
+	return &StringArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((string)("")))}
+}
+
+func (captor *StringArgumentCaptor) Capture() string {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return ""
+}
+
+func (captor *StringArgumentCaptor) GetValue() string {
+	value, _ := captor.Value().(string)
+	return value
+}
+
+func (captor *StringArgumentCaptor) GetAllValues() []string {
+	capturedValues := captor.Values()
+	values := make([]string, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value.(string)
+	}
+	return values
+}
+
 func EqInterface(value interface{}) interface{} {
 	RegisterMatcher(&EqMatcher{Value: value})
 	return nil
@@ -273,4 +698,28 @@ func AnyInterfaceSlice() []interface{} {
 	RegisterMatcher(NewAnyMatcher(reflect.SliceOf(reflect.TypeOf((*(interface{}))(nil)).Elem())))
 	return nil
 }
+
+type InterfaceArgumentCaptor struct{ *ArgumentCaptor }
+
+func InterfaceCaptor() *InterfaceArgumentCaptor {
+	return &InterfaceArgumentCaptor{NewArgumentCaptor(reflect.TypeOf((*(interface{}))(nil)).Elem())}
+}
+
+func (captor *InterfaceArgumentCaptor) Capture() interface{} {
+	RegisterMatcher(captor.ArgumentCaptor)
+	return nil
+}
+
+func (captor *InterfaceArgumentCaptor) GetValue() interface{} {
+	return captor.Value()
+}
+
+func (captor *InterfaceArgumentCaptor) GetAllValues() []interface{} {
+	capturedValues := captor.Values()
+	values := make([]interface{}, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i] = value
+	}
+	return values
+}
 	
\ No newline at end of file
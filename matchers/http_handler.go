@@ -0,0 +1,46 @@
+// Code generated by pegomock. DO NOT EDIT.
+package matchers
+
+import (
+	"reflect"
+	"github.com/petergtz/pegomock"
+	http "net/http"
+)
+
+func AnyHttpHandler() http.Handler {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(http.Handler))(nil)).Elem()))
+	var nullValue http.Handler
+	return nullValue
+}
+
+func EqHttpHandler(value http.Handler) http.Handler {
+	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
+	var nullValue http.Handler
+	return nullValue
+}
+
+type HttpHandlerArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func HttpHandlerCaptor() *HttpHandlerArgumentCaptor {
+	return &HttpHandlerArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(http.Handler))(nil)).Elem())}
+}
+
+func (captor *HttpHandlerArgumentCaptor) Capture() http.Handler {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue http.Handler
+	return nullValue
+}
+
+func (captor *HttpHandlerArgumentCaptor) GetValue() http.Handler {
+	value, _ := captor.Value().(http.Handler)
+	return value
+}
+
+func (captor *HttpHandlerArgumentCaptor) GetAllValues() []http.Handler {
+	capturedValues := captor.Values()
+	values := make([]http.Handler, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(http.Handler)
+	}
+	return values
+}
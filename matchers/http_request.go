@@ -18,3 +18,29 @@ func EqHttpRequest(value http.Request) http.Request {
 	var nullValue http.Request
 	return nullValue
 }
+
+type HttpRequestArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func HttpRequestCaptor() *HttpRequestArgumentCaptor {
+	return &HttpRequestArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(http.Request))(nil)).Elem())}
+}
+
+func (captor *HttpRequestArgumentCaptor) Capture() http.Request {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue http.Request
+	return nullValue
+}
+
+func (captor *HttpRequestArgumentCaptor) GetValue() http.Request {
+	value, _ := captor.Value().(http.Request)
+	return value
+}
+
+func (captor *HttpRequestArgumentCaptor) GetAllValues() []http.Request {
+	capturedValues := captor.Values()
+	values := make([]http.Request, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(http.Request)
+	}
+	return values
+}
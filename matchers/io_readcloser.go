@@ -18,3 +18,29 @@ func EqIoReadCloser(value io.ReadCloser) io.ReadCloser {
 	var nullValue io.ReadCloser
 	return nullValue
 }
+
+type IoReadCloserArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func IoReadCloserCaptor() *IoReadCloserArgumentCaptor {
+	return &IoReadCloserArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(io.ReadCloser))(nil)).Elem())}
+}
+
+func (captor *IoReadCloserArgumentCaptor) Capture() io.ReadCloser {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue io.ReadCloser
+	return nullValue
+}
+
+func (captor *IoReadCloserArgumentCaptor) GetValue() io.ReadCloser {
+	value, _ := captor.Value().(io.ReadCloser)
+	return value
+}
+
+func (captor *IoReadCloserArgumentCaptor) GetAllValues() []io.ReadCloser {
+	capturedValues := captor.Values()
+	values := make([]io.ReadCloser, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(io.ReadCloser)
+	}
+	return values
+}
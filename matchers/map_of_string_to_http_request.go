@@ -4,8 +4,7 @@ package matchers
 import (
 	"reflect"
 	"github.com/petergtz/pegomock"
-	
-http "net/http"
+	http "net/http"
 )
 
 func AnyMapOfStringToHttpRequest() map[string]http.Request {
@@ -19,3 +18,29 @@ func EqMapOfStringToHttpRequest(value map[string]http.Request) map[string]http.R
 	var nullValue map[string]http.Request
 	return nullValue
 }
+
+type MapOfStringToHttpRequestArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func MapOfStringToHttpRequestCaptor() *MapOfStringToHttpRequestArgumentCaptor {
+	return &MapOfStringToHttpRequestArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(map[string]http.Request))(nil)).Elem())}
+}
+
+func (captor *MapOfStringToHttpRequestArgumentCaptor) Capture() map[string]http.Request {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue map[string]http.Request
+	return nullValue
+}
+
+func (captor *MapOfStringToHttpRequestArgumentCaptor) GetValue() map[string]http.Request {
+	value, _ := captor.Value().(map[string]http.Request)
+	return value
+}
+
+func (captor *MapOfStringToHttpRequestArgumentCaptor) GetAllValues() []map[string]http.Request {
+	capturedValues := captor.Values()
+	values := make([]map[string]http.Request, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(map[string]http.Request)
+	}
+	return values
+}
@@ -5,7 +5,6 @@ import (
 	"reflect"
 	"github.com/petergtz/pegomock"
 	
-
 )
 
 func AnyMapOfStringToInterface() map[string]interface{} {
@@ -19,3 +18,29 @@ func EqMapOfStringToInterface(value map[string]interface{}) map[string]interface
 	var nullValue map[string]interface{}
 	return nullValue
 }
+
+type MapOfStringToInterfaceArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func MapOfStringToInterfaceCaptor() *MapOfStringToInterfaceArgumentCaptor {
+	return &MapOfStringToInterfaceArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(map[string]interface{}))(nil)).Elem())}
+}
+
+func (captor *MapOfStringToInterfaceArgumentCaptor) Capture() map[string]interface{} {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue map[string]interface{}
+	return nullValue
+}
+
+func (captor *MapOfStringToInterfaceArgumentCaptor) GetValue() map[string]interface{} {
+	value, _ := captor.Value().(map[string]interface{})
+	return value
+}
+
+func (captor *MapOfStringToInterfaceArgumentCaptor) GetAllValues() []map[string]interface{} {
+	capturedValues := captor.Values()
+	values := make([]map[string]interface{}, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(map[string]interface{})
+	}
+	return values
+}
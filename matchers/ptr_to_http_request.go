@@ -18,3 +18,29 @@ func EqPtrToHttpRequest(value *http.Request) *http.Request {
 	var nullValue *http.Request
 	return nullValue
 }
+
+type PtrToHttpRequestArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func PtrToHttpRequestCaptor() *PtrToHttpRequestArgumentCaptor {
+	return &PtrToHttpRequestArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(*http.Request))(nil)).Elem())}
+}
+
+func (captor *PtrToHttpRequestArgumentCaptor) Capture() *http.Request {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue *http.Request
+	return nullValue
+}
+
+func (captor *PtrToHttpRequestArgumentCaptor) GetValue() *http.Request {
+	value, _ := captor.Value().(*http.Request)
+	return value
+}
+
+func (captor *PtrToHttpRequestArgumentCaptor) GetAllValues() []*http.Request {
+	capturedValues := captor.Values()
+	values := make([]*http.Request, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(*http.Request)
+	}
+	return values
+}
@@ -18,3 +18,29 @@ func EqRecvChanOfString(value <-chan string) <-chan string {
 	var nullValue <-chan string
 	return nullValue
 }
+
+type RecvChanOfStringArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func RecvChanOfStringCaptor() *RecvChanOfStringArgumentCaptor {
+	return &RecvChanOfStringArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(<-chan string))(nil)).Elem())}
+}
+
+func (captor *RecvChanOfStringArgumentCaptor) Capture() <-chan string {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue <-chan string
+	return nullValue
+}
+
+func (captor *RecvChanOfStringArgumentCaptor) GetValue() <-chan string {
+	value, _ := captor.Value().(<-chan string)
+	return value
+}
+
+func (captor *RecvChanOfStringArgumentCaptor) GetAllValues() []<-chan string {
+	capturedValues := captor.Values()
+	values := make([]<-chan string, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(<-chan string)
+	}
+	return values
+}
@@ -18,3 +18,29 @@ func EqSendChanOfError(value chan<- error) chan<- error {
 	var nullValue chan<- error
 	return nullValue
 }
+
+type SendChanOfErrorArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func SendChanOfErrorCaptor() *SendChanOfErrorArgumentCaptor {
+	return &SendChanOfErrorArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(chan<- error))(nil)).Elem())}
+}
+
+func (captor *SendChanOfErrorArgumentCaptor) Capture() chan<- error {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue chan<- error
+	return nullValue
+}
+
+func (captor *SendChanOfErrorArgumentCaptor) GetValue() chan<- error {
+	value, _ := captor.Value().(chan<- error)
+	return value
+}
+
+func (captor *SendChanOfErrorArgumentCaptor) GetAllValues() []chan<- error {
+	capturedValues := captor.Values()
+	values := make([]chan<- error, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(chan<- error)
+	}
+	return values
+}
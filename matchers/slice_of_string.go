@@ -18,3 +18,29 @@ func EqSliceOfString(value []string) []string {
 	var nullValue []string
 	return nullValue
 }
+
+type SliceOfStringArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func SliceOfStringCaptor() *SliceOfStringArgumentCaptor {
+	return &SliceOfStringArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*([]string))(nil)).Elem())}
+}
+
+func (captor *SliceOfStringArgumentCaptor) Capture() []string {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue []string
+	return nullValue
+}
+
+func (captor *SliceOfStringArgumentCaptor) GetValue() []string {
+	value, _ := captor.Value().([]string)
+	return value
+}
+
+func (captor *SliceOfStringArgumentCaptor) GetAllValues() [][]string {
+	capturedValues := captor.Values()
+	values := make([][]string, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.([]string)
+	}
+	return values
+}
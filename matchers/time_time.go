@@ -18,3 +18,29 @@ func EqTimeTime(value time.Time) time.Time {
 	var nullValue time.Time
 	return nullValue
 }
+
+type TimeTimeArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func TimeTimeCaptor() *TimeTimeArgumentCaptor {
+	return &TimeTimeArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(time.Time))(nil)).Elem())}
+}
+
+func (captor *TimeTimeArgumentCaptor) Capture() time.Time {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue time.Time
+	return nullValue
+}
+
+func (captor *TimeTimeArgumentCaptor) GetValue() time.Time {
+	value, _ := captor.Value().(time.Time)
+	return value
+}
+
+func (captor *TimeTimeArgumentCaptor) GetAllValues() []time.Time {
+	capturedValues := captor.Values()
+	values := make([]time.Time, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(time.Time)
+	}
+	return values
+}
@@ -0,0 +1,58 @@
+package pegomock
+
+import (
+	"expvar"
+	"reflect"
+	"sync/atomic"
+)
+
+// MetricsRegisterer counts invocations of mocked methods. Implementations must
+// be safe for concurrent use, since mocks can be invoked from any goroutine.
+type MetricsRegisterer interface {
+	IncInvocationCount(mock, method string)
+}
+
+// metricsRegisterer holds a metricsRegistererHolder. It is only ever set through
+// ExposeMetrics, so Invoke gets away with a single load and nil check.
+var metricsRegisterer atomic.Value
+
+type metricsRegistererHolder struct{ registerer MetricsRegisterer }
+
+// ExposeMetrics makes every invocation of a mocked method increment the
+// invocation count for its mock type and method name in registerer.
+// Metrics are off by default. Passing nil turns them off again.
+func ExposeMetrics(registerer MetricsRegisterer) {
+	metricsRegisterer.Store(metricsRegistererHolder{registerer})
+}
+
+func currentMetricsRegisterer() MetricsRegisterer {
+	holder, _ := metricsRegisterer.Load().(metricsRegistererHolder)
+	return holder.registerer
+}
+
+// ExpvarMetrics returns a MetricsRegisterer that publishes invocation counts as
+// expvar map with the given name, keyed by "<mock>.<method>". This way, they show
+// up under /debug/vars when the expvar handler is served.
+//
+// Calling ExpvarMetrics again with the same name returns a registerer for the
+// same map.
+func ExpvarMetrics(name string) MetricsRegisterer {
+	if existingMap, ok := expvar.Get(name).(*expvar.Map); ok {
+		return expvarMetrics{existingMap}
+	}
+	return expvarMetrics{expvar.NewMap(name)}
+}
+
+type expvarMetrics struct{ counts *expvar.Map }
+
+func (metrics expvarMetrics) IncInvocationCount(mock, method string) {
+	metrics.counts.Add(mock+"."+method, 1)
+}
+
+func mockTypeNameOf(mock Mock) string {
+	mockType := reflect.TypeOf(mock)
+	if mockType.Kind() == reflect.Ptr {
+		mockType = mockType.Elem()
+	}
+	return mockType.Name()
+}
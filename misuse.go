@@ -0,0 +1,83 @@
+package pegomock
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Where argument matchers were registered is kept per goroutine alongside the
+// matchers, as program counters. They are only turned into file and line when
+// reporting misuse, since that is slow.
+var (
+	argMatcherCallers      = make(map[int64][][]uintptr)
+	argMatcherCallersMutex sync.Mutex
+)
+
+func recordArgMatcherCaller(goroutineID int64) {
+	// skip runtime.Callers, recordArgMatcherCaller and RegisterMatcher
+	pcs := captureStack(3, 8)
+	argMatcherCallersMutex.Lock()
+	defer argMatcherCallersMutex.Unlock()
+	argMatcherCallers[goroutineID] = append(argMatcherCallers[goroutineID], pcs)
+}
+
+func clearArgMatcherCallers(goroutineID int64) {
+	argMatcherCallersMutex.Lock()
+	defer argMatcherCallersMutex.Unlock()
+	delete(argMatcherCallers, goroutineID)
+}
+
+// argMatcherLocations returns where the argument matchers from index from to to
+// of the current goroutine were registered. It skips frames of Pegomock and of
+// generated matchers, so it points to test code.
+func argMatcherLocations(from, to int) (locations []string) {
+	argMatcherCallersMutex.Lock()
+	callers := argMatcherCallers[currentGoroutineID()]
+	argMatcherCallersMutex.Unlock()
+	if to > len(callers) {
+		to = len(callers)
+	}
+	for _, pcs := range callers[from:to] {
+		location := "<unknown>"
+		if found := testCodeLocations(pcs, 1); len(found) > 0 {
+			location = found[0]
+		}
+		locations = append(locations, location)
+	}
+	return
+}
+
+// panicOnOrphanArgMatchers panics, if argument matchers from index from to to
+// were registered without being passed to a call on a mock, e.g. because they
+// were passed to a method of a real object.
+func panicOnOrphanArgMatchers(from, to int) {
+	if from >= to {
+		return
+	}
+	panic(fmt.Sprintf("Invalid use of matchers!\n\n"+
+		"Argument matchers were not passed to a call on a mock. They were registered at:\n\t%v\n\n"+
+		"Matchers can only be used as arguments of calls on mocks inside When() or a verification, e.g.:\n"+
+		"    When(mock.Method(AnyInt()))\n"+
+		"Maybe the method they were passed to is not one of a mock?",
+		strings.Join(argMatcherLocations(from, to), "\n\t")))
+}
+
+// panicOnNestedInvocation panics, if a call on a mock happened while evaluating
+// the arguments of another call on a mock with argument matchers, e.g. in
+// When(outer.Method(inner.Method(AnyInt()))). The matchers can't be told apart.
+// Nested calls without matchers work: they are recorded as regular invocations.
+func panicOnNestedInvocation(previous *invocation, genericMock *GenericMock, methodName string) {
+	if previous == nil || previous.stubbedAt != "" || previous.pendingArgMatchers == 0 {
+		return
+	}
+	panic(fmt.Sprintf("Invalid use of matchers!\n\n"+
+		"%v was called while argument matchers used for a call to %v were still pending.\n\n"+
+		"This error occurs if calls on mocks with matchers are nested, e.g.:\n"+
+		"    //incorrect:\n"+
+		"    When(outer.Method(inner.Method(AnyInt())))\n"+
+		"Call %v before When() or the verification and pass its result instead.\n"+
+		"It also occurs if a call on a mock with matchers was not passed to When().",
+		genericMock.qualified(methodName), previous.genericMock.qualified(previous.MethodName),
+		previous.genericMock.qualified(previous.MethodName)))
+}
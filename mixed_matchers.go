@@ -0,0 +1,117 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// paramMatchersMixedWithParams returns a matcher for each of params, given the
+// fewer argMatchers registered while evaluating them, i.e. matchers mixed with
+// raw values as in someFunc(AnyInt(), "raw String"). The raw values are matched
+// with EqMatchers.
+//
+// Matcher functions return zero values, so a matcher can only have been passed
+// at a position holding the zero value of a type the matcher accepts, or the
+// matcher itself, as IsA returns it. Since matchers are registered in argument
+// order, this mostly tells their positions. If it doesn't, e.g. for
+// someFunc(AnyString(), ""), it panics and asks to wrap the raw zero values in
+// matchers.
+func paramMatchersMixedWithParams(argMatchers []Matcher, params []Param) []Matcher {
+	positions := argMatcherPositions(argMatchers, params)
+	if len(positions) != 1 {
+		panicOnUnpositionableArgMatchers(argMatchers, params, len(positions) > 1)
+	}
+	paramMatchers := transformParamsIntoEqMatchers(params)
+	for i, position := range positions[0] {
+		paramMatchers[position] = argMatchers[i]
+	}
+	return paramMatchers
+}
+
+// argMatcherPositions returns up to two possible assignments of argMatchers to
+// positions in params, preserving their order.
+func argMatcherPositions(argMatchers []Matcher, params []Param) [][]int {
+	var solutions [][]int
+	var search func(matcherIndex, paramIndex int, positions []int)
+	search = func(matcherIndex, paramIndex int, positions []int) {
+		if len(solutions) == 2 {
+			return
+		}
+		if matcherIndex == len(argMatchers) {
+			solutions = append(solutions, append([]int(nil), positions...))
+			return
+		}
+		for i := paramIndex; i <= len(params)-(len(argMatchers)-matcherIndex); i++ {
+			if couldBePassedAt(argMatchers[matcherIndex], params, i) {
+				search(matcherIndex+1, i+1, append(positions, i))
+			}
+		}
+	}
+	search(0, 0, nil)
+	return solutions
+}
+
+// couldBePassedAt reports whether a matcher function registering matcher could
+// have returned params[i].
+func couldBePassedAt(matcher Matcher, params []Param, i int) bool {
+	param := params[i]
+	if _, isVariadicDefaulting := matcher.(*VariadicDefaultingMatcher); isVariadicDefaulting && i != len(params)-1 {
+		return false
+	}
+	if isSameMatcher(matcher, param) {
+		return true
+	}
+	if param == nil {
+		return true
+	}
+	if !reflect.ValueOf(param).IsZero() {
+		return false
+	}
+	acceptedType := typeAcceptedBy(matcher)
+	return acceptedType == nil || reflect.TypeOf(param).AssignableTo(acceptedType)
+}
+
+func isSameMatcher(matcher Matcher, param Param) bool {
+	paramMatcher, isMatcher := param.(Matcher)
+	if !isMatcher {
+		return false
+	}
+	matcherValue, paramValue := reflect.ValueOf(matcher), reflect.ValueOf(paramMatcher)
+	return matcherValue.Kind() == reflect.Ptr && paramValue.Kind() == reflect.Ptr && matcherValue.Pointer() == paramValue.Pointer()
+}
+
+// typeAcceptedBy returns the type of params matcher can match, or nil if it
+// can't be told.
+func typeAcceptedBy(matcher Matcher) reflect.Type {
+	switch typedMatcher := matcher.(type) {
+	case *AnyMatcher:
+		return typedMatcher.Type
+	case *IsAMatcher:
+		return typedMatcher.Type
+	case *EqMatcher:
+		return reflect.TypeOf(typedMatcher.Value)
+	case *StringMatchingMatcher:
+		return reflect.TypeOf("")
+	}
+	return nil
+}
+
+func panicOnUnpositionableArgMatchers(argMatchers []Matcher, params []Param, ambiguous bool) {
+	formattedMatchers := make([]string, len(argMatchers))
+	for i, matcher := range argMatchers {
+		formattedMatchers[i] = matcher.String()
+	}
+	reason := "none of the arguments could have been returned by them"
+	if ambiguous {
+		reason = "there are several arguments they could have been passed as"
+	}
+	panic(fmt.Sprintf("Invalid use of matchers!\n\n %v matchers expected, %v recorded.\n\n"+
+		"Matchers can be combined with raw values, e.g.:\n"+
+		"    someFunc(AnyInt(), \"raw String\")\n"+
+		"but the matchers %v can't be told apart from the raw values in (%v), because %v.\n"+
+		"Matcher functions return zero values, so wrap raw zero values, e.g. \"\", 0 or nil, in matchers, too.\n"+
+		"For example:\n"+
+		"    someFunc(AnyString(), EqString(\"\"))",
+		len(params), len(argMatchers), strings.Join(formattedMatchers, ", "), formatParams(params), reason))
+}
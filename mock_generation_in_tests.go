@@ -0,0 +1,117 @@
+package pegomock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/petergtz/pegomock/pegomock/filehandling"
+	"github.com/petergtz/pegomock/pegomock/util"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// GenerateInTest generates the mock of the interface ifaceName of the package
+// pkgPath like the pegomock generate command does, but without running it, and
+// returns the path of the generated file. The file, and the matchers with
+// --generate-matchers, are written into t.TempDir().
+//
+// flags are the generate command's flags that affect a single mock, e.g.
+// "--mock-name=MockStore", "--use-experimental-model-gen" or "-m". --output is
+// taken relative to the temporary directory. As with the generate command, the
+// package defaults to the working directory's name suffixed with _test, so
+// running GenerateInTest in the tests of the package a mock is checked in to
+// reproduces it. That way, tests can check that checked-in mocks are up to date:
+//
+//	generated, _ := ioutil.ReadFile(pegomock.GenerateInTest(t, "github.com/example/store", "Store", "--use-experimental-model-gen"))
+//	checkedIn, _ := ioutil.ReadFile("mock_store_test.go")
+//	if !bytes.Equal(generated, checkedIn) {
+//		t.Fatal("mock_store_test.go is stale. Run go generate.")
+//	}
+//
+// Invalid flags and failures to generate the mock fail t right away.
+func GenerateInTest(t testing.TB, pkgPath string, ifaceName string, flags ...string) (outputFilePath string) {
+	t.Helper()
+
+	app := kingpin.New("GenerateInTest", "Flags of the pegomock generate command")
+	app.Terminate(nil)
+	generateFlags := util.DefineGenerateFlags(app)
+	debugParser := app.Flag("debug", "Print debug information.").Short('d').Bool()
+	shouldGenerateMatchers := app.Flag("generate-matchers", "Generate matchers.").Short('m').Bool()
+	matchersDestination := app.Flag("matchers-dir", "Directory to generate matchers in, relative to the temporary directory.").Short('p').String()
+	useExperimentalModelGen := app.Flag("use-experimental-model-gen", "Load the interface with golang.org/x/tools/go/loader.").Bool()
+	withExamples := app.Flag("with-examples", "Also generate examples.").Bool()
+	if _, e := app.Parse(flags); e != nil {
+		t.Fatalf("Invalid flags for GenerateInTest: %v", e)
+	}
+	if e := generateFlags.ValidateStandalone(); e != nil {
+		t.Fatalf("Invalid flags for GenerateInTest: %v", e)
+	}
+	if e := generateFlags.ValidateNameAffixes(); e != nil {
+		t.Fatalf("Invalid flags for GenerateInTest: %v", e)
+	}
+
+	workingDir, e := os.Getwd()
+	if e != nil {
+		t.Fatalf("Could not determine working directory: %v", e)
+	}
+	packageOut := *generateFlags.Package
+	if packageOut == "" {
+		packageOut = strings.Replace(filepath.Base(workingDir), "-", "_", -1) + "_test"
+		if *generateFlags.Export {
+			packageOut = strings.TrimSuffix(packageOut, "_test")
+		}
+	}
+	outputDir := t.TempDir()
+	sourceArgs := []string{pkgPath, ifaceName}
+	destination := ""
+	if *generateFlags.Output != "" {
+		destination = filepath.Join(outputDir, *generateFlags.Output)
+	}
+	matchersDir := ""
+	if *matchersDestination != "" {
+		matchersDir = filepath.Join(outputDir, *matchersDestination)
+	}
+	if *generateFlags.Export {
+		outputFilePath = filehandling.ExportedOutputFilePath(sourceArgs, outputDir, destination)
+	} else {
+		outputFilePath = filehandling.OutputFilePath(sourceArgs, outputDir, destination)
+	}
+
+	e = generateMockInTest(func() {
+		if *generateFlags.Export {
+			util.PanicOnError(filehandling.ValidateExport(*generateFlags.MockName, *generateFlags.NamePrefix, *generateFlags.NameSuffix, packageOut, outputFilePath))
+		}
+		if *generateFlags.Strict {
+			util.PanicOnError(filehandling.ValidateErrorPositions(sourceArgs, *useExperimentalModelGen, generateFlags.BuildTags()...))
+		}
+		options := filehandling.GenerateOptionsFromFlags(generateFlags)
+		options.PackageOut = packageOut
+		options.DebugParser = *debugParser
+		options.DebugOut = os.Stdout
+		options.UseExperimentalModelGen = *useExperimentalModelGen
+		options.GenerateMatchers = *shouldGenerateMatchers
+		options.MatchersDestination = matchersDir
+		_, err := filehandling.GenerateMockFileInOutputDir(sourceArgs, outputDir, outputFilePath, options)
+		util.PanicOnError(err)
+		if *withExamples {
+			filehandling.GenerateExamplesFile(sourceArgs, outputFilePath, options)
+		}
+	})
+	if e != nil {
+		t.Fatalf("Generating mock for %v.%v failed: %v", pkgPath, ifaceName, e)
+	}
+	return outputFilePath
+}
+
+// generateMockInTest turns the panics generate fails with into an error.
+func generateMockInTest(generate func()) (e error) {
+	defer func() {
+		if value := recover(); value != nil {
+			e = fmt.Errorf("%v", value)
+		}
+	}()
+	generate()
+	return nil
+}
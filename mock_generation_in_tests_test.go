@@ -0,0 +1,129 @@
+package pegomock_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/petergtz/pegomock"
+)
+
+// checkedInDisplayMock writes a mock of Display generated with flags into a
+// temporary directory, as if it had been checked in. The mock_display_test.go
+// of this package can't serve as such, because the generate_test_mocks tests
+// generate it in several ways.
+func checkedInDisplayMock(t *testing.T, flags ...string) (path string, content string) {
+	generated, e := ioutil.ReadFile(pegomock.GenerateInTest(t, "github.com/petergtz/pegomock/test_interface", "Display", flags...))
+	if e != nil {
+		t.Fatal(e)
+	}
+	return writeCheckedInMock(t, string(generated)), string(generated)
+}
+
+func writeCheckedInMock(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "mock_display_test.go")
+	if e := ioutil.WriteFile(path, []byte(content), 0644); e != nil {
+		t.Fatal(e)
+	}
+	return path
+}
+
+func isStale(t *testing.T, checkedInMockPath string, pkgPath string, ifaceName string, flags ...string) bool {
+	generated, e := ioutil.ReadFile(pegomock.GenerateInTest(t, pkgPath, ifaceName, flags...))
+	if e != nil {
+		t.Fatal(e)
+	}
+	checkedIn, e := ioutil.ReadFile(checkedInMockPath)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return !bytes.Equal(generated, checkedIn)
+}
+
+func TestGenerateInTestReproducesUpToDateMock(t *testing.T) {
+	checkedInMockPath, _ := checkedInDisplayMock(t, "--use-experimental-model-gen", "--context-aware")
+
+	if isStale(t, checkedInMockPath, "github.com/petergtz/pegomock/test_interface", "Display", "--use-experimental-model-gen", "--context-aware") {
+		t.Fatal("Expected the checked-in mock to be reproduced, but it differs")
+	}
+}
+
+func TestGenerateInTestDetectsStaleMock(t *testing.T) {
+	_, upToDate := checkedInDisplayMock(t, "--use-experimental-model-gen")
+	// A mock generated before Display got its Show method.
+	stale := strings.Replace(upToDate, "func (mock *MockDisplay) Show(", "func (mock *MockDisplay) show(", 1)
+	if stale == upToDate {
+		t.Fatal("The generated mock has no Show method to make stale")
+	}
+
+	if !isStale(t, writeCheckedInMock(t, stale), "github.com/petergtz/pegomock/test_interface", "Display", "--use-experimental-model-gen") {
+		t.Fatal("Expected stale mock to differ from the generated one")
+	}
+	// A mock generated with other flags is stale, too.
+	if !isStale(t, writeCheckedInMock(t, upToDate), "github.com/petergtz/pegomock/test_interface", "Display", "--use-experimental-model-gen", "--provide") {
+		t.Fatal("Expected mock generated without --provide to differ from one generated with it")
+	}
+}
+
+func TestGenerateInTestAppliesFlags(t *testing.T) {
+	path := pegomock.GenerateInTest(t, "github.com/petergtz/pegomock/test_interface", "Display",
+		"--use-experimental-model-gen", "--mock-name=FakeDisplay", "--package=fakes", "--output=fakes/display.go")
+
+	if filepath.Base(filepath.Dir(path)) != "fakes" || filepath.Base(path) != "display.go" {
+		t.Fatalf("Expected mock in fakes/display.go of the temporary directory, but got %v", path)
+	}
+	generated, e := ioutil.ReadFile(path)
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, expected := range []string{"package fakes\n", "type FakeDisplay struct"} {
+		if !strings.Contains(string(generated), expected) {
+			t.Errorf("Expected generated mock to contain %q", expected)
+		}
+	}
+}
+
+func TestGenerateInTestFailsOnInvalidFlagsAndInterfaces(t *testing.T) {
+	for _, testCase := range []struct {
+		ifaceName       string
+		flags           []string
+		expectedFailure string
+	}{
+		{"Display", []string{"--no-such-flag"}, "Invalid flags for GenerateInTest: unknown long flag '--no-such-flag'"},
+		{"Display", []string{"--standalone", "--provide"}, "Invalid flags for GenerateInTest: Cannot use --provide with --standalone"},
+		{"NoSuchInterface", []string{"--use-experimental-model-gen"}, "Generating mock for github.com/petergtz/pegomock/test_interface.NoSuchInterface failed"},
+	} {
+		failure := fatalFailureOf(t, func(fakeT testing.TB) {
+			pegomock.GenerateInTest(fakeT, "github.com/petergtz/pegomock/test_interface", testCase.ifaceName, testCase.flags...)
+		})
+		if !strings.HasPrefix(failure, testCase.expectedFailure) {
+			t.Errorf("Expected failure starting with %q, but got %q", testCase.expectedFailure, failure)
+		}
+	}
+}
+
+// fatalTB records the failure of Fatalf instead of failing the test.
+type fatalTB struct {
+	testing.TB
+	failure string
+}
+
+func (t *fatalTB) Fatalf(format string, args ...interface{}) {
+	t.failure = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func fatalFailureOf(t *testing.T, fn func(testing.TB)) string {
+	fakeT := &fatalTB{TB: t}
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		fn(fakeT)
+	}()
+	<-done
+	return fakeT.failure
+}
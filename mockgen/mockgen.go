@@ -24,64 +24,232 @@ package mockgen
 import (
 	"bytes"
 	"fmt"
+	"go/build/constraint"
 	"go/format"
 	"go/token"
 	"path"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 	"unicode"
 
 	"github.com/petergtz/pegomock/mockgen/util"
 
 	"github.com/petergtz/pegomock/model"
+	"github.com/petergtz/pegomock/version"
 )
 
 const mockFrameworkImportPath = "github.com/petergtz/pegomock"
 
-func GenerateOutput(ast *model.Package, source, nameOut, packageOut, selfPackage string) ([]byte, map[string]string) {
+// GenerateOutput renders mocks for all interfaces in ast using mockTemplate, or
+// the built-in template if mockTemplate is empty. templateData is made available
+// to the template as .Data. The mocks are named nameOut or, if it is empty,
+// namePrefix + the interface's name + nameSuffix, e.g. MockDisplay.
+func GenerateOutput(ast *model.Package, source, nameOut, namePrefix, nameSuffix, packageOut, selfPackage, buildTag string, contextAware, provide bool, mockTemplate string, templateData map[string]string) ([]byte, map[string]string) {
+	if mockTemplate == "" {
+		mockTemplate = builtinMockTemplate
+	}
 	g := generator{typesSet: make(map[string]string)}
-	g.generateCode(source, ast, nameOut, packageOut, selfPackage)
+	g.generateCode(source, ast, nameOut, namePrefix, nameSuffix, packageOut, selfPackage, buildTag, contextAware, provide, mockTemplate, templateData)
 	return g.formattedOutput(), g.typesSet
 }
 
+// GenerateStandaloneOutput renders mocks for all interfaces in ast that don't
+// depend on the pegomock library, see builtinStandaloneTemplate. They support
+// stubbing return values and verifying invocation counts, but no matchers, so
+// no matchers are generated for them.
+func GenerateStandaloneOutput(ast *model.Package, source, nameOut, namePrefix, nameSuffix, packageOut, selfPackage, buildTag string, contextAware bool) []byte {
+	g := generator{typesSet: make(map[string]string), standalone: true}
+	tmpl := template.Must(template.New("standalone mocks").Parse(builtinStandaloneTemplate))
+	data := g.templateDataFor(source, ast, nameOut, namePrefix, nameSuffix, packageOut, selfPackage, buildTag, contextAware, false, nil)
+	if err := tmpl.Execute(&g.buf, data); err != nil {
+		panic(fmt.Errorf("Failed to execute standalone mock template: %v", err))
+	}
+	return g.formattedOutput()
+}
+
 type generator struct {
 	buf        bytes.Buffer
 	packageMap map[string]string // map from import path to package name
 	typesSet   map[string]string
+	// standalone is set when generating mocks that import the standard library
+	// packages in standaloneImports instead of pegomock.
+	standalone bool
+}
+
+// standaloneImports are the packages the standalone template imports itself.
+var standaloneImports = map[string]bool{"fmt": true, "reflect": true, "strings": true, "sync": true}
+
+// importedByTemplate reports whether the built-in template imports the package
+// at importPath itself.
+func (g *generator) importedByTemplate(importPath string) bool {
+	if g.standalone {
+		return standaloneImports[importPath]
+	}
+	return importPath == "time" || importPath == "reflect"
 }
 
-func (g *generator) generateCode(source string, pkg *model.Package, structName, pkgName, selfPackage string) {
-	g.p("// Code generated by pegomock. DO NOT EDIT.")
-	g.p("// Source: %v", source)
-	g.emptyLine()
+func (g *generator) generateCode(source string, pkg *model.Package, structName, namePrefix, nameSuffix, pkgName, selfPackage, buildTag string, contextAware, provide bool, mockTemplate string, templateData map[string]string) {
+	tmpl, err := template.New("mocks").Parse(mockTemplate)
+	if err != nil {
+		panic(fmt.Errorf("Failed to parse mock template: %v", err))
+	}
 
+	data := g.templateDataFor(source, pkg, structName, namePrefix, nameSuffix, pkgName, selfPackage, buildTag, contextAware, provide, templateData)
+	if err := tmpl.Execute(&g.buf, data); err != nil {
+		panic(fmt.Errorf("Failed to execute mock template: %v", err))
+	}
+}
+
+func (g *generator) templateDataFor(source string, pkg *model.Package, structName, namePrefix, nameSuffix, pkgName, selfPackage, buildTag string, contextAware, provide bool, templateData map[string]string) TemplateData {
+	if selfPackage == "" && pkg.PkgPath != "" && pkg.Name == pkgName {
+		// Generating into the interface's own package, whose types must not be qualified.
+		selfPackage = pkg.PkgPath
+	}
 	importPaths := pkg.Imports()
-	importPaths[mockFrameworkImportPath] = true
+	if g.standalone {
+		for importPath := range standaloneImports {
+			importPaths[importPath] = true
+		}
+	} else {
+		importPaths[mockFrameworkImportPath] = true
+	}
 	packageMap, nonVendorPackageMap := generateUniquePackageNamesFor(importPaths)
 	g.packageMap = packageMap
 
-	g.p("package %v", pkgName)
-	g.emptyLine()
-	g.p("import (")
-	g.p("\"reflect\"")
-	g.p("\"time\"")
+	data := TemplateData{
+		Source:           source,
+		InterfaceHash:    pkg.InterfaceHash(),
+		GeneratorVersion: version.Generator(),
+		PackageName:      pkgName,
+		BuildConstraint:  buildConstraintLinesFor(buildTag),
+		DotImports:       pkg.DotImports,
+		Data:             templateData,
+	}
 	for packagePath, packageName := range nonVendorPackageMap {
-		if packagePath != selfPackage && packagePath != "time" && packagePath != "reflect" {
-			g.p("%v %q", packageName, packagePath)
+		if packagePath != selfPackage && !g.importedByTemplate(packagePath) {
+			data.Imports = append(data.Imports, Import{Name: packageName, Path: packagePath})
 		}
 	}
-	for _, packagePath := range pkg.DotImports {
-		g.p(". %q", packagePath)
-	}
-	g.p(")")
-
+	sort.Slice(data.Imports, func(i, j int) bool { return data.Imports[i].Path < data.Imports[j].Path })
 	for _, iface := range pkg.Interfaces {
 		sName := structName
 		if sName == "" {
-			sName = "Mock" + iface.Name
+			sName = namePrefix + iface.Name + nameSuffix
+		}
+		mock := g.mockDataFor(iface, sName, pkg.PkgPath, selfPackage, contextAware)
+		mock.InterfaceType = interfaceTypeFor(iface.Name, pkg, pkgName, selfPackage, &data)
+		mock.Provide = provide
+		data.Mocks = append(data.Mocks, mock)
+	}
+	return data
+}
+
+// ExamplesData is what the examples template is executed with.
+type ExamplesData struct {
+	TemplateData
+	ExamplesPackageName string
+	// ExampleImports holds the imports the examples use.
+	ExampleImports []Import
+	// MockQualifier is prefixed to identifiers from the mocks' package, e.g.
+	// "mocks.". It is empty if the examples are in the mocks' package.
+	MockQualifier string
+}
+
+// GenerateExamples renders an Example function for each method of the mocks
+// GenerateOutput renders for ast with the same nameOut and packageOut. The
+// examples show how to stub and verify the method. If packageOut is a _test
+// package, the examples are put into it. Otherwise they are put into packageOut
+// suffixed with _test, which imports the mocks from mockPackagePath.
+func GenerateExamples(ast *model.Package, source, nameOut, namePrefix, nameSuffix, packageOut, mockPackagePath, buildTag string, contextAware bool) []byte {
+	g := generator{typesSet: make(map[string]string)}
+	data := ExamplesData{
+		TemplateData:        g.templateDataFor(source, ast, nameOut, namePrefix, nameSuffix, packageOut, "", buildTag, contextAware, false, nil),
+		ExamplesPackageName: packageOut,
+	}
+	candidateImports := append(append([]Import(nil), data.Imports...), Import{Name: "reflect", Path: "reflect"}, Import{Name: "time", Path: "time"})
+	if !strings.HasSuffix(packageOut, "_test") {
+		if mockPackagePath == "" {
+			panic(fmt.Errorf("Cannot import the mocks into the examples: import path of package %v unknown", packageOut))
 		}
-		g.generateMockFor(iface, sName, selfPackage)
+		data.ExamplesPackageName = packageOut + "_test"
+		data.MockQualifier = packageOut + "."
+		candidateImports = append(candidateImports, Import{Name: packageOut, Path: mockPackagePath})
 	}
+	for _, candidate := range candidateImports {
+		if candidate.Name == "pegomock" || examplesUse(data, candidate.Name) {
+			data.ExampleImports = append(data.ExampleImports, candidate)
+		}
+	}
+	sort.Slice(data.ExampleImports, func(i, j int) bool { return data.ExampleImports[i].Path < data.ExampleImports[j].Path })
+
+	tmpl := template.Must(template.New("examples").Parse(builtinExamplesTemplate))
+	if err := tmpl.Execute(&g.buf, data); err != nil {
+		panic(fmt.Errorf("Failed to execute examples template: %v", err))
+	}
+	return g.formattedOutput()
+}
+
+// examplesUse reports whether the examples refer to the package imported as
+// packageName.
+func examplesUse(data ExamplesData, packageName string) bool {
+	if data.MockQualifier == packageName+"." {
+		return true
+	}
+	qualifier := regexp.MustCompile(`\b` + regexp.QuoteMeta(packageName) + `\.`)
+	for _, mock := range data.Mocks {
+		for _, method := range mock.Methods {
+			if qualifier.MatchString(method.ExampleArgs()) || qualifier.MatchString(method.ExampleReturnValues()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildConstraintLinesFor returns the //go:build line for buildTag together with
+// the equivalent legacy // +build lines, so older toolchains honor it too.
+func buildConstraintLinesFor(buildTag string) []string {
+	if buildTag == "" {
+		return nil
+	}
+	expr, err := constraint.Parse("//go:build " + buildTag)
+	if err != nil {
+		panic(fmt.Errorf("Invalid build tag %q: %v", buildTag, err))
+	}
+	plusBuildLines, err := constraint.PlusBuildLines(expr)
+	if err != nil {
+		panic(fmt.Errorf("Invalid build tag %q: %v", buildTag, err))
+	}
+	return append([]string{"//go:build " + expr.String()}, plusBuildLines...)
+}
+
+// interfaceTypeFor returns how code in package outputPackageName refers to the
+// interface called name in pkg. If that requires importing pkg, it adds the
+// import to data.InterfaceImports.
+func interfaceTypeFor(name string, pkg *model.Package, outputPackageName, selfPackage string, data *TemplateData) string {
+	if pkg.Name == outputPackageName || (pkg.PkgPath != "" && pkg.PkgPath == selfPackage) {
+		return name
+	}
+	if pkg.PkgPath == "" {
+		return ""
+	}
+	// These are imported by the built-in templates themselves.
+	packageNamesAlreadyUsed := map[string]bool{"reflect": true, "time": true, "fmt": true, "strings": true, "sync": true}
+	for _, imp := range append(data.Imports, data.InterfaceImports...) {
+		if imp.Path == pkg.PkgPath {
+			return imp.Name + "." + name
+		}
+		packageNamesAlreadyUsed[imp.Name] = true
+	}
+	packageName := sanitize(pkg.Name)
+	for i := 0; packageNamesAlreadyUsed[packageName] || token.Lookup(packageName).IsKeyword(); i++ {
+		packageName = sanitize(pkg.Name) + strconv.Itoa(i)
+	}
+	data.InterfaceImports = append(data.InterfaceImports, Import{Name: packageName, Path: pkg.PkgPath})
+	return packageName + "." + name
 }
 
 func generateUniquePackageNamesFor(importPaths map[string]bool) (packageMap, nonVendorPackageMap map[string]string) {
@@ -143,11 +311,17 @@ func sanitize(s string) string {
 	return t
 }
 
-func (g *generator) generateMockFor(iface *model.Interface, mockTypeName, selfPackage string) {
-	g.generateMockType(mockTypeName)
+func (g *generator) mockDataFor(iface *model.Interface, mockTypeName, packagePath, selfPackage string, contextAware bool) Mock {
+	mock := Mock{
+		InterfaceName: iface.Name,
+		MockName:      mockTypeName,
+		PackagePath:   packagePath,
+	}
 	for _, method := range iface.Methods {
-		g.generateMockMethod(mockTypeName, method, selfPackage)
-		g.emptyLine()
+		methodData := methodDataFor(method, g.packageMap, selfPackage)
+		methodData.ContextAware = contextAware && isContextAware(method)
+		methodData.NonStandardErrorPosition = hasNonStandardErrorPosition(method)
+		mock.Methods = append(mock.Methods, methodData)
 
 		addTypesFromMethodParamsTo(g.typesSet, method.In, g.packageMap)
 		addTypesFromMethodParamsTo(g.typesSet, method.Out, g.packageMap)
@@ -155,267 +329,132 @@ func (g *generator) generateMockFor(iface *model.Interface, mockTypeName, selfPa
 			addTypesFromMethodParamsTo(g.typesSet, []*model.Parameter{method.Variadic}, g.packageMap)
 		}
 	}
-	g.generateMockVerifyMethods(mockTypeName)
-	g.generateVerifierType(mockTypeName)
-	for _, method := range iface.Methods {
-		ongoingVerificationTypeName := fmt.Sprintf("%v_%v_OngoingVerification", mockTypeName, method.Name)
-		args, argNames, argTypes, _ := argDataFor(method, g.packageMap, selfPackage)
-		g.generateVerifierMethod(mockTypeName, method, selfPackage, ongoingVerificationTypeName, args, argNames)
-		g.generateOngoingVerificationType(mockTypeName, ongoingVerificationTypeName)
-		g.generateOngoingVerificationGetCapturedArguments(ongoingVerificationTypeName, argNames, argTypes)
-		g.generateOngoingVerificationGetAllCapturedArguments(ongoingVerificationTypeName, argTypes, method.Variadic != nil)
-	}
-}
-
-func (g *generator) generateMockType(mockTypeName string) {
-	g.
-		emptyLine().
-		p("type %v struct {", mockTypeName).
-		p("	fail func(message string, callerSkip ...int)").
-		p("}").
-		emptyLine().
-		p("func New%v(options ...pegomock.Option) *%v {", mockTypeName, mockTypeName).
-		p("	mock := &%v{}", mockTypeName).
-		p("	for _, option := range options {").
-		p("		option.Apply(mock)").
-		p("	}").
-		p("	return mock").
-		p("}").
-		emptyLine().
-		p("func (mock *%v) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }", mockTypeName).
-		p("func (mock *%v) FailHandler() pegomock.FailHandler      { return mock.fail }", mockTypeName).
-		emptyLine()
+	return mock
 }
 
 // If non-empty, pkgOverride is the package in which unqualified types reside.
-func (g *generator) generateMockMethod(mockType string, method *model.Method, pkgOverride string) *generator {
-	args, argNames, _, returnTypes := argDataFor(method, g.packageMap, pkgOverride)
-	g.p("func (mock *%v) %v(%v) (%v) {", mockType, method.Name, join(args), join(stringSliceFrom(returnTypes, g.packageMap, pkgOverride)))
-	g.p("if mock == nil {").
-		p("	panic(\"mock must not be nil. Use myMock := New%v().\")", mockType).
-		p("}")
-	g.GenerateParamsDeclaration(argNames, method.Variadic != nil)
-	reflectReturnTypes := make([]string, len(returnTypes))
-	for i, returnType := range returnTypes {
-		reflectReturnTypes[i] = fmt.Sprintf("reflect.TypeOf((*%v)(nil)).Elem()", returnType.String(g.packageMap, pkgOverride))
-	}
-	resultAssignment := ""
-	if len(method.Out) > 0 {
-		resultAssignment = "result :="
-	}
-	g.p("%v pegomock.GetGenericMockFrom(mock).Invoke(\"%v\", params, []reflect.Type{%v})",
-		resultAssignment, method.Name, strings.Join(reflectReturnTypes, ", "))
-	if len(method.Out) > 0 {
-		// TODO: translate LastInvocation into a Matcher so it can be used as key for Stubbings
-		for i, returnType := range returnTypes {
-			g.p("var ret%v %v", i, returnType.String(g.packageMap, pkgOverride))
+func methodDataFor(method *model.Method, packageMap map[string]string, pkgOverride string) Method {
+	m := Method{Name: method.Name}
+	for i, arg := range method.In {
+		m.Params = append(m.Params, Param{
+			Name: paramNameFor(arg, i, packageMap),
+			Type: arg.Type.String(packageMap, pkgOverride),
+		})
+	}
+	if method.Variadic != nil {
+		m.Params = append(m.Params, Param{
+			Name:     paramNameFor(method.Variadic, len(method.In), packageMap),
+			Type:     method.Variadic.Type.String(packageMap, pkgOverride),
+			Variadic: true,
+		})
+	}
+	// Either all or none of the results of a method are named.
+	named := len(method.Out) > 0 && method.Out[0].Name != ""
+	for i, ret := range method.Out {
+		r := Return{Name: fmt.Sprintf("ret%d", i), Type: ret.Type.String(packageMap, pkgOverride)}
+		if named {
+			r.Name, r.Named = identifierFor(ret, fmt.Sprintf("_ret%d", i), packageMap), true
 		}
-		g.p("if len(result) != 0 {")
-		returnValues := make([]string, len(returnTypes))
-		for i, returnType := range returnTypes {
-			g.p("if result[%v] != nil {", i)
-			if chanType, isChanType := returnType.(*model.ChanType); isChanType && chanType.Dir != 0 {
-				undirectedChanType := *chanType
-				undirectedChanType.Dir = 0
-				g.p("var ok bool").
-					p("  ret%v, ok = result[%v].(%v)", i, i, undirectedChanType.String(g.packageMap, pkgOverride))
-				g.p("if !ok{").
-					p("ret%v = result[%v].(%v)", i, i, chanType.String(g.packageMap, pkgOverride)).
-					p("}")
-			} else {
-				g.p("ret%v  = result[%v].(%v)", i, i, returnType.String(g.packageMap, pkgOverride))
-			}
-			g.p("}")
-			returnValues[i] = fmt.Sprintf("ret%v", i)
+		if chanType, isChanType := ret.Type.(*model.ChanType); isChanType && chanType.Dir != 0 {
+			undirectedChanType := *chanType
+			undirectedChanType.Dir = 0
+			r.UndirectedChanType = undirectedChanType.String(packageMap, pkgOverride)
 		}
-		g.p("}")
-		g.p("return %v", strings.Join(returnValues, ", "))
-	}
-	g.p("}")
-	return g
-}
-
-func (g *generator) generateVerifierType(interfaceName string) *generator {
-	return g.
-		p("type Verifier%v struct {", interfaceName).
-		p("	mock *%v", interfaceName).
-		p("	invocationCountMatcher pegomock.Matcher").
-		p("	inOrderContext *pegomock.InOrderContext").
-		p("	timeout time.Duration").
-		p("}").
-		emptyLine()
-}
-
-func (g *generator) generateMockVerifyMethods(interfaceName string) {
-	g.
-		p("func (mock *%v) VerifyWasCalledOnce() *Verifier%v {", interfaceName, interfaceName).
-		p("	return &Verifier%v{", interfaceName).
-		p("		mock: mock,").
-		p("		invocationCountMatcher: pegomock.Times(1),").
-		p("	}").
-		p("}").
-		emptyLine().
-		p("func (mock *%v) VerifyWasCalled(invocationCountMatcher pegomock.Matcher) *Verifier%v {", interfaceName, interfaceName).
-		p("	return &Verifier%v{", interfaceName).
-		p("		mock: mock,").
-		p("		invocationCountMatcher: invocationCountMatcher,").
-		p("	}").
-		p("}").
-		emptyLine().
-		p("func (mock *%v) VerifyWasCalledInOrder(invocationCountMatcher pegomock.Matcher, inOrderContext *pegomock.InOrderContext) *Verifier%v {", interfaceName, interfaceName).
-		p("	return &Verifier%v{", interfaceName).
-		p("		mock: mock,").
-		p("		invocationCountMatcher: invocationCountMatcher,").
-		p("		inOrderContext: inOrderContext,").
-		p("	}").
-		p("}").
-		emptyLine().
-		p("func (mock *%v) VerifyWasCalledEventually(invocationCountMatcher pegomock.Matcher, timeout time.Duration) *Verifier%v {", interfaceName, interfaceName).
-		p("	return &Verifier%v{", interfaceName).
-		p("		mock: mock,").
-		p("		invocationCountMatcher: invocationCountMatcher,").
-		p("		timeout: timeout,").
-		p("	}").
-		p("}").
-		emptyLine()
-}
-
-func (g *generator) generateVerifierMethod(interfaceName string, method *model.Method, pkgOverride string, returnTypeString string, args []string, argNames []string) *generator {
-	return g.
-		p("func (verifier *Verifier%v) %v(%v) *%v {", interfaceName, method.Name, join(args), returnTypeString).
-		GenerateParamsDeclaration(argNames, method.Variadic != nil).
-		p("methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, \"%v\", params, verifier.timeout)", method.Name).
-		p("return &%v{mock: verifier.mock, methodInvocations: methodInvocations}", returnTypeString).
-		p("}")
-}
-
-func (g *generator) GenerateParamsDeclaration(argNames []string, isVariadic bool) *generator {
-	if isVariadic {
-		return g.
-			p("params := []pegomock.Param{%v}", strings.Join(argNames[0:len(argNames)-1], ", ")).
-			p("for _, param := range %v {", argNames[len(argNames)-1]).
-			p("params = append(params, param)").
-			p("}")
-	} else {
-		return g.p("params := []pegomock.Param{%v}", join(argNames))
+		m.Returns = append(m.Returns, r)
 	}
+	return m
 }
 
-func (g *generator) generateOngoingVerificationType(interfaceName string, ongoingVerificationStructName string) *generator {
-	return g.
-		p("type %v struct {", ongoingVerificationStructName).
-		p("mock *%v", interfaceName).
-		p("	methodInvocations []pegomock.MethodInvocation").
-		p("}").
-		emptyLine()
+// isContextAware reports whether method takes a context.Context as first
+// parameter and returns an error as last result.
+func isContextAware(method *model.Method) bool {
+	if len(method.In) == 0 || len(method.Out) == 0 {
+		return false
+	}
+	firstParamType, isNamedType := method.In[0].Type.(*model.NamedType)
+	return isNamedType && firstParamType.Package == "context" && firstParamType.Type == "Context" &&
+		method.Out[len(method.Out)-1].Type == model.PredeclaredType("error")
 }
 
-func (g *generator) generateOngoingVerificationGetCapturedArguments(ongoingVerificationStructName string, argNames []string, argTypes []string) *generator {
-	g.p("func (c *%v) GetCapturedArguments() (%v) {", ongoingVerificationStructName, join(argTypes))
-	if len(argNames) > 0 {
-		indexedArgNames := make([]string, len(argNames))
-		for i, argName := range argNames {
-			indexedArgNames[i] = argName + "[len(" + argName + ")-1]"
-		}
-		g.p("%v := c.GetAllCapturedArguments()", join(argNames))
-		g.p("return %v", strings.Join(indexedArgNames, ", "))
-	}
-	g.p("}")
-	g.emptyLine()
-	return g
-}
-
-func (g *generator) generateOngoingVerificationGetAllCapturedArguments(ongoingVerificationStructName string, argTypes []string, isVariadic bool) *generator {
-	argsAsArray := make([]string, len(argTypes))
-	for i, argType := range argTypes {
-		argsAsArray[i] = fmt.Sprintf("_param%v []%v", i, argType)
-	}
-	g.p("func (c *%v) GetAllCapturedArguments() (%v) {", ongoingVerificationStructName, strings.Join(argsAsArray, ", "))
-	if len(argTypes) > 0 {
-		g.p("params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)")
-		g.p("if len(params) > 0 {")
-		for i, argType := range argTypes {
-			if isVariadic && i == len(argTypes)-1 {
-				variadicBasicType := strings.Replace(argType, "[]", "", 1)
-				g.
-					p("_param%v = make([]%v, len(c.methodInvocations))", i, argType).
-					p("for u := 0; u < len(c.methodInvocations); u++ {").
-					p("_param%v[u] = make([]%v, len(params)-%v)", i, variadicBasicType, i).
-					p("for x := %v; x < len(params); x++ {", i).
-					p("if params[x][u] != nil {").
-					p("_param%v[u][x-%v] = params[x][u].(%v)", i, i, variadicBasicType).
-					p("}").
-					p("}").
-					p("}")
-				break
-			} else {
-				g.p("_param%v = make([]%v, len(c.methodInvocations))", i, argType)
-				g.p("for u, param := range params[%v] {", i)
-				g.p("_param%v[u]=param.(%v)", i, argType)
-				g.p("}")
-			}
-		}
-		g.p("}")
-		g.p("return")
-	}
-	g.p("}")
-	g.emptyLine()
-	return g
-}
-
-func argDataFor(method *model.Method, packageMap map[string]string, pkgOverride string) (
-	args []string,
-	argNames []string,
-	argTypes []string,
-	returnTypes []model.Type,
-) {
-	args = make([]string, len(method.In))
-	argNames = make([]string, len(method.In))
-	argTypes = make([]string, len(args))
-	for i, arg := range method.In {
-		argName := arg.Name
-		if argName == "" {
-			argName = fmt.Sprintf("_param%d", i)
+// hasNonStandardErrorPosition reports whether method returns an error other than
+// as its last result.
+func hasNonStandardErrorPosition(method *model.Method) bool {
+	for i, ret := range method.Out {
+		if i < len(method.Out)-1 && ret.Type == model.PredeclaredType("error") {
+			return true
 		}
-		argType := arg.Type.String(packageMap, pkgOverride)
-		args[i] = argName + " " + argType
-		argNames[i] = argName
-		argTypes[i] = argType
 	}
-	if method.Variadic != nil {
-		argName := method.Variadic.Name
-		if argName == "" {
-			argName = fmt.Sprintf("_param%d", len(method.In))
+	return false
+}
+
+// NonStandardErrorPositions returns the methods of the interfaces in pkg that
+// return an error other than as their last result, e.g. "Store.Load".
+func NonStandardErrorPositions(pkg *model.Package) (methods []string) {
+	for _, iface := range pkg.Interfaces {
+		for _, method := range iface.Methods {
+			if hasNonStandardErrorPosition(method) {
+				methods = append(methods, iface.Name+"."+method.Name)
+			}
 		}
-		argType := method.Variadic.Type.String(packageMap, pkgOverride)
-		args = append(args, argName+" ..."+argType)
-		argNames = append(argNames, argName)
-		argTypes = append(argTypes, "[]"+argType)
-	}
-	returnTypes = make([]model.Type, len(method.Out))
-	for i, ret := range method.Out {
-		returnTypes[i] = ret.Type
 	}
 	return
 }
 
-func stringSliceFrom(types []model.Type, packageMap map[string]string, pkgOverride string) []string {
-	result := make([]string, len(types))
-	for i, t := range types {
-		result[i] = t.String(packageMap, pkgOverride)
+// paramNameFor returns param's name, unless it is missing or would clash with
+// identifiers the generated code uses; then it returns _param<index> instead.
+func paramNameFor(param *model.Parameter, index int, packageMap map[string]string) string {
+	return identifierFor(param, fmt.Sprintf("_param%d", index), packageMap)
+}
+
+// identifierFor returns the name of param, which is a parameter or a named
+// result, unless it is missing or would clash with identifiers the generated
+// code uses; then it returns generatedName instead.
+func identifierFor(param *model.Parameter, generatedName string, packageMap map[string]string) string {
+	if param.Name == "" || param.Name == "_" || reservedIdentifiers[param.Name] || generatedIdentifierPattern.MatchString(param.Name) {
+		return generatedName
+	}
+	for _, packageName := range packageMap {
+		if param.Name == packageName {
+			return generatedName
+		}
 	}
-	return result
+	return param.Name
+}
+
+// reservedIdentifiers are identifiers that generated mock methods, verifiers and
+// ongoing verifications refer to. Parameters with these names would shadow them.
+var reservedIdentifiers = map[string]bool{
+	// receivers and local variables
+	"mock": true, "verifier": true, "c": true, "params": true, "param": true, "result": true, "ok": true,
+	"methodInvocations": true, "delegate": true, "results": true, "stubber": true, "stubbing": true,
+	// packages
+	"pegomock": true, "reflect": true, "time": true, "fmt": true, "strings": true, "sync": true,
+	// predeclared identifiers
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true, "error": true,
+	"float32": true, "float64": true, "int": true, "int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"true": true, "false": true, "iota": true, "nil": true,
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true, "delete": true, "imag": true,
+	"len": true, "make": true, "max": true, "min": true, "new": true, "panic": true, "print": true, "println": true,
+	"real": true, "recover": true,
 }
 
+// generatedIdentifierPattern matches names the generator synthesizes for
+// parameters and return values.
+var generatedIdentifierPattern = regexp.MustCompile(`^(_param|_?ret)\d+$`)
+
 func addTypesFromMethodParamsTo(typesSet map[string]string, params []*model.Parameter, packageMap map[string]string) {
 	for _, param := range params {
 		switch typedType := param.Type.(type) {
 		case *model.NamedType, *model.PointerType, *model.ArrayType, *model.MapType, *model.ChanType:
+			if !matcherGenerationSupportedFor(typedType) {
+				continue
+			}
 			if _, exists := typesSet[underscoreNameFor(typedType, packageMap)]; !exists {
 				typesSet[underscoreNameFor(typedType, packageMap)] = generateMatcherSourceCode(typedType, packageMap)
 			}
-		case *model.FuncType:
-			// matcher generation for funcs not supported yet
+		case *model.FuncType, *model.StructType, *model.InterfaceType:
+			// matcher generation for funcs, unnamed structs and unnamed interfaces not supported yet
 			// TODO implement
 		case model.PredeclaredType:
 			// skip. These come as part of pegomock.
@@ -425,65 +464,132 @@ func addTypesFromMethodParamsTo(typesSet map[string]string, params []*model.Para
 	}
 }
 
+// matcherGenerationSupportedFor reports whether t contains no func, unnamed
+// struct or unnamed interface types, which matcher generation can't name yet.
+func matcherGenerationSupportedFor(t model.Type) bool {
+	switch typedType := t.(type) {
+	case *model.FuncType, *model.StructType, *model.InterfaceType:
+		return false
+	case *model.PointerType:
+		return matcherGenerationSupportedFor(typedType.Type)
+	case *model.ArrayType:
+		return matcherGenerationSupportedFor(typedType.Type)
+	case *model.ChanType:
+		return matcherGenerationSupportedFor(typedType.Type)
+	case *model.MapType:
+		return matcherGenerationSupportedFor(typedType.Key) && matcherGenerationSupportedFor(typedType.Value)
+	}
+	return true
+}
+
 func generateMatcherSourceCode(t model.Type, packageMap map[string]string) string {
+	if isContextType(t) {
+		return generateContextMatcherSourceCode(t, packageMap)
+	}
 	return fmt.Sprintf(`// Code generated by pegomock. DO NOT EDIT.
 package matchers
 
 import (
 	"reflect"
 	"github.com/petergtz/pegomock"
-	%v
+	%[1]v
 )
 
-func Any%v() %v {
-	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(%v))(nil)).Elem()))
-	var nullValue %v
+func Any%[2]v() %[3]v {
+	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((*(%[3]v))(nil)).Elem()))
+	var nullValue %[3]v
 	return nullValue
 }
 
-func Eq%v(value %v) %v {
+func Eq%[2]v(value %[3]v) %[3]v {
 	pegomock.RegisterMatcher(&pegomock.EqMatcher{Value: value})
-	var nullValue %v
+	var nullValue %[3]v
+	return nullValue
+}
+
+type %[2]vArgumentCaptor struct{ *pegomock.ArgumentCaptor }
+
+func %[2]vCaptor() *%[2]vArgumentCaptor {
+	return &%[2]vArgumentCaptor{pegomock.NewArgumentCaptor(reflect.TypeOf((*(%[3]v))(nil)).Elem())}
+}
+
+func (captor *%[2]vArgumentCaptor) Capture() %[3]v {
+	pegomock.RegisterMatcher(captor.ArgumentCaptor)
+	var nullValue %[3]v
 	return nullValue
 }
+
+func (captor *%[2]vArgumentCaptor) GetValue() %[3]v {
+	value, _ := captor.Value().(%[3]v)
+	return value
+}
+
+func (captor *%[2]vArgumentCaptor) GetAllValues() []%[3]v {
+	capturedValues := captor.Values()
+	values := make([]%[3]v, len(capturedValues))
+	for i, value := range capturedValues {
+		values[i], _ = value.(%[3]v)
+	}
+	return values
+}
 `,
-		optionalPackageOf(t, packageMap),
+		importsFor(t, packageMap),
 		camelcaseNameFor(t, packageMap),
 		t.String(packageMap, ""),
-		t.String(packageMap, ""),
-		t.String(packageMap, ""),
+	)
+}
+
+// MatcherSourceCodeInPackage moves a matcher's source code, as returned by
+// GenerateOutput, from package matchers to the package named after
+// matchersDirName, e.g. when several mock packages keep their matchers in
+// differently named directories.
+func MatcherSourceCodeInPackage(matcherSourceCode, matchersDirName string) string {
+	return strings.Replace(matcherSourceCode, "\npackage matchers\n", "\npackage "+sanitize(matchersDirName)+"\n", 1)
+}
+
+func isContextType(t model.Type) bool {
+	namedType, isNamedType := t.(*model.NamedType)
+	return isNamedType && namedType.Package == "context" && namedType.Type == "Context"
+}
+
+// generateContextMatcherSourceCode delegates to pegomock's built-in context
+// matchers, so that derived contexts match Any and Eq compares by identity.
+func generateContextMatcherSourceCode(t model.Type, packageMap map[string]string) string {
+	return fmt.Sprintf(`// Code generated by pegomock. DO NOT EDIT.
+package matchers
+
+import (
+	"github.com/petergtz/pegomock"
+	%v
+)
 
+func Any%v() %v {
+	return pegomock.AnyContext()
+}
+
+func Eq%v(value %v) %v {
+	return pegomock.EqContext(value)
+}
+`,
+		importsFor(t, packageMap),
 		camelcaseNameFor(t, packageMap),
 		t.String(packageMap, ""),
+
+		camelcaseNameFor(t, packageMap),
 		t.String(packageMap, ""),
 		t.String(packageMap, ""),
 	)
 }
 
-func optionalPackageOf(t model.Type, packageMap map[string]string) string {
-	switch typedType := t.(type) {
-	case model.PredeclaredType:
-		return ""
-	case *model.NamedType:
-		return fmt.Sprintf("%v \"%v\"", packageMap[typedType.Package], vendorCleaned(typedType.Package))
-	case *model.PointerType:
-		return optionalPackageOf(typedType.Type, packageMap)
-	case *model.ArrayType:
-		return optionalPackageOf(typedType.Type, packageMap)
-	case *model.MapType:
-		keyPackage := optionalPackageOf(typedType.Key, packageMap)
-		valuePackage := optionalPackageOf(typedType.Value, packageMap)
-		if keyPackage == valuePackage {
-			return keyPackage
-		}
-		return keyPackage + "\n" + valuePackage
-	case *model.ChanType:
-		return optionalPackageOf(typedType.Type, packageMap)
-		// TODO:
-	// case *model.FuncType:
-	default:
-		panic(fmt.Sprintf("TODO implement optionalPackageOf for: %v\nis type of %T\n", typedType, typedType))
+// importsFor returns the import specs of all packages t refers to, e.g. of
+// net/http and database/sql for map[*http.Request]*sql.Rows, one per line.
+func importsFor(t model.Type, packageMap map[string]string) string {
+	var imports []string
+	for importPath := range model.ImportsOf(t) {
+		imports = append(imports, fmt.Sprintf("%v \"%v\"", packageMap[importPath], vendorCleaned(importPath)))
 	}
+	sort.Strings(imports)
+	return strings.Join(imports, "\n\t")
 }
 
 func spaceSeparatedNameFor(t model.Type, packageMap map[string]string) string {
@@ -532,13 +638,6 @@ func underscoreNameFor(t model.Type, packageMap map[string]string) string {
 	return strings.ToLower(strings.Replace(spaceSeparatedNameFor(t, packageMap), " ", "_", -1))
 }
 
-func (g *generator) p(format string, args ...interface{}) *generator {
-	fmt.Fprintf(&g.buf, format+"\n", args...)
-	return g
-}
-
-func (g *generator) emptyLine() *generator { return g.p("") }
-
 func (g *generator) formattedOutput() []byte {
 	src, err := format.Source(g.buf.Bytes())
 	if err != nil {
@@ -546,5 +645,3 @@ func (g *generator) formattedOutput() []byte {
 	}
 	return src
 }
-
-func join(s []string) string { return strings.Join(s, ", ") }
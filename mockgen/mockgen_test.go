@@ -1,7 +1,14 @@
 package mockgen_test
 
 import (
+	"go/build"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
 	"github.com/petergtz/pegomock/mockgen"
+	"github.com/petergtz/pegomock/model"
 	"github.com/petergtz/pegomock/modelgen/loader"
 
 	. "github.com/onsi/ginkgo"
@@ -13,10 +20,10 @@ var _ = Describe("Mockgen", func() {
 		It("uses correct naming pattern with underscores for keys, and correct types etc. in source code", func() {
 			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
 			Expect(e).NotTo(HaveOccurred())
-			_, matcherSourceCodes := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "test_package", "")
+			_, matcherSourceCodes := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
 
 			Expect(matcherSourceCodes).To(SatisfyAll(
-				HaveLen(11),
+				HaveLen(18),
 				HaveKeyWithValue("http_request", SatisfyAll(
 					ContainSubstring("http \"net/http\""),
 					ContainSubstring("func AnyHttpRequest() http.Request"),
@@ -45,6 +52,13 @@ var _ = Describe("Mockgen", func() {
 				HaveKeyWithValue("recv_chan_of_string", SatisfyAll(
 					ContainSubstring("func AnyRecvChanOfString() <-chan string"),
 				)),
+				HaveKeyWithValue("send_chan_of_test_interface_event", SatisfyAll(
+					ContainSubstring(`test_interface "github.com/petergtz/pegomock/test_interface"`),
+					ContainSubstring("func AnySendChanOfTestInterfaceEvent() chan<- test_interface.Event"),
+				)),
+				HaveKeyWithValue("ptr_to_test_interface_event", SatisfyAll(
+					ContainSubstring("func AnyPtrToTestInterfaceEvent() *test_interface.Event"),
+				)),
 				HaveKeyWithValue("send_chan_of_error", SatisfyAll(
 					ContainSubstring("func AnySendChanOfError() chan<- error"),
 				)),
@@ -55,7 +69,374 @@ var _ = Describe("Mockgen", func() {
 					ContainSubstring("http \"net/http\""),
 					Not(MatchRegexp("http \"net/http\"\\s+http \"net/http\"")),
 				)),
+				HaveKeyWithValue("context_context", SatisfyAll(
+					ContainSubstring("func AnyContextContext() context.Context {\n\treturn pegomock.AnyContext()"),
+					ContainSubstring("func EqContextContext(value context.Context) context.Context {\n\treturn pegomock.EqContext(value)"),
+				)),
+			))
+		})
+
+		It("moves matchers into the package named after their directory", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			_, matcherSourceCodes := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
+
+			Expect(mockgen.MatcherSourceCodeInPackage(matcherSourceCodes["time_time"], "display-matchers")).To(SatisfyAll(
+				ContainSubstring("\npackage display_matchers\n"),
+				ContainSubstring("func AnyTimeTime() time.Time"),
+				Not(ContainSubstring("package matchers")),
+			))
+		})
+	})
+
+	Context("interface name", func() {
+		It("declares a constant with the interface name qualified with its package path and returns it", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring(`const mockDisplayMocksInterface = "github.com/petergtz/pegomock/test_interface.Display"`),
+				ContainSubstring("func (mock *MockDisplay) PegomockInterfaceName() string { return mockDisplayMocksInterface }"),
+			))
+		})
+	})
+
+	Context("method metadata", func() {
+		It("declares the number of params of each method, including variadic ones", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring("func (mock *MockDisplay) MethodMetadata() map[string]pegomock.MethodMetadata {"),
+				MatchRegexp(`"Flash":\s+\{NumParams: 2\},`),
+				MatchRegexp(`"SomeValue":\s+\{NumParams: 0\},`),
+				MatchRegexp(`"NormalAndVariadicParam":\s+\{NumParams: 3, Variadic: true\},`),
+			))
+		})
+	})
+
+	Context("interface compliance check and spies", func() {
+		var ast *model.Package
+
+		BeforeEach(func() {
+			var e error
+			ast, e = loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+		})
+
+		It("imports the interface's package to refer to the interface", func() {
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring(`test_interface "github.com/petergtz/pegomock/test_interface"`),
+				ContainSubstring("var _ test_interface.Display = (*MockDisplay)(nil)"),
+				ContainSubstring("func NewSpyDisplay(delegate test_interface.Display, options ...pegomock.Option) *MockDisplay {"),
+				ContainSubstring("_ret0 := delegate.MultipleParamsAndReturnValue(_param0, _param1)"),
+				ContainSubstring("delegate.NormalAndVariadicParam(_param0, _param1, _param2...)"),
+			))
+		})
+
+		It("refers to the interface without qualifier when generating into the interface's package", func() {
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_interface", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				Not(ContainSubstring(`"github.com/petergtz/pegomock/test_interface"`)),
+				ContainSubstring("var _ Display = (*MockDisplay)(nil)"),
+				ContainSubstring("func NewSpyDisplay(delegate Display, options ...pegomock.Option) *MockDisplay {"),
+				ContainSubstring("func (mock *MockDisplay) Subscribe() <-chan Event {"),
+			))
+		})
+
+		It("makes the mock's helpers unexported if the mock's name is unexported", func() {
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "mockDisplay", "Mock", "", "test_interface", "", "", false, true, "", nil)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring("func newMockDisplay(options ...pegomock.Option) *mockDisplay {"),
+				ContainSubstring("func newSpyDisplay(delegate Display, options ...pegomock.Option) *mockDisplay {"),
+				ContainSubstring("func provideMockDisplay(c pegomock.Container, options ...pegomock.Option) *mockDisplay {"),
+				ContainSubstring("type verifierMockDisplay struct {"),
+				ContainSubstring("type mockDisplay_Show_OngoingVerification struct {"),
+				Not(MatchRegexp(`func (New|Provide|Verifier)`)),
+			))
+		})
+
+		It("omits both if the interface's package is unknown", func() {
+			ast.PkgPath = ""
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).NotTo(ContainSubstring("NewSpyDisplay"))
+			Expect(string(mockSourceCode)).NotTo(ContainSubstring("var _ "))
+		})
+	})
+
+	Context("context-aware methods", func() {
+		It("returns the context's error early only when generating with contextAware", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
+			Expect(string(mockSourceCode)).NotTo(ContainSubstring(".Err()"))
+
+			mockSourceCode, _ = mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", true, false, "", nil)
+			Expect(string(mockSourceCode)).To(ContainSubstring(
+				"func (mock *MockDisplay) ContextAwareCall(ctx context.Context, s string) (string, error) {\n" +
+					"\tif mock == nil {\n" +
+					"\t\tpanic(\"mock must not be nil. Use myMock := NewMockDisplay().\")\n" +
+					"\t}\n" +
+					"\tif ctx != nil && ctx.Err() != nil {\n" +
+					"\t\treturn *new(string), ctx.Err()\n" +
+					"\t}\n"))
+			Expect(strings.Count(string(mockSourceCode), ".Err()")).To(Equal(2), "methods without error result must not be affected")
+		})
+	})
+
+	Context("provide", func() {
+		It("generates a Provide function and registers it as mock provider only when generating with provide", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
+			Expect(string(mockSourceCode)).NotTo(ContainSubstring("ProvideMockDisplay"))
+
+			mockSourceCode, _ = mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, true, "", nil)
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring("func ProvideMockDisplay(c pegomock.Container, options ...pegomock.Option) *MockDisplay {\n"+
+					"\tmock := NewMockDisplay(options...)\n"+
+					"\tc.Register(reflect.TypeOf((*test_interface.Display)(nil)).Elem(), mock)\n"),
+				ContainSubstring("pegomock.RegisterMockProvider(reflect.TypeOf((*test_interface.Display)(nil)).Elem(), "),
+			))
+		})
+	})
+
+	Context("build tag", func() {
+		It("emits no build constraint by default", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).NotTo(ContainSubstring("//go:build"))
+			Expect(string(mockSourceCode)).NotTo(ContainSubstring("// +build"))
+		})
+
+		It("emits the build constraint after the header and before the package clause", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "mock", false, false, "", nil)
+
+			source := string(mockSourceCode)
+			Expect(source).To(MatchRegexp("^// Code generated by pegomock. DO NOT EDIT.\n// Source: irrelevant\n// Interface hash: [0-9a-f]{64}\n// Generator version: .+\n\n//go:build mock\n// \\+build mock\n\npackage test_package\n"))
+			Expect(strings.Index(source, "//go:build mock")).To(BeNumerically("<", strings.Index(source, "package test_package")))
+		})
+
+		It("translates build expressions into legacy +build lines", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "mock && !integration", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).To(ContainSubstring("//go:build mock && !integration\n// +build mock,!integration\n"))
+		})
+
+		It("panics on an invalid build tag", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(func() {
+				mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "mock &&", false, false, "", nil)
+			}).To(Panic())
+		})
+	})
+
+	Context("examples", func() {
+		It("generates an Example function per mocked method in the external test package", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			examplesSourceCode := mockgen.GenerateExamples(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "example.com/test_package", "", false)
+
+			Expect(string(examplesSourceCode)).To(SatisfyAll(
+				ContainSubstring("package test_package_test"),
+				ContainSubstring(`"example.com/test_package"`),
+				ContainSubstring("func ExampleMockDisplay_Show() {"),
+				ContainSubstring("mock := test_package.NewMockDisplay("),
+				ContainSubstring("pegomock.When(mock.SomeValue()).ThenReturn(*new(string))"),
+				ContainSubstring("mock.VerifyWasCalledOnce().Show(*new(string))"),
+				ContainSubstring("// Output:"),
+			))
+		})
+
+		It("puts the examples into the mocks' package if it is a test package already", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			examplesSourceCode := mockgen.GenerateExamples(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package_test", "", "", false)
+
+			Expect(string(examplesSourceCode)).To(SatisfyAll(
+				ContainSubstring("package test_package_test"),
+				ContainSubstring("mock := NewMockDisplay("),
+			))
+		})
+
+		It("panics when the mocks' package cannot be imported", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(func() {
+				mockgen.GenerateExamples(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false)
+			}).To(Panic())
+		})
+	})
+
+	Context("named results", func() {
+		var ast *model.Package
+
+		BeforeEach(func() {
+			ast = &model.Package{Name: "repo", Interfaces: []*model.Interface{{Name: "Repo", Methods: []*model.Method{
+				{Name: "Find", In: []*model.Parameter{{Name: "id", Type: model.PredeclaredType("int")}}, Out: []*model.Parameter{
+					{Name: "user", Type: &model.PointerType{Type: model.PredeclaredType("string")}},
+					{Name: "err", Type: model.PredeclaredType("error")}}},
+				{Name: "Count", Out: []*model.Parameter{
+					{Name: "result", Type: model.PredeclaredType("int")},
+					{Name: "_", Type: model.PredeclaredType("error")}}},
+			}}}}
+		})
+
+		It("declares the results with their names and returns them", func() {
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockRepo", "Mock", "", "repo_test", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring("func (mock *MockRepo) Find(id int) (user *string, err error) {"),
+				ContainSubstring("\t\t\tuser = result[0].(*string)\n"),
+				ContainSubstring("\treturn user, err\n"),
+				Not(ContainSubstring("var user")),
+				Not(ContainSubstring("var ret0")),
+			))
+		})
+
+		It("renames results whose names would clash with the generated code or can't be used", func() {
+			mockSourceCode, _ := mockgen.GenerateOutput(ast, "irrelevant", "MockRepo", "Mock", "", "repo_test", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring("func (mock *MockRepo) Count() (_ret0 int, _ret1 error) {"),
+				ContainSubstring("\treturn _ret0, _ret1\n"),
+			))
+		})
+
+		It("declares the results with their names in standalone mocks", func() {
+			mockSourceCode := mockgen.GenerateStandaloneOutput(ast, "irrelevant", "MockRepo", "Mock", "", "repo_test", "", "", false)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring("func (mock *MockRepo) Find(id int) (user *string, err error) {"),
+				ContainSubstring("\treturn user, err\n"),
+			))
+		})
+	})
+
+	Context("imports", func() {
+		var ast *model.Package
+
+		BeforeEach(func() {
+			request := &model.PointerType{Type: &model.NamedType{Package: "net/http", Type: "Request"}}
+			rows := &model.PointerType{Type: &model.NamedType{Package: "database/sql", Type: "Rows"}}
+			ast = &model.Package{Name: "store", Interfaces: []*model.Interface{{Name: "Store", Methods: []*model.Method{
+				{Name: "Batch", In: []*model.Parameter{{Name: "byRequest", Type: &model.MapType{Key: request, Value: rows}}}},
+				{Name: "Lookup", Out: []*model.Parameter{{Type: &model.InterfaceType{Methods: []*model.Method{
+					{Name: "Header", Out: []*model.Parameter{{Type: &model.NamedType{Package: "net/url", Type: "Values"}}}}}}}}},
+			}}}}
+		})
+
+		It("imports the packages of types nested in other types", func() {
+			mockSourceCode, matcherSourceCodes := mockgen.GenerateOutput(ast, "irrelevant", "MockStore", "Mock", "", "store_test", "", "", false, false, "", nil)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring(`sql "database/sql"`),
+				ContainSubstring(`http "net/http"`),
+				ContainSubstring(`url "net/url"`),
+				ContainSubstring("func (mock *MockStore) Lookup() interface{ Header() url.Values } {"),
+			))
+			Expect(matcherSourceCodes).To(SatisfyAll(
+				HaveLen(1),
+				HaveKeyWithValue("map_of_ptr_to_http_request_to_ptr_to_sql_rows", SatisfyAll(
+					ContainSubstring(`sql "database/sql"`),
+					ContainSubstring(`http "net/http"`),
+				)),
+			))
+		})
+	})
+
+	Context("standalone", func() {
+		It("renders mocks that only import the standard library and the interface's package", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+
+			mockSourceCode := mockgen.GenerateStandaloneOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false)
+
+			file, e := parser.ParseFile(token.NewFileSet(), "mock_display_test.go", mockSourceCode, parser.ImportsOnly)
+			Expect(e).NotTo(HaveOccurred())
+			for _, imp := range file.Imports {
+				importPath, e := strconv.Unquote(imp.Path.Value)
+				Expect(e).NotTo(HaveOccurred())
+				Expect(build.IsLocalImport(importPath)).To(BeFalse())
+				if importPath != "github.com/petergtz/pegomock/test_interface" {
+					pkg, e := build.Import(importPath, "", build.FindOnly)
+					Expect(e).NotTo(HaveOccurred())
+					Expect(pkg.Goroot).To(BeTrue(), importPath+" is not part of the standard library")
+				}
+			}
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				Not(MatchRegexp(`pegomock\.[A-Z]`)),
+				ContainSubstring("var _ test_interface.Display = (*MockDisplay)(nil)"),
+				ContainSubstring("func (stubbing *MockDisplay_SomeValue_OngoingStubbing) ThenReturn(ret0 string) {"),
+				ContainSubstring("func (verifier *VerifierMockDisplay) Show(_param0 string) {"),
+			))
+		})
+
+		It("makes the mock's helpers unexported if the mock's name is unexported", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+
+			mockSourceCode := mockgen.GenerateStandaloneOutput(ast, "irrelevant", "mockDisplay", "Mock", "", "test_interface", "", "", false)
+
+			Expect(string(mockSourceCode)).To(SatisfyAll(
+				ContainSubstring("func newMockDisplay() *mockDisplay {"),
+				ContainSubstring("type stubberMockDisplay struct {"),
+				ContainSubstring("type verifierMockDisplay struct {"),
+				ContainSubstring("type mockDisplayCall struct {"),
 			))
 		})
 	})
+
+	Context("custom template", func() {
+		It("renders the mock with the given template and template data", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			mockSourceCode, matcherSourceCodes := mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false,
+				`// {{index .Data "header"}}
+package {{.PackageName}}
+{{range .Mocks}}{{$mock := .MockName}}
+// {{.MockName}} mocks {{.PackagePath}}.{{.InterfaceName}}
+type {{.MockName}} struct{}
+{{range .Methods}}{{if eq .Name "Flash" "SomeValue"}}
+func (*{{$mock}}) {{.Name}}({{.ParamsDeclaration}}) ({{.ReturnTypes}}) { panic("not implemented") }
+{{end}}{{end}}{{end}}`,
+				map[string]string{"header": "my custom header"})
+
+			Expect(string(mockSourceCode)).To(Equal(`// my custom header
+package test_package
+
+// MockDisplay mocks github.com/petergtz/pegomock/test_interface.Display
+type MockDisplay struct{}
+
+func (*MockDisplay) Flash(_param0 string, _param1 int) { panic("not implemented") }
+
+func (*MockDisplay) SomeValue() string { panic("not implemented") }
+`))
+			Expect(matcherSourceCodes).To(HaveLen(18))
+		})
+
+		It("panics on an invalid template", func() {
+			ast, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(func() {
+				mockgen.GenerateOutput(ast, "irrelevant", "MockDisplay", "Mock", "", "test_package", "", "", false, false, "package {{.PackageName", nil)
+			}).To(Panic())
+		})
+	})
 })
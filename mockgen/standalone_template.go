@@ -0,0 +1,218 @@
+package mockgen
+
+// builtinStandaloneTemplate renders mocks that only import the standard library
+// and the packages of the mocked interfaces' types, for projects that cannot
+// depend on pegomock. Each mock carries its own minimal runtime: it records
+// invocations, returns what was stubbed via When().<Method>(...).ThenReturn(...)
+// for equal params, and checks invocation counts via the Verify methods. There
+// are no matchers, in-order verifications, captors or spies.
+const builtinStandaloneTemplate = `// Code generated by pegomock. DO NOT EDIT.
+// Source: {{.Source}}
+// Interface hash: {{.InterfaceHash}}
+// Generator version: {{.GeneratorVersion}}
+
+{{range .BuildConstraint}}{{.}}
+{{end}}
+package {{.PackageName}}
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+{{- range .Imports}}
+	{{.Name}} {{printf "%q" .Path}}
+{{- end}}
+{{- range .InterfaceImports}}
+	{{.Name}} {{printf "%q" .Path}}
+{{- end}}
+{{- range .DotImports}}
+	. {{printf "%q" .}}
+{{- end}}
+)
+{{range .Mocks}}{{template "mock" .}}{{end}}
+
+{{- define "mock"}}{{$mock := .MockName}}{{$constructor := .ConstructorName}}{{$verifier := .VerifierName}}{{$stubber := .StubberName}}{{$call := .CallTypeName}}
+// {{$call}} is an invocation of, or a stubbing for, a method of {{$mock}}.
+type {{$call}} struct {
+	method  string
+	params  []interface{}
+	results []interface{}
+}
+
+// {{$mock}} is a standalone mock: it works without the pegomock library.
+type {{$mock}} struct {
+	fail        func(message string, callerSkip ...int)
+	mutex       sync.Mutex
+	invocations []{{$call}}
+	stubbings   []{{$call}}
+}
+
+func {{$constructor}}() *{{$mock}} {
+	return &{{$mock}}{}
+}
+{{if .InterfaceType}}
+var _ {{.InterfaceType}} = (*{{$mock}})(nil)
+{{end}}
+// SetFailHandler sets the function failed verifications are reported to, e.g.
+// func(message string, _ ...int) { t.Fatal(message) }. Without one, they panic.
+func (mock *{{$mock}}) SetFailHandler(fh func(message string, callerSkip ...int)) { mock.fail = fh }
+
+func (mock *{{$mock}}) invoke(method string, params []interface{}) []interface{} {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	mock.invocations = append(mock.invocations, {{$call}}{method: method, params: params})
+	for i := len(mock.stubbings) - 1; i >= 0; i-- {
+		if mock.stubbings[i].method == method && mock.paramsEqual(mock.stubbings[i].params, params) {
+			return mock.stubbings[i].results
+		}
+	}
+	return nil
+}
+
+func (mock *{{$mock}}) stub(method string, params []interface{}, results []interface{}) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	mock.stubbings = append(mock.stubbings, {{$call}}{method: method, params: params, results: results})
+}
+
+func (mock *{{$mock}}) verify(verifier *{{$verifier}}, method string, params []interface{}) {
+	mock.mutex.Lock()
+	count := 0
+	for _, invocation := range mock.invocations {
+		if invocation.method == method && mock.paramsEqual(params, invocation.params) {
+			count++
+		}
+	}
+	mock.mutex.Unlock()
+	if count == verifier.times || (verifier.atLeast && count > verifier.times) {
+		return
+	}
+	formattedParams := make([]string, len(params))
+	for i, param := range params {
+		formattedParams[i] = fmt.Sprintf("%#v", param)
+	}
+	expectation := fmt.Sprint(verifier.times)
+	if verifier.atLeast {
+		expectation = "at least " + expectation
+	}
+	message := fmt.Sprintf("Mock invocation count for %v(%v) does not match expectation.\n\n\tExpected: %v; but got: %v",
+		method, strings.Join(formattedParams, ", "), expectation, count)
+	if mock.fail == nil {
+		panic(message)
+	}
+	mock.fail(message)
+}
+
+// paramsEqual compares params with reflect.DeepEqual, except for functions,
+// which are compared by pointer identity.
+func (mock *{{$mock}}) paramsEqual(expected []interface{}, actual []interface{}) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		expectedValue, actualValue := reflect.ValueOf(expected[i]), reflect.ValueOf(actual[i])
+		if expectedValue.Kind() == reflect.Func && !expectedValue.IsNil() {
+			if actualValue.Kind() != reflect.Func || actualValue.IsNil() || expectedValue.Pointer() != actualValue.Pointer() {
+				return false
+			}
+		} else if !reflect.DeepEqual(expected[i], actual[i]) {
+			return false
+		}
+	}
+	return true
+}
+{{range .Methods}}
+func (mock *{{$mock}}) {{.Name}}({{.ParamsDeclaration}}) ({{.ReturnsDeclaration}}) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := {{$constructor}}().")
+	}
+{{- if .ContextAware}}
+	if {{(index .Params 0).Name}} != nil && {{(index .Params 0).Name}}.Err() != nil {
+		return {{.ContextErrReturnValues}}
+	}
+{{- end}}
+	{{template "params" .}}
+	{{if .Returns}}results := {{end}}mock.invoke("{{.Name}}", params)
+{{- if .Returns}}
+{{- range .Returns}}{{if not .Named}}
+	var {{.Name}} {{.Type}}
+{{- end}}{{end}}
+	if results != nil {
+{{- range $i, $ret := .Returns}}
+		if results[{{$i}}] != nil {
+			{{$ret.Name}} = results[{{$i}}].({{$ret.Type}})
+		}
+{{- end}}
+	}
+	return {{range $i, $ret := .Returns}}{{if $i}}, {{end}}{{$ret.Name}}{{end}}
+{{- end}}
+}
+{{end}}
+// When starts stubbing a method, e.g. mock.When().Method(params).ThenReturn(results).
+// Calls with params equal to the stubbed ones return the stubbed results, calls
+// without stubbing return zero values. Later stubbings take precedence.
+func (mock *{{$mock}}) When() *{{$stubber}} {
+	return &{{$stubber}}{mock: mock}
+}
+
+type {{$stubber}} struct {
+	mock *{{$mock}}
+}
+{{range .Methods}}{{if .Returns}}{{$ongoingStubbing := printf "%v_%v_OngoingStubbing" $mock .Name}}
+func (stubber *{{$stubber}}) {{.Name}}({{.ParamsDeclaration}}) *{{$ongoingStubbing}} {
+	{{template "params" .}}
+	return &{{$ongoingStubbing}}{mock: stubber.mock, params: params}
+}
+
+type {{$ongoingStubbing}} struct {
+	mock   *{{$mock}}
+	params []interface{}
+}
+
+func (stubbing *{{$ongoingStubbing}}) ThenReturn({{range $i, $ret := .Returns}}{{if $i}}, {{end}}ret{{$i}} {{$ret.Type}}{{end}}) {
+	stubbing.mock.stub("{{.Name}}", stubbing.params, []interface{}{ {{- range $i, $ret := .Returns}}{{if $i}}, {{end}}ret{{$i}}{{end -}} })
+}
+{{end}}{{end}}
+func (mock *{{$mock}}) VerifyWasCalledOnce() *{{$verifier}} {
+	return &{{$verifier}}{mock: mock, times: 1}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledAtLeastOnce() *{{$verifier}} {
+	return &{{$verifier}}{mock: mock, times: 1, atLeast: true}
+}
+
+func (mock *{{$mock}}) VerifyWasNeverCalled() *{{$verifier}} {
+	return &{{$verifier}}{mock: mock, times: 0}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledExactly(numInvocations int) *{{$verifier}} {
+	return &{{$verifier}}{mock: mock, times: numInvocations}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledAtLeast(numInvocations int) *{{$verifier}} {
+	return &{{$verifier}}{mock: mock, times: numInvocations, atLeast: true}
+}
+
+type {{$verifier}} struct {
+	mock    *{{$mock}}
+	times   int
+	atLeast bool
+}
+{{range .Methods}}
+func (verifier *{{$verifier}}) {{.Name}}({{.ParamsDeclaration}}) {
+	{{template "params" .}}
+	verifier.mock.verify(verifier, "{{.Name}}", params)
+}
+{{end}}
+{{- end}}
+
+{{- define "params"}}
+{{- if .IsVariadic}}params := []interface{}{ {{- range $i, $param := .Params}}{{if not $param.Variadic}}{{if $i}}, {{end}}{{$param.Name}}{{end}}{{end -}} }
+	for _, param := range {{.VariadicParam.Name}} {
+		params = append(params, param)
+	}
+{{- else}}params := []interface{}{ {{- .ParamNames -}} }
+{{- end}}
+{{- end}}
+`
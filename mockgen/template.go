@@ -0,0 +1,706 @@
+// Copyright 2015 Peter Goetz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mockgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TemplateData is what the built-in template and custom templates (see
+// pegomock generate --template) are executed with.
+type TemplateData struct {
+	Source string
+	// InterfaceHash identifies the method sets of the mocked interfaces, see
+	// model.Package.InterfaceHash. Templates should put it into the header as
+	// "// Interface hash: <hash>", so stale mocks can be detected.
+	InterfaceHash string
+	// GeneratorVersion is the version of pegomock generating the mocks, see
+	// version.Generator. Templates should put it into the header as
+	// "// Generator version: <version>", so pegomock doctor can tell which
+	// mocks were generated by another version.
+	GeneratorVersion string
+	PackageName      string
+	// BuildConstraint holds the //go:build and // +build lines to emit before the
+	// package clause, if any.
+	BuildConstraint []string
+	Imports         []Import
+	// InterfaceImports holds imports that are only needed to refer to the
+	// mocked interfaces themselves, see Mock.InterfaceType.
+	InterfaceImports []Import
+	DotImports       []string
+	Mocks            []Mock
+	// Data holds the key-value pairs passed via --template-data.
+	Data map[string]string
+}
+
+type Import struct {
+	Name string
+	Path string
+}
+
+type Mock struct {
+	InterfaceName string
+	MockName      string
+	// PackagePath is the import path of the interface's package. It is empty
+	// when the mock is generated from a source file whose package could not be
+	// resolved.
+	PackagePath string
+	// InterfaceType is how the generated code refers to the interface, e.g.
+	// "io.Reader". It is empty if the interface cannot be referred to, because
+	// its package is unknown and the mock is generated into a different package.
+	InterfaceType string
+	// Provide is set if mocks are generated with --provide. The mock then gets a
+	// Provide<MockName> function registering it in a pegomock.Container, if
+	// InterfaceType is known.
+	Provide bool
+	Methods []Method
+}
+
+// InterfaceNameConstant returns the name of the constant holding the mocked
+// interface's name qualified with its package path, e.g.
+// mockDisplayMocksInterface for MockDisplay.
+func (m Mock) InterfaceNameConstant() string {
+	return strings.ToLower(m.MockName[:1]) + m.MockName[1:] + "MocksInterface"
+}
+
+// SpyConstructorName returns the name of the constructor for spies, e.g.
+// NewSpyDisplay for MockDisplay and newSpyDisplay for mockDisplay.
+func (m Mock) SpyConstructorName() string {
+	return m.helperName("NewSpy", strings.TrimPrefix(strings.TrimPrefix(m.MockName, "Mock"), "mock"))
+}
+
+// ConstructorName returns the name of the mock's constructor, e.g.
+// NewMockDisplay for MockDisplay and newMockDisplay for mockDisplay.
+func (m Mock) ConstructorName() string { return m.helperName("New", m.MockName) }
+
+// VerifierName returns the name of the mock's verifier type, e.g.
+// VerifierMockDisplay for MockDisplay and verifierMockDisplay for mockDisplay.
+func (m Mock) VerifierName() string { return m.helperName("Verifier", m.MockName) }
+
+// ProviderName returns the name of the function registering the mock in a
+// pegomock.Container, e.g. ProvideMockDisplay for MockDisplay and
+// provideMockDisplay for mockDisplay.
+func (m Mock) ProviderName() string { return m.helperName("Provide", m.MockName) }
+
+// StubberName returns the name of the type standalone mocks are stubbed with,
+// e.g. StubberMockDisplay for MockDisplay and stubberMockDisplay for mockDisplay.
+func (m Mock) StubberName() string { return m.helperName("Stubber", m.MockName) }
+
+// CallTypeName returns the name of the type standalone mocks record invocations
+// and stubbings with, e.g. mockDisplayCall for MockDisplay.
+func (m Mock) CallTypeName() string {
+	return strings.ToLower(m.MockName[:1]) + m.MockName[1:] + "Call"
+}
+
+// helperName prefixes name with prefix, such that the result is exported if and
+// only if the mock is. This way, mocks with unexported names, e.g. ones
+// generated into the interface's own package, don't add to its API.
+func (m Mock) helperName(prefix string, name string) string {
+	if name == "" || m.MockName == "" || unicode.IsUpper([]rune(m.MockName)[0]) {
+		return prefix + name
+	}
+	return strings.ToLower(prefix[:1]) + prefix[1:] + strings.ToUpper(name[:1]) + name[1:]
+}
+
+type Method struct {
+	Name    string
+	Params  []Param
+	Returns []Return
+	// ContextAware is set if mocks are generated with --context-aware and the
+	// method takes a context.Context as first parameter and returns an error as
+	// last result. The mock then returns the context's error right away if the
+	// context is done, without recording the invocation or consulting stubbings.
+	ContextAware bool
+	// NonStandardErrorPosition is set if the method returns an error, but not as
+	// its last result. The mock method then gets a warning comment, because
+	// ThenReturn takes the error at an unusual position.
+	NonStandardErrorPosition bool
+}
+
+type Param struct {
+	Name string
+	// Type is the element type for a variadic parameter.
+	Type     string
+	Variadic bool
+}
+
+type Return struct {
+	// Name is the name of the result if the method's results are named, or the
+	// name of the local variable the mock returns it in otherwise.
+	Name  string
+	Named bool
+	Type  string
+	// UndirectedChanType is set for directional channel types and holds the
+	// equivalent bidirectional channel type.
+	UndirectedChanType string
+}
+
+// CapturedType is the type under which arguments for this parameter are captured.
+func (p Param) CapturedType() string {
+	if p.Variadic {
+		return "[]" + p.Type
+	}
+	return p.Type
+}
+
+// IsVariadic reports whether the last parameter of m is variadic.
+func (m Method) IsVariadic() bool {
+	return len(m.Params) > 0 && m.Params[len(m.Params)-1].Variadic
+}
+
+// VariadicParam returns the last parameter of m. Only meaningful if m.IsVariadic().
+func (m Method) VariadicParam() Param {
+	return m.Params[len(m.Params)-1]
+}
+
+// ParamsDeclaration returns the parameter list as used in a method signature.
+func (m Method) ParamsDeclaration() string {
+	params := make([]string, len(m.Params))
+	for i, param := range m.Params {
+		if param.Variadic {
+			params[i] = param.Name + " ..." + param.Type
+		} else {
+			params[i] = param.Name + " " + param.Type
+		}
+	}
+	return strings.Join(params, ", ")
+}
+
+// NumParamsExpression returns a Go expression for the number of params an
+// invocation of m passes to Invoke, e.g. "1+len(v)" for Log(format string, v ...interface{}).
+func (m Method) NumParamsExpression() string {
+	if m.IsVariadic() {
+		return fmt.Sprintf("%v+len(%v)", len(m.Params)-1, m.VariadicParam().Name)
+	}
+	return fmt.Sprint(len(m.Params))
+}
+
+// ParamNames returns the comma-separated names of all parameters.
+func (m Method) ParamNames() string {
+	names := make([]string, len(m.Params))
+	for i, param := range m.Params {
+		names[i] = param.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// CapturedTypes returns the comma-separated CapturedType of all parameters.
+func (m Method) CapturedTypes() string {
+	types := make([]string, len(m.Params))
+	for i, param := range m.Params {
+		types[i] = param.CapturedType()
+	}
+	return strings.Join(types, ", ")
+}
+
+// CapturedZeroValues returns the comma-separated zero values of all CapturedTypes.
+func (m Method) CapturedZeroValues() string {
+	values := make([]string, len(m.Params))
+	for i, param := range m.Params {
+		values[i] = "*new(" + param.CapturedType() + ")"
+	}
+	return strings.Join(values, ", ")
+}
+
+// ReturnTypes returns the comma-separated return types.
+func (m Method) ReturnTypes() string {
+	types := make([]string, len(m.Returns))
+	for i, ret := range m.Returns {
+		types[i] = ret.Type
+	}
+	return strings.Join(types, ", ")
+}
+
+// ReturnsDeclaration returns the results as declared in a method signature,
+// i.e. with their names if they are named.
+func (m Method) ReturnsDeclaration() string {
+	returns := make([]string, len(m.Returns))
+	for i, ret := range m.Returns {
+		if ret.Named {
+			returns[i] = ret.Name + " " + ret.Type
+		} else {
+			returns[i] = ret.Type
+		}
+	}
+	return strings.Join(returns, ", ")
+}
+
+// ContextErrReturnValues returns the values a context-aware method returns when
+// its context is done: zero values followed by the context's error.
+func (m Method) ContextErrReturnValues() string {
+	values := make([]string, len(m.Returns))
+	for i, ret := range m.Returns[:len(m.Returns)-1] {
+		values[i] = "*new(" + ret.Type + ")"
+	}
+	values[len(values)-1] = m.Params[0].Name + ".Err()"
+	return strings.Join(values, ", ")
+}
+
+// ReflectReturnTypes returns the return types as comma-separated reflect.Type expressions.
+func (m Method) ReflectReturnTypes() string {
+	types := make([]string, len(m.Returns))
+	for i, ret := range m.Returns {
+		types[i] = fmt.Sprintf("reflect.TypeOf((*%v)(nil)).Elem()", ret.Type)
+	}
+	return strings.Join(types, ", ")
+}
+
+// ExampleArgs returns the comma-separated placeholder arguments the generated
+// examples pass to the method: zero values, a background context for
+// context-aware methods, and no variadic arguments.
+func (m Method) ExampleArgs() string {
+	var args []string
+	for i, param := range m.Params {
+		switch {
+		case param.Variadic:
+		case i == 0 && m.ContextAware:
+			args = append(args, strings.TrimSuffix(param.Type, "Context")+"Background()")
+		default:
+			args = append(args, "*new("+param.Type+")")
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
+// ExampleReturnValues returns the comma-separated zero values of the return types.
+func (m Method) ExampleReturnValues() string {
+	values := make([]string, len(m.Returns))
+	for i, ret := range m.Returns {
+		values[i] = "*new(" + ret.Type + ")"
+	}
+	return strings.Join(values, ", ")
+}
+
+// ExampleResultBlanks returns a blank identifier for each return value.
+func (m Method) ExampleResultBlanks() string {
+	return strings.TrimSuffix(strings.Repeat("_, ", len(m.Returns)), ", ")
+}
+
+const builtinMockTemplate = `// Code generated by pegomock. DO NOT EDIT.
+// Source: {{.Source}}
+// Interface hash: {{.InterfaceHash}}
+// Generator version: {{.GeneratorVersion}}
+
+{{range .BuildConstraint}}{{.}}
+{{end}}
+package {{.PackageName}}
+
+import (
+	"reflect"
+	"time"
+{{- range .Imports}}
+	{{.Name}} {{printf "%q" .Path}}
+{{- end}}
+{{- range .InterfaceImports}}
+	{{.Name}} {{printf "%q" .Path}}
+{{- end}}
+{{- range .DotImports}}
+	. {{printf "%q" .}}
+{{- end}}
+)
+{{range .Mocks}}{{template "mock" .}}{{end}}
+
+{{- define "mock"}}{{$mock := .MockName}}{{$constructor := .ConstructorName}}{{$verifier := .VerifierName}}
+type {{$mock}} struct {
+	fail func(message string, callerSkip ...int)
+{{- range .Methods}}
+	handle{{.Name}} pegomock.MethodHandle
+{{- end}}
+}
+
+func {{$constructor}}(options ...pegomock.Option) *{{$mock}} {
+	mock := &{{$mock}}{}
+	// Registering the mock right away makes its creation its start, see pegomock.Within.
+	pegomock.GetGenericMockFrom(mock)
+	for _, option := range options {
+		option.Apply(mock)
+	}
+	return mock
+}
+
+{{if .InterfaceType}}
+var _ {{.InterfaceType}} = (*{{$mock}})(nil)
+{{if .Provide}}
+// {{.ProviderName}} creates a {{$mock}} and registers it in c as {{.InterfaceType}}.
+func {{.ProviderName}}(c pegomock.Container, options ...pegomock.Option) *{{$mock}} {
+	mock := {{$constructor}}(options...)
+	c.Register(reflect.TypeOf((*{{.InterfaceType}})(nil)).Elem(), mock)
+	return mock
+}
+
+func init() {
+	pegomock.RegisterMockProvider(reflect.TypeOf((*{{.InterfaceType}})(nil)).Elem(), func(c pegomock.Container) pegomock.Mock {
+		return {{.ProviderName}}(c)
+	})
+}
+{{end}}
+// {{.SpyConstructorName}} returns a {{$mock}} that passes calls to methods without
+// matching stubbing through to delegate. Calls are recorded and can be verified
+// as with any other mock. It panics if delegate is nil.
+func {{.SpyConstructorName}}(delegate {{.InterfaceType}}, options ...pegomock.Option) *{{$mock}} {
+	if delegate == nil {
+		panic("{{.SpyConstructorName}} requires a delegate to pass calls through to, but got nil")
+	}
+	mock := {{$constructor}}(options...)
+	pegomock.GetGenericMockFrom(mock).SetFallback(func(methodName string, params []pegomock.Param) pegomock.ReturnValues {
+		switch methodName {
+{{- range .Methods}}
+		case "{{.Name}}":
+{{- range $i, $param := .Params}}
+{{- if $param.Variadic}}
+			_param{{$i}} := make([]{{$param.Type}}, len(params)-{{$i}})
+			for i, param := range params[{{$i}}:] {
+				if param != nil {
+					_param{{$i}}[i] = param.({{$param.Type}})
+				}
+			}
+{{- else}}
+			var _param{{$i}} {{$param.Type}}
+			if params[{{$i}}] != nil {
+				_param{{$i}} = params[{{$i}}].({{$param.Type}})
+			}
+{{- end}}
+{{- end}}
+{{- if .Returns}}
+			{{range $i, $ret := .Returns}}{{if $i}}, {{end}}_ret{{$i}}{{end}} := delegate.{{.Name}}({{template "spyArgs" .}})
+			return pegomock.ReturnValues{ {{- range $i, $ret := .Returns}}{{if $i}}, {{end}}_ret{{$i}}{{end -}} }
+{{- else}}
+			delegate.{{.Name}}({{template "spyArgs" .}})
+			return nil
+{{- end}}
+{{- end}}
+		}
+		return nil
+	})
+	return mock
+}
+{{end}}
+func (mock *{{$mock}}) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
+func (mock *{{$mock}}) FailHandler() pegomock.FailHandler      { return mock.fail }
+
+{{if .PackagePath}}
+const {{.InterfaceNameConstant}} = "{{.PackagePath}}.{{.InterfaceName}}"
+
+func (mock *{{$mock}}) PegomockInterfaceName() string { return {{.InterfaceNameConstant}} }
+{{end}}
+func (mock *{{$mock}}) MethodMetadata() map[string]pegomock.MethodMetadata {
+	return map[string]pegomock.MethodMetadata{
+{{- range .Methods}}
+		"{{.Name}}": {NumParams: {{len .Params}}{{if .IsVariadic}}, Variadic: true{{end}}},
+{{- end}}
+	}
+}
+
+// InvocationsOf returns copies of the invocations of methodName in the order
+// they were made. See pegomock.GenericMock.InvocationsOf.
+func (mock *{{$mock}}) InvocationsOf(methodName string) []pegomock.Invocation {
+	return pegomock.GetGenericMockFrom(mock).InvocationsOf(methodName)
+}
+
+// InvocationCount returns how often methodName was invoked, without failing like
+// a verification. See pegomock.GenericMock.InvocationCount.
+func (mock *{{$mock}}) InvocationCount(methodName string) int {
+	return pegomock.GetGenericMockFrom(mock).InvocationCount(methodName)
+}
+
+// ResetForNextTest makes the mock behave like a new one, e.g. for the next case
+// of a table-driven test. See pegomock.GenericMock.ResetForNextTest for what is
+// reset and what survives.
+func (mock *{{$mock}}) ResetForNextTest(options ...pegomock.ResetOption) {
+	pegomock.GetGenericMockFrom(mock).ResetForNextTest(options...)
+}
+{{range .Methods}}
+{{- if .NonStandardErrorPosition}}
+// WARNING: non-standard error position. {{.Name}} returns ({{.ReturnTypes}}), i.e. an error
+// that is not its last return value. Mind the order of the values passed to ThenReturn.
+{{- end}}
+func (mock *{{$mock}}) {{.Name}}({{.ParamsDeclaration}}) ({{.ReturnsDeclaration}}) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := {{$constructor}}().")
+	}
+{{- if .ContextAware}}
+	if {{(index .Params 0).Name}} != nil && {{(index .Params 0).Name}}.Err() != nil {
+		return {{.ContextErrReturnValues}}
+	}
+{{- end}}
+	{{template "invocationParams" .}}
+	{{if .Returns}}result := {{end}}mock.handle{{.Name}}.Invoke(mock, "{{.Name}}", params, []reflect.Type{ {{- .ReflectReturnTypes -}} })
+{{- if .Returns}}
+{{- range .Returns}}{{if not .Named}}
+	var {{.Name}} {{.Type}}
+{{- end}}{{end}}
+	if len(result) != 0 {
+{{- range $i, $ret := .Returns}}
+		if result[{{$i}}] != nil {
+{{- if $ret.UndirectedChanType}}
+			var ok bool
+			{{$ret.Name}}, ok = result[{{$i}}].({{$ret.UndirectedChanType}})
+			if !ok {
+				{{$ret.Name}} = result[{{$i}}].({{$ret.Type}})
+			}
+{{- else}}
+			{{$ret.Name}} = result[{{$i}}].({{$ret.Type}})
+{{- end}}
+		}
+{{- end}}
+	}
+	return {{range $i, $ret := .Returns}}{{if $i}}, {{end}}{{$ret.Name}}{{end}}
+{{- end}}
+}
+{{- if .Returns}}
+
+// {{$mock}}_{{.Name}}_Returns holds the return values of {{.Name}}. Passed to
+// ThenReturnStruct, it gets the number and types of return values checked at
+// compile time.
+type {{$mock}}_{{.Name}}_Returns struct {
+{{- range $i, $ret := .Returns}}
+	Ret{{$i}} {{$ret.Type}}
+{{- end}}
+}
+
+func (returns {{$mock}}_{{.Name}}_Returns) ReturnValues() pegomock.ReturnValues {
+	return pegomock.ReturnValues{ {{- range $i, $ret := .Returns}}{{if $i}}, {{end}}returns.Ret{{$i}}{{end -}} }
+}
+{{- end}}
+
+// {{$mock}}_{{.Name}}_Answer answers calls of {{.Name}}. Passed to ThenAnswer, it
+// gets its params and results checked at compile time.
+type {{$mock}}_{{.Name}}_Answer func({{.ParamsDeclaration}}) ({{.ReturnTypes}})
+
+func (answer {{$mock}}_{{.Name}}_Answer) Answer(params []pegomock.Param) pegomock.ReturnValues {
+{{- range $i, $param := .Params}}
+{{- if $param.Variadic}}
+	_param{{$i}} := make([]{{$param.Type}}, len(params)-{{$i}})
+	for i, param := range params[{{$i}}:] {
+		if param != nil {
+			_param{{$i}}[i] = param.({{$param.Type}})
+		}
+	}
+{{- else}}
+	var _param{{$i}} {{$param.Type}}
+	if params[{{$i}}] != nil {
+		_param{{$i}} = params[{{$i}}].({{$param.Type}})
+	}
+{{- end}}
+{{- end}}
+{{- if .Returns}}
+	{{range $i, $ret := .Returns}}{{if $i}}, {{end}}_ret{{$i}}{{end}} := answer({{template "spyArgs" .}})
+	return pegomock.ReturnValues{ {{- range $i, $ret := .Returns}}{{if $i}}, {{end}}_ret{{$i}}{{end -}} }
+{{- else}}
+	answer({{template "spyArgs" .}})
+	return nil
+{{- end}}
+}
+{{end}}
+func (mock *{{$mock}}) VerifyWasCalledOnce() *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: pegomock.Times(1),
+	}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledAtLeastOnce() *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: pegomock.AtLeast(1),
+	}
+}
+
+func (mock *{{$mock}}) VerifyWasNeverCalled() *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: pegomock.Never(),
+	}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledExactly(numInvocations int) *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: pegomock.Times(numInvocations),
+	}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledAtLeast(numInvocations int) *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: pegomock.AtLeast(numInvocations),
+	}
+}
+
+func (mock *{{$mock}}) VerifyWasCalled(invocationCountMatcher pegomock.Matcher) *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: invocationCountMatcher,
+	}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledInOrder(invocationCountMatcher pegomock.Matcher, inOrderContext *pegomock.InOrderContext) *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: invocationCountMatcher,
+		inOrderContext:         inOrderContext,
+	}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledEventually(invocationCountMatcher pegomock.Matcher, timeout time.Duration) *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: invocationCountMatcher,
+		timeout:                timeout,
+	}
+}
+
+func (mock *{{$mock}}) VerifyWasCalledWithin(timeout time.Duration) *{{$verifier}} {
+	return &{{$verifier}}{
+		mock:                   mock,
+		invocationCountMatcher: pegomock.Within(timeout),
+	}
+}
+
+type {{$verifier}} struct {
+	mock                   *{{$mock}}
+	invocationCountMatcher pegomock.Matcher
+	inOrderContext         *pegomock.InOrderContext
+	timeout                time.Duration
+}
+{{range .Methods}}{{$ongoingVerification := printf "%v_%v_OngoingVerification" $mock .Name}}
+func (verifier *{{$verifier}}) {{.Name}}({{.ParamsDeclaration}}) *{{$ongoingVerification}} {
+	{{template "params" .}}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "{{.Name}}", params, verifier.timeout)
+	return &{{$ongoingVerification}}{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+{{if .Params}}
+func (verifier *{{$verifier}}) {{.Name}}_GetCapturedArguments() ({{.CapturedTypes}}) {
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).VerifyAnyParams(verifier.inOrderContext, verifier.invocationCountMatcher, "{{.Name}}", verifier.timeout)
+	if len(methodInvocations) == 0 {
+		pegomock.GetGenericMockFrom(verifier.mock).ReportNothingCaptured("{{.Name}}")
+		return {{.CapturedZeroValues}}
+	}
+	{{.ParamNames}} := (&{{$ongoingVerification}}{mock: verifier.mock, methodInvocations: methodInvocations}).GetAllCapturedArguments()
+	return {{range $i, $param := .Params}}{{if $i}}, {{end}}{{$param.Name}}[len({{$param.Name}})-1]{{end}}
+}
+
+func (verifier *{{$verifier}}) {{.Name}}_GetAllCapturedArguments() ({{range $i, $param := .Params}}{{if $i}}, {{end}}_param{{$i}} []{{$param.CapturedType}}{{end}}) {
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).VerifyAnyParams(verifier.inOrderContext, verifier.invocationCountMatcher, "{{.Name}}", verifier.timeout)
+	return (&{{$ongoingVerification}}{mock: verifier.mock, methodInvocations: methodInvocations}).GetAllCapturedArguments()
+}
+{{end}}
+type {{$ongoingVerification}} struct {
+	mock              *{{$mock}}
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *{{$ongoingVerification}}) GetCapturedArguments() ({{.CapturedTypes}}) {
+{{- if .Params}}
+	if len(c.methodInvocations) == 0 {
+		pegomock.GetGenericMockFrom(c.mock).ReportNothingCaptured("{{.Name}}")
+		return {{.CapturedZeroValues}}
+	}
+	{{.ParamNames}} := c.GetAllCapturedArguments()
+	return {{range $i, $param := .Params}}{{if $i}}, {{end}}{{$param.Name}}[len({{$param.Name}})-1]{{end}}
+{{- end}}
+}
+
+func (c *{{$ongoingVerification}}) GetAllCapturedArguments() ({{range $i, $param := .Params}}{{if $i}}, {{end}}_param{{$i}} []{{$param.CapturedType}}{{end}}) {
+{{- if .Params}}
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+{{- range $i, $param := .Params}}
+{{- if $param.Variadic}}
+		_param{{$i}} = make([][]{{$param.Type}}, len(c.methodInvocations))
+		for u := 0; u < len(c.methodInvocations); u++ {
+			_param{{$i}}[u] = make([]{{$param.Type}}, len(params)-{{$i}})
+			for x := {{$i}}; x < len(params); x++ {
+				if params[x][u] != nil {
+					_param{{$i}}[u][x-{{$i}}] = params[x][u].({{$param.Type}})
+				}
+			}
+		}
+{{- else}}
+		_param{{$i}} = make([]{{$param.Type}}, len(c.methodInvocations))
+		for u, param := range params[{{$i}}] {
+			if param != nil {
+				_param{{$i}}[u] = param.({{$param.Type}})
+			}
+		}
+{{- end}}
+{{- end}}
+	}
+	return
+{{- end}}
+}
+{{end}}
+{{- end}}
+
+{{- define "spyArgs"}}
+{{- range $i, $param := .Params}}{{if $i}}, {{end}}_param{{$i}}{{if $param.Variadic}}...{{end}}{{end}}
+{{- end}}
+
+{{- define "invocationParams"}}params := mock.handle{{.Name}}.Params(mock, "{{.Name}}", {{.NumParamsExpression}})
+{{- range $i, $param := .Params}}
+{{- if $param.Variadic}}
+	for i, param := range {{$param.Name}} {
+		params[{{$i}}+i] = param
+	}
+{{- else}}
+	params[{{$i}}] = {{$param.Name}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- define "params"}}
+{{- if .IsVariadic}}params := []pegomock.Param{ {{- range $i, $param := .Params}}{{if not $param.Variadic}}{{if $i}}, {{end}}{{$param.Name}}{{end}}{{end -}} }
+	for _, param := range {{.VariadicParam.Name}} {
+		params = append(params, param)
+	}
+{{- else}}params := []pegomock.Param{ {{- .ParamNames -}} }
+{{- end}}
+{{- end}}
+`
+
+// builtinExamplesTemplate is executed with an ExamplesData value.
+const builtinExamplesTemplate = `// Code generated by pegomock. DO NOT EDIT.
+// Source: {{.Source}}
+
+{{range .BuildConstraint}}{{.}}
+{{end}}
+package {{.ExamplesPackageName}}
+
+import (
+{{- range .ExampleImports}}
+	{{.Name}} {{printf "%q" .Path}}
+{{- end}}
+)
+{{range $mock := .Mocks}}{{range .Methods}}
+// Example{{$mock.MockName}}_{{.Name}} shows how to stub and verify {{.Name}}. Tests
+// pass the arguments and return values they need instead of the placeholders.
+func Example{{$mock.MockName}}_{{.Name}}() {
+	mock := {{$.MockQualifier}}{{$mock.ConstructorName}}(pegomock.WithFailHandler(func(message string, _ ...int) { panic(message) }))
+{{- if .Returns}}
+	pegomock.When(mock.{{.Name}}({{.ExampleArgs}})).ThenReturn({{.ExampleReturnValues}})
+
+	{{.ExampleResultBlanks}} = mock.{{.Name}}({{.ExampleArgs}})
+{{- else}}
+
+	mock.{{.Name}}({{.ExampleArgs}})
+{{- end}}
+
+	mock.VerifyWasCalledOnce().{{.Name}}({{.ExampleArgs}})
+	// Output:
+}
+{{end}}{{end}}`
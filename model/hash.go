@@ -0,0 +1,93 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InterfaceHash returns a hash of the method sets of all interfaces in pkg. It
+// only changes if a method, its parameter types or its result types change, so
+// it can tell whether mocks generated from pkg are up to date. Parameter names
+// and the order of methods don't affect it. That way, models of the same
+// interfaces yield the same hash no matter which model generator built them.
+func (pkg *Package) InterfaceHash() string {
+	interfaces := make([]string, len(pkg.Interfaces))
+	for i, intf := range pkg.Interfaces {
+		methods := make([]string, len(intf.Methods))
+		for j, m := range intf.Methods {
+			methods[j] = m.Name + canonicalSignature(m.In, m.Variadic, m.Out, pkg.PkgPath)
+		}
+		sort.Strings(methods)
+		interfaces[i] = "interface " + intf.Name + "\n" + strings.Join(methods, "\n")
+	}
+	sort.Strings(interfaces)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(interfaces, "\n"))))
+}
+
+func canonicalSignature(in []*Parameter, variadic *Parameter, out []*Parameter, pkgPath string) string {
+	params := make([]string, len(in))
+	for i, p := range in {
+		params[i] = canonicalType(p.Type, pkgPath)
+	}
+	if variadic != nil {
+		params = append(params, "..."+canonicalType(variadic.Type, pkgPath))
+	}
+	results := make([]string, len(out))
+	for i, p := range out {
+		results[i] = canonicalType(p.Type, pkgPath)
+	}
+	return "(" + strings.Join(params, ", ") + ") (" + strings.Join(results, ", ") + ")"
+}
+
+// canonicalType renders t with all named types qualified by their full import
+// path, and with aliases of predeclared types resolved, since model generators
+// differ in both respects. Named types without package belong to pkgPath.
+func canonicalType(t Type, pkgPath string) string {
+	switch t := t.(type) {
+	case PredeclaredType:
+		switch t {
+		case "byte":
+			return "uint8"
+		case "rune":
+			return "int32"
+		case "any":
+			return "interface{}"
+		}
+		return string(t)
+	case *NamedType:
+		if t.Package == "" {
+			return pkgPath + "." + t.Type
+		}
+		return t.Package + "." + t.Type
+	case *PointerType:
+		return "*" + canonicalType(t.Type, pkgPath)
+	case *ArrayType:
+		if t.Len > -1 {
+			return fmt.Sprintf("[%d]", t.Len) + canonicalType(t.Type, pkgPath)
+		}
+		return "[]" + canonicalType(t.Type, pkgPath)
+	case *MapType:
+		return "map[" + canonicalType(t.Key, pkgPath) + "]" + canonicalType(t.Value, pkgPath)
+	case *ChanType:
+		return (&ChanType{Dir: t.Dir, Type: PredeclaredType(canonicalType(t.Type, pkgPath))}).String(nil, "")
+	case *FuncType:
+		return "func" + canonicalSignature(t.In, t.Variadic, t.Out, pkgPath)
+	case *StructType:
+		fields := make([]*StructField, len(t.Fields))
+		for i, f := range t.Fields {
+			fields[i] = &StructField{Name: f.Name, Type: PredeclaredType(canonicalType(f.Type, pkgPath)), Tag: f.Tag}
+		}
+		return (&StructType{Fields: fields}).String(nil, "")
+	case *InterfaceType:
+		methods := make([]string, len(t.Methods))
+		for i, m := range t.Methods {
+			methods[i] = m.Name + canonicalSignature(m.In, m.Variadic, m.Out, pkgPath)
+		}
+		sort.Strings(methods)
+		return "interface{ " + strings.Join(methods, "; ") + " }"
+	default:
+		return t.String(nil, "")
+	}
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// The JSON form of a Package mirrors its structure. Types are objects with a
+// "kind" telling which of their other fields apply, e.g.
+//
+//	{"kind": "named", "package": "net/http", "name": "Request"}
+//	{"kind": "slice", "elem": {"kind": "predeclared", "name": "string"}}
+//
+// This way, models can be stored, e.g. as contracts of services whose Go
+// source can't be imported, and mocks generated from them later.
+
+// WriteJSON writes pkg to w in its JSON form.
+func (pkg *Package) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(pkg)
+}
+
+// ReadJSON reads a Package written by WriteJSON from r.
+func ReadJSON(r io.Reader) (*Package, error) {
+	var pkg Package
+	if err := json.NewDecoder(r).Decode(&pkg); err != nil {
+		return nil, err
+	}
+	if pkg.Name == "" {
+		return nil, fmt.Errorf("model has no package name")
+	}
+	return &pkg, nil
+}
+
+type jsonParameter struct {
+	Name string    `json:"name,omitempty"`
+	Type *jsonType `json:"type"`
+}
+
+func (p *Parameter) MarshalJSON() ([]byte, error) {
+	t, err := jsonTypeOf(p.Type)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonParameter{Name: p.Name, Type: t})
+}
+
+func (p *Parameter) UnmarshalJSON(data []byte) error {
+	var jp jsonParameter
+	if err := json.Unmarshal(data, &jp); err != nil {
+		return err
+	}
+	t, err := jp.Type.modelType()
+	if err != nil {
+		return fmt.Errorf("parameter %v: %v", jp.Name, err)
+	}
+	p.Name, p.Type = jp.Name, t
+	return nil
+}
+
+type jsonStructField struct {
+	Name string    `json:"name,omitempty"`
+	Type *jsonType `json:"type"`
+	Tag  string    `json:"tag,omitempty"`
+}
+
+func (f *StructField) MarshalJSON() ([]byte, error) {
+	t, err := jsonTypeOf(f.Type)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonStructField{Name: f.Name, Type: t, Tag: f.Tag})
+}
+
+func (f *StructField) UnmarshalJSON(data []byte) error {
+	var jf jsonStructField
+	if err := json.Unmarshal(data, &jf); err != nil {
+		return err
+	}
+	t, err := jf.Type.modelType()
+	if err != nil {
+		return fmt.Errorf("field %v: %v", jf.Name, err)
+	}
+	f.Name, f.Type, f.Tag = jf.Name, t, jf.Tag
+	return nil
+}
+
+// jsonType is the JSON form of a Type.
+type jsonType struct {
+	Kind string `json:"kind"`
+	// Name is set for predeclared and named types.
+	Name string `json:"name,omitempty"`
+	// Package is set for named types.
+	Package string `json:"package,omitempty"`
+	// Len is set for arrays.
+	Len int `json:"len,omitempty"`
+	// Dir is set for directional channels.
+	Dir ChanDir `json:"dir,omitempty"`
+	// Key is set for maps.
+	Key *jsonType `json:"key,omitempty"`
+	// Elem is set for arrays, slices, channels, pointers and maps.
+	Elem *jsonType `json:"elem,omitempty"`
+	// In, Variadic and Out are set for funcs.
+	In       []*Parameter `json:"in,omitempty"`
+	Variadic *Parameter   `json:"variadic,omitempty"`
+	Out      []*Parameter `json:"out,omitempty"`
+	// Fields is set for structs.
+	Fields []*StructField `json:"fields,omitempty"`
+	// Methods is set for interfaces.
+	Methods []*Method `json:"methods,omitempty"`
+}
+
+func jsonTypeOf(t Type) (*jsonType, error) {
+	switch t := t.(type) {
+	case PredeclaredType:
+		return &jsonType{Kind: "predeclared", Name: string(t)}, nil
+	case *NamedType:
+		return &jsonType{Kind: "named", Package: t.Package, Name: t.Type}, nil
+	case *ArrayType:
+		elem, err := jsonTypeOf(t.Type)
+		if t.Len == -1 {
+			return &jsonType{Kind: "slice", Elem: elem}, err
+		}
+		return &jsonType{Kind: "array", Len: t.Len, Elem: elem}, err
+	case *ChanType:
+		elem, err := jsonTypeOf(t.Type)
+		return &jsonType{Kind: "chan", Dir: t.Dir, Elem: elem}, err
+	case *PointerType:
+		elem, err := jsonTypeOf(t.Type)
+		return &jsonType{Kind: "pointer", Elem: elem}, err
+	case *MapType:
+		key, err := jsonTypeOf(t.Key)
+		if err != nil {
+			return nil, err
+		}
+		elem, err := jsonTypeOf(t.Value)
+		return &jsonType{Kind: "map", Key: key, Elem: elem}, err
+	case *FuncType:
+		return &jsonType{Kind: "func", In: t.In, Variadic: t.Variadic, Out: t.Out}, nil
+	case *StructType:
+		return &jsonType{Kind: "struct", Fields: t.Fields}, nil
+	case *InterfaceType:
+		return &jsonType{Kind: "interface", Methods: t.Methods}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", t)
+	}
+}
+
+func (t *jsonType) modelType() (Type, error) {
+	if t == nil {
+		return nil, fmt.Errorf("missing type")
+	}
+	switch t.Kind {
+	case "predeclared":
+		return PredeclaredType(t.Name), nil
+	case "named":
+		return &NamedType{Package: t.Package, Type: t.Name}, nil
+	case "slice":
+		elem, err := t.Elem.modelType()
+		return &ArrayType{Len: -1, Type: elem}, err
+	case "array":
+		elem, err := t.Elem.modelType()
+		return &ArrayType{Len: t.Len, Type: elem}, err
+	case "chan":
+		elem, err := t.Elem.modelType()
+		return &ChanType{Dir: t.Dir, Type: elem}, err
+	case "pointer":
+		elem, err := t.Elem.modelType()
+		return &PointerType{Type: elem}, err
+	case "map":
+		key, err := t.Key.modelType()
+		if err != nil {
+			return nil, err
+		}
+		elem, err := t.Elem.modelType()
+		return &MapType{Key: key, Value: elem}, err
+	case "func":
+		return &FuncType{In: t.In, Variadic: t.Variadic, Out: t.Out}, nil
+	case "struct":
+		return &StructType{Fields: t.Fields}, nil
+	case "interface":
+		return &InterfaceType{Methods: t.Methods}, nil
+	default:
+		return nil, fmt.Errorf("unknown kind of type %q", t.Kind)
+	}
+}
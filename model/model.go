@@ -18,14 +18,16 @@ package model
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 )
 
 // Package is a Go package. It may be a subset.
 type Package struct {
-	Name       string
-	Interfaces []*Interface
-	DotImports []string
+	Name       string       `json:"name"`
+	PkgPath    string       `json:"pkgPath,omitempty"` // empty if the source file's package could not be resolved
+	Interfaces []*Interface `json:"interfaces"`
+	DotImports []string     `json:"dotImports,omitempty"`
 }
 
 func (pkg *Package) Print(w io.Writer) {
@@ -44,10 +46,19 @@ func (pkg *Package) Imports() map[string]bool {
 	return im
 }
 
+// ImportsOf returns the imports needed by t as a set of import paths. Like
+// Imports, it follows the element, key, value, field, parameter and result
+// types t is composed of.
+func ImportsOf(t Type) map[string]bool {
+	im := make(map[string]bool)
+	t.addImports(im)
+	return im
+}
+
 // Interface is a Go interface.
 type Interface struct {
-	Name    string
-	Methods []*Method
+	Name    string    `json:"name"`
+	Methods []*Method `json:"methods"`
 }
 
 func (intf *Interface) Print(w io.Writer) {
@@ -65,9 +76,10 @@ func (intf *Interface) addImports(im map[string]bool) {
 
 // Method is a single method of an interface.
 type Method struct {
-	Name     string
-	In, Out  []*Parameter
-	Variadic *Parameter // may be nil
+	Name     string       `json:"name"`
+	In       []*Parameter `json:"in,omitempty"`
+	Out      []*Parameter `json:"out,omitempty"`
+	Variadic *Parameter   `json:"variadic,omitempty"` // may be nil
 }
 
 func (m *Method) Print(w io.Writer) {
@@ -204,6 +216,28 @@ func (ft *FuncType) addImports(im map[string]bool) {
 	}
 }
 
+// InterfaceType is an unnamed interface type with methods, e.g.
+// interface{ Header() http.Header }. Unnamed interfaces without methods are
+// the predeclared type interface{}.
+type InterfaceType struct {
+	Methods []*Method
+}
+
+func (it *InterfaceType) String(pm map[string]string, pkgOverride string) string {
+	methods := make([]string, len(it.Methods))
+	for i, m := range it.Methods {
+		signature := (&FuncType{In: m.In, Out: m.Out, Variadic: m.Variadic}).String(pm, pkgOverride)
+		methods[i] = m.Name + strings.TrimPrefix(signature, "func")
+	}
+	return "interface{ " + strings.Join(methods, "; ") + " }"
+}
+
+func (it *InterfaceType) addImports(im map[string]bool) {
+	for _, m := range it.Methods {
+		m.addImports(im)
+	}
+}
+
 // MapType is a map type.
 type MapType struct {
 	Key, Value Type
@@ -247,6 +281,41 @@ func (pt *PointerType) String(pm map[string]string, pkgOverride string) string {
 }
 func (pt *PointerType) addImports(im map[string]bool) { pt.Type.addImports(im) }
 
+// StructType is an unnamed struct type, e.g. struct{ Name string }.
+type StructType struct {
+	Fields []*StructField
+}
+
+// StructField is a field of a StructType.
+type StructField struct {
+	Name string // empty for embedded fields
+	Type Type
+	Tag  string
+}
+
+func (st *StructType) String(pm map[string]string, pkgOverride string) string {
+	fields := make([]string, len(st.Fields))
+	for i, f := range st.Fields {
+		fields[i] = f.Type.String(pm, pkgOverride)
+		if f.Name != "" {
+			fields[i] = f.Name + " " + fields[i]
+		}
+		if f.Tag != "" {
+			fields[i] += " " + strconv.Quote(f.Tag)
+		}
+	}
+	if len(fields) == 0 {
+		return "struct{}"
+	}
+	return "struct{ " + strings.Join(fields, "; ") + " }"
+}
+
+func (st *StructType) addImports(im map[string]bool) {
+	for _, f := range st.Fields {
+		f.Type.addImports(im)
+	}
+}
+
 // PredeclaredType is a predeclared type such as "int".
 type PredeclaredType string
 
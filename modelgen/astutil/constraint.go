@@ -0,0 +1,60 @@
+// Package astutil holds helpers for the model generators that work on the AST
+// of interface declarations.
+package astutil
+
+import (
+	"go/ast"
+)
+
+// IsTypeConstraint reports whether it can only be used as a type constraint,
+// because it has type terms, such as unions, ~T approximations, non-interface
+// types or comparable, directly or via embedded interfaces. Such interfaces
+// cannot be mocked. resolveEmbedded returns the declaration of an embedded
+// interface, or nil if it is unknown. Unknown embedded interfaces are assumed
+// to be mockable.
+func IsTypeConstraint(it *ast.InterfaceType, resolveEmbedded func(ast.Expr) *ast.InterfaceType) bool {
+	return isTypeConstraint(it, resolveEmbedded, make(map[*ast.InterfaceType]bool))
+}
+
+func isTypeConstraint(it *ast.InterfaceType, resolveEmbedded func(ast.Expr) *ast.InterfaceType, visited map[*ast.InterfaceType]bool) bool {
+	if it == nil || it.Methods == nil || visited[it] {
+		return false
+	}
+	visited[it] = true
+	for _, field := range it.Methods.List {
+		if len(field.Names) != 0 {
+			continue
+		}
+		switch embedded := field.Type.(type) {
+		case *ast.BinaryExpr, *ast.UnaryExpr:
+			// union of type terms or ~T approximation
+			return true
+		case *ast.InterfaceType:
+			if isTypeConstraint(embedded, resolveEmbedded, visited) {
+				return true
+			}
+		case *ast.Ident, *ast.SelectorExpr:
+			if embeddedInterface := resolveEmbedded(embedded); embeddedInterface != nil {
+				if isTypeConstraint(embeddedInterface, resolveEmbedded, visited) {
+					return true
+				}
+			} else if ident, isIdent := embedded.(*ast.Ident); isIdent && predeclaredTypeTerms[ident.Name] {
+				return true
+			}
+		default:
+			// a non-interface type, e.g. []byte, as single type term
+			return true
+		}
+	}
+	return false
+}
+
+// predeclaredTypeTerms are the predeclared identifiers that make an interface
+// embedding them a type constraint.
+var predeclaredTypeTerms = map[string]bool{
+	"comparable": true,
+	"bool":       true, "string": true, "byte": true, "rune": true, "uintptr": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
@@ -23,11 +23,14 @@ import (
 	"go/parser"
 	"go/token"
 	"log"
+	"os/exec"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/petergtz/pegomock/model"
+	"github.com/petergtz/pegomock/modelgen/astutil"
 )
 
 var (
@@ -72,7 +75,8 @@ func ParseFile(source string) (*model.Package, error) {
 	}
 	p.addAuxInterfacesFromFile("", file) // this file
 
-	pkg, err := p.parseFile(file)
+	pkgPath := importPathOfDir(filepath.Dir(source))
+	pkg, err := p.parseFile(file, pkgPath)
 	if err != nil {
 		return nil, err
 	}
@@ -80,9 +84,26 @@ func ParseFile(source string) (*model.Package, error) {
 	for path := range dotImports {
 		pkg.DotImports = append(pkg.DotImports, path)
 	}
+	pkg.PkgPath = pkgPath
 	return pkg, nil
 }
 
+// importPathOfDir returns the import path of the package in dir, or "" if it
+// cannot be determined, e.g. because dir is neither in GOPATH nor in a module.
+func importPathOfDir(dir string) string {
+	cmd := exec.Command("go", "list", "-find", "-f", "{{.ImportPath}}", ".")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	importPath := strings.TrimSpace(string(output))
+	if importPath == "." || strings.HasPrefix(importPath, "_") {
+		return ""
+	}
+	return importPath
+}
+
 type fileParser struct {
 	fileSet *token.FileSet
 	imports map[string]string // package name => import path
@@ -127,7 +148,10 @@ func (p *fileParser) addAuxInterfacesFromFile(pkg string, file *ast.File) {
 	}
 }
 
-func (p *fileParser) parseFile(file *ast.File) (*model.Package, error) {
+// parseFile parses the interfaces of file. Exported types declared in the file's
+// own package are qualified with pkgPath, so mocks generated into other packages
+// can refer to them.
+func (p *fileParser) parseFile(file *ast.File, pkgPath string) (*model.Package, error) {
 	allImports := importsOfFile(file)
 	// Don't stomp imports provided by -imports. Those should take precedence.
 	for pkg, path := range allImports {
@@ -147,7 +171,11 @@ func (p *fileParser) parseFile(file *ast.File) (*model.Package, error) {
 
 	var is []*model.Interface
 	for ni := range iterInterfaces(file) {
-		i, err := p.parseInterface(ni.name.String(), "", ni.it)
+		if astutil.IsTypeConstraint(ni.it, p.resolveEmbeddedInterface) {
+			log.Printf("Skipping %v: it is a type constraint, not a mockable interface", ni.name)
+			continue
+		}
+		i, err := p.parseInterface(ni.name.String(), pkgPath, ni.it)
 		if err != nil {
 			return nil, err
 		}
@@ -159,6 +187,20 @@ func (p *fileParser) parseFile(file *ast.File) (*model.Package, error) {
 	}, nil
 }
 
+// resolveEmbeddedInterface returns the declaration of an interface embedded as
+// name or pkg.name, or nil if it is unknown.
+func (p *fileParser) resolveEmbeddedInterface(embedded ast.Expr) *ast.InterfaceType {
+	switch v := embedded.(type) {
+	case *ast.Ident:
+		return p.auxInterfaces[""][v.Name]
+	case *ast.SelectorExpr:
+		if pkg, ok := v.X.(*ast.Ident); ok {
+			return p.auxInterfaces[pkg.Name][v.Sel.Name]
+		}
+	}
+	return nil
+}
+
 func (p *fileParser) parseInterface(name, pkg string, it *ast.InterfaceType) (*model.Interface, error) {
 	intf := &model.Interface{Name: name}
 	for _, field := range it.Methods.List {
@@ -326,10 +368,14 @@ func (p *fileParser) parseType(pkg string, typ ast.Expr) (model.Type, error) {
 			return model.PredeclaredType(v.Name), nil
 		}
 	case *ast.InterfaceType:
-		if v.Methods != nil && len(v.Methods.List) > 0 {
-			return nil, p.errorf(v.Pos(), "can't handle non-empty unnamed interface types")
+		if v.Methods == nil || len(v.Methods.List) == 0 {
+			return model.PredeclaredType("interface{}"), nil
+		}
+		intf, err := p.parseInterface("", pkg, v)
+		if err != nil {
+			return nil, err
 		}
-		return model.PredeclaredType("interface{}"), nil
+		return &model.InterfaceType{Methods: intf.Methods}, nil
 	case *ast.MapType:
 		key, err := p.parseType(pkg, v.Key)
 		if err != nil {
@@ -354,10 +400,31 @@ func (p *fileParser) parseType(pkg string, typ ast.Expr) (model.Type, error) {
 		}
 		return &model.PointerType{Type: t}, nil
 	case *ast.StructType:
-		if v.Fields != nil && len(v.Fields.List) > 0 {
-			return nil, p.errorf(v.Pos(), "can't handle non-empty unnamed struct types")
+		if v.Fields == nil || len(v.Fields.List) == 0 {
+			return model.PredeclaredType("struct{}"), nil
+		}
+		st := &model.StructType{}
+		for _, f := range v.Fields.List {
+			t, err := p.parseType(pkg, f.Type)
+			if err != nil {
+				return nil, err
+			}
+			var tag string
+			if f.Tag != nil {
+				if tag, err = strconv.Unquote(f.Tag.Value); err != nil {
+					return nil, p.errorf(f.Tag.Pos(), "bad struct tag: %v", err)
+				}
+			}
+			if len(f.Names) == 0 {
+				st.Fields = append(st.Fields, &model.StructField{Type: t, Tag: tag})
+			}
+			for _, name := range f.Names {
+				st.Fields = append(st.Fields, &model.StructField{Name: name.Name, Type: t, Tag: tag})
+			}
 		}
-		return model.PredeclaredType("struct{}"), nil
+		return st, nil
+	case *ast.ParenExpr:
+		return p.parseType(pkg, v.X)
 	}
 
 	return nil, fmt.Errorf("don't know how to parse type %T", typ)
@@ -26,7 +26,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
 	"text/template"
 
 	"github.com/petergtz/pegomock/model"
@@ -37,7 +39,16 @@ var (
 	execOnly = flag.String("exec_only", "", "(reflect mode) If set, execute this reflection program.")
 )
 
-func Reflect(importPath string, symbols []string) (*model.Package, error) {
+// typeConstraintUsePattern matches the compiler error for an interface that can
+// only be used as a type constraint, e.g. "cannot use type pkg_.Number outside a
+// type constraint: interface contains type constraints".
+var typeConstraintUsePattern = regexp.MustCompile(`cannot use type (?:\w+\.)?(\w+) outside a type constraint`)
+
+// Reflect builds the model of the interfaces symbols of the package importPath by
+// compiling and running a program that reflects on them. The program is built
+// with buildTags, so interfaces and types guarded by build constraints are seen
+// as they are with these tags.
+func Reflect(importPath string, symbols []string, buildTags ...string) (*model.Package, error) {
 	// TODO: sanity check arguments
 	progPath := *execOnly
 	if *execOnly == "" {
@@ -75,11 +86,18 @@ func Reflect(importPath string, symbols []string) (*model.Package, error) {
 		}
 
 		// Build the program.
-		cmd := exec.Command("go", "build", "-o", progBinary, progSource)
+		buildArgs := []string{"build", "-o", progBinary}
+		if len(buildTags) > 0 {
+			buildArgs = append(buildArgs, "-tags", strings.Join(buildTags, ","))
+		}
+		cmd := exec.Command("go", append(buildArgs, progSource)...)
 		cmd.Dir = tmpDir
 		stderr := &bytes.Buffer{}
 		cmd.Stderr = stderr
 		if err := cmd.Run(); err != nil {
+			if match := typeConstraintUsePattern.FindStringSubmatch(stderr.String()); match != nil {
+				return nil, fmt.Errorf("%v is a type constraint, not a mockable interface", match[1])
+			}
 			return nil, fmt.Errorf("%v caused by:\n%v", err, stderr.String())
 		}
 		progPath = filepath.Join(tmpDir, progBinary)
@@ -99,6 +117,7 @@ func Reflect(importPath string, symbols []string) (*model.Package, error) {
 	if err := gob.NewDecoder(&stdout).Decode(&pkg); err != nil {
 		return nil, err
 	}
+	pkg.PkgPath = importPath
 	return &pkg, nil
 }
 
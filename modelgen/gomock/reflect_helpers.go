@@ -15,6 +15,8 @@ func init() {
 	gob.Register(&model.MapType{})
 	gob.Register(&model.NamedType{})
 	gob.Register(&model.PointerType{})
+	gob.Register(&model.StructType{})
+	gob.Register(&model.InterfaceType{})
 	gob.Register(model.PredeclaredType(""))
 }
 
@@ -153,6 +155,16 @@ func typeFromType(t reflect.Type) (model.Type, error) {
 		if t == errorType {
 			return model.PredeclaredType("error"), nil
 		}
+		it := &model.InterfaceType{}
+		for i := 0; i < t.NumMethod(); i++ {
+			method := t.Method(i)
+			in, variadic, out, err := funcArgsFromType(method.Type)
+			if err != nil {
+				return nil, err
+			}
+			it.Methods = append(it.Methods, &model.Method{Name: method.Name, In: in, Variadic: variadic, Out: out})
+		}
+		return it, nil
 	case reflect.Map:
 		kt, err := typeFromType(t.Key())
 		if err != nil {
@@ -175,8 +187,22 @@ func typeFromType(t reflect.Type) (model.Type, error) {
 		if t.NumField() == 0 {
 			return model.PredeclaredType("struct{}"), nil
 		}
+		st := &model.StructType{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			ft, err := typeFromType(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			name := field.Name
+			if field.Anonymous {
+				name = ""
+			}
+			st.Fields = append(st.Fields, &model.StructField{Name: name, Type: ft, Tag: string(field.Tag)})
+		}
+		return st, nil
 	}
 
-	// TODO: Struct, UnsafePointer
+	// TODO: UnsafePointer
 	return nil, fmt.Errorf("can't yet turn %v (%v) into a model.Type", t, t.Kind())
 }
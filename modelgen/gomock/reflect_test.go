@@ -20,4 +20,18 @@ var _ = Describe("reflect", func() {
 		_, e := gomock.Reflect("github.com/petergtz/vendored_package", []string{"Interface"})
 		Expect(e).NotTo(HaveOccurred())
 	})
+
+	It("reports type constraints as not mockable", func() {
+		_, e := gomock.Reflect("github.com/petergtz/pegomock/modelgen/test_data/constraints", []string{"Greeter", "Number"})
+		Expect(e).To(MatchError("Number is a type constraint, not a mockable interface"))
+	})
+})
+
+var _ = Describe("ParseFile", func() {
+	It("skips type constraints", func() {
+		pkg, e := gomock.ParseFile("../test_data/constraints/constraints.go")
+		Expect(e).NotTo(HaveOccurred())
+		Expect(pkg.Interfaces).To(HaveLen(1))
+		Expect(pkg.Interfaces[0].Name).To(Equal("Greeter"))
+	})
 })
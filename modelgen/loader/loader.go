@@ -4,25 +4,67 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/types"
 
 	"github.com/petergtz/pegomock/model"
+	"github.com/petergtz/pegomock/modelgen/astutil"
 	"golang.org/x/tools/go/loader"
 )
 
-func GenerateModel(importPath string, interfaceName string) (*model.Package, error) {
-	var conf loader.Config
+// GenerateModel builds the model of the interface interfaceName of the package
+// importPath from its source. Files are selected by their build constraints as
+// if buildTags were set.
+func GenerateModel(importPath string, interfaceName string, buildTags ...string) (*model.Package, error) {
+	conf := loader.Config{Build: buildContextWith(buildTags)}
 	conf.Import(importPath)
 	program, e := conf.Load()
 	if e != nil {
 		panic(e)
 	}
-	info := program.Imported[importPath]
+	return modelFrom(program.Imported[importPath], importPath, interfaceName)
+}
+
+// GenerateModelWithTests is like GenerateModel, but also loads the package's
+// _test.go files, so interfaces declared in them are found, too. If the
+// interface is declared in the external test package, the returned package is
+// that one, e.g. "foo_test" with import path "example.com/foo_test".
+func GenerateModelWithTests(importPath string, interfaceName string, buildTags ...string) (*model.Package, error) {
+	conf := loader.Config{Build: buildContextWith(buildTags)}
+	conf.ImportWithTests(importPath)
+	program, e := conf.Load()
+	if e != nil {
+		panic(e)
+	}
+	pkg, e := modelFrom(program.Imported[importPath], importPath, interfaceName)
+	if e == nil {
+		return pkg, nil
+	}
+	for _, info := range program.Created {
+		if info.Pkg.Path() == importPath+"_test" {
+			if pkg, e := modelFrom(info, info.Pkg.Path(), interfaceName); e == nil {
+				return pkg, nil
+			}
+		}
+	}
+	return nil, e
+}
 
+// buildContextWith returns the default build context with buildTags added.
+func buildContextWith(buildTags []string) *build.Context {
+	buildContext := build.Default
+	buildContext.BuildTags = append(append([]string(nil), buildContext.BuildTags...), buildTags...)
+	return &buildContext
+}
+
+func modelFrom(info *loader.PackageInfo, importPath string, interfaceName string) (*model.Package, error) {
 	for def := range info.Defs {
 		if def.Name == interfaceName && def.Obj.Kind == ast.Typ {
 			interfacetype, ok := def.Obj.Decl.(*ast.TypeSpec).Type.(*ast.InterfaceType)
 			if ok {
+				if astutil.IsTypeConstraint(interfacetype, resolveEmbeddedInterface) {
+					return nil, fmt.Errorf("%v is a type constraint, not a mockable interface", interfaceName)
+				}
 				g := &modelGenerator{info: info}
 				iface := &model.Interface{
 					Name:    interfaceName,
@@ -30,6 +72,7 @@ func GenerateModel(importPath string, interfaceName string) (*model.Package, err
 				}
 				return &model.Package{
 					Name:       info.Pkg.Name(),
+					PkgPath:    importPath,
 					Interfaces: []*model.Interface{iface},
 				}, nil
 			}
@@ -39,6 +82,39 @@ func GenerateModel(importPath string, interfaceName string) (*model.Package, err
 	return nil, errors.New("Did not find interface name \"" + interfaceName + "\"")
 }
 
+// resolveEmbeddedInterface returns the declaration of an interface embedded by
+// name from the same package, or nil if it is unknown.
+func resolveEmbeddedInterface(embedded ast.Expr) *ast.InterfaceType {
+	ident, isIdent := embedded.(*ast.Ident)
+	if !isIdent || ident.Obj == nil {
+		return nil
+	}
+	typeSpec, isTypeSpec := ident.Obj.Decl.(*ast.TypeSpec)
+	if !isTypeSpec {
+		return nil
+	}
+	interfaceType, _ := typeSpec.Type.(*ast.InterfaceType)
+	return interfaceType
+}
+
+// InterfaceFromTypes builds the model of the interface called name from its
+// type-checked representation, e.g. as obtained via golang.org/x/tools/go/packages.
+func InterfaceFromTypes(name string, iface *types.Interface) *model.Interface {
+	g := &modelGenerator{}
+	modelInterface := &model.Interface{Name: name}
+	for i := 0; i < iface.NumMethods(); i++ {
+		signature := iface.Method(i).Type().(*types.Signature)
+		in, variadic := g.generateInParamsFrom(signature)
+		modelInterface.Methods = append(modelInterface.Methods, &model.Method{
+			Name:     iface.Method(i).Name(),
+			In:       in,
+			Variadic: variadic,
+			Out:      g.generateOutParamsFrom(signature.Results()),
+		})
+	}
+	return modelInterface
+}
+
 type modelGenerator struct {
 	info *loader.PackageInfo
 }
@@ -158,9 +234,26 @@ func (g *modelGenerator) modelTypeFrom(typesType types.Type) model.Type {
 			Type:    typedTyp.Obj().Name(),
 		}
 	case *types.Interface:
-		return model.PredeclaredType(typedTyp.String())
+		if typedTyp.NumMethods() == 0 {
+			return model.PredeclaredType(typedTyp.String())
+		}
+		return &model.InterfaceType{Methods: InterfaceFromTypes("", typedTyp).Methods}
+	case *types.Struct:
+		if typedTyp.NumFields() == 0 {
+			return model.PredeclaredType("struct{}")
+		}
+		st := &model.StructType{}
+		for i := 0; i < typedTyp.NumFields(); i++ {
+			field := typedTyp.Field(i)
+			name := field.Name()
+			if field.Embedded() {
+				name = ""
+			}
+			st.Fields = append(st.Fields, &model.StructField{Name: name, Type: g.modelTypeFrom(field.Type()), Tag: typedTyp.Tag(i)})
+		}
+		return st
 	case *types.Signature:
-		in, variadic := g.generateInParamsFrom(typedTyp.Params())
+		in, variadic := g.generateInParamsFrom(typedTyp)
 		out := g.generateOutParamsFrom(typedTyp.Results())
 		return &model.FuncType{In: in, Out: out, Variadic: variadic}
 	default:
@@ -168,15 +261,19 @@ func (g *modelGenerator) modelTypeFrom(typesType types.Type) model.Type {
 	}
 }
 
-func (g *modelGenerator) generateInParamsFrom(params *types.Tuple) (in []*model.Parameter, variadic *model.Parameter) {
-	// TODO: variadic
-
+func (g *modelGenerator) generateInParamsFrom(signature *types.Signature) (in []*model.Parameter, variadic *model.Parameter) {
+	params := signature.Params()
 	for i := 0; i < params.Len(); i++ {
 		in = append(in, &model.Parameter{
 			Name: params.At(i).Name(),
 			Type: g.modelTypeFrom(params.At(i).Type()),
 		})
 	}
+	if signature.Variadic() {
+		variadic = in[len(in)-1]
+		variadic.Type = variadic.Type.(*model.ArrayType).Type
+		in = in[:len(in)-1]
+	}
 	return
 }
 
@@ -30,5 +30,14 @@ var _ = Describe("Loader", func() {
 
 			})
 		})
+
+		Context("using a type constraint", func() {
+			It("reports it as not mockable", func() {
+				for _, name := range []string{"Number", "IntOrString", "Key", "Measurement"} {
+					_, e := GenerateModel("github.com/petergtz/pegomock/modelgen/test_data/constraints", name)
+					Expect(e).To(MatchError(name + " is a type constraint, not a mockable interface"))
+				}
+			})
+		})
 	})
 })
@@ -59,6 +59,18 @@ var _ = Describe("modelgen/loader", func() {
 		}
 	})
 
+	It("yields the same interface hash as gomock/reflect and gomock/source do", func() {
+		pkgFromReflect, e := gomock.Reflect("github.com/petergtz/pegomock/test_interface", []string{"Display"})
+		Expect(e).NotTo(HaveOccurred())
+		pkgFromSource, e := gomock.ParseFile("../test_interface/display.go")
+		Expect(e).NotTo(HaveOccurred())
+		pkgFromLoader, e := loader.GenerateModel("github.com/petergtz/pegomock/test_interface", "Display")
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(pkgFromLoader.InterfaceHash()).To(Equal(pkgFromReflect.InterfaceHash()))
+		Expect(pkgFromLoader.InterfaceHash()).To(Equal(pkgFromSource.InterfaceHash()))
+	})
+
 	It("generates a model with the basic properties", func() {
 		pkg, e := loader.GenerateModel("github.com/petergtz/pegomock/modelgen/test_data/default_test_interface", "Display")
 		Expect(e).NotTo(HaveOccurred())
@@ -94,6 +106,6 @@ func expectParamsEqual(methodName string, actual, expected []*model.Parameter) {
 		if actual[i].Name != expected[i].Name {
 			fmt.Printf("Note: In method %v, param names differ \"%v\" != \"%v\"\n", methodName, actual[i].Name, expected[i].Name)
 		}
-		Expect(actual[i].Type).To(Equal(expected[i].Type))
+		Expect(actual[i].Type.String(nil, "")).To(Equal(expected[i].Type.String(nil, "")))
 	}
 }
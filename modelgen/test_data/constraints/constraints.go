@@ -0,0 +1,45 @@
+// Copyright 2015 Peter Goetz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package constraints
+
+// Number has union terms with ~ approximations and cannot be mocked.
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// IntOrString has union terms without approximations and cannot be mocked.
+type IntOrString interface {
+	int | string
+}
+
+// Key embeds comparable and cannot be mocked, although it has methods.
+type Key interface {
+	comparable
+	String() string
+}
+
+// Measurement embeds a type constraint and cannot be mocked.
+type Measurement interface {
+	Number
+	Unit() string
+}
+
+// Greeter is an ordinary interface among type constraints and can be mocked.
+type Greeter interface {
+	Greet(name string) string
+}
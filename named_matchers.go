@@ -0,0 +1,152 @@
+package pegomock
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// MatcherFactory creates a matcher from args, e.g. the values given for it in a
+// configuration file. It panics if args are invalid.
+type MatcherFactory func(args []interface{}) Matcher
+
+var (
+	namedMatchersMutex sync.RWMutex
+	namedMatchers      = make(map[string]MatcherFactory)
+)
+
+// RegisterNamedMatcher makes the matchers created by factory available under
+// name, so configurations, e.g. StubbingConfigs, can refer to them. Registering
+// a name again replaces its factory. RegisterMatcher, in contrast, registers a
+// matcher for the call on a mock in progress.
+//
+// The built-in Any<Type> and Eq<Type> matchers for Go's basic types, e.g.
+// AnyString and EqInt, as well as AnyInterface, EqInterface, IsNil, AnyContext,
+// StringMatching, SliceContaining, MapContaining and FieldEqual are registered
+// under their names.
+func RegisterNamedMatcher(name string, factory MatcherFactory) {
+	verify.Argument(name != "", "RegisterNamedMatcher needs a name")
+	verify.Argument(factory != nil, "RegisterNamedMatcher needs a factory")
+	namedMatchersMutex.Lock()
+	defer namedMatchersMutex.Unlock()
+	namedMatchers[name] = factory
+}
+
+// LookupMatcher creates the matcher registered under name with args. It returns
+// an error if no matcher is registered under name or its factory rejects args.
+func LookupMatcher(name string, args []interface{}) (matcher Matcher, e error) {
+	namedMatchersMutex.RLock()
+	factory, registered := namedMatchers[name]
+	namedMatchersMutex.RUnlock()
+	if !registered {
+		return nil, fmt.Errorf("No matcher named %v. Registered matchers are: %v", name, strings.Join(namedMatcherNames(), ", "))
+	}
+	defer func() {
+		if value := recover(); value != nil {
+			matcher, e = nil, fmt.Errorf("Invalid args %v for matcher %v: %v", formatCycleSafe("%v", args), name, value)
+		}
+	}()
+	matcher = factory(args)
+	verify.Argument(matcher != nil, "its factory returned nil")
+	return matcher, nil
+}
+
+func namedMatcherNames() []string {
+	namedMatchersMutex.RLock()
+	defer namedMatchersMutex.RUnlock()
+	names := make([]string, 0, len(namedMatchers))
+	for name := range namedMatchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var basicTypes = []reflect.Type{
+	reflect.TypeOf(false),
+	reflect.TypeOf(int(0)), reflect.TypeOf(int8(0)), reflect.TypeOf(int16(0)), reflect.TypeOf(int32(0)), reflect.TypeOf(int64(0)),
+	reflect.TypeOf(uint(0)), reflect.TypeOf(uint8(0)), reflect.TypeOf(uint16(0)), reflect.TypeOf(uint32(0)), reflect.TypeOf(uint64(0)),
+	reflect.TypeOf(uintptr(0)),
+	reflect.TypeOf(float32(0)), reflect.TypeOf(float64(0)),
+	reflect.TypeOf(complex64(0)), reflect.TypeOf(complex128(0)),
+	reflect.TypeOf(""),
+}
+
+func init() {
+	for _, typ := range basicTypes {
+		typ := typ
+		typeName := strings.ToUpper(typ.Name()[:1]) + typ.Name()[1:]
+		RegisterNamedMatcher("Any"+typeName, func(args []interface{}) Matcher {
+			expectArgs(args, 0)
+			return NewAnyMatcher(typ)
+		})
+		RegisterNamedMatcher("Eq"+typeName, func(args []interface{}) Matcher {
+			expectArgs(args, 1)
+			return &EqMatcher{Value: configValueAs(args[0], typ).Interface()}
+		})
+	}
+	RegisterNamedMatcher("AnyInterface", func(args []interface{}) Matcher {
+		expectArgs(args, 0)
+		return NewAnyMatcher(reflect.TypeOf((*interface{})(nil)).Elem())
+	})
+	RegisterNamedMatcher("EqInterface", func(args []interface{}) Matcher {
+		expectArgs(args, 1)
+		return &EqMatcher{Value: args[0]}
+	})
+	RegisterNamedMatcher("IsNil", func(args []interface{}) Matcher {
+		expectArgs(args, 0)
+		return &NilMatcher{}
+	})
+	RegisterNamedMatcher("AnyContext", func(args []interface{}) Matcher {
+		expectArgs(args, 0)
+		return NewAnyMatcher(reflect.TypeOf((*context.Context)(nil)).Elem())
+	})
+	RegisterNamedMatcher("StringMatching", func(args []interface{}) Matcher {
+		expectArgs(args, 1)
+		return &StringMatchingMatcher{Regexp: regexp.MustCompile(configValueAs(args[0], reflect.TypeOf("")).String())}
+	})
+	RegisterNamedMatcher("SliceContaining", func(args []interface{}) Matcher {
+		return &SliceContainingMatcher{Elems: args}
+	})
+	RegisterNamedMatcher("MapContaining", func(args []interface{}) Matcher {
+		expectArgs(args, 2)
+		return &MapContainingMatcher{Key: args[0], Value: args[1]}
+	})
+	RegisterNamedMatcher("FieldEqual", func(args []interface{}) Matcher {
+		expectArgs(args, 2)
+		return &FieldEqualMatcher{FieldPath: configValueAs(args[0], reflect.TypeOf("")).String(), Value: args[1]}
+	})
+}
+
+func expectArgs(args []interface{}, count int) {
+	verify.Argument(len(args) == count, "expected %v args, but got %v", count, len(args))
+}
+
+// NilMatcher matches nil, including nil pointers, slices, maps, channels and
+// functions.
+type NilMatcher struct {
+	actual Param
+	sync.Mutex
+}
+
+func (matcher *NilMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	return param == nil || isNilValue(reflect.ValueOf(param))
+}
+
+func (matcher *NilMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: nil; but got: %v", formatCycleSafe("%#v", matcher.actual))
+}
+
+func (matcher *NilMatcher) String() string {
+	return "IsNil()"
+}
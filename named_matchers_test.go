@@ -0,0 +1,126 @@
+package pegomock_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+type greaterThanIntMatcher struct {
+	threshold int
+	actual    Param
+}
+
+func (matcher *greaterThanIntMatcher) Matches(param Param) bool {
+	matcher.actual = param
+	value, isInt := param.(int)
+	return isInt && value > matcher.threshold
+}
+
+func (matcher *greaterThanIntMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: int greater than %v; but got: %v", matcher.threshold, matcher.actual)
+}
+
+func (matcher *greaterThanIntMatcher) String() string {
+	return fmt.Sprintf("GreaterThanInt(%v)", matcher.threshold)
+}
+
+var _ = Describe("Named matchers", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+		RegisterNamedMatcher("GreaterThanInt", func(args []interface{}) Matcher {
+			// Numbers decoded from JSON are float64.
+			return &greaterThanIntMatcher{threshold: int(args[0].(float64))}
+		})
+	})
+
+	It("stubs with custom and built-in matchers from a serialized config", func() {
+		serialized, e := json.Marshal([]StubbingConfig{
+			{
+				Method:  "MultipleParamsAndReturnValue",
+				Params:  []ParamConfig{{Matcher: "AnyString"}, {Matcher: "GreaterThanInt", Value: 5}},
+				Returns: []interface{}{"big"},
+			},
+			{
+				Method:  "MultipleParamsAndReturnValue",
+				Params:  []ParamConfig{{Matcher: "EqString", Value: "exact"}, {Value: 1}},
+				Returns: []interface{}{"exact one"},
+			},
+			{Method: "ErrorReturnValue", Returns: []interface{}{"boom"}},
+		})
+		Expect(e).NotTo(gomega.HaveOccurred())
+
+		var configs []StubbingConfig
+		Expect(json.Unmarshal(serialized, &configs)).To(gomega.Succeed())
+		Expect(ApplyStubbingConfigs(display, configs...)).To(gomega.Succeed())
+
+		Expect(display.MultipleParamsAndReturnValue("any", 6)).To(Equal("big"))
+		Expect(display.MultipleParamsAndReturnValue("any", 5)).To(Equal(""))
+		Expect(display.MultipleParamsAndReturnValue("exact", 1)).To(Equal("exact one"))
+		Expect(display.MultipleParamsAndReturnValue("other", 1)).To(Equal(""))
+		Expect(display.ErrorReturnValue()).To(MatchError("boom"))
+	})
+
+	It("creates built-in matchers converting their args", func() {
+		matcher, e := LookupMatcher("EqInt", []interface{}{float64(3)})
+		Expect(e).NotTo(gomega.HaveOccurred())
+		Expect(matcher.Matches(3)).To(BeTrue())
+
+		matcher, e = LookupMatcher("IsNil", nil)
+		Expect(e).NotTo(gomega.HaveOccurred())
+		Expect(matcher.Matches((*MyEvent)(nil))).To(BeTrue())
+		Expect(matcher.Matches(&MyEvent{})).To(gomega.BeFalse())
+		Expect(matcher.FailureMessage()).To(Equal("Expected: nil; but got: &pegomock_test.MyEvent{}"))
+
+		matcher, e = LookupMatcher("StringMatching", []interface{}{"^a"})
+		Expect(e).NotTo(gomega.HaveOccurred())
+		Expect(matcher.Matches("abc")).To(BeTrue())
+	})
+
+	It("reports unknown matchers and invalid args", func() {
+		_, e := LookupMatcher("NoSuchMatcher", nil)
+		Expect(e).To(MatchError(ContainSubstring("No matcher named NoSuchMatcher. Registered matchers are: ")))
+		Expect(e).To(MatchError(ContainSubstring("GreaterThanInt")))
+
+		_, e = LookupMatcher("EqInt", []interface{}{1.5})
+		Expect(e).To(MatchError("Invalid args [1.5] for matcher EqInt: cannot use 1.5 as int"))
+		_, e = LookupMatcher("AnyString", []interface{}{"unexpected"})
+		Expect(e).To(MatchError(ContainSubstring("expected 0 args, but got 1")))
+	})
+
+	It("doesn't stub anything if a config is invalid", func() {
+		e := ApplyStubbingConfigs(display,
+			StubbingConfig{Method: "SomeValue", Returns: []interface{}{"stubbed"}},
+			StubbingConfig{Method: "SomeValue", Params: []ParamConfig{{Value: 1}}, Returns: []interface{}{"stubbed"}})
+
+		Expect(e).To(MatchError("Invalid stubbing config 1 for SomeValue: expected 0 params, but got 1"))
+		Expect(display.SomeValue()).To(Equal(""))
+		Expect(ApplyStubbingConfigs(display, StubbingConfig{Method: "NoSuchMethod"})).To(
+			MatchError("Invalid stubbing config 0 for NoSuchMethod: *pegomock_test.MockDisplay has no method NoSuchMethod"))
+	})
+
+	It("is safe for concurrent use", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer ginkgo.GinkgoRecover()
+				name := fmt.Sprintf("Concurrent%v", i)
+				RegisterNamedMatcher(name, func([]interface{}) Matcher { return &NilMatcher{} })
+				matcher, e := LookupMatcher(name, nil)
+				Expect(e).NotTo(gomega.HaveOccurred())
+				Expect(reflect.TypeOf(matcher)).To(Equal(reflect.TypeOf(&NilMatcher{})))
+			}(i)
+		}
+		wg.Wait()
+	})
+})
@@ -0,0 +1,53 @@
+package pegomock
+
+import (
+	"fmt"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// VerifyNoMoreInteractions fails the test for each of mocks that was invoked in
+// ways no verification matched so far, listing those invocations. Call it after
+// verifying everything a test expects, to make sure the code under test made no
+// other calls:
+//
+//	display.VerifyWasCalledOnce().Show("Hello")
+//	VerifyNoMoreInteractions(display)
+//
+// Invocations evicted because of WithInvocationLimit are not checked.
+func VerifyNoMoreInteractions(mocks ...Mock) {
+	for i, mock := range mocks {
+		verify.Argument(mock != nil, "Mock %v passed to VerifyNoMoreInteractions must not be nil", i+1)
+		genericMock := GetGenericMockFrom(mock)
+		unverified := genericMock.GetUnverifiedInvocations()
+		if len(unverified) == 0 {
+			continue
+		}
+		invocations := ""
+		for _, methodName := range sortedMethodNames(unverified) {
+			invocations += formatInvocations(methodName, unverified[methodName])
+		}
+		genericMock.failHandler()(fmt.Sprintf("Expected no more interactions with %T, but it had unverified ones:\n%v", mock, invocations), 1)
+	}
+}
+
+// VerifyZeroInteractions fails the test for each of mocks that was invoked at
+// all since its creation or last ResetForNextTest, listing all its invocations.
+// Unlike VerifyNoMoreInteractions, verified invocations count, too.
+func VerifyZeroInteractions(mocks ...Mock) {
+	for i, mock := range mocks {
+		verify.Argument(mock != nil, "Mock %v passed to VerifyZeroInteractions must not be nil", i+1)
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		interactions := genericMock.allInteractions()
+		genericMock.Unlock()
+		if len(interactions) == 0 {
+			continue
+		}
+		invocations := ""
+		for _, methodName := range sortedMethodNames(interactions) {
+			invocations += formatInvocations(methodName, interactions[methodName])
+		}
+		genericMock.failHandler()(fmt.Sprintf("Expected no interactions with %T, but it had:\n%v", mock, invocations), 1)
+	}
+}
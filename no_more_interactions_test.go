@@ -0,0 +1,100 @@
+package pegomock_test
+
+import (
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("VerifyNoMoreInteractions", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("succeeds when every invocation was verified", func() {
+		display.Show("Hello")
+		display.Flash("Hello", 333)
+		display.Flash("Hello", 333)
+
+		display.VerifyWasCalledOnce().Show("Hello")
+		display.VerifyWasCalled(Twice()).Flash(AnyString(), AnyInt())
+
+		Expect(func() { VerifyNoMoreInteractions(display) }).NotTo(Panic())
+	})
+
+	It("succeeds for mocks that weren't invoked", func() {
+		When(display.SomeValue()).ThenReturn("Hello")
+
+		Expect(func() { VerifyNoMoreInteractions(display, NewMockDisplay()) }).NotTo(Panic())
+	})
+
+	It("fails listing the invocations no verification matched", func() {
+		display.Show("Hello")
+		display.Show("World")
+		display.Flash("Hello", 333)
+
+		display.VerifyWasCalledOnce().Show("Hello")
+
+		Expect(func() { VerifyNoMoreInteractions(display) }).To(PanicWith(
+			"Expected no more interactions with *pegomock_test.MockDisplay, but it had unverified ones:\n" +
+				"\tFlash(\"Hello\", 333)\n" +
+				"\tShow(\"World\")\n"))
+	})
+
+	It("doesn't count failed verifications as covering invocations", func() {
+		display.Show("Hello")
+
+		Expect(func() { display.VerifyWasCalled(Twice()).Show("Hello") }).To(Panic())
+
+		Expect(func() { VerifyNoMoreInteractions(display) }).To(PanicWithMessageTo(ContainSubstring("\tShow(\"Hello\")")))
+	})
+
+	It("checks invocations since the last reset only", func() {
+		display.Show("Hello")
+		display.ResetForNextTest()
+
+		Expect(func() { VerifyNoMoreInteractions(display) }).NotTo(Panic())
+	})
+
+	It("panics on nil mocks", func() {
+		Expect(func() { VerifyNoMoreInteractions(display, nil) }).To(PanicWith(
+			"Mock 2 passed to VerifyNoMoreInteractions must not be nil"))
+	})
+})
+
+var _ = Describe("VerifyZeroInteractions", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("succeeds for mocks that were only stubbed", func() {
+		When(display.SomeValue()).ThenReturn("Hello")
+
+		Expect(func() { VerifyZeroInteractions(display, NewMockDisplay()) }).NotTo(Panic())
+	})
+
+	It("fails listing all invocations, including verified ones", func() {
+		display.Show("Hello")
+		display.Flash("Hello", 333)
+		display.VerifyWasCalledOnce().Show("Hello")
+
+		Expect(func() { VerifyZeroInteractions(NewMockDisplay(), display) }).To(PanicWith(
+			"Expected no interactions with *pegomock_test.MockDisplay, but it had:\n" +
+				"\tFlash(\"Hello\", 333)\n" +
+				"\tShow(\"Hello\")\n"))
+	})
+
+	It("checks invocations since the last reset only", func() {
+		display.Show("Hello")
+		display.ResetForNextTest()
+
+		Expect(func() { VerifyZeroInteractions(display) }).NotTo(Panic())
+	})
+
+	It("panics on nil mocks", func() {
+		Expect(func() { VerifyZeroInteractions(nil) }).To(PanicWith(
+			"Mock 1 passed to VerifyZeroInteractions must not be nil"))
+	})
+})
@@ -0,0 +1,360 @@
+// Package checker verifies from within tests that mocks generated by pegomock
+// are up to date with the interfaces they were generated from.
+package checker
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/petergtz/pegomock/model"
+	"github.com/petergtz/pegomock/modelgen/loader"
+	"golang.org/x/tools/go/packages"
+)
+
+// T is the subset of *testing.T needed by RequireMocksUpToDate.
+type T interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+// StaleMock describes a generated mock that doesn't match its interfaces anymore.
+type StaleMock struct {
+	MockFilePath        string
+	Reason              string
+	RegenerationCommand string
+}
+
+// RequireMocksUpToDate fails the test if any mock generated by pegomock in the
+// packages matching patterns, e.g. "./...", is out of date. It recomputes the
+// interface hash stored in the header of each mock file and lists the stale
+// mocks together with the commands to regenerate them. It neither needs the
+// pegomock binary nor generates any code, so it is cheap enough to run as part
+// of a normal test.
+func RequireMocksUpToDate(t T, patterns ...string) {
+	t.Helper()
+	staleMocks, err := FindStaleMocks(patterns...)
+	if err != nil {
+		t.Fatalf("Checking mocks failed: %v", err)
+		return
+	}
+	if len(staleMocks) == 0 {
+		return
+	}
+	var message strings.Builder
+	fmt.Fprintf(&message, "%v mock(s) out of date:\n", len(staleMocks))
+	for _, staleMock := range staleMocks {
+		fmt.Fprintf(&message, "\t%v: %v\n\t\tRegenerate with: %v\n",
+			staleMock.MockFilePath, staleMock.Reason, staleMock.RegenerationCommand)
+	}
+	t.Fatalf("%s", message.String())
+}
+
+// mockFile is a file generated by pegomock, as described by its header.
+type mockFile struct {
+	path          string
+	packageName   string
+	source        string
+	interfaceHash string
+
+	// Set for mocks generated in reflect mode, i.e. from an import path.
+	importPath     string
+	interfaceNames []string
+	// Set for mocks generated in source mode, i.e. from a file.
+	sourceFilePath string
+}
+
+var reflectModeSourcePattern = regexp.MustCompile(`^(\S+) \(interfaces: (.+)\)$`)
+
+// FindStaleMocks returns the mocks generated by pegomock in the packages matching
+// patterns that don't match their interfaces anymore.
+func FindStaleMocks(patterns ...string) ([]StaleMock, error) {
+	mockFiles, err := findMockFiles(patterns)
+	if err != nil {
+		return nil, err
+	}
+	if len(mockFiles) == 0 {
+		return nil, nil
+	}
+	interfacePackages, err := loadInterfacePackages(mockFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var staleMocks []StaleMock
+	for _, mockFile := range mockFiles {
+		if reason := mockFile.staleness(interfacePackages); reason != "" {
+			staleMocks = append(staleMocks, StaleMock{
+				MockFilePath:        mockFile.path,
+				Reason:              reason,
+				RegenerationCommand: mockFile.regenerationCommand(),
+			})
+		}
+	}
+	return staleMocks, nil
+}
+
+func findMockFiles(patterns []string) ([]*mockFile, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles, Tests: true}, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("Loading packages %v failed: %v", patterns, err)
+	}
+	filePaths := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, filePath := range append(pkg.GoFiles, pkg.IgnoredFiles...) {
+			filePaths[filePath] = true
+		}
+	}
+	var mockFiles []*mockFile
+	for filePath := range filePaths {
+		mockFile, err := readMockFileHeader(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if mockFile != nil {
+			mockFiles = append(mockFiles, mockFile)
+		}
+	}
+	sort.Slice(mockFiles, func(i, j int) bool { return mockFiles[i].path < mockFiles[j].path })
+	return mockFiles, nil
+}
+
+// readMockFileHeader returns nil if filePath is not a mock generated by pegomock.
+// Matcher files are generated by pegomock as well, but don't have a source.
+func readMockFileHeader(filePath string) (*mockFile, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || scanner.Text() != "// Code generated by pegomock. DO NOT EDIT." {
+		return nil, scanner.Err()
+	}
+	mockFile := &mockFile{path: filePath}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "// Source: "):
+			mockFile.source = strings.TrimPrefix(line, "// Source: ")
+		case strings.HasPrefix(line, "// Interface hash: "):
+			mockFile.interfaceHash = strings.TrimPrefix(line, "// Interface hash: ")
+		case strings.HasPrefix(line, "package "):
+			mockFile.packageName = strings.TrimSpace(strings.TrimPrefix(line, "package "))
+		}
+		if mockFile.packageName != "" {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if mockFile.source == "" {
+		return nil, nil
+	}
+	if match := reflectModeSourcePattern.FindStringSubmatch(mockFile.source); match != nil {
+		mockFile.importPath = match[1]
+		mockFile.interfaceNames = strings.Split(match[2], ",")
+	} else if filepath.IsAbs(mockFile.source) {
+		mockFile.sourceFilePath = mockFile.source
+	} else {
+		mockFile.sourceFilePath = filepath.Join(filepath.Dir(filePath), mockFile.source)
+	}
+	return mockFile, nil
+}
+
+// loadInterfacePackages type-checks the packages declaring the mocked interfaces
+// using a single packages.Load. The result is keyed by import path for mocks in
+// reflect mode and by source file path for mocks in source mode.
+func loadInterfacePackages(mockFiles []*mockFile) (map[string]*packages.Package, error) {
+	queries := make(map[string]bool)
+	for _, mockFile := range mockFiles {
+		if mockFile.importPath != "" {
+			queries[mockFile.importPath] = true
+		} else {
+			queries["file="+mockFile.sourceFilePath] = true
+		}
+	}
+	var patterns []string
+	for query := range queries {
+		patterns = append(patterns, query)
+	}
+	sort.Strings(patterns)
+
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes}, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("Loading packages of mocked interfaces failed: %v", err)
+	}
+	interfacePackages := make(map[string]*packages.Package)
+	for _, pkg := range pkgs {
+		interfacePackages[pkg.PkgPath] = pkg
+		for _, filePath := range pkg.GoFiles {
+			interfacePackages[filePath] = pkg
+		}
+	}
+	return interfacePackages, nil
+}
+
+// staleness returns why the mock is out of date, or "" if it is up to date.
+func (mockFile *mockFile) staleness(interfacePackages map[string]*packages.Package) string {
+	if mockFile.interfaceHash == "" {
+		return "no interface hash in header. It was generated by an older version of pegomock or with a custom template that doesn't emit it"
+	}
+	key := mockFile.importPath
+	if key == "" {
+		key = mockFile.sourceFilePath
+	}
+	pkg, exists := interfacePackages[key]
+	if !exists || pkg.Types == nil {
+		return fmt.Sprintf("could not load %v", mockFile.source)
+	}
+	if len(pkg.Errors) > 0 {
+		return fmt.Sprintf("could not load %v: %v", mockFile.source, pkg.Errors[0])
+	}
+
+	interfaceNames := mockFile.interfaceNames
+	if mockFile.sourceFilePath != "" {
+		var err error
+		interfaceNames, err = interfacesDeclaredIn(mockFile.sourceFilePath)
+		if err != nil {
+			return fmt.Sprintf("could not parse %v: %v", mockFile.sourceFilePath, err)
+		}
+	}
+	modelPackage := &model.Package{Name: pkg.Types.Name(), PkgPath: pkg.PkgPath}
+	for _, interfaceName := range interfaceNames {
+		object := pkg.Types.Scope().Lookup(interfaceName)
+		if object == nil {
+			return fmt.Sprintf("interface %v not found in %v", interfaceName, pkg.PkgPath)
+		}
+		iface, isInterface := object.Type().Underlying().(*types.Interface)
+		if !isInterface {
+			return fmt.Sprintf("%v in %v is not an interface anymore", interfaceName, pkg.PkgPath)
+		}
+		modelPackage.Interfaces = append(modelPackage.Interfaces, loader.InterfaceFromTypes(interfaceName, iface))
+	}
+	if modelPackage.InterfaceHash() != mockFile.interfaceHash {
+		return "interface hash differs. The mocked interfaces changed since the mock was generated"
+	}
+	return ""
+}
+
+// OrphanedMock describes a generated mock whose interfaces don't exist anymore,
+// e.g. because they were renamed or deleted. Unlike a stale mock, it can't be
+// regenerated, only deleted.
+type OrphanedMock struct {
+	MockFilePath string
+	Reason       string
+}
+
+// FindOrphanedMocks returns the mocks generated by pegomock in the packages
+// matching patterns whose interfaces don't exist anymore. Mocks whose
+// interfaces can't be checked, e.g. because their package doesn't compile,
+// are not considered orphaned.
+func FindOrphanedMocks(patterns ...string) ([]OrphanedMock, error) {
+	mockFiles, err := findMockFiles(patterns)
+	if err != nil {
+		return nil, err
+	}
+	var reflectModeMockFiles []*mockFile
+	for _, mockFile := range mockFiles {
+		if mockFile.importPath != "" {
+			reflectModeMockFiles = append(reflectModeMockFiles, mockFile)
+		}
+	}
+	interfacePackages := make(map[string]*packages.Package)
+	if len(reflectModeMockFiles) > 0 {
+		interfacePackages, err = loadInterfacePackages(reflectModeMockFiles)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var orphanedMocks []OrphanedMock
+	for _, mockFile := range mockFiles {
+		if reason := mockFile.orphanage(interfacePackages); reason != "" {
+			orphanedMocks = append(orphanedMocks, OrphanedMock{MockFilePath: mockFile.path, Reason: reason})
+		}
+	}
+	return orphanedMocks, nil
+}
+
+// orphanage returns why the mock's interfaces don't exist anymore, or "" if
+// they do or if that can't be told.
+func (mockFile *mockFile) orphanage(interfacePackages map[string]*packages.Package) string {
+	if mockFile.sourceFilePath != "" {
+		if _, err := os.Stat(mockFile.sourceFilePath); os.IsNotExist(err) {
+			return fmt.Sprintf("source file %v does not exist anymore", mockFile.source)
+		}
+		interfaceNames, err := interfacesDeclaredIn(mockFile.sourceFilePath)
+		if err != nil || len(interfaceNames) > 0 {
+			return ""
+		}
+		return fmt.Sprintf("source file %v does not declare any interfaces anymore", mockFile.source)
+	}
+	pkg, exists := interfacePackages[mockFile.importPath]
+	if !exists {
+		return ""
+	}
+	if len(pkg.GoFiles) == 0 && len(pkg.Errors) > 0 {
+		return fmt.Sprintf("package %v does not exist anymore", mockFile.importPath)
+	}
+	if len(pkg.Errors) > 0 || pkg.Types == nil {
+		return ""
+	}
+	for _, interfaceName := range mockFile.interfaceNames {
+		object := pkg.Types.Scope().Lookup(interfaceName)
+		if object == nil {
+			return fmt.Sprintf("interface %v not found in %v", interfaceName, pkg.PkgPath)
+		}
+		if _, isInterface := object.Type().Underlying().(*types.Interface); !isInterface {
+			return fmt.Sprintf("%v in %v is not an interface anymore", interfaceName, pkg.PkgPath)
+		}
+	}
+	return ""
+}
+
+// interfacesDeclaredIn returns the names of the interfaces declared in a file,
+// which is what pegomock mocks in source mode.
+func interfacesDeclaredIn(filePath string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var interfaceNames []string
+	for _, decl := range file.Decls {
+		genDecl, isGenDecl := decl.(*ast.GenDecl)
+		if !isGenDecl || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if typeSpec := spec.(*ast.TypeSpec); isInterfaceType(typeSpec.Type) {
+				interfaceNames = append(interfaceNames, typeSpec.Name.Name)
+			}
+		}
+	}
+	return interfaceNames, nil
+}
+
+func isInterfaceType(expr ast.Expr) bool {
+	_, isInterface := expr.(*ast.InterfaceType)
+	return isInterface
+}
+
+func (mockFile *mockFile) regenerationCommand() string {
+	mockDir := filepath.Dir(mockFile.path)
+	if mockFile.importPath != "" {
+		return fmt.Sprintf("cd %v && pegomock generate --package %v --output %v %v %v",
+			mockDir, mockFile.packageName, filepath.Base(mockFile.path), mockFile.importPath, strings.Join(mockFile.interfaceNames, ","))
+	}
+	return fmt.Sprintf("cd %v && pegomock generate --package %v --output %v %v",
+		mockDir, mockFile.packageName, filepath.Base(mockFile.path), mockFile.source)
+}
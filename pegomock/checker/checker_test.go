@@ -0,0 +1,206 @@
+// Copyright 2016 Peter Goetz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker_test
+
+import (
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/pegomock/checker"
+	"github.com/petergtz/pegomock/pegomock/filehandling"
+	. "github.com/petergtz/pegomock/pegomock/testutil"
+)
+
+var (
+	joinPath = filepath.Join
+)
+
+func TestChecker(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Checker Suite")
+}
+
+type fakeT struct {
+	failures []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+var _ = Describe("RequireMocksUpToDate", func() {
+	var (
+		packageDir     string
+		origWorkingDir string
+		t              *fakeT
+	)
+
+	BeforeEach(func() {
+		packageDir = joinPath(build.Default.GOPATH, "src", "pegomockcheckertest")
+		Expect(os.MkdirAll(packageDir, 0755)).To(Succeed())
+
+		var e error
+		origWorkingDir, e = os.Getwd()
+		Expect(e).NotTo(HaveOccurred())
+		os.Chdir(packageDir)
+
+		WriteFile(joinPath(packageDir, "display.go"),
+			"package pegomockcheckertest; type Display interface { Show(text string) }")
+		WriteFile(joinPath(packageDir, "renderer.go"),
+			"package pegomockcheckertest; type Renderer interface { Render(width, height int) ([]byte, error) }")
+		filehandling.GenerateMockFile([]string{"display.go"}, "mock_display_test.go",
+			filehandling.GenerateOptions{NamePrefix: "Mock", PackageOut: "pegomockcheckertest_test"})
+		filehandling.GenerateMockFile([]string{"pegomockcheckertest", "Renderer"}, "mock_renderer_test.go",
+			filehandling.GenerateOptions{NamePrefix: "Mock", PackageOut: "pegomockcheckertest_test", UseExperimentalModelGen: true})
+
+		t = &fakeT{}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(packageDir)).To(Succeed())
+		os.Chdir(origWorkingDir)
+	})
+
+	It("does not fail when all mocks are up to date", func() {
+		checker.RequireMocksUpToDate(t, "./...")
+
+		Expect(t.failures).To(BeEmpty())
+	})
+
+	It("does not fail when only parameter names changed", func() {
+		WriteFile(joinPath(packageDir, "renderer.go"),
+			"package pegomockcheckertest; type Renderer interface { Render(w int, h int) (data []byte, err error) }")
+
+		checker.RequireMocksUpToDate(t, "./...")
+
+		Expect(t.failures).To(BeEmpty())
+	})
+
+	It("fails listing the mock and how to regenerate it when a mocked interface changed", func() {
+		WriteFile(joinPath(packageDir, "renderer.go"),
+			"package pegomockcheckertest; type Renderer interface { Render(width, height int) ([]byte, error); Close() }")
+
+		checker.RequireMocksUpToDate(t, "./...")
+
+		Expect(t.failures).To(HaveLen(1))
+		Expect(t.failures[0]).To(SatisfyAll(
+			ContainSubstring("1 mock(s) out of date"),
+			ContainSubstring(joinPath(packageDir, "mock_renderer_test.go")+": interface hash differs"),
+			ContainSubstring("Regenerate with: cd "+packageDir+" && pegomock generate --package pegomockcheckertest_test "+
+				"--output mock_renderer_test.go pegomockcheckertest Renderer"),
+			Not(ContainSubstring("mock_display_test.go"))))
+	})
+
+	It("detects changes of interfaces mocked in source mode", func() {
+		WriteFile(joinPath(packageDir, "display.go"),
+			"package pegomockcheckertest; type Display interface { Show(text string, times int) }")
+
+		staleMocks, e := checker.FindStaleMocks("./...")
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(staleMocks).To(ConsistOf(checker.StaleMock{
+			MockFilePath:        joinPath(packageDir, "mock_display_test.go"),
+			Reason:              "interface hash differs. The mocked interfaces changed since the mock was generated",
+			RegenerationCommand: "cd " + packageDir + " && pegomock generate --package pegomockcheckertest_test --output mock_display_test.go display.go",
+		}))
+	})
+
+	It("reports mocks without interface hash as stale", func() {
+		mockFilePath := joinPath(packageDir, "mock_display_test.go")
+		content, e := ioutil.ReadFile(mockFilePath)
+		Expect(e).NotTo(HaveOccurred())
+		lines := strings.Split(string(content), "\n")
+		Expect(lines[2]).To(HavePrefix("// Interface hash: "))
+		WriteFile(mockFilePath, strings.Join(append(lines[:2], lines[3:]...), "\n"))
+
+		staleMocks, e := checker.FindStaleMocks("./...")
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(staleMocks).To(HaveLen(1))
+		Expect(staleMocks[0].Reason).To(HavePrefix("no interface hash in header"))
+	})
+
+	Context("FindOrphanedMocks", func() {
+		It("does not report mocks whose interfaces exist, even if they changed", func() {
+			WriteFile(joinPath(packageDir, "renderer.go"),
+				"package pegomockcheckertest; type Renderer interface { Close() }")
+
+			orphanedMocks, e := checker.FindOrphanedMocks("./...")
+
+			Expect(e).NotTo(HaveOccurred())
+			Expect(orphanedMocks).To(BeEmpty())
+		})
+
+		It("reports mocks whose interface was renamed", func() {
+			WriteFile(joinPath(packageDir, "renderer.go"),
+				"package pegomockcheckertest; type Painter interface { Render(width, height int) ([]byte, error) }")
+
+			orphanedMocks, e := checker.FindOrphanedMocks("./...")
+
+			Expect(e).NotTo(HaveOccurred())
+			Expect(orphanedMocks).To(ConsistOf(checker.OrphanedMock{
+				MockFilePath: joinPath(packageDir, "mock_renderer_test.go"),
+				Reason:       "interface Renderer not found in pegomockcheckertest",
+			}))
+		})
+
+		It("reports mocks whose source file was deleted", func() {
+			Expect(os.Remove(joinPath(packageDir, "display.go"))).To(Succeed())
+
+			orphanedMocks, e := checker.FindOrphanedMocks("./...")
+
+			Expect(e).NotTo(HaveOccurred())
+			Expect(orphanedMocks).To(ConsistOf(checker.OrphanedMock{
+				MockFilePath: joinPath(packageDir, "mock_display_test.go"),
+				Reason:       "source file display.go does not exist anymore",
+			}))
+		})
+
+		It("reports mocks whose package was deleted", func() {
+			subPackageDir := joinPath(packageDir, "sub")
+			Expect(os.MkdirAll(subPackageDir, 0755)).To(Succeed())
+			WriteFile(joinPath(subPackageDir, "sub.go"), "package sub; type Sub interface { Do() }")
+			filehandling.GenerateMockFile([]string{"pegomockcheckertest/sub", "Sub"}, "mock_sub_test.go",
+				filehandling.GenerateOptions{NamePrefix: "Mock", PackageOut: "pegomockcheckertest_test", UseExperimentalModelGen: true})
+			Expect(os.RemoveAll(subPackageDir)).To(Succeed())
+
+			orphanedMocks, e := checker.FindOrphanedMocks("./...")
+
+			Expect(e).NotTo(HaveOccurred())
+			Expect(orphanedMocks).To(ConsistOf(checker.OrphanedMock{
+				MockFilePath: joinPath(packageDir, "mock_sub_test.go"),
+				Reason:       "package pegomockcheckertest/sub does not exist anymore",
+			}))
+		})
+
+		It("does not report mocks whose package doesn't compile", func() {
+			WriteFile(joinPath(packageDir, "renderer.go"), "package pegomockcheckertest; type Painter interface {")
+
+			orphanedMocks, e := checker.FindOrphanedMocks("./...")
+
+			Expect(e).NotTo(HaveOccurred())
+			Expect(orphanedMocks).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,58 @@
+// Package clean deletes mocks generated by pegomock whose interfaces don't
+// exist anymore.
+package clean
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/petergtz/pegomock/pegomock/checker"
+)
+
+// Clean deletes the orphaned mocks in dirs, i.e. mocks whose interfaces were
+// renamed or deleted, and returns them. With dryRun, it only lists them. It
+// writes nothing to out if there are no orphaned mocks.
+func Clean(dirs []string, recursive bool, dryRun bool, out io.Writer, removeFn func(path string) error) ([]checker.OrphanedMock, error) {
+	orphanedMocks, e := checker.FindOrphanedMocks(patternsFor(dirs, recursive)...)
+	if e != nil {
+		return nil, e
+	}
+	if len(orphanedMocks) == 0 {
+		return nil, nil
+	}
+	if dryRun {
+		fmt.Fprintln(out, "This is a dry-run. Would delete the following stale mocks:")
+		for _, orphanedMock := range orphanedMocks {
+			fmt.Fprintf(out, "%v: %v\n", orphanedMock.MockFilePath, orphanedMock.Reason)
+		}
+		return orphanedMocks, nil
+	}
+	var errs []error
+	for _, orphanedMock := range orphanedMocks {
+		fmt.Fprintf(out, "Deleting stale mock %v: %v\n", orphanedMock.MockFilePath, orphanedMock.Reason)
+		if e := removeFn(orphanedMock.MockFilePath); e != nil {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) > 0 {
+		return orphanedMocks, fmt.Errorf("There were some errors when trying to delete files: %v", errs)
+	}
+	return orphanedMocks, nil
+}
+
+func patternsFor(dirs []string, recursive bool) []string {
+	patterns := make([]string, len(dirs))
+	for i, dir := range dirs {
+		absDir, e := filepath.Abs(dir)
+		if e != nil {
+			absDir = dir
+		}
+		if recursive {
+			patterns[i] = absDir + string(filepath.Separator) + "..."
+		} else {
+			patterns[i] = absDir
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,210 @@
+// Package doctor diagnoses mismatches between the pegomock binary, the
+// pegomock library a module requires and the versions of pegomock that
+// generated the module's mocks.
+package doctor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/petergtz/pegomock/pegomock/util"
+	"github.com/petergtz/pegomock/version"
+)
+
+// Diagnosis is what Diagnose found out about a directory.
+type Diagnosis struct {
+	Dir string
+	// GeneratorVersion and GeneratorCommit describe the running pegomock binary.
+	GeneratorVersion string
+	GeneratorCommit  string
+	// GoModPath is the go.mod file of the module Dir is part of, or "".
+	GoModPath string
+	// RequiredVersion is the version of the pegomock library required in
+	// GoModPath, or "" if it doesn't require it.
+	RequiredVersion string
+	MockFiles       []MockFile
+}
+
+// MockFile is a mock generated by pegomock.
+type MockFile struct {
+	Path string
+	// GeneratorVersion is the version of pegomock that generated the mock, as
+	// stated in its header, or "" if the header doesn't state it.
+	GeneratorVersion string
+	// Reason is why the mock needs to be regenerated, or "" if it doesn't.
+	Reason string
+}
+
+// Diagnose finds the mocks generated by pegomock in dir and its sub-directories
+// and compares the versions that generated them with the version of the
+// running pegomock binary and the version of the pegomock library required in
+// the go.mod file of dir's module.
+func Diagnose(dir string) (Diagnosis, error) {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return Diagnosis{}, e
+	}
+	diagnosis := Diagnosis{
+		Dir:              dir,
+		GeneratorVersion: version.Generator(),
+		GeneratorCommit:  version.GeneratorCommit(),
+	}
+	if moduleRoot := util.ModuleRoot(dir); moduleRoot != "" {
+		diagnosis.GoModPath = filepath.Join(moduleRoot, "go.mod")
+		diagnosis.RequiredVersion, e = requiredPegomockVersion(diagnosis.GoModPath)
+		if e != nil {
+			return Diagnosis{}, e
+		}
+	}
+	e = filepath.Walk(dir, func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		if info.IsDir() {
+			if path != dir && (info.Name() == "vendor" || strings.HasPrefix(info.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		generatorVersion, isMock, e := readGeneratorVersion(path)
+		if e != nil || !isMock {
+			return e
+		}
+		diagnosis.MockFiles = append(diagnosis.MockFiles, MockFile{
+			Path:             path,
+			GeneratorVersion: generatorVersion,
+			Reason:           diagnosis.regenerationReason(generatorVersion),
+		})
+		return nil
+	})
+	if e != nil {
+		return Diagnosis{}, e
+	}
+	return diagnosis, nil
+}
+
+var requirePattern = regexp.MustCompile(`(?m)^\s*(?:require\s+)?(\S+)\s+(v\S+)`)
+
+// requiredPegomockVersion returns the version of the pegomock library required
+// in the go.mod file at goModPath, or "".
+func requiredPegomockVersion(goModPath string) (string, error) {
+	content, e := ioutil.ReadFile(goModPath)
+	if e != nil {
+		return "", fmt.Errorf("Could not read file %v: %v", goModPath, e)
+	}
+	for _, match := range requirePattern.FindAllStringSubmatch(string(content), -1) {
+		if version.IsModulePath(match[1]) {
+			return match[2], nil
+		}
+	}
+	return "", nil
+}
+
+// readGeneratorVersion reads the header of the file at path. Matcher files are
+// generated by pegomock as well, but don't have a source and are not reported.
+func readGeneratorVersion(path string) (generatorVersion string, isMock bool, e error) {
+	file, e := os.Open(path)
+	if e != nil {
+		return "", false, e
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || scanner.Text() != "// Code generated by pegomock. DO NOT EDIT." {
+		return "", false, scanner.Err()
+	}
+	for scanner.Scan() && !strings.HasPrefix(scanner.Text(), "package ") {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "// Source: "):
+			isMock = true
+		case strings.HasPrefix(line, "// Generator version: "):
+			generatorVersion = strings.TrimPrefix(line, "// Generator version: ")
+		}
+	}
+	return generatorVersion, isMock, scanner.Err()
+}
+
+func (diagnosis Diagnosis) regenerationReason(generatorVersion string) string {
+	if generatorVersion == "" {
+		return "no generator version in header. It was generated by an older version of pegomock or with a custom template that doesn't emit it"
+	}
+	var reasons []string
+	if generatorVersion != diagnosis.GeneratorVersion {
+		reasons = append(reasons, "the pegomock binary is "+diagnosis.GeneratorVersion)
+	}
+	if diagnosis.RequiredVersion != "" && generatorVersion != diagnosis.RequiredVersion {
+		reasons = append(reasons, "go.mod requires the pegomock library "+diagnosis.RequiredVersion)
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("generated by %v, but %v", generatorVersion, strings.Join(reasons, " and "))
+}
+
+// BinaryMismatch returns why the running pegomock binary doesn't match the
+// required pegomock library, or "" if it does or if that can't be told.
+func (diagnosis Diagnosis) BinaryMismatch() string {
+	if diagnosis.RequiredVersion == "" || diagnosis.GeneratorVersion == version.Unknown ||
+		diagnosis.GeneratorVersion == diagnosis.RequiredVersion {
+		return ""
+	}
+	return fmt.Sprintf("The pegomock binary is %v, but %v requires the pegomock library %v. "+
+		"Mocks generated by this binary may not compile against the library. Install the matching pegomock binary.",
+		diagnosis.GeneratorVersion, diagnosis.GoModPath, diagnosis.RequiredVersion)
+}
+
+// FilesNeedingRegeneration returns the mocks that were not generated by the
+// version of the pegomock binary and the required pegomock library.
+func (diagnosis Diagnosis) FilesNeedingRegeneration() []MockFile {
+	var mockFiles []MockFile
+	for _, mockFile := range diagnosis.MockFiles {
+		if mockFile.Reason != "" {
+			mockFiles = append(mockFiles, mockFile)
+		}
+	}
+	return mockFiles
+}
+
+// HasProblems reports whether the binary mismatches the library or any mock
+// needs regeneration.
+func (diagnosis Diagnosis) HasProblems() bool {
+	return diagnosis.BinaryMismatch() != "" || len(diagnosis.FilesNeedingRegeneration()) > 0
+}
+
+// WriteReport writes a human-readable report of the diagnosis to out.
+func (diagnosis Diagnosis) WriteReport(out io.Writer) {
+	fmt.Fprintf(out, "pegomock binary:  %v (commit %v)\n", diagnosis.GeneratorVersion, diagnosis.GeneratorCommit)
+	switch {
+	case diagnosis.GoModPath == "":
+		fmt.Fprintln(out, "pegomock library: not part of a module")
+	case diagnosis.RequiredVersion == "":
+		fmt.Fprintf(out, "pegomock library: not required in %v\n", diagnosis.GoModPath)
+	default:
+		fmt.Fprintf(out, "pegomock library: %v (required in %v)\n", diagnosis.RequiredVersion, diagnosis.GoModPath)
+	}
+	fmt.Fprintf(out, "generated mocks:  %v in %v\n", len(diagnosis.MockFiles), diagnosis.Dir)
+
+	if !diagnosis.HasProblems() {
+		fmt.Fprintln(out, "\nNo problems found.")
+		return
+	}
+	if mismatch := diagnosis.BinaryMismatch(); mismatch != "" {
+		fmt.Fprintf(out, "\n%v\n", mismatch)
+	}
+	if mockFiles := diagnosis.FilesNeedingRegeneration(); len(mockFiles) > 0 {
+		fmt.Fprintf(out, "\n%v mock(s) need regeneration:\n", len(mockFiles))
+		for _, mockFile := range mockFiles {
+			fmt.Fprintf(out, "\t%v: %v\n", mockFile.Path, mockFile.Reason)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package filehandling
 
 import (
+	"bytes"
 	"fmt"
+	"go/token"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -16,38 +19,82 @@ import (
 	"github.com/petergtz/pegomock/pegomock/util"
 )
 
-func GenerateMockFileInOutputDir(
-	args []string,
-	outputDirPath string,
-	outputFilePathOverride string,
-	nameOut string,
-	packageOut string,
-	selfPackage string,
-	debugParser bool,
-	out io.Writer,
-	useExperimentalModelGen bool,
-	shouldGenerateMatchers bool,
-	matchersDestination string) {
+// GenerateOptions configure how GenerateMockFile and the functions like it
+// generate mocks. The zero value generates mocks named after their interfaces,
+// without prefix, and writes no matchers.
+type GenerateOptions struct {
+	// MockName is the name of the mock. If it's empty, mocks are named
+	// NamePrefix + interface + NameSuffix.
+	MockName   string
+	NamePrefix string
+	NameSuffix string
+	// PackageOut is the package of the generated mocks.
+	PackageOut  string
+	SelfPackage string
+	// DebugParser makes the loaded model be printed to DebugOut.
+	DebugParser             bool
+	DebugOut                io.Writer
+	UseExperimentalModelGen bool
+	// GenerateMatchers makes the matchers for the mocks' types be written to
+	// MatchersDestination, or to the matchers directory next to the mocks.
+	GenerateMatchers    bool
+	MatchersDestination string
+	// BuildConstraint is put at the top of the generated files, see
+	// util.GenerateFlags.BuildConstraint.
+	BuildConstraint string
+	ContextAware    bool
+	Provide         bool
+	TemplatePath    string
+	TemplateData    map[string]string
+	// Export names the matchers' package after MatchersDestination instead of
+	// always naming it matchers, see ValidateExport.
+	Export bool
+	// Standalone generates mocks that don't depend on pegomock, see
+	// mockgen.GenerateStandaloneOutput.
+	Standalone bool
+	// Force makes GenerateMockFileInOutputDir and GenerateMockFileFromModel
+	// write files even if their content wouldn't change.
+	Force bool
+	// BuildTags are the tags the interfaces are loaded with.
+	BuildTags []string
+}
+
+// GenerateOptionsFromFlags returns the GenerateOptions set by flags. Options
+// that the commands determine themselves, like PackageOut, are left unset.
+func GenerateOptionsFromFlags(flags util.GenerateFlags) GenerateOptions {
+	return GenerateOptions{
+		MockName:        *flags.MockName,
+		NamePrefix:      *flags.NamePrefix,
+		NameSuffix:      *flags.NameSuffix,
+		SelfPackage:     *flags.SelfPackage,
+		BuildConstraint: flags.BuildConstraint(),
+		ContextAware:    *flags.ContextAware,
+		Provide:         *flags.Provide,
+		TemplatePath:    *flags.TemplatePath,
+		TemplateData:    *flags.TemplateData,
+		Export:          *flags.Export,
+		Standalone:      *flags.Standalone,
+		BuildTags:       flags.BuildTags(),
+	}
+}
 
+// GenerateMockFileInOutputDir is like GenerateMockFile, but writes the mocks to
+// the file OutputFilePath returns. It always loads the interfaces and generates
+// the mocks, but unless options.Force is set, it doesn't rewrite mock and
+// matcher files whose content wouldn't change. Their modification times stay
+// as they are, so builds depending on them stay cached. written reports whether
+// it wrote any file.
+func GenerateMockFileInOutputDir(args []string, outputDirPath string, outputFilePathOverride string, options GenerateOptions) (written bool, err error) {
 	// if a file path override is specified
 	// ensure all directories in the path are created
 	if outputFilePathOverride != "" {
 		if err := os.MkdirAll(filepath.Dir(outputFilePathOverride), 0755); err != nil {
-			panic(fmt.Errorf("Failed to make output directory, error: %v", err))
+			return false, fmt.Errorf("Failed to make output directory, error: %v", err)
 		}
 	}
 
-	GenerateMockFile(
-		args,
-		OutputFilePath(args, outputDirPath, outputFilePathOverride),
-		nameOut,
-		packageOut,
-		selfPackage,
-		debugParser,
-		out,
-		useExperimentalModelGen,
-		shouldGenerateMatchers,
-		matchersDestination)
+	mockSourceCode, matcherSourceCodes := GenerateMockSourceCode(args, options)
+	return writeMockFile(OutputFilePath(args, outputDirPath, outputFilePathOverride), mockSourceCode, matcherSourceCodes, options)
 }
 
 func OutputFilePath(args []string, outputDirPath string, outputFilePathOverride string) string {
@@ -60,33 +107,192 @@ func OutputFilePath(args []string, outputDirPath string, outputFilePathOverride
 	}
 }
 
-func GenerateMockFile(args []string, outputFilePath string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer, useExperimentalModelGen bool, shouldGenerateMatchers bool, matchersDestination string) {
-	mockSourceCode, matcherSourceCodes := GenerateMockSourceCode(args, nameOut, packageOut, selfPackage, debugParser, out, useExperimentalModelGen)
+// GenerateMockFile writes the mocks to outputFilePath and, if
+// options.GenerateMatchers is set, their matchers. It always writes the files,
+// regardless of options.Force.
+func GenerateMockFile(args []string, outputFilePath string, options GenerateOptions) {
+	mockSourceCode, matcherSourceCodes := GenerateMockSourceCode(args, options)
+	options.Force = true
+	_, err := writeMockFile(outputFilePath, mockSourceCode, matcherSourceCodes, options)
+	util.PanicOnError(err)
+}
+
+// GenerateMockFileFromModel is like GenerateMockFile, but generates the mocks
+// from ast, read from the model file at modelPath, see LoadModelFile. Like
+// GenerateMockFileInOutputDir, it only writes files whose content changed
+// unless options.Force is set.
+func GenerateMockFileFromModel(ast *model.Package, modelPath string, outputFilePath string, options GenerateOptions) {
+	mockSourceCode, matcherSourceCodes := mockSourceCodeFor(ast, modelPath, options)
+	_, err := writeMockFile(outputFilePath, mockSourceCode, matcherSourceCodes, options)
+	util.PanicOnError(err)
+}
 
-	err := ioutil.WriteFile(outputFilePath, mockSourceCode, 0664)
+func writeMockFile(outputFilePath string, mockSourceCode []byte, matcherSourceCodes map[string]string, options GenerateOptions) (written bool, err error) {
+	written, err = writeFileUnlessUnchanged(outputFilePath, mockSourceCode, options.Force)
 	if err != nil {
-		panic(fmt.Errorf("Failed writing to destination: %v", err))
+		return false, err
 	}
 
-	if shouldGenerateMatchers {
+	if options.GenerateMatchers {
 		matchersPath := filepath.Join(filepath.Dir(outputFilePath), "matchers")
-		if matchersDestination != "" {
-			matchersPath = matchersDestination
+		if options.MatchersDestination != "" {
+			matchersPath = options.MatchersDestination
 		}
 		err = os.MkdirAll(matchersPath, 0755)
 		if err != nil {
-			panic(fmt.Errorf("Failed making dirs \"%v\": %v", matchersPath, err))
+			return written, fmt.Errorf("Failed making dirs \"%v\": %v", matchersPath, err)
 		}
 		for matcherTypeName, matcherSourceCode := range matcherSourceCodes {
-			err := ioutil.WriteFile(filepath.Join(matchersPath, matcherTypeName+".go"), []byte(matcherSourceCode), 0664)
+			if options.Export {
+				matcherSourceCode = mockgen.MatcherSourceCodeInPackage(matcherSourceCode, filepath.Base(matchersPath))
+			}
+			matcherWritten, err := writeFileUnlessUnchanged(filepath.Join(matchersPath, matcherTypeName+".go"), []byte(matcherSourceCode), options.Force)
 			if err != nil {
-				panic(fmt.Errorf("Failed writing to destination: %v", err))
+				return written, err
 			}
+			written = written || matcherWritten
+		}
+	}
+	return written, nil
+}
+
+// writeFileUnlessUnchanged writes content to path, unless force is not set and
+// the file's content already equals content. Skipping the write keeps the
+// file's modification time, so go build doesn't recompile its package.
+func writeFileUnlessUnchanged(path string, content []byte, force bool) (bool, error) {
+	if !force {
+		if existingContent, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existingContent, content) {
+			return false, nil
+		}
+	}
+	if err := ioutil.WriteFile(path, content, 0664); err != nil {
+		return false, fmt.Errorf("Failed writing to destination: %v", err)
+	}
+	return true, nil
+}
+
+// ExportedOutputFilePath is like OutputFilePath, but names the default output
+// file without the _test suffix, because mocks generated with --export must be
+// importable.
+func ExportedOutputFilePath(args []string, outputDirPath string, outputFilePathOverride string) string {
+	if outputFilePathOverride != "" {
+		return outputFilePathOverride
+	}
+	return strings.TrimSuffix(OutputFilePath(args, outputDirPath, ""), "_test.go") + ".go"
+}
+
+// ValidateExport returns an error if mocks generated with --export into
+// outputFilePath and packageOut could not be imported by other packages, or if
+// the mock named nameOut could not be used by them. An empty nameOut stands for
+// the default names, namePrefix + interface + nameSuffix, which are exported if
+// namePrefix is, or if it is empty, if the interface is. All other generated identifiers,
+// e.g. Verifier<MockName> and <MockName>_<Method>_Returns, are prefixed with the
+// mock name and thus exported, too.
+func ValidateExport(nameOut string, namePrefix string, nameSuffix string, packageOut string, outputFilePath string) error {
+	if strings.HasSuffix(packageOut, "_test") {
+		return fmt.Errorf("Cannot export mocks from test package %v. Choose another package using --package.", packageOut)
+	}
+	if strings.HasSuffix(outputFilePath, "_test.go") {
+		return fmt.Errorf("Cannot export mocks from test file %v. Choose another output file using --output.", outputFilePath)
+	}
+	if nameOut != "" && !token.IsExported(nameOut) {
+		return fmt.Errorf("Cannot export mock %v, because its name is not exported. Choose another name using --mock-name.", nameOut)
+	}
+	if nameOut == "" && namePrefix != "" && !token.IsExported(namePrefix) {
+		return fmt.Errorf("Cannot export mocks with prefix %v, because their names are not exported. Choose another prefix using --name-prefix.", namePrefix)
+	}
+	return nil
+}
+
+// ValidateErrorPositions returns an error if the interfaces in args have methods
+// returning an error other than as their last result. Mocks generated with
+// --strict must not have such methods.
+func ValidateErrorPositions(args []string, useExperimentalModelGen bool, buildTags ...string) error {
+	ast, _ := loadModel(args, useExperimentalModelGen, buildTags...)
+	return ValidateModelErrorPositions(ast)
+}
+
+// ValidateModelErrorPositions is like ValidateErrorPositions for a model that is
+// loaded already.
+func ValidateModelErrorPositions(ast *model.Package) error {
+	if methods := mockgen.NonStandardErrorPositions(ast); len(methods) > 0 {
+		return fmt.Errorf("Refusing to generate mocks in strict mode: %v return an error, but not as their last return value.",
+			strings.Join(methods, ", "))
+	}
+	return nil
+}
+
+func GenerateMockSourceCode(args []string, options GenerateOptions) ([]byte, map[string]string) {
+	ast, src := loadModel(args, options.UseExperimentalModelGen, options.BuildTags...)
+
+	if options.DebugParser {
+		ast.Print(options.DebugOut)
+	}
+	return mockSourceCodeFor(ast, src, options)
+}
+
+func mockSourceCodeFor(ast *model.Package, src string, options GenerateOptions) ([]byte, map[string]string) {
+	if options.Standalone {
+		return mockgen.GenerateStandaloneOutput(ast, src, options.MockName, options.NamePrefix, options.NameSuffix, options.PackageOut,
+			options.SelfPackage, options.BuildConstraint, options.ContextAware), nil
+	}
+	var mockTemplate string
+	if options.TemplatePath != "" {
+		templateBytes, err := ioutil.ReadFile(options.TemplatePath)
+		if err != nil {
+			panic(fmt.Errorf("Reading template failed: %v", err))
 		}
+		mockTemplate = string(templateBytes)
+	}
+
+	return mockgen.GenerateOutput(ast, src, options.MockName, options.NamePrefix, options.NameSuffix, options.PackageOut, options.SelfPackage,
+		options.BuildConstraint, options.ContextAware, options.Provide, mockTemplate, options.TemplateData)
+}
+
+// ExamplesFilePath returns the path of the examples file for the mock file at
+// mockFilePath, e.g. mock_display_example_test.go for mock_display_test.go.
+func ExamplesFilePath(mockFilePath string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(mockFilePath, ".go"), "_test") + "_example_test.go"
+}
+
+// GenerateExamplesFile writes Example functions for the mocks in the mock file
+// at mockFilePath to ExamplesFilePath(mockFilePath). See mockgen.GenerateExamples.
+func GenerateExamplesFile(args []string, mockFilePath string, options GenerateOptions) {
+	ast, src := loadModel(args, options.UseExperimentalModelGen, options.BuildTags...)
+	GenerateExamplesFileFromModel(ast, src, mockFilePath, options)
+}
+
+// GenerateExamplesFileFromModel is like GenerateExamplesFile, but for mocks
+// generated from ast. src tells where ast came from, e.g. a model file's path.
+func GenerateExamplesFileFromModel(ast *model.Package, src string, mockFilePath string, options GenerateOptions) {
+	var mockPackagePath string
+	if !strings.HasSuffix(options.PackageOut, "_test") {
+		mockPackagePath = importPathOfDir(filepath.Dir(mockFilePath))
 	}
+	examplesSourceCode := mockgen.GenerateExamples(ast, src, options.MockName, options.NamePrefix, options.NameSuffix, options.PackageOut,
+		mockPackagePath, options.BuildConstraint, options.ContextAware)
+	if err := ioutil.WriteFile(ExamplesFilePath(mockFilePath), examplesSourceCode, 0664); err != nil {
+		panic(fmt.Errorf("Failed writing to destination: %v", err))
+	}
+}
+
+// importPathOfDir returns the import path of the package in dir, or "" if it
+// cannot be determined.
+func importPathOfDir(dir string) string {
+	cmd := exec.Command("go", "list", "-find", "-f", "{{.ImportPath}}", ".")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	importPath := strings.TrimSpace(string(output))
+	if importPath == "." || strings.HasPrefix(importPath, "_") {
+		return ""
+	}
+	return importPath
 }
 
-func GenerateMockSourceCode(args []string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer, useExperimentalModelGen bool) ([]byte, map[string]string) {
+func loadModel(args []string, useExperimentalModelGen bool, buildTags ...string) (*model.Package, string) {
 	var err error
 
 	var ast *model.Package
@@ -99,20 +305,160 @@ func GenerateMockSourceCode(args []string, nameOut string, packageOut string, se
 			log.Fatal("Expected exactly two arguments, but got " + fmt.Sprint(args))
 		}
 		if useExperimentalModelGen {
-			ast, err = loader.GenerateModel(args[0], args[1])
+			ast, err = loader.GenerateModel(args[0], args[1], buildTags...)
 
 		} else {
-			ast, err = gomock.Reflect(args[0], strings.Split(args[1], ","))
+			ast, err = gomock.Reflect(args[0], strings.Split(args[1], ","), buildTags...)
 		}
 		src = fmt.Sprintf("%v (interfaces: %v)", args[0], args[1])
 	}
 	if err != nil {
 		panic(fmt.Errorf("Loading input failed: %v", err))
 	}
+	return ast, src
+}
+
+// LoadModelWithTests is like loading the model for GenerateMockFile with
+// useExperimentalModelGen, but also finds interfaces declared in the package's
+// _test.go files. It returns the model and a description of its source.
+func LoadModelWithTests(args []string, buildTags ...string) (*model.Package, string) {
+	if util.SourceMode(args) {
+		// Source files are parsed as they are, whether they are test files or not.
+		return loadModel(args, false, buildTags...)
+	}
+	if len(args) != 2 {
+		log.Fatal("Expected exactly two arguments, but got " + fmt.Sprint(args))
+	}
+	ast, err := loader.GenerateModelWithTests(args[0], args[1], buildTags...)
+	if err != nil {
+		panic(fmt.Errorf("Loading input failed: %v", err))
+	}
+	return ast, fmt.Sprintf("%v (interfaces: %v)", args[0], args[1])
+}
+
+// ValidateVisibility returns an error if mocks for interfaces of the package
+// pkgPath, named pkgName, cannot be generated into package packageOut at
+// outputFilePath, because that package is not allowed to import pkgPath. This is
+// the case if pkgPath is internal to a tree the output is not part of, or if it
+// is an external test package, which no package can import. pkgName may be
+// empty if it is unknown.
+func ValidateVisibility(pkgPath string, pkgName string, outputFilePath string, packageOut string) error {
+	if pkgPath == "" {
+		return nil
+	}
+	outputPkgPath := importPathOfPossiblyMissingDir(filepath.Dir(outputFilePath))
+	if outputPkgPath == "" {
+		return nil
+	}
+	if strings.HasSuffix(pkgName, "_test") {
+		if packageOut == pkgName && outputPkgPath+"_test" == pkgPath {
+			return nil
+		}
+		return fmt.Errorf("Cannot generate mocks into package %v: the interfaces are declared in test package %v, which cannot be imported. "+
+			"Generate the mocks into its directory using --package %v.", packageOut, pkgName, pkgName)
+	}
+	if outputPkgPath == pkgPath || canImport(outputPkgPath, pkgPath) {
+		return nil
+	}
+	return fmt.Errorf("Cannot generate mocks into %v: package %v is internal and can only be imported from within %v. "+
+		"Choose another output directory or use --self_package %v to generate the mocks into the interfaces' own package.",
+		outputPkgPath, pkgPath, internalRoot(pkgPath), pkgPath)
+}
+
+// canImport reports whether the package importer may import the package
+// imported according to Go's rules for internal packages.
+func canImport(importer string, imported string) bool {
+	root := internalRoot(imported)
+	return root == "" || importer == root || strings.HasPrefix(importer, root+"/")
+}
+
+// internalRoot returns the import path of the tree that may import pkgPath, i.e.
+// the parent of its last internal element. It returns "" if pkgPath is not
+// internal or if its internal element is its first one, as for the standard
+// library's internal packages.
+func internalRoot(pkgPath string) string {
+	elements := strings.Split(pkgPath, "/")
+	for i := len(elements) - 1; i > 0; i-- {
+		if elements[i] == "internal" {
+			return strings.Join(elements[:i], "/")
+		}
+	}
+	return ""
+}
+
+// importPathOfPossiblyMissingDir is like importPathOfDir, but also works for
+// directories that don't exist yet, e.g. ones given via --output-dir.
+func importPathOfPossiblyMissingDir(dir string) string {
+	if _, err := os.Stat(dir); err == nil {
+		return importPathOfDir(dir)
+	}
+	parent := filepath.Dir(dir)
+	if parent == dir {
+		return ""
+	}
+	parentPkgPath := importPathOfPossiblyMissingDir(parent)
+	if parentPkgPath == "" {
+		return ""
+	}
+	return parentPkgPath + "/" + filepath.Base(dir)
+}
+
+// ColocatedOutput returns where the mock for interfaceName goes when it is
+// generated into the interface's own package pkgPath, e.g. because pkgPath is
+// internal. The output file keeps its name, but is moved into the package's
+// directory. The mock name defaults to an unexported version of namePrefix +
+// interfaceName + nameSuffix, e.g. mockDisplay, which makes the mock's
+// helpers, e.g. its constructor and verifier, unexported too. This way, the
+// package's API doesn't change.
+func ColocatedOutput(pkgPath string, outputFilePath string, nameOut string, namePrefix string, nameSuffix string, interfaceName string) (colocatedOutputFilePath string, packageOut string, colocatedNameOut string, err error) {
+	output, err := exec.Command("go", "list", "-f", "{{.Dir}}\n{{.Name}}", strings.TrimSuffix(pkgPath, "_test")).Output()
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not find directory of package %v: %v", pkgPath, err)
+	}
+	dirAndName := strings.Split(strings.TrimSpace(string(output)), "\n")
+	packageOut = dirAndName[len(dirAndName)-1]
+	if strings.HasSuffix(pkgPath, "_test") {
+		packageOut += "_test"
+	}
+	if nameOut == "" && !strings.Contains(interfaceName, ",") {
+		nameOut = namePrefix + interfaceName + nameSuffix
+		nameOut = strings.ToLower(nameOut[:1]) + nameOut[1:]
+	}
+	return filepath.Join(dirAndName[0], filepath.Base(outputFilePath)), packageOut, nameOut, nil
+}
+
+// DumpModel writes the model of the interfaces in args, given like to
+// GenerateMockFile, to w in its JSON form. GenerateMockFileFromModel can generate
+// mocks from it later.
+func DumpModel(args []string, useExperimentalModelGen bool, w io.Writer, buildTags ...string) error {
+	ast, _ := loadModel(args, useExperimentalModelGen, buildTags...)
+	return ast.WriteJSON(w)
+}
 
-	if debugParser {
-		ast.Print(out)
+// LoadModelFile reads the model written by DumpModel from modelPath.
+func LoadModelFile(modelPath string) *model.Package {
+	file, err := os.Open(modelPath)
+	if err != nil {
+		panic(fmt.Errorf("Loading model failed: %v", err))
+	}
+	defer file.Close()
+	ast, err := model.ReadJSON(file)
+	if err != nil {
+		panic(fmt.Errorf("Loading model %v failed: %v", modelPath, err))
 	}
+	return ast
+}
 
-	return mockgen.GenerateOutput(ast, src, nameOut, packageOut, selfPackage)
+// ModelSourceArgs returns args like the ones GenerateMockFile takes for the
+// interfaces in ast, e.g. to pass them to OutputFilePath.
+func ModelSourceArgs(ast *model.Package) []string {
+	packagePath := ast.PkgPath
+	if packagePath == "" {
+		packagePath = ast.Name
+	}
+	names := make([]string, len(ast.Interfaces))
+	for i, iface := range ast.Interfaces {
+		names[i] = iface.Name
+	}
+	return []string{packagePath, strings.Join(names, ",")}
 }
@@ -15,43 +15,72 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/petergtz/pegomock/model"
+	"github.com/petergtz/pegomock/pegomock/clean"
+	"github.com/petergtz/pegomock/pegomock/doctor"
 	"github.com/petergtz/pegomock/pegomock/filehandling"
+	"github.com/petergtz/pegomock/pegomock/pattern"
 	"github.com/petergtz/pegomock/pegomock/remove"
+	"github.com/petergtz/pegomock/pegomock/report"
 	"github.com/petergtz/pegomock/pegomock/util"
 	"github.com/petergtz/pegomock/pegomock/watch"
+	"github.com/petergtz/pegomock/version"
 )
 
 var (
 	app = kingpin.New("pegomock", "Generates mocks based on interfaces.")
 )
 
+const (
+	// watchInterval is how often the watch command updates mocks.
+	watchInterval = 2 * time.Second
+	// watchTestDebounce is how long --run-tests waits for further regenerations
+	// before running tests. It spans more than one update.
+	watchTestDebounce = watchInterval + time.Second
+)
+
+// Exit statuses of pegomock.
+const (
+	// exitFailure is for failures like generation errors.
+	exitFailure = 1
+	// exitUsage is for invalid command lines.
+	exitUsage = 2
+)
+
+// failureStatus is the status pegomock exits with when it fails. kingpin
+// always terminates with 1, so fatalUsage sets it to exitUsage first.
+var failureStatus = exitFailure
+
 func main() {
+	app.Terminate(func(status int) {
+		if status != 0 {
+			status = failureStatus
+		}
+		os.Exit(status)
+	})
 	Run(os.Args, os.Stderr, os.Stdin, app, make(chan bool))
 }
 
 func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application, done chan bool) {
+	failureStatus = exitFailure
 
 	workingDir, err := os.Getwd()
 	app.FatalIfError(err, "")
 
 	var (
-		generateCmd    = app.Command("generate", "Generate mocks based on the args provided. ")
-		destination    = generateCmd.Flag("output", "Output file; defaults to mock_<interface>_test.go.").Short('o').String()
-		destinationDir = generateCmd.Flag("output-dir", "Output directory; defaults to current directory. If set, package name defaults to this directory, unless explicitly overridden.").String()
-		mockNameOut    = generateCmd.Flag("mock-name", "Struct name of the generated mock; defaults to the interface prefixed with Mock").String()
-		packageOut     = generateCmd.Flag("package", "Package of the generated code; defaults to the package from which pegomock was executed suffixed with _test").String()
-		// TODO: self_package was taken as is from GoMock.
-		//       Still don't understand what it's really there for.
-		//       So for now it's not tested.
-		selfPackage            = generateCmd.Flag("self_package", "If set, the package this mock will be part of.").String()
+		generateCmd            = app.Command("generate", "Generate mocks based on the args provided. ")
+		generateFlags          = util.DefineGenerateFlags(generateCmd)
+		destinationDir         = generateCmd.Flag("output-dir", "Output directory; defaults to current directory. If set, package name defaults to this directory, unless explicitly overridden.").String()
 		debugParser            = generateCmd.Flag("debug", "Print debug information.").Short('d').Bool()
 		shouldGenerateMatchers = generateCmd.Flag("generate-matchers", "Generate matchers for all non built-in types in a \"matchers\" "+
 			"directory in the same directory where the mock file gets generated.").Short('m').Default("false").Bool()
@@ -62,11 +91,37 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 			"than the current reflect-based modelgen. E.g. reflect cannot detect method parameter names,"+
 			" and has to generate them based on a pattern. In a code editor with code assistence, this doesn't provide good help. "+
 			"\n\nThis option only works when specifying package path + interface, not with .go source files. Also, you can only specify *one* interface. This option cannot be used with the watch command.").Bool()
-		generateCmdArgs = generateCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file").Required().Strings()
+		withExamples = generateCmd.Flag("with-examples", "Also generate a mock_<interface>_example_test.go file with an Example function "+
+			"for each method, showing how to stub and verify it. The examples assume the built-in template.").Bool()
+		generateDryRun    = generateCmd.Flag("dry-run", "With a package pattern, just list the mocks that would be generated. Don't write anything.").Bool()
+		generateFromModel = generateCmd.Flag("from-model", "Generate the mocks from a JSON model file written by dump-model instead of "+
+			"from Go source. No args must be given then.").ExistingFile()
+		includeTests = generateCmd.Flag("include-tests", "Also load the package's _test.go files, so interfaces declared in them "+
+			"can be mocked. Uses the source parser of --use-experimental-model-gen.").Bool()
+		generateJSON = generateCmd.Flag("json", "Write a JSON report with the package, interface, output file and status "+
+			"(created, updated, unchanged or failed) of every mock to standard out. Logs still go to standard error. "+
+			"With a package pattern, failing mocks don't stop the others from being generated.").Bool()
+		generateForce = generateCmd.Flag("force", "Write the mock and matcher files even if their content wouldn't change. Without it, "+
+			"such files are left untouched, so builds depending on them stay cached.").Bool()
+		generateCmdArgs = generateCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file. "+
+			"Alternatively, a package pattern like ./... + an (optional) interface pattern like Repo* to generate mocks for all matching interfaces.").Strings()
 
 		watchCmd       = app.Command("watch", "Watch over changes in interfaces and regenerate mocks if changes are detected.")
 		watchRecursive = watchCmd.Flag("recursive", "Recursively watch sub-directories as well.").Short('r').Bool()
-		watchPackages  = watchCmd.Arg("directories...", "One or more directories of Go packages to watch").Strings()
+		watchOnce      = watchCmd.Flag("once", "Generate all mocks listed in interfaces_to_mock files once, print a summary and exit. "+
+			"Exits with non-zero status if generating any mock failed. Useful for CI.").Bool()
+		watchSummaryFile = watchCmd.Flag("summary-file", "Write a JSON summary with the status (generated, unchanged, skipped or failed), "+
+			"reason and output file of every interfaces_to_mock line to this file after every pass.").String()
+		watchClean = watchCmd.Flag("clean", "On every pass, also delete mocks whose interfaces don't exist anymore, "+
+			"like the clean command does.").Bool()
+		watchTags = watchCmd.Flag("tags", "Comma-separated build tags to generate all mocks with, in addition to the ones "+
+			"of a line. See the generate command's --tags.").String()
+		watchRunTests = watchCmd.Flag("run-tests", "After mocks of a package were regenerated, run its tests with go test. "+
+			"Regenerations in quick succession trigger a single run. Failing tests don't stop watching.").Bool()
+		watchTestArgs = watchCmd.Flag("test-args", `Arguments passed on to go test by --run-tests, separated by spaces, e.g. "-run TestFoo -count=1".`).String()
+		watchJSON     = watchCmd.Flag("json", "With --once, also write the JSON summary of --summary-file to standard out.").Bool()
+		watchPackages = watchCmd.Arg("directories...", "One or more directories of Go packages to watch. Defaults to the working directory or, "+
+			"if it has a go.work file, the directories of the workspace's modules.").Strings()
 
 		removeMocks          = app.Command("remove", "Remove mocks generated by Pegomock")
 		removeRecursive      = removeMocks.Flag("recursive", "Remove recursively in all sub-directories").Default("false").Short('r').Bool()
@@ -74,36 +129,96 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 		removeDryRun         = removeMocks.Flag("dry-run", "Just show what would be done. Don't delete anything.").Default("false").Short('d').Bool()
 		removeSilent         = removeMocks.Flag("silent", "Don't write anything to standard out.").Default("false").Short('s').Bool()
 		removePath           = removeMocks.Arg("path", "Use as root directory instead of current working directory.").Default("").String()
+
+		cleanCmd       = app.Command("clean", "Delete mocks generated by Pegomock whose interfaces were renamed or deleted.")
+		cleanRecursive = cleanCmd.Flag("recursive", "Clean recursively in all sub-directories").Default("false").Short('r').Bool()
+		cleanDryRun    = cleanCmd.Flag("dry-run", "Just list the stale mocks. Don't delete anything.").Default("false").Short('d').Bool()
+		cleanDirs      = cleanCmd.Arg("dirs", "Directories to clean instead of the current working directory.").Strings()
+
+		dumpModelCmd    = app.Command("dump-model", "Write the model of interfaces as JSON, e.g. to check it into a contracts repository and generate mocks from it with generate --from-model.")
+		dumpModelOutput = dumpModelCmd.Flag("output", "Output file; defaults to standard out.").Short('o').String()
+		dumpModelArgs   = dumpModelCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file.").Required().Strings()
+
+		versionCmd = app.Command("version", "Print the version and commit of pegomock and the version of the pegomock library it was built against.")
+
+		doctorCmd = app.Command("doctor", "Check that the pegomock binary matches the pegomock library required in go.mod, "+
+			"and list the mocks that were generated by other versions and need regeneration. Exits with non-zero status if there are problems.")
+		doctorDir = doctorCmd.Arg("dir", "Directory to check recursively instead of the current working directory.").Default("").String()
 	)
 
 	app.Writer(out)
-	switch kingpin.MustParse(app.Parse(cliArgs[1:])) {
+	command, err := app.Parse(cliArgs[1:])
+	if err != nil {
+		failureStatus = exitUsage
+		app.Fatalf("%s, try --help", err)
+	}
+	switch command {
 
 	case generateCmd.FullCommand():
-		if err := util.ValidateArgs(*generateCmdArgs); err != nil {
-			app.FatalUsage(err.Error())
+		if err := generateFlags.ValidateStandalone(); err != nil {
+			fatalUsage(app, err.Error())
 		}
-		sourceArgs, err := util.SourceArgs(*generateCmdArgs)
-		if err != nil {
-			app.FatalUsage(err.Error())
+		if err := generateFlags.ValidateNameAffixes(); err != nil {
+			fatalUsage(app, err.Error())
+		}
+		if *generateFlags.Standalone && (*shouldGenerateMatchers || *withExamples) {
+			fatalUsage(app, "Cannot use --generate-matchers or --with-examples with --standalone, because they need the pegomock library")
+		}
+		if *generateJSON && *generateDryRun {
+			fatalUsage(app, "Cannot use --json with --dry-run")
+		}
+		defer exitOnFailure(app)
+		reporter := &mockReporter{json: *generateJSON}
+		var sourceArgs []string
+		var modelPackage *model.Package
+		modelSource := *generateFromModel
+		if *generateFromModel != "" {
+			if len(*generateCmdArgs) != 0 {
+				fatalUsage(app, "Cannot use args with --from-model")
+			}
+			if *includeTests {
+				fatalUsage(app, "Cannot use --include-tests with --from-model")
+			}
+			modelPackage = filehandling.LoadModelFile(*generateFromModel)
+			sourceArgs = filehandling.ModelSourceArgs(modelPackage)
+		} else {
+			if err := util.ValidateArgs(*generateCmdArgs); err != nil {
+				fatalUsage(app, err.Error())
+			}
+			if pattern.IsPattern(*generateCmdArgs) {
+				if *generateFlags.Output != "" || *generateFlags.MockName != "" {
+					fatalUsage(app, "Cannot use --output or --mock-name with a package or interface pattern")
+				}
+				generateMatchingMocks(app, out, reporter, *generateCmdArgs, generateFlags, *destinationDir, *generateDryRun, *debugParser,
+					*useExperimentalModelGen, *shouldGenerateMatchers, *matchersDestination, *withExamples, *generateForce)
+				reporter.finish(app)
+				return
+			}
+			sourceArgs, err = util.SourceArgs(*generateCmdArgs)
+			if err != nil {
+				fatalUsage(app, err.Error())
+			}
+			if *includeTests {
+				modelPackage, modelSource = filehandling.LoadModelWithTests(sourceArgs, generateFlags.BuildTags()...)
+			}
 		}
 
-		if *destination != "" && *destinationDir != "" {
-			app.FatalUsage("Cannot use --output and --output-dir together")
+		if *generateFlags.Output != "" && *destinationDir != "" {
+			fatalUsage(app, "Cannot use --output and --output-dir together")
 		}
 
-		realPackageOut := *packageOut
-		if *packageOut == "" {
+		realPackageOut := *generateFlags.Package
+		if *generateFlags.Package == "" {
 			realPackageOut, err = DeterminePackageNameIn(workingDir)
 			app.FatalIfError(err, "Could not determine package name.")
 		}
 
-		realDestination := *destination
+		realDestination := *generateFlags.Output
 		realDestinationDir := workingDir
 		if *destinationDir != "" {
 			realDestinationDir, err = filepath.Abs(*destinationDir)
 			app.FatalIfError(err, "")
-			if *packageOut == "" {
+			if *generateFlags.Package == "" {
 				realPackageOut = filepath.Base(*destinationDir)
 			}
 			if util.SourceMode(sourceArgs) {
@@ -113,18 +228,67 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 			}
 		}
 
-		filehandling.GenerateMockFileInOutputDir(
-			sourceArgs,
-			realDestinationDir,
-			realDestination,
-			*mockNameOut,
-			realPackageOut,
-			*selfPackage,
-			*debugParser,
-			out,
-			*useExperimentalModelGen,
-			*shouldGenerateMatchers,
-			*matchersDestination)
+		if *generateFlags.Export {
+			if *generateFlags.Package == "" {
+				realPackageOut = strings.TrimSuffix(realPackageOut, "_test")
+			}
+			realDestination = filehandling.ExportedOutputFilePath(sourceArgs, realDestinationDir, realDestination)
+			if err := filehandling.ValidateExport(*generateFlags.MockName, *generateFlags.NamePrefix, *generateFlags.NameSuffix, realPackageOut, realDestination); err != nil {
+				fatalUsage(app, err.Error())
+			}
+		}
+		mockName := *generateFlags.MockName
+		reportedPackage, reportedInterface := sourceArgs[0], ""
+		if modelPackage != nil {
+			reportedPackage = modelPackage.PkgPath
+		}
+		if !util.SourceMode(sourceArgs) {
+			reportedInterface = sourceArgs[1]
+		}
+		reporter.generate(reportedPackage, reportedInterface, func() string {
+			if !util.SourceMode(sourceArgs) {
+				pkgPath, pkgName := sourceArgs[0], ""
+				if modelPackage != nil {
+					pkgPath, pkgName = modelPackage.PkgPath, modelPackage.Name
+				}
+				e := filehandling.ValidateVisibility(pkgPath, pkgName, filehandling.OutputFilePath(sourceArgs, realDestinationDir, realDestination), realPackageOut)
+				if e != nil && *generateFlags.SelfPackage == pkgPath {
+					realDestination, realPackageOut, mockName, e = filehandling.ColocatedOutput(
+						pkgPath, filehandling.OutputFilePath(sourceArgs, realDestinationDir, realDestination), mockName, *generateFlags.NamePrefix, *generateFlags.NameSuffix, sourceArgs[1])
+				}
+				util.PanicOnError(e)
+			}
+			return filehandling.OutputFilePath(sourceArgs, realDestinationDir, realDestination)
+		}, func() {
+			if *generateFlags.Strict {
+				if modelPackage != nil {
+					util.PanicOnError(filehandling.ValidateModelErrorPositions(modelPackage))
+				} else {
+					util.PanicOnError(filehandling.ValidateErrorPositions(sourceArgs, *useExperimentalModelGen, generateFlags.BuildTags()...))
+				}
+			}
+			if modelPackage != nil {
+				generateMockFromModel(modelPackage, modelSource, realDestinationDir, realDestination, mockName, generateFlags, realPackageOut,
+					*shouldGenerateMatchers, *matchersDestination, *withExamples, *generateForce)
+				return
+			}
+
+			options := filehandling.GenerateOptionsFromFlags(generateFlags)
+			options.MockName = mockName
+			options.PackageOut = realPackageOut
+			options.DebugParser = *debugParser
+			options.DebugOut = out
+			options.UseExperimentalModelGen = *useExperimentalModelGen
+			options.GenerateMatchers = *shouldGenerateMatchers
+			options.MatchersDestination = *matchersDestination
+			options.Force = *generateForce
+			_, e := filehandling.GenerateMockFileInOutputDir(sourceArgs, realDestinationDir, realDestination, options)
+			util.PanicOnError(e)
+			if *withExamples {
+				filehandling.GenerateExamplesFile(sourceArgs, filehandling.OutputFilePath(sourceArgs, realDestinationDir, realDestination), options)
+			}
+		})
+		reporter.finish(app)
 
 	case watchCmd.FullCommand():
 		var targetPaths []string
@@ -133,8 +297,94 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 		} else {
 			targetPaths = *watchPackages
 		}
+		if *watchRunTests && *watchOnce {
+			fatalUsage(app, "--run-tests can't be used with --once.")
+		}
+		if *watchJSON && !*watchOnce {
+			fatalUsage(app, "--json can only be used with --once.")
+		}
+		var testRunner *watch.TestRunner
+		if *watchRunTests {
+			out = watch.SynchronizedWriter(out)
+			testRunner = watch.NewTestRunner(out, strings.Fields(*watchTestArgs), watchTestDebounce)
+			// Stopping interrupts tests still running when done stops watching.
+			defer testRunner.Stop()
+		}
+		var updater interface{ Update() watch.UpdateSummary }
+		if goWorkPath := util.WorkspaceFile(workingDir); goWorkPath != "" && len(*watchPackages) == 0 {
+			workspaceUpdater, err := watch.NewWorkspaceMockFileUpdater(goWorkPath, *watchRecursive, util.SplitBuildTags(*watchTags)...)
+			app.FatalIfError(err, "Could not read workspace %v", goWorkPath)
+			targetPaths = workspaceUpdater.ModuleDirs()
+			updater = workspaceUpdater
+		} else {
+			updater = watch.NewMockFileUpdater(targetPaths, *watchRecursive, util.ModuleRoot(workingDir), util.SplitBuildTags(*watchTags)...)
+		}
+		update := func() watch.UpdateSummary {
+			summary := updater.Update()
+			if *watchClean {
+				if _, e := clean.Clean(targetPaths, *watchRecursive, false, out, os.Remove); e != nil {
+					fmt.Fprintf(out, "Cleaning stale mocks failed: %v\n", e)
+				}
+			}
+			return summary
+		}
+		if *watchOnce {
+			summary := update()
+			writeSummaryFile(app, summary, *watchSummaryFile)
+			if *watchJSON {
+				app.FatalIfError(summary.EncodeJSON(os.Stdout), "Could not write summary")
+			}
+			fmt.Fprint(out, summary)
+			fmt.Fprint(out, summary.FailureTable())
+			if summary.HasFailures() {
+				app.Fatalf("Generating %v mock(s) failed.", len(summary.Failures))
+			}
+			return
+		}
 		watch.CreateWellKnownInterfaceListFilesIfNecessary(targetPaths)
-		util.Ticker(watch.NewMockFileUpdater(targetPaths, *watchRecursive).Update, 2*time.Second, done)
+		util.Ticker(func() {
+			summary := update()
+			writeSummaryFile(app, summary, *watchSummaryFile)
+			if summary.HasNews() {
+				fmt.Fprint(out, summary)
+			}
+			if testRunner != nil {
+				testRunner.Schedule(summary.ChangedPackageDirs())
+			}
+		}, watchInterval, done)
+
+	case dumpModelCmd.FullCommand():
+		if err := util.ValidateArgs(*dumpModelArgs); err != nil {
+			fatalUsage(app, err.Error())
+		}
+		sourceArgs, err := util.SourceArgs(*dumpModelArgs)
+		if err != nil {
+			fatalUsage(app, err.Error())
+		}
+		modelOut := io.Writer(os.Stdout)
+		if *dumpModelOutput != "" {
+			file, e := os.Create(*dumpModelOutput)
+			app.FatalIfError(e, "Could not create output file")
+			defer file.Close()
+			modelOut = file
+		}
+		app.FatalIfError(filehandling.DumpModel(sourceArgs, false, modelOut), "Could not write model")
+
+	case versionCmd.FullCommand():
+		fmt.Fprintf(out, "pegomock version %v\ncommit: %v\nlibrary: %v %v\n",
+			version.Generator(), version.GeneratorCommit(), version.ModulePath, version.Library())
+
+	case doctorCmd.FullCommand():
+		dir := *doctorDir
+		if dir == "" {
+			dir = workingDir
+		}
+		diagnosis, e := doctor.Diagnose(dir)
+		app.FatalIfError(e, "Could not diagnose %v", dir)
+		diagnosis.WriteReport(out)
+		if diagnosis.HasProblems() {
+			app.Fatalf("Found problems. Install the matching pegomock binary and regenerate the listed mocks.")
+		}
 
 	case removeMocks.FullCommand():
 		path := *removePath
@@ -144,5 +394,176 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 			app.FatalIfError(e, "Could not get current working directory")
 		}
 		remove.Remove(path, *removeRecursive, !*removeNonInteractive, *removeDryRun, *removeSilent, out, in, os.Remove)
+
+	case cleanCmd.FullCommand():
+		dirs := *cleanDirs
+		if len(dirs) == 0 {
+			dirs = []string{workingDir}
+		}
+		orphanedMocks, e := clean.Clean(dirs, *cleanRecursive, *cleanDryRun, out, os.Remove)
+		app.FatalIfError(e, "Could not clean stale mocks")
+		if len(orphanedMocks) == 0 {
+			fmt.Fprintln(out, "No stale mocks found.")
+		}
+	}
+}
+
+// generateMatchingMocks generates a mock for each interface matched by the
+// package and interface patterns in args. The mocks go into the directory of the
+// interface's package, or into --output-dir relative to it.
+func generateMatchingMocks(app *kingpin.Application, out io.Writer, reporter *mockReporter, args []string, generateFlags util.GenerateFlags, destinationDir string, dryRun bool,
+	debugParser bool, useExperimentalModelGen bool, shouldGenerateMatchers bool, matchersDestination string, withExamples bool, force bool) {
+	packagePattern, interfaceGlob := pattern.Split(args)
+	interfaces, err := pattern.FindInterfaces(packagePattern, interfaceGlob, generateFlags.BuildTags()...)
+	app.FatalIfError(err, "Could not find interfaces")
+	if len(interfaces) == 0 {
+		app.Fatalf("No interfaces match %v", strings.Join(args, " "))
+	}
+	if dryRun {
+		fmt.Fprintln(out, "This is a dry-run. Would generate the following mocks:")
+	}
+	for _, iface := range interfaces {
+		sourceArgs := []string{iface.PackagePath, iface.Name}
+		outputDir := iface.PackageDir
+		packageOut := iface.PackageName + "_test"
+		destination := filehandling.OutputFilePath(sourceArgs, outputDir, "")
+		if destinationDir != "" {
+			outputDir = filepath.Join(iface.PackageDir, destinationDir)
+			packageOut = filepath.Base(destinationDir)
+			destination = filepath.Join(outputDir, "mock_"+strings.ToLower(iface.Name)+".go")
+		}
+		if *generateFlags.Package != "" {
+			packageOut = *generateFlags.Package
+		}
+		prepare := func() string {
+			if *generateFlags.Export {
+				packageOut = strings.TrimSuffix(packageOut, "_test")
+				destination = filehandling.ExportedOutputFilePath(sourceArgs, outputDir, destination)
+				util.PanicOnError(filehandling.ValidateExport("", *generateFlags.NamePrefix, *generateFlags.NameSuffix, packageOut, destination))
+			}
+			return destination
+		}
+		validate := func() {
+			if *generateFlags.Strict {
+				util.PanicOnError(filehandling.ValidateErrorPositions(sourceArgs, useExperimentalModelGen, generateFlags.BuildTags()...))
+			}
+		}
+		if dryRun {
+			prepare()
+			validate()
+			fmt.Fprintf(out, "%v.%v: %v\n", iface.PackagePath, iface.Name, destination)
+			continue
+		}
+		reporter.generate(iface.PackagePath, iface.Name, prepare, func() {
+			validate()
+			options := filehandling.GenerateOptionsFromFlags(generateFlags)
+			options.MockName = "" // each matching interface gets its default mock name
+			options.PackageOut = packageOut
+			options.DebugParser = debugParser
+			options.DebugOut = out
+			options.UseExperimentalModelGen = useExperimentalModelGen
+			options.GenerateMatchers = shouldGenerateMatchers
+			options.MatchersDestination = matchersDestination
+			options.Force = force
+			written, e := filehandling.GenerateMockFileInOutputDir(sourceArgs, outputDir, destination, options)
+			util.PanicOnError(e)
+			if withExamples {
+				filehandling.GenerateExamplesFile(sourceArgs, destination, options)
+			}
+			if written {
+				fmt.Fprintf(out, "Generated %v for %v.%v\n", destination, iface.PackagePath, iface.Name)
+			} else {
+				fmt.Fprintf(out, "%v for %v.%v is unchanged\n", destination, iface.PackagePath, iface.Name)
+			}
+		})
+	}
+}
+
+// generateMockFromModel generates the mock for modelPackage, read from the model
+// file at modelPath, like the generate command does for Go source. modelPath
+// may also describe other sources of the model, e.g. a package loaded with its
+// test files.
+func generateMockFromModel(modelPackage *model.Package, modelPath string, destinationDir string, destination string, mockName string, generateFlags util.GenerateFlags,
+	packageOut string, shouldGenerateMatchers bool, matchersDestination string, withExamples bool, force bool) {
+	outputFilePath := filehandling.OutputFilePath(filehandling.ModelSourceArgs(modelPackage), destinationDir, destination)
+	if destination != "" {
+		util.PanicOnError(os.MkdirAll(filepath.Dir(destination), 0755))
+	}
+	options := filehandling.GenerateOptionsFromFlags(generateFlags)
+	options.MockName = mockName
+	options.PackageOut = packageOut
+	options.GenerateMatchers = shouldGenerateMatchers
+	options.MatchersDestination = matchersDestination
+	options.Force = force
+	filehandling.GenerateMockFileFromModel(modelPackage, modelPath, outputFilePath, options)
+	if withExamples {
+		filehandling.GenerateExamplesFileFromModel(modelPackage, modelPath, outputFilePath, options)
+	}
+}
+
+func writeSummaryFile(app *kingpin.Application, summary watch.UpdateSummary, path string) {
+	if path == "" {
+		return
+	}
+	app.FatalIfError(summary.WriteJSON(path), "Could not write summary file")
+}
+
+// fatalUsage reports a usage error like app.FatalUsage, but makes pegomock
+// exit with exitUsage.
+func fatalUsage(app *kingpin.Application, format string, args ...interface{}) {
+	failureStatus = exitUsage
+	app.FatalUsage(format, args...)
+}
+
+// exitOnFailure reports the errors generation panics with like app.Fatalf, so
+// pegomock exits with exitFailure. Other panics, e.g. runtime errors caused by
+// bugs, keep crashing pegomock with their stack trace.
+func exitOnFailure(app *kingpin.Application) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	if err, isError := value.(error); isError {
+		if _, isRuntimeError := err.(runtime.Error); !isRuntimeError {
+			app.Fatalf("%v", err)
+		}
+	}
+	panic(value)
+}
+
+// mockReporter records the outcome of every mock the generate command
+// generates. With --json, a failing mock doesn't stop the others from being
+// generated and finish writes the report to standard out.
+type mockReporter struct {
+	json   bool
+	report report.Report
+}
+
+// generate generates the mock for iface in pkg: prepare validates it and
+// returns its output file, generateMock writes it.
+func (reporter *mockReporter) generate(pkg string, iface string, prepare func() string, generateMock func()) {
+	mock := report.Mock{Package: pkg, Interface: iface}
+	defer func() {
+		if reporter.json {
+			if err := recover(); err != nil {
+				mock.Status, mock.Error = report.StatusFailed, fmt.Sprint(err)
+			}
+			reporter.report.Mocks = append(reporter.report.Mocks, mock)
+		}
+	}()
+	mock.Output = prepare()
+	snapshot := report.TakeSnapshot(mock.Output)
+	generateMock()
+	mock.Status = snapshot.Status()
+}
+
+// finish writes the report with --json and fails if any mock failed.
+func (reporter *mockReporter) finish(app *kingpin.Application) {
+	if !reporter.json {
+		return
+	}
+	app.FatalIfError(reporter.report.Write(os.Stdout), "Could not write report")
+	if failures := reporter.report.Failures(); failures > 0 {
+		app.Fatalf("Generating %v mock(s) failed.", failures)
 	}
 }
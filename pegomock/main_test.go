@@ -17,15 +17,20 @@ package main_test
 import (
 	"bytes"
 	"go/build"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	main "github.com/petergtz/pegomock/pegomock"
+	"github.com/petergtz/pegomock/version"
 
 	. "github.com/petergtz/pegomock/pegomock/testutil"
 
@@ -140,6 +145,46 @@ func describeCLIWithGoModulesEnabled(useGoModules bool) interface{} {
 				})
 			})
 
+			Context("with a mock that wouldn't change", func() {
+				var mockPath string
+				past := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+				BeforeEach(func() {
+					mockPath = joinPath(packageDir, "mock_mydisplay_test.go")
+					main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, done)
+					Expect(os.Chtimes(mockPath, past, past)).To(Succeed())
+				})
+
+				It(`leaves the mock file untouched`, func() {
+					main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, done)
+
+					Expect(modTime(mockPath)).To(Equal(past))
+				})
+
+				It(`writes the mock file with --force`, func() {
+					main.Run(cmd("pegomock generate --force MyDisplay"), os.Stdout, os.Stdin, app, done)
+
+					Expect(modTime(mockPath)).To(BeTemporally(">", past))
+				})
+
+				It(`rewrites the mock file when the interface changed`, func() {
+					WriteFile(joinPath(packageDir, "mydisplay.go"),
+						"package pegomocktest; type MyDisplay interface {  Show(something string); Hide() }")
+
+					main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, done)
+
+					Expect(modTime(mockPath)).To(BeTemporally(">", past))
+					Expect(mockPath).To(BeAFileContainingSubString("func (mock *MockMyDisplay) Hide()"))
+				})
+
+				It(`rewrites the mock file when the flags changed`, func() {
+					main.Run(cmd("pegomock generate --mock-name DisplayMock MyDisplay"), os.Stdout, os.Stdin, app, done)
+
+					Expect(modTime(mockPath)).To(BeTemporally(">", past))
+					Expect(mockPath).To(BeAFileContainingSubString("DisplayMock"))
+				})
+			})
+
 			Context(`with args "VendorDisplay""`, func() {
 
 				It(`generates a file mock_vendordisplay_test.go that contains 'import ( vendored_package "github.com/petergtz/vendored_package" )'`, func() {
@@ -207,6 +252,54 @@ func describeCLIWithGoModulesEnabled(useGoModules bool) interface{} {
 				})
 			})
 
+			Context("with args --with-examples", func() {
+				It(`creates an example test file next to the mocks with examples that pass`, func() {
+					main.Run(cmd("pegomock generate MyDisplay --with-examples"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_mydisplay_example_test.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("func ExampleMockMyDisplay_Show()")))
+					output, e := exec.Command("go", "test", "-run", "Example", ".").CombinedOutput()
+					Expect(e).NotTo(HaveOccurred(), string(output))
+				})
+			})
+
+			Context("with args --standalone", func() {
+				It(`creates a mock that works without the pegomock library`, func() {
+					main.Run(cmd("pegomock generate MyDisplay --standalone"), os.Stdout, os.Stdin, app, done)
+					WriteFile(joinPath(packageDir, "mydisplay_test.go"), `package pegomocktest_test
+						import "testing"
+						func TestShow(t *testing.T) {
+							display := NewMockMyDisplay()
+							display.SetFailHandler(func(message string, _ ...int) { t.Fatal(message) })
+							display.Show("Hello")
+							display.VerifyWasCalledOnce().Show("Hello")
+							display.VerifyWasNeverCalled().Show("Bye")
+						}`)
+
+					output, e := exec.Command("go", "test", "-run", "TestShow", ".").CombinedOutput()
+					Expect(e).NotTo(HaveOccurred(), string(output))
+					output, e = exec.Command("go", "list", "-test", "-deps", ".").CombinedOutput()
+					Expect(e).NotTo(HaveOccurred(), string(output))
+					Expect(string(output)).NotTo(ContainSubstring("github.com/petergtz/pegomock"))
+				})
+
+				It(`reports an error when combined with flags that need the pegomock library`, func() {
+					var buf bytes.Buffer
+					Expect(func() {
+						main.Run(cmd("pegomock generate MyDisplay --standalone --provide"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+					Expect(func() {
+						main.Run(cmd("pegomock generate MyDisplay --standalone --generate-matchers"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+
+					Expect(buf.String()).To(SatisfyAll(
+						ContainSubstring("Cannot use --provide with --standalone"),
+						ContainSubstring("Cannot use --generate-matchers or --with-examples with --standalone")))
+					Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+				})
+			})
+
 			Context("with args --output-dir and --package", func() {
 				It(`creates the mocks in output dir with the specified package name`, func() {
 					var buf bytes.Buffer
@@ -228,6 +321,73 @@ func describeCLIWithGoModulesEnabled(useGoModules bool) interface{} {
 				})
 			})
 
+			Context("with args --name-prefix and --name-suffix", func() {
+				It(`names the mock, its constructor and its verifier with them`, func() {
+					main.Run(cmd("pegomock generate MyDisplay --name-prefix Fake --name-suffix Impl"), os.Stdout, os.Stdin, app, done)
+					WriteFile(joinPath(packageDir, "mydisplay_test.go"), `package pegomocktest_test
+						import ( "testing"; "github.com/petergtz/pegomock"; "pegomocktest" )
+						func TestShow(t *testing.T) {
+							fake := NewFakeMyDisplayImpl(pegomock.WithT(t))
+							var display pegomocktest.MyDisplay = fake
+							display.Show("Hello")
+							var verifier *VerifierFakeMyDisplayImpl = fake.VerifyWasCalledOnce()
+							verifier.Show("Hello")
+						}`)
+
+					output, e := exec.Command("go", "test", "-run", "TestShow", ".").CombinedOutput()
+					Expect(e).NotTo(HaveOccurred(), string(output))
+				})
+
+				It(`adds no prefix with an empty --name-prefix`, func() {
+					main.Run(cmd("pegomock generate MyDisplay --name-prefix= --name-suffix Fake"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+						BeAFileContainingSubString("type MyDisplayFake struct"),
+						BeAFileContainingSubString("func NewMyDisplayFake(")))
+				})
+
+				It(`prefers --mock-name`, func() {
+					main.Run(cmd("pegomock generate MyDisplay --name-prefix Fake --mock-name RenamedMock"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+						BeAFileContainingSubString("type RenamedMock struct"),
+						Not(BeAFileContainingSubString("FakeMyDisplay"))))
+				})
+
+				It(`reports an error if they don't make valid names`, func() {
+					var buf bytes.Buffer
+					Expect(func() {
+						main.Run(cmd("pegomock generate MyDisplay --name-prefix 1Fake"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+
+					Expect(buf.String()).To(ContainSubstring(`Cannot name mocks with prefix "1Fake" and suffix ""`))
+					Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("with args --build-tag", func() {
+				It(`puts the build constraint into the generated file`, func() {
+					main.Run(cmd("pegomock generate MyDisplay --build-tag mock -o mock_mydisplay.go"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_mydisplay.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("//go:build mock\n// +build mock\n\npackage pegomocktest_test")))
+				})
+			})
+
+			Context("with args --template and --template-data", func() {
+				It(`generates the mock from the custom template`, func() {
+					WriteFile(joinPath(packageDir, "mock.tmpl"),
+						"package {{.PackageName}}\n{{range .Mocks}}type {{.MockName}}{{index $.Data \"suffix\"}} struct{}\n{{end}}")
+
+					main.Run(cmd("pegomock generate MyDisplay --template mock.tmpl --template-data suffix=Custom"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("type MockMyDisplayCustom struct{}")))
+				})
+			})
+
 			Context("with args for specifying matcher directory", func() {
 				It(`creates matchers in the specified directory`, func() {
 					if useGoModules {
@@ -241,6 +401,289 @@ func describeCLIWithGoModulesEnabled(useGoModules bool) interface{} {
 				})
 			})
 
+			Context("with args --export", func() {
+				It(`creates the mocks in a non-test file and package, with matchers in a package named after their dir`, func() {
+					main.Run(cmd("pegomock generate RequestHandler --export --output-dir sharedmocks "+
+						"--generate-matchers --matchers-dir sharedmocks/sharedmatchers"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "sharedmocks/mock_requesthandler.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("package sharedmocks"),
+						BeAFileContainingSubString("type VerifierMockRequestHandler struct")))
+					Expect(joinPath(packageDir, "sharedmocks/sharedmatchers/ptr_to_http_request.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("package sharedmatchers")))
+				})
+
+				It(`creates the mocks next to the interface in a non-test file of the interface's package`, func() {
+					main.Run(cmd("pegomock generate MyDisplay --export"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_mydisplay.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("package pegomocktest\n")))
+					Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+				})
+
+				It(`reports an error for a test package or an unexported mock name`, func() {
+					var buf bytes.Buffer
+					Expect(func() {
+						main.Run(cmd("pegomock generate MyDisplay --export --package pegomocktest_test"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+					Expect(func() {
+						main.Run(cmd("pegomock generate MyDisplay --export --mock-name mockDisplay"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+
+					Expect(buf.String()).To(SatisfyAll(
+						ContainSubstring("Cannot export mocks from test package pegomocktest_test."),
+						ContainSubstring("Cannot export mock mockDisplay, because its name is not exported.")))
+				})
+			})
+
+			Context("with an interface returning an error other than as its last return value", func() {
+				BeforeEach(func() {
+					WriteFile(joinPath(packageDir, "legacystore.go"),
+						"package pegomocktest; type LegacyStore interface {  Load(key string) (error, int) }")
+				})
+
+				It(`generates the mock with a warning comment on the method`, func() {
+					main.Run(cmd("pegomock generate LegacyStore"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_legacystore_test.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("// WARNING: non-standard error position. Load returns (error, int)")))
+				})
+
+				It(`refuses to generate the mock with --strict`, func() {
+					var buf bytes.Buffer
+					Expect(func() {
+						main.Run(cmd("pegomock generate LegacyStore --strict"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+
+					Expect(buf.String()).To(ContainSubstring("Refusing to generate mocks in strict mode: LegacyStore.Load return an error"))
+					Expect(joinPath(packageDir, "mock_legacystore_test.go")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("with an interface with named return values", func() {
+				BeforeEach(func() {
+					WriteFile(joinPath(packageDir, "repo.go"), `package pegomocktest
+						type User struct{ Name string }
+						type Repo interface {
+							Find(id int) (user *User, err error)
+							Count() (result, total int)
+						}`)
+				})
+
+				It(`generates a mock that keeps the names and passes go vet`, func() {
+					main.Run(cmd("pegomock generate --use-experimental-model-gen Repo"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_repo_test.go")).To(SatisfyAll(
+						BeAFileContainingSubString("Find(id int) (user *pegomocktest.User, err error) {"),
+						BeAFileContainingSubString("Count() (_ret0 int, total int) {")))
+					output, e := exec.Command("go", "vet", ".").CombinedOutput()
+					Expect(e).NotTo(HaveOccurred(), string(output))
+				})
+
+				It(`generates a standalone mock that keeps the names and passes go vet`, func() {
+					main.Run(cmd("pegomock generate --use-experimental-model-gen Repo --standalone"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_repo_test.go")).To(
+						BeAFileContainingSubString("Find(id int) (user *pegomocktest.User, err error) {"))
+					output, e := exec.Command("go", "vet", ".").CombinedOutput()
+					Expect(e).NotTo(HaveOccurred(), string(output))
+				})
+			})
+
+			Context("with an interface referring to types of other packages", func() {
+				BeforeEach(func() {
+					WriteFile(joinPath(packageDir, "store.go"), `package pegomocktest
+						import (
+							"database/sql"
+							"net/http"
+						)
+						type Store interface {
+							Handle(r *http.Request, rows *sql.Rows) error
+							Batch(byRequest map[*http.Request][]*sql.Rows, c chan<- *sql.Rows)
+							Lookup(key sql.NullString) interface{ Header() http.Header }
+						}`)
+				})
+
+				for _, flags := range []string{"-m", "-m --use-experimental-model-gen", "-m store.go"} {
+					flags := flags
+					It(`generates a mock and matchers that pass go vet with "`+flags+`"`, func() {
+						args := flags
+						if !strings.HasSuffix(flags, ".go") {
+							args += " Store"
+						}
+						main.Run(cmd("pegomock generate "+args), os.Stdout, os.Stdin, app, done)
+
+						output, e := exec.Command("go", "vet", ".", "./matchers").CombinedOutput()
+						Expect(e).NotTo(HaveOccurred(), string(output))
+					})
+				}
+			})
+
+			Context("with an interface declared in a file with a build constraint", func() {
+				BeforeEach(func() {
+					WriteFile(joinPath(packageDir, "integration_store.go"),
+						"//go:build integration\n\npackage pegomocktest\n\ntype IntegrationStore interface { Get(key string) string }")
+				})
+
+				It(`generates the mock with --tags and stamps the tags into its build constraint`, func() {
+					main.Run(cmd("pegomock generate IntegrationStore --tags integration"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_integrationstore_test.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("//go:build integration\n"),
+						BeAFileContainingSubString("func (mock *MockIntegrationStore) Get(")))
+				})
+
+				It(`combines the tags with --build-tag`, func() {
+					main.Run(cmd("pegomock generate IntegrationStore --tags integration --build-tag mock"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_integrationstore_test.go")).To(
+						BeAFileContainingSubString("//go:build mock && integration\n"))
+				})
+
+				It(`doesn't find the interface without --tags`, func() {
+					Expect(func() {
+						main.Run(cmd("pegomock generate IntegrationStore"), os.Stdout, os.Stdin, app, done)
+					}).To(Panic())
+					Expect(joinPath(packageDir, "mock_integrationstore_test.go")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("with an interface declared in a _test.go file", func() {
+				BeforeEach(func() {
+					WriteFile(joinPath(packageDir, "observer_test.go"),
+						"package pegomocktest_test; type Observer interface { Notify(event string) }")
+				})
+
+				It(`generates the mock into the same test package with --include-tests`, func() {
+					main.Run(cmd("pegomock generate --include-tests Observer"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_observer_test.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("package pegomocktest_test"),
+						BeAFileContainingSubString("var _ Observer = (*MockObserver)(nil)"),
+						BeAFileContainingSubString("func (mock *MockObserver) Notify(event string) {")))
+				})
+
+				It(`reports an error when generating the mock into another package`, func() {
+					var buf bytes.Buffer
+					Expect(func() {
+						main.Run(cmd("pegomock generate --include-tests Observer --output-dir fakes"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+
+					Expect(buf.String()).To(ContainSubstring(
+						"Cannot generate mocks into package fakes: the interfaces are declared in test package pegomocktest_test, which cannot be imported."))
+					Expect(joinPath(packageDir, "fakes", "mock_observer.go")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("with an interface in an internal package", func() {
+				var internalPackageDir string
+
+				BeforeEach(func() {
+					internalPackageDir = joinPath(subPackageDir, "internal", "store")
+					Expect(os.MkdirAll(internalPackageDir, 0755)).To(Succeed())
+					WriteFile(joinPath(internalPackageDir, "store.go"), "package store; type Store interface { Get(key string) string }")
+				})
+
+				It(`reports an error when generating the mock into a package that cannot import it`, func() {
+					var buf bytes.Buffer
+					Expect(func() {
+						main.Run(cmd("pegomock generate pegomocktest/subpackage/internal/store Store"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+
+					Expect(buf.String()).To(ContainSubstring("Cannot generate mocks into pegomocktest: " +
+						"package pegomocktest/subpackage/internal/store is internal and can only be imported from within pegomocktest/subpackage."))
+					Expect(joinPath(packageDir, "mock_store_test.go")).NotTo(BeAnExistingFile())
+				})
+
+				It(`generates the mock into the interface's own package with unexported names with --self_package`, func() {
+					main.Run(cmd("pegomock generate --use-experimental-model-gen pegomocktest/subpackage/internal/store Store "+
+						"--self_package pegomocktest/subpackage/internal/store"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(internalPackageDir, "mock_store_test.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("package store\n"),
+						BeAFileContainingSubString("func newMockStore(options ...pegomock.Option) *mockStore {"),
+						BeAFileContainingSubString("type verifierMockStore struct {")))
+					Expect(joinPath(packageDir, "mock_store_test.go")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("with --from-model", func() {
+				It(`generates the mock from a model written by dump-model`, func() {
+					main.Run(cmd("pegomock dump-model RequestHandler --output model.json"), os.Stdout, os.Stdin, app, done)
+					Expect(joinPath(packageDir, "model.json")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString(`"name": "RequestHandler"`),
+						BeAFileContainingSubString(`"package": "net/http"`)))
+
+					main.Run(cmd("pegomock generate --from-model model.json --output-dir contractmocks"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "contractmocks", "mock_requesthandler.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("// Source: model.json"),
+						BeAFileContainingSubString("package contractmocks"),
+						BeAFileContainingSubString("func (mock *MockRequestHandler) Handler("),
+						BeAFileContainingSubString(`"net/http"`)))
+				})
+
+				It(`reports an error when args are given, too`, func() {
+					main.Run(cmd("pegomock dump-model MyDisplay -o model.json"), os.Stdout, os.Stdin, app, done)
+
+					var buf bytes.Buffer
+					Expect(func() {
+						main.Run(cmd("pegomock generate --from-model model.json MyDisplay"), &buf, os.Stdin, app, done)
+					}).To(Panic())
+
+					Expect(buf.String()).To(ContainSubstring("Cannot use args with --from-model"))
+				})
+			})
+
+			Context("with a package pattern", func() {
+				BeforeEach(func() {
+					WriteFile(joinPath(subPackageDir, "subrepository.go"),
+						"package subpackage; type SubRepository interface {  Load() }")
+				})
+
+				It(`generates mocks for all matching interfaces in all matching packages`, func() {
+					var buf bytes.Buffer
+					main.Run(cmd("pegomock generate ./... *Display"), &buf, os.Stdin, app, done)
+
+					Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("package pegomocktest_test")))
+					Expect(joinPath(subPackageDir, "mock_subdisplay_test.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("package subpackage_test")))
+					Expect(joinPath(subPackageDir, "mock_subrepository_test.go")).NotTo(BeAnExistingFile())
+					Expect(buf.String()).To(ContainSubstring("Generated " + joinPath(subPackageDir, "mock_subdisplay_test.go")))
+				})
+
+				It(`generates the mocks into --output-dir relative to each package`, func() {
+					main.Run(cmd("pegomock generate ./subpackage/... --output-dir fakes"), os.Stdout, os.Stdin, app, done)
+
+					Expect(joinPath(subPackageDir, "fakes", "mock_subdisplay.go")).To(SatisfyAll(
+						BeAnExistingFile(),
+						BeAFileContainingSubString("package fakes")))
+					Expect(joinPath(subPackageDir, "fakes", "mock_subrepository.go")).To(BeAnExistingFile())
+				})
+
+				It(`only lists the mocks it would generate with --dry-run`, func() {
+					var buf bytes.Buffer
+					main.Run(cmd("pegomock generate ./subpackage/... --dry-run"), &buf, os.Stdin, app, done)
+
+					Expect(buf.String()).To(Equal("This is a dry-run. Would generate the following mocks:\n" +
+						"pegomocktest/subpackage.SubDisplay: " + joinPath(subPackageDir, "mock_subdisplay_test.go") + "\n" +
+						"pegomocktest/subpackage.SubRepository: " + joinPath(subPackageDir, "mock_subrepository_test.go") + "\n"))
+					Expect(joinPath(subPackageDir, "mock_subdisplay_test.go")).NotTo(BeAnExistingFile())
+				})
+			})
+
 			Context("with too many args", func() {
 
 				It(`reports an error and the usage`, func() {
@@ -254,6 +697,96 @@ func describeCLIWithGoModulesEnabled(useGoModules bool) interface{} {
 				})
 			})
 
+			Context("with --json", func() {
+				It(`writes a report of the mock to standard out`, func() {
+					report, panicValue := captureStdout(func() {
+						main.Run(cmd("pegomock generate --json MyDisplay"), ioutil.Discard, os.Stdin, app, done)
+					})
+					Expect(panicValue).To(BeNil())
+
+					Expect(report).To(MatchJSON(`{"mocks": [{
+						"package": "pegomocktest",
+						"interface": "MyDisplay",
+						"output": "` + joinPath(packageDir, "mock_mydisplay_test.go") + `",
+						"status": "created"
+					}]}`))
+
+					report, _ = captureStdout(func() {
+						main.Run(cmd("pegomock generate --json MyDisplay"), ioutil.Discard, os.Stdin, app, done)
+					})
+
+					Expect(report).To(ContainSubstring(`"status": "unchanged"`))
+
+					WriteFile(joinPath(packageDir, "mydisplay.go"),
+						"package pegomocktest; type MyDisplay interface {  Show(something string); Hide() }")
+					report, _ = captureStdout(func() {
+						main.Run(cmd("pegomock generate --json MyDisplay"), ioutil.Discard, os.Stdin, app, done)
+					})
+
+					Expect(report).To(ContainSubstring(`"status": "updated"`))
+				})
+
+				It(`reports failing mocks of a package pattern, generates the others and fails`, func() {
+					WriteFile(joinPath(subPackageDir, "subrepository.go"),
+						"package subpackage; type SubRepository interface {  Load() (error, string) }")
+
+					report, panicValue := captureStdout(func() {
+						main.Run(cmd("pegomock generate --json --strict ./subpackage/..."), ioutil.Discard, os.Stdin, app, done)
+					})
+
+					Expect(panicValue).To(Equal("Unexpected terminate"))
+
+					Expect(joinPath(subPackageDir, "mock_subdisplay_test.go")).To(BeAnExistingFile())
+					Expect(report).To(SatisfyAll(
+						MatchRegexp(`"interface": "SubDisplay",\s+"output": "`+regexp.QuoteMeta(joinPath(subPackageDir, "mock_subdisplay_test.go"))+`",\s+"status": "created"`),
+						MatchRegexp(`"interface": "SubRepository",\s+"output": "[^"]+",\s+"status": "failed",\s+"error": "[^"]*Load`)))
+				})
+
+				It(`reports an error when used with --dry-run`, func() {
+					var buf bytes.Buffer
+					Expect(func() {
+						main.Run(cmd("pegomock generate --json --dry-run ./..."), &buf, os.Stdin, app, done)
+					}).To(Panic())
+
+					Expect(buf.String()).To(ContainSubstring("Cannot use --json with --dry-run"))
+				})
+			})
+
+		})
+
+		Describe("exit status", func() {
+			var pegomockBinary string
+
+			BeforeEach(func() {
+				pegomockBinary = joinPath(packageDir, "pegomock-binary")
+				build := exec.Command("go", "build", "-o", pegomockBinary, ".")
+				build.Dir = origWorkingDir
+				output, e := build.CombinedOutput()
+				Expect(e).NotTo(HaveOccurred(), string(output))
+			})
+
+			exitStatusOf := func(args string) int {
+				e := exec.Command(pegomockBinary, strings.Split(args, " ")...).Run()
+				if exitError, isExitError := e.(*exec.ExitError); isExitError {
+					return exitError.ExitCode()
+				}
+				Expect(e).NotTo(HaveOccurred())
+				return 0
+			}
+
+			It("is 0 on success", func() {
+				Expect(exitStatusOf("generate MyDisplay")).To(Equal(0))
+			})
+
+			It("is 1 when generating fails", func() {
+				Expect(exitStatusOf("generate NoSuchInterface")).To(Equal(1))
+			})
+
+			It("is 2 for usage errors", func() {
+				Expect(exitStatusOf("generate --no-such-flag MyDisplay")).To(Equal(2))
+				Expect(exitStatusOf("generate with too many args")).To(Equal(2))
+				Expect(exitStatusOf("generate --output mock.go --output-dir mocks MyDisplay")).To(Equal(2))
+			})
 		})
 
 		Describe(`"watch" command`, func() {
@@ -281,6 +814,71 @@ func describeCLIWithGoModulesEnabled(useGoModules bool) interface{} {
 
 		})
 
+		Describe(`"watch --once" command`, func() {
+			It("generates all mocks once, prints a summary and does not create interfaces_to_mock", func() {
+				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay")
+				var buf bytes.Buffer
+
+				main.Run(cmd("pegomock watch --once -r"), &buf, os.Stdin, app, done)
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+				Expect(joinPath(subPackageDir, "interfaces_to_mock")).NotTo(BeAnExistingFile())
+				Expect(buf.String()).To(SatisfyAll(
+					ContainSubstring("(Re)generated mock for MyDisplay in "+joinPath(packageDir, "mock_mydisplay_test.go")),
+					ContainSubstring("1 mock(s) (re)generated, 0 unchanged, 0 failed.")))
+
+				buf.Reset()
+				main.Run(cmd("pegomock watch --once -r"), &buf, os.Stdin, app, done)
+
+				Expect(buf.String()).To(Equal("0 mock(s) (re)generated, 1 unchanged, 0 failed.\n"))
+			})
+
+			It("reports failures and exits with non-zero status, but still generates the other mocks", func() {
+				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "--no-such-flag MyDisplay\nMyDisplay")
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock watch --once"), &buf, os.Stdin, app, done)
+				}).To(Panic())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+				Expect(buf.String()).To(SatisfyAll(
+					ContainSubstring("Error while trying to generate mock for --no-such-flag MyDisplay in "+packageDir),
+					ContainSubstring("1 mock(s) (re)generated, 0 unchanged, 1 failed."),
+					ContainSubstring("Generating 1 mock(s) failed.")))
+			})
+
+			It("writes a summary file and prints a table of failures", func() {
+				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "--no-such-flag MyDisplay\nMyDisplay")
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock watch --once --summary-file "+joinPath(packageDir, "summary.json")), &buf, os.Stdin, app, done)
+				}).To(Panic())
+
+				Expect(buf.String()).To(MatchRegexp(`LINE\s+INTERFACE\s+REASON\n` +
+					regexp.QuoteMeta(joinPath(packageDir, "interfaces_to_mock")) + `:1\s+--no-such-flag MyDisplay\s+unknown long flag '--no-such-flag'\n`))
+				Expect(joinPath(packageDir, "summary.json")).To(SatisfyAll(
+					BeAFileContainingSubString(`"status": "failed"`),
+					BeAFileContainingSubString(`"status": "generated"`),
+					BeAFileContainingSubString(`"output": "`+joinPath(packageDir, "mock_mydisplay_test.go")+`"`)))
+			})
+
+			It("writes the summary to standard out with --json", func() {
+				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay")
+
+				summary, panicValue := captureStdout(func() {
+					main.Run(cmd("pegomock watch --once --json"), ioutil.Discard, os.Stdin, app, done)
+				})
+
+				Expect(panicValue).To(BeNil())
+
+				Expect(summary).To(SatisfyAll(
+					ContainSubstring(`"status": "generated"`),
+					ContainSubstring(`"output": "`+joinPath(packageDir, "mock_mydisplay_test.go")+`"`)))
+			})
+		})
+
 		Describe(`"remove" command`, func() {
 			Context("there are no mock files", func() {
 				It("removes mock files in current directory only", func() {
@@ -448,14 +1046,132 @@ Continue? [y/n]:`))
 			})
 		})
 
+		Describe(`"clean" command`, func() {
+			BeforeEach(func() {
+				main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, done)
+				main.Run(cmd("pegomock generate RequestHandler"), os.Stdout, os.Stdin, app, done)
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+				Expect(joinPath(packageDir, "mock_requesthandler_test.go")).To(BeAnExistingFile())
+			})
+
+			It("reports that there is nothing to clean while all interfaces exist", func() {
+				var buf bytes.Buffer
+
+				main.Run(cmd("pegomock clean"), &buf, os.Stdin, app, done)
+
+				Expect(buf.String()).To(Equal("No stale mocks found.\n"))
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+			})
+
+			It("deletes mocks whose interfaces were deleted", func() {
+				Expect(os.Remove(joinPath(packageDir, "mydisplay.go"))).To(Succeed())
+				var buf bytes.Buffer
+
+				main.Run(cmd("pegomock clean"), &buf, os.Stdin, app, done)
+
+				Expect(buf.String()).To(Equal("Deleting stale mock " + joinPath(packageDir, "mock_mydisplay_test.go") +
+					": interface MyDisplay not found in pegomocktest\n"))
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+				Expect(joinPath(packageDir, "mock_requesthandler_test.go")).To(BeAnExistingFile())
+			})
+
+			It("only lists mocks with --dry-run", func() {
+				Expect(os.Remove(joinPath(packageDir, "mydisplay.go"))).To(Succeed())
+				var buf bytes.Buffer
+
+				main.Run(cmd("pegomock clean --dry-run"), &buf, os.Stdin, app, done)
+
+				Expect(buf.String()).To(Equal("This is a dry-run. Would delete the following stale mocks:\n" +
+					joinPath(packageDir, "mock_mydisplay_test.go") + ": interface MyDisplay not found in pegomocktest\n"))
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+			})
+
+			It("deletes stale mocks on every pass of watch with --clean", func() {
+				Expect(os.Remove(joinPath(packageDir, "mydisplay.go"))).To(Succeed())
+				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "RequestHandler")
+				var buf bytes.Buffer
+
+				main.Run(cmd("pegomock watch --once --clean"), &buf, os.Stdin, app, done)
+
+				Expect(buf.String()).To(ContainSubstring("Deleting stale mock " + joinPath(packageDir, "mock_mydisplay_test.go")))
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+				Expect(joinPath(packageDir, "mock_requesthandler_test.go")).To(BeAnExistingFile())
+			})
+		})
+
+		Describe(`"version" command`, func() {
+			It("prints the version, the commit and the version of the library pegomock was built against", func() {
+				version.Version, version.Commit = "v2.9.0", "0123abc"
+				defer func() { version.Version, version.Commit = "", "" }()
+				var buf bytes.Buffer
+
+				main.Run(cmd("pegomock version"), &buf, os.Stdin, app, done)
+
+				Expect(buf.String()).To(MatchRegexp("^pegomock version v2.9.0\ncommit: 0123abc\nlibrary: github.com/petergtz/pegomock \\S+\n$"))
+			})
+		})
+
+		Describe(`"doctor" command`, func() {
+			BeforeEach(func() {
+				if !useGoModules {
+					Skip("Comparing with the pegomock library needs a go.mod")
+				}
+				version.Version = "v2.3.0+incompatible"
+				main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, done)
+			})
+
+			AfterEach(func() {
+				version.Version = ""
+			})
+
+			It("reports no problems if the binary, the library and the mocks have the same version", func() {
+				var buf bytes.Buffer
+
+				main.Run(cmd("pegomock doctor"), &buf, os.Stdin, app, done)
+
+				Expect(buf.String()).To(SatisfyAll(
+					ContainSubstring("pegomock library: v2.3.0+incompatible (required in "+joinPath(packageDir, "go.mod")+")"),
+					ContainSubstring("generated mocks:  1 in "+packageDir),
+					HaveSuffix("No problems found.\n")))
+			})
+
+			It("reports mocks generated by another version and exits with non-zero status", func() {
+				mockFilePath := joinPath(packageDir, "mock_mydisplay_test.go")
+				content, e := ioutil.ReadFile(mockFilePath)
+				Expect(e).NotTo(HaveOccurred())
+				WriteFile(mockFilePath, strings.Replace(string(content), "// Generator version: v2.3.0+incompatible", "// Generator version: v2.2.0", 1))
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock doctor "+packageDir), &buf, os.Stdin, app, done)
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("1 mock(s) need regeneration:\n\t" + mockFilePath +
+					": generated by v2.2.0, but the pegomock binary is v2.3.0+incompatible and go.mod requires the pegomock library v2.3.0+incompatible\n"))
+			})
+
+			It("reports a binary that doesn't match the library", func() {
+				version.Version = "v2.9.0"
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock doctor"), &buf, os.Stdin, app, done)
+				}).To(Panic())
+
+				Expect(buf.String()).To(SatisfyAll(
+					ContainSubstring("The pegomock binary is v2.9.0, but "+joinPath(packageDir, "go.mod")+" requires the pegomock library v2.3.0+incompatible."),
+					ContainSubstring(joinPath(packageDir, "mock_mydisplay_test.go")+": generated by v2.3.0+incompatible, but the pegomock binary is v2.9.0\n")))
+			})
+		})
+
 		Context("with some unknown command", func() {
-			It(`reports an error and the usage`, func() {
+			It(`reports an error`, func() {
 				var buf bytes.Buffer
-				kingpin.CommandLine.Terminate(nil)
-				kingpin.CommandLine.Writer(&buf)
+				Expect(func() {
+					main.Run(cmd("pegomock some unknown command"), &buf, os.Stdin, app, done)
+				}).To(Panic())
 
-				main.Run(cmd("pegomock some unknown command"), &buf, os.Stdin, app, done)
-				Expect(buf.String()).To(ContainSubstring("error"))
+				Expect(buf.String()).To(ContainSubstring("error: expected command but got \"some\", try --help"))
 			})
 		})
 
@@ -465,3 +1181,29 @@ Continue? [y/n]:`))
 func cmd(line string) []string {
 	return strings.Split(line, " ")
 }
+
+// captureStdout returns what fn writes to os.Stdout and what it panics with.
+func captureStdout(fn func()) (output string, panicValue interface{}) {
+	reader, writer, e := os.Pipe()
+	Expect(e).NotTo(HaveOccurred())
+	content := make(chan []byte)
+	go func() {
+		bytes, _ := ioutil.ReadAll(reader)
+		content <- bytes
+	}()
+	origStdout := os.Stdout
+	os.Stdout = writer
+	func() {
+		defer func() { panicValue = recover() }()
+		fn()
+	}()
+	os.Stdout = origStdout
+	writer.Close()
+	return string(<-content), panicValue
+}
+
+func modTime(path string) time.Time {
+	info, e := os.Stat(path)
+	Expect(e).NotTo(HaveOccurred())
+	return info.ModTime()
+}
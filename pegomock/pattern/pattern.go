@@ -0,0 +1,154 @@
+// Package pattern finds the interfaces pegomock generate mocks when called with
+// a package pattern like ./... and an interface glob like Repo*, instead of a
+// single interface.
+package pattern
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/petergtz/pegomock/modelgen/astutil"
+)
+
+// Interface is an interface matched by a package pattern and an interface glob.
+type Interface struct {
+	PackagePath string
+	PackageName string
+	// PackageDir is the directory of the interface's package.
+	PackageDir string
+	Name       string
+}
+
+// IsPattern reports whether args of pegomock generate are a package pattern,
+// optionally followed by an interface glob, or an interface glob for the
+// package in the working directory, instead of a single interface.
+func IsPattern(args []string) bool {
+	switch len(args) {
+	case 1:
+		return !strings.HasSuffix(args[0], ".go") && (strings.Contains(args[0], "...") || isGlob(args[0]))
+	case 2:
+		return strings.Contains(args[0], "...") || isGlob(args[1])
+	default:
+		return false
+	}
+}
+
+// Split returns the package pattern and interface glob of args for which
+// IsPattern is true. Missing parts default to all interfaces and the package in
+// the working directory respectively.
+func Split(args []string) (packagePattern string, interfaceGlob string) {
+	if len(args) == 2 {
+		return args[0], args[1]
+	}
+	if strings.Contains(args[0], "...") {
+		return args[0], "*"
+	}
+	return ".", args[0]
+}
+
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// FindInterfaces returns the interfaces whose names match interfaceGlob in the
+// packages matching packagePattern, sorted by package path and name. Only
+// exported interfaces are returned, because mocks are generated from outside
+// their package. Type constraints and generic interfaces are left out, because
+// they cannot be mocked. Files are selected by their build constraints as if
+// buildTags were set.
+func FindInterfaces(packagePattern string, interfaceGlob string, buildTags ...string) ([]Interface, error) {
+	if _, err := path.Match(interfaceGlob, ""); err != nil {
+		return nil, fmt.Errorf("Invalid interface pattern %v: %v", interfaceGlob, err)
+	}
+	config := &packages.Config{Mode: packages.NeedName | packages.NeedFiles}
+	if len(buildTags) > 0 {
+		config.BuildFlags = []string{"-tags", strings.Join(buildTags, ",")}
+	}
+	pkgs, err := packages.Load(config, packagePattern)
+	if err != nil {
+		return nil, fmt.Errorf("Loading packages %v failed: %v", packagePattern, err)
+	}
+	var interfaces []Interface
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
+			if len(pkg.Errors) > 0 {
+				return nil, fmt.Errorf("Loading package %v failed: %v", pkg.PkgPath, pkg.Errors[0])
+			}
+			continue
+		}
+		declarations, err := interfaceDeclarationsIn(pkg.GoFiles)
+		if err != nil {
+			return nil, err
+		}
+		resolveEmbedded := func(expr ast.Expr) *ast.InterfaceType {
+			if ident, isIdent := expr.(*ast.Ident); isIdent {
+				return declarations[ident.Name]
+			}
+			return nil
+		}
+		for name, declaration := range declarations {
+			if declaration == nil || !token.IsExported(name) || astutil.IsTypeConstraint(declaration, resolveEmbedded) {
+				continue
+			}
+			if matches, _ := path.Match(interfaceGlob, name); matches {
+				interfaces = append(interfaces, Interface{
+					PackagePath: pkg.PkgPath,
+					PackageName: pkg.Name,
+					PackageDir:  dirOf(pkg),
+					Name:        name,
+				})
+			}
+		}
+	}
+	sort.Slice(interfaces, func(i, j int) bool {
+		if interfaces[i].PackagePath != interfaces[j].PackagePath {
+			return interfaces[i].PackagePath < interfaces[j].PackagePath
+		}
+		return interfaces[i].Name < interfaces[j].Name
+	})
+	return interfaces, nil
+}
+
+// interfaceDeclarationsIn returns the interfaces declared in files, keyed by
+// name. Generic interfaces are kept with a nil declaration, so they are known
+// as embedded interfaces, but not mocked.
+func interfaceDeclarationsIn(filePaths []string) (map[string]*ast.InterfaceType, error) {
+	declarations := make(map[string]*ast.InterfaceType)
+	fileSet := token.NewFileSet()
+	for _, filePath := range filePaths {
+		file, err := parser.ParseFile(fileSet, filePath, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			genDecl, isGenDecl := decl.(*ast.GenDecl)
+			if !isGenDecl || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				interfaceType, isInterface := typeSpec.Type.(*ast.InterfaceType)
+				if !isInterface {
+					continue
+				}
+				if typeSpec.TypeParams != nil {
+					interfaceType = nil
+				}
+				declarations[typeSpec.Name.Name] = interfaceType
+			}
+		}
+	}
+	return declarations, nil
+}
+
+func dirOf(pkg *packages.Package) string {
+	return filepath.Dir(pkg.GoFiles[0])
+}
@@ -0,0 +1,106 @@
+package pattern_test
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/pegomock/pattern"
+	. "github.com/petergtz/pegomock/pegomock/testutil"
+)
+
+var (
+	joinPath = filepath.Join
+)
+
+func TestPattern(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pattern Suite")
+}
+
+var _ = Describe("IsPattern", func() {
+	It("tells package patterns and interface globs from single interfaces", func() {
+		Expect(pattern.IsPattern([]string{"./..."})).To(BeTrue())
+		Expect(pattern.IsPattern([]string{"github.com/myorg/...", "Repo*"})).To(BeTrue())
+		Expect(pattern.IsPattern([]string{"github.com/myorg/storage", "Repo*"})).To(BeTrue())
+		Expect(pattern.IsPattern([]string{"Repo*"})).To(BeTrue())
+
+		Expect(pattern.IsPattern([]string{"Repository"})).To(BeFalse())
+		Expect(pattern.IsPattern([]string{"github.com/myorg/storage", "Repository"})).To(BeFalse())
+		Expect(pattern.IsPattern([]string{"repository.go"})).To(BeFalse())
+	})
+
+	It("splits args into package pattern and interface glob", func() {
+		Expect(splitOf("./...")).To(Equal([]string{"./...", "*"}))
+		Expect(splitOf("Repo*")).To(Equal([]string{".", "Repo*"}))
+		Expect(splitOf("github.com/myorg/...", "Repo*")).To(Equal([]string{"github.com/myorg/...", "Repo*"}))
+	})
+})
+
+func splitOf(args ...string) []string {
+	packagePattern, interfaceGlob := pattern.Split(args)
+	return []string{packagePattern, interfaceGlob}
+}
+
+var _ = Describe("FindInterfaces", func() {
+	var (
+		packageDir, subPackageDir string
+		origWorkingDir            string
+	)
+
+	BeforeEach(func() {
+		packageDir = joinPath(build.Default.GOPATH, "src", "pegomockpatterntest")
+		subPackageDir = joinPath(packageDir, "sub")
+		Expect(os.MkdirAll(subPackageDir, 0755)).To(Succeed())
+
+		var e error
+		origWorkingDir, e = os.Getwd()
+		Expect(e).NotTo(HaveOccurred())
+		os.Chdir(packageDir)
+
+		WriteFile(joinPath(packageDir, "storage.go"), `package storage
+			type Repository interface { Save(id string) }
+			type Store interface { Get(id string) string }
+			type unexported interface { Do() }
+			type Number interface { ~int | ~float64 }
+			type Numbers interface { Number; Sum() int }
+			type Getter[T any] interface { Get() T }
+			type NoInterface struct{}`)
+		WriteFile(joinPath(subPackageDir, "sub.go"), `package sub
+			type RepositoryReader interface { Read() }`)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(packageDir)).To(Succeed())
+		os.Chdir(origWorkingDir)
+	})
+
+	It("finds all mockable interfaces in all matching packages", func() {
+		interfaces, e := pattern.FindInterfaces("./...", "*")
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(interfaces).To(Equal([]pattern.Interface{
+			{PackagePath: "pegomockpatterntest", PackageName: "storage", PackageDir: packageDir, Name: "Repository"},
+			{PackagePath: "pegomockpatterntest", PackageName: "storage", PackageDir: packageDir, Name: "Store"},
+			{PackagePath: "pegomockpatterntest/sub", PackageName: "sub", PackageDir: subPackageDir, Name: "RepositoryReader"},
+		}))
+	})
+
+	It("finds only interfaces matching the glob", func() {
+		interfaces, e := pattern.FindInterfaces("pegomockpatterntest/...", "Repo*")
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(interfaces).To(HaveLen(2))
+		Expect(interfaces[0].Name).To(Equal("Repository"))
+		Expect(interfaces[1].Name).To(Equal("RepositoryReader"))
+	})
+
+	It("reports an invalid glob", func() {
+		_, e := pattern.FindInterfaces("./...", "Repo[")
+
+		Expect(e).To(MatchError(HavePrefix("Invalid interface pattern Repo[")))
+	})
+})
@@ -0,0 +1,88 @@
+// Package report describes the mocks the generate command generated in a
+// machine-readable form, see its --json flag.
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+)
+
+// Statuses of a Mock.
+const (
+	StatusCreated   = "created"
+	StatusUpdated   = "updated"
+	StatusUnchanged = "unchanged"
+	StatusFailed    = "failed"
+)
+
+// Mock is the outcome of generating the mock for a single interface.
+type Mock struct {
+	// Package is the interface's package path, or the Go file the mock was
+	// generated from.
+	Package string `json:"package"`
+	// Interface is empty for mocks generated from a Go file. For models with
+	// several interfaces, it holds their comma-separated names.
+	Interface string `json:"interface"`
+	// Output is empty if the mock failed before its file was determined.
+	Output string `json:"output,omitempty"`
+	Status string `json:"status"`
+	// Error is set for failed mocks.
+	Error string `json:"error,omitempty"`
+}
+
+// Report holds the Mocks in the order they were generated.
+type Report struct {
+	Mocks []Mock `json:"mocks"`
+}
+
+// Failures returns how many mocks failed.
+func (report Report) Failures() int {
+	failures := 0
+	for _, mock := range report.Mocks {
+		if mock.Status == StatusFailed {
+			failures++
+		}
+	}
+	return failures
+}
+
+// Write writes the report as JSON to out.
+func (report Report) Write(out io.Writer) error {
+	if report.Mocks == nil {
+		report.Mocks = []Mock{}
+	}
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(content, '\n'))
+	return err
+}
+
+// Snapshot is the content of a mock file before the mock is generated into it.
+type Snapshot struct {
+	path    string
+	existed bool
+	content []byte
+}
+
+// TakeSnapshot reads the file at path, which doesn't need to exist.
+func TakeSnapshot(path string) Snapshot {
+	content, err := ioutil.ReadFile(path)
+	return Snapshot{path: path, existed: err == nil, content: content}
+}
+
+// Status compares the file with the snapshot and returns StatusCreated,
+// StatusUpdated or StatusUnchanged.
+func (snapshot Snapshot) Status() string {
+	if !snapshot.existed {
+		return StatusCreated
+	}
+	content, err := ioutil.ReadFile(snapshot.path)
+	if err != nil || !bytes.Equal(content, snapshot.content) {
+		return StatusUpdated
+	}
+	return StatusUnchanged
+}
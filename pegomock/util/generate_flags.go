@@ -0,0 +1,126 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"strings"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// FlagDefiner is implemented by *kingpin.Application and *kingpin.CmdClause.
+type FlagDefiner interface {
+	Flag(name, help string) *kingpin.FlagClause
+}
+
+// GenerateFlags are the flags shared by the generate command and the lines of
+// interfaces_to_mock files.
+type GenerateFlags struct {
+	Output       *string
+	MockName     *string
+	NamePrefix   *string
+	NameSuffix   *string
+	Package      *string
+	SelfPackage  *string
+	BuildTag     *string
+	ContextAware *bool
+	Provide      *bool
+	TemplatePath *string
+	TemplateData *map[string]string
+	Export       *bool
+	Strict       *bool
+	Tags         *string
+	Standalone   *bool
+}
+
+// DefineGenerateFlags defines the GenerateFlags on cmd.
+func DefineGenerateFlags(cmd FlagDefiner) GenerateFlags {
+	return GenerateFlags{
+		Output:   cmd.Flag("output", "Output file; defaults to mock_<interface>_test.go.").Short('o').String(),
+		MockName: cmd.Flag("mock-name", "Struct name of the generated mock; defaults to the interface with --name-prefix and --name-suffix").String(),
+		NamePrefix: cmd.Flag("name-prefix", "Prefix of the default struct names of the generated mocks, e.g. Fake; "+
+			"use --name-prefix= for none.").Default("Mock").String(),
+		NameSuffix: cmd.Flag("name-suffix", "Suffix of the default struct names of the generated mocks, e.g. Impl; defaults to none.").String(),
+		Package:    cmd.Flag("package", "Package of the generated code; defaults to the package from which pegomock was executed suffixed with _test").String(),
+		// TODO: self_package was taken as is from GoMock.
+		//       Still don't understand what it's really there for.
+		//       So for now it's not tested.
+		SelfPackage: cmd.Flag("self_package", "If set, the package this mock will be part of.").String(),
+		BuildTag: cmd.Flag("build-tag", "Build constraint to put at the top of the generated file; defaults to none. "+
+			"Mocks written to a _test.go file need none, but when using --output or --output-dir with a non-test file name, "+
+			"consider e.g. --build-tag mock to keep the mock out of production binaries.").String(),
+		ContextAware: cmd.Flag("context-aware", "For methods taking a context.Context as first parameter and returning an error, "+
+			"return the context's error right away if the context is done, without consulting stubbings.").Bool(),
+		Provide: cmd.Flag("provide", "Also generate Provide<MockName>(pegomock.Container), which creates the mock and registers it "+
+			"in a dependency container, and make it available to pegomock.ProvideAllMocksFor.").Bool(),
+		TemplatePath: cmd.Flag("template", "Go text/template file to generate the mock with instead of the built-in template. "+
+			"It is executed with a mockgen.TemplateData value.").ExistingFile(),
+		TemplateData: cmd.Flag("template-data", "Additional key=value pair made available to the template as .Data.<key>; "+
+			"can be repeated.").StringMap(),
+		Export: cmd.Flag("export", "Generate mocks meant to be imported by the tests of other packages: into a non-test file "+
+			"and package named after the output directory, with matchers in a package named after the matchers directory. "+
+			"Fails if the mock name is not exported.").Bool(),
+		Strict: cmd.Flag("strict", "Refuse to generate mocks for interfaces with methods returning an error other than as their "+
+			"last return value. Without it, such methods get a warning comment.").Bool(),
+		Tags: cmd.Flag("tags", "Comma-separated build tags to load the interfaces with, like go build -tags. "+
+			"They are added to the generated file's build constraint, so the mock only compiles with them.").String(),
+		Standalone: cmd.Flag("standalone", "Generate mocks that don't depend on the pegomock library, for projects that cannot add it. "+
+			"They only support stubbing return values for equal params and verifying invocation counts.").Bool(),
+	}
+}
+
+// ValidateNameAffixes returns an error if --name-prefix and --name-suffix don't
+// turn interface names into valid identifiers.
+func (flags GenerateFlags) ValidateNameAffixes() error {
+	if !token.IsIdentifier(*flags.NamePrefix + "Interface" + *flags.NameSuffix) {
+		return fmt.Errorf("Cannot name mocks with prefix %q and suffix %q, because they must be made of letters, digits and underscores "+
+			"and the prefix must not start with a digit", *flags.NamePrefix, *flags.NameSuffix)
+	}
+	return nil
+}
+
+// ValidateStandalone returns an error if flags that need the pegomock library
+// are combined with --standalone.
+func (flags GenerateFlags) ValidateStandalone() error {
+	if !*flags.Standalone {
+		return nil
+	}
+	if *flags.Provide {
+		return errors.New("Cannot use --provide with --standalone, because pegomock.Container is part of the pegomock library")
+	}
+	if *flags.TemplatePath != "" {
+		return errors.New("Cannot use --template with --standalone")
+	}
+	return nil
+}
+
+// BuildTags returns the tags given via --tags, which may be separated by commas
+// or spaces like with go build -tags.
+func (flags GenerateFlags) BuildTags() []string {
+	return SplitBuildTags(*flags.Tags)
+}
+
+// BuildConstraint returns the build constraint for the generated file, see
+// BuildConstraint.
+func (flags GenerateFlags) BuildConstraint() string {
+	return BuildConstraint(*flags.BuildTag, flags.BuildTags())
+}
+
+// BuildConstraint returns the build constraint buildTag given via --build-tag,
+// and'ed with the tags the interfaces were loaded with. The mocks then only
+// compile with the tags they were generated for. This way, e.g. mocks generated
+// with --tags integration can coexist with ones generated with --build-tag
+// '!integration'.
+func BuildConstraint(buildTag string, buildTags []string) string {
+	var constraints []string
+	if buildTag != "" {
+		constraints = append(constraints, "("+buildTag+")")
+	}
+	return strings.Join(append(constraints, buildTags...), " && ")
+}
+
+// SplitBuildTags splits a list of build tags separated by commas or spaces.
+func SplitBuildTags(tags string) []string {
+	return strings.FieldsFunc(tags, func(r rune) bool { return r == ',' || r == ' ' })
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ModuleRoot returns the directory of the go.mod file of the module dir is part
+// of, or "" if dir is not part of a module.
+func ModuleRoot(dir string) string {
+	return findModuleRoot(dir)
+}
+
+// PackagePaths returns the import paths of all packages in the module rooted at
+// moduleRoot, keyed by their absolute directories. Unlike deriving the import
+// path from the directory, this also works for modules whose go.mod the simple
+// parser of SourceArgs doesn't understand and for modules of a go.work
+// workspace.
+func PackagePaths(moduleRoot string) (map[string]string, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedModule, Dir: moduleRoot}, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("Loading packages in %v failed: %v", moduleRoot, err)
+	}
+	packagePaths := make(map[string]string)
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 || !resolved(pkg) {
+			continue
+		}
+		packagePaths[filepath.Dir(pkg.GoFiles[0])] = pkg.PkgPath
+	}
+	return packagePaths, nil
+}
+
+// PackagePathOfDir returns the import path of the package in dir using the go
+// command's module information.
+func PackagePathOfDir(dir string) (string, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedModule, Dir: dir}, ".")
+	if err != nil {
+		return "", fmt.Errorf("Loading package in %v failed: %v", dir, err)
+	}
+	if len(pkgs) != 1 || !resolved(pkgs[0]) {
+		return "", fmt.Errorf("Could not determine import path of package in %v", dir)
+	}
+	return pkgs[0].PkgPath, nil
+}
+
+// resolved reports whether pkg's import path is a proper one, rather than a
+// placeholder for a directory outside of any module and GOPATH.
+func resolved(pkg *packages.Package) bool {
+	return pkg.PkgPath != "" && pkg.PkgPath != "command-line-arguments" && !strings.HasPrefix(pkg.PkgPath, "_")
+}
+
+// SourceArgsInPackage is like SourceArgs, but an interface given without package
+// is looked up in the package packagePath instead of the package in the working
+// directory.
+func SourceArgsInPackage(args []string, packagePath string) ([]string, error) {
+	if len(args) == 1 && !SourceMode(args) {
+		return []string{packagePath, args[0]}, nil
+	}
+	return SourceArgs(args)
+}
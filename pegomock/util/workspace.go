@@ -0,0 +1,72 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WorkspaceFile returns the path of the go.work file in dir, or "" if dir has
+// none.
+func WorkspaceFile(dir string) string {
+	path := filepath.Join(dir, "go.work")
+	if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+		return ""
+	}
+	return path
+}
+
+// WorkspaceModules returns the absolute directories of the modules the use
+// directives of the go.work file at path refer to, in the order they appear.
+func WorkspaceModules(path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	workspaceDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	var moduleDirs []string
+	inUseBlock := false
+	for i, line := range strings.Split(string(content), "\n") {
+		if commentStart := strings.Index(line, "//"); commentStart != -1 {
+			line = line[:commentStart]
+		}
+		fields := strings.Fields(line)
+		var useArg string
+		switch {
+		case len(fields) == 0:
+			continue
+		case inUseBlock && fields[0] == ")":
+			inUseBlock = false
+			continue
+		case inUseBlock:
+			useArg = fields[0]
+		case fields[0] == "use" && len(fields) == 2 && fields[1] == "(":
+			inUseBlock = true
+			continue
+		case fields[0] == "use" && len(fields) == 2:
+			useArg = fields[1]
+		case fields[0] == "use":
+			return nil, fmt.Errorf("Cannot parse %v:%v: expected a single module directory after use", path, i+1)
+		default:
+			continue
+		}
+		if unquoted, err := strconv.Unquote(useArg); err == nil {
+			useArg = unquoted
+		}
+		moduleDir := filepath.FromSlash(useArg)
+		if !filepath.IsAbs(moduleDir) {
+			moduleDir = filepath.Join(workspaceDir, moduleDir)
+		}
+		moduleDirs = append(moduleDirs, moduleDir)
+	}
+	if inUseBlock {
+		return nil, fmt.Errorf("Cannot parse %v: use block is not closed", path)
+	}
+	return moduleDirs, nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2016 Peter Goetz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// interruptGracePeriod is how long an interrupted go test may take to exit
+// before it is killed.
+const interruptGracePeriod = 5 * time.Second
+
+var errInterrupted = errors.New("interrupted")
+
+// TestRunner runs go test for the packages whose mocks were regenerated. Runs
+// are debounced: packages scheduled in quick succession, e.g. because of a burst
+// of saves, are tested in a single run. Runs happen on their own goroutine, so
+// they don't hold up watching.
+type TestRunner struct {
+	out      io.Writer
+	testArgs []string
+	debounce time.Duration
+
+	mutex       sync.Mutex
+	pendingDirs map[string]bool
+	timer       *time.Timer
+	stopped     bool
+	stop        chan struct{}
+	// runMutex is held while tests run, so runs don't overlap.
+	runMutex sync.Mutex
+}
+
+// NewTestRunner returns a runner that runs go test with testArgs and writes its
+// output to out, which must be safe for concurrent use, see SynchronizedWriter.
+// Tests run once no packages were scheduled for debounce.
+func NewTestRunner(out io.Writer, testArgs []string, debounce time.Duration) *TestRunner {
+	return &TestRunner{
+		out:         out,
+		testArgs:    testArgs,
+		debounce:    debounce,
+		pendingDirs: make(map[string]bool),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Schedule runs the tests of the packages in dirs, once no further packages
+// were scheduled for the debounce duration.
+func (runner *TestRunner) Schedule(dirs []string) {
+	if len(dirs) == 0 {
+		return
+	}
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	if runner.stopped {
+		return
+	}
+	for _, dir := range dirs {
+		runner.pendingDirs[dir] = true
+	}
+	if runner.timer == nil {
+		runner.timer = time.AfterFunc(runner.debounce, runner.runPending)
+	} else {
+		runner.timer.Reset(runner.debounce)
+	}
+}
+
+// Stop cancels scheduled runs, interrupts a run in progress and waits for it
+// to finish.
+func (runner *TestRunner) Stop() {
+	runner.mutex.Lock()
+	if !runner.stopped {
+		runner.stopped = true
+		if runner.timer != nil {
+			runner.timer.Stop()
+		}
+		close(runner.stop)
+	}
+	runner.mutex.Unlock()
+
+	runner.runMutex.Lock()
+	runner.runMutex.Unlock()
+}
+
+func (runner *TestRunner) runPending() {
+	runner.runMutex.Lock()
+	defer runner.runMutex.Unlock()
+
+	runner.mutex.Lock()
+	dirs := make([]string, 0, len(runner.pendingDirs))
+	for dir := range runner.pendingDirs {
+		dirs = append(dirs, dir)
+	}
+	runner.pendingDirs = make(map[string]bool)
+	runner.mutex.Unlock()
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		select {
+		case <-runner.stop:
+			return
+		default:
+		}
+		fmt.Fprintln(runner.out, "Running tests in", dir)
+		switch err := runner.runTests(dir); err {
+		case nil:
+		case errInterrupted:
+			fmt.Fprintln(runner.out, "Interrupted tests in", dir)
+			return
+		default:
+			fmt.Fprintf(runner.out, "Tests in %v failed: %v\n", dir, err)
+		}
+	}
+}
+
+func (runner *TestRunner) runTests(dir string) error {
+	cmd := exec.Command("go", append(append([]string{"test"}, runner.testArgs...), ".")...)
+	cmd.Dir = dir
+	cmd.Stdout = runner.out
+	cmd.Stderr = runner.out
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	finished := make(chan error, 1)
+	go func() { finished <- cmd.Wait() }()
+
+	select {
+	case err := <-finished:
+		return err
+	case <-runner.stop:
+		// Interrupting lets go test clean up. It's not supported on Windows.
+		if cmd.Process.Signal(os.Interrupt) != nil {
+			cmd.Process.Kill()
+		}
+		select {
+		case <-finished:
+		case <-time.After(interruptGracePeriod):
+			cmd.Process.Kill()
+		}
+		return errInterrupted
+	}
+}
+
+type synchronizedWriter struct {
+	mutex sync.Mutex
+	out   io.Writer
+}
+
+// SynchronizedWriter returns a writer that serializes writes to out, so the
+// summaries of Update passes and the output of tests don't interleave.
+func SynchronizedWriter(out io.Writer) io.Writer {
+	return &synchronizedWriter{out: out}
+}
+
+func (writer *synchronizedWriter) Write(p []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	return writer.out.Write(p)
+}
@@ -15,12 +15,15 @@
 package watch
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"text/tabwriter"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 
@@ -32,75 +35,365 @@ const wellKnownInterfaceListFile = "interfaces_to_mock"
 
 var join = strings.Join
 
+// typeConstraintReason is part of the error for lines that refer to type
+// constraints. Such lines are skipped rather than failed.
+const typeConstraintReason = "is a type constraint, not a mockable interface"
+
 type MockFileUpdater struct {
 	recursive   bool
 	targetPaths []string
-	lastErrors  map[string]string
+	// moduleRoot is the root directory of the module the target paths are part
+	// of, or "" if unknown. packagePaths holds the import paths of its packages
+	// by directory, as of the current Update pass.
+	moduleRoot   string
+	packagePaths map[string]string
+	// buildTags are used for all lines, in addition to the ones of a line.
+	buildTags  []string
+	lastErrors map[string]string
+	// ownedMockFiles holds per target path the mock files this updater wrote or
+	// found up to date. Only those are removed when they become stale.
+	ownedMockFiles map[string]map[string]bool
+}
+
+// UpdateSummary describes the outcome of a single Update pass.
+type UpdateSummary struct {
+	// Regenerated describes the mocks that were (re)generated.
+	Regenerated []string
+	// Unchanged describes the mocks that were already up to date.
+	Unchanged []string
+	// Failures describes the interfaces_to_mock lines no mock could be generated for.
+	Failures []string
+	// Skipped describes the interfaces_to_mock lines that refer to type
+	// constraints, which can't be mocked.
+	Skipped []string
+	// Removed describes the mock files that were removed, because their
+	// interfaces_to_mock lines were removed or changed to generate other files.
+	Removed []string
+	// Mocks has a result for every interfaces_to_mock line, in the order the
+	// lines were processed.
+	Mocks []MockResult
+
+	failuresChanged bool
+	// regeneratedIn and failedIn hold the directories of the interfaces_to_mock
+	// files with regenerated and failed lines.
+	regeneratedIn []string
+	failedIn      map[string]bool
+}
+
+// Statuses of a MockResult.
+const (
+	StatusGenerated = "generated"
+	StatusUnchanged = "unchanged"
+	StatusSkipped   = "skipped"
+	StatusFailed    = "failed"
+)
+
+// MockResult is the outcome of generating the mock for a single
+// interfaces_to_mock line.
+type MockResult struct {
+	// Interface holds the line's args, i.e. the interface and its package or the
+	// Go file, or the whole line if it can't be parsed.
+	Interface string `json:"interface"`
+	// Line is the interfaces_to_mock file and line number.
+	Line   string `json:"line"`
+	Status string `json:"status"`
+	// Reason is set for skipped and failed lines.
+	Reason string `json:"reason,omitempty"`
+	// Output is empty if the line failed before the mock file was determined.
+	Output string `json:"output,omitempty"`
+}
+
+// HasFailures reports whether generating any mock failed.
+func (summary UpdateSummary) HasFailures() bool {
+	return len(summary.Failures) > 0
+}
+
+// HasNews reports whether mocks were (re)generated or failures differ from the
+// previous Update pass. Continuous watching only prints summaries with news.
+func (summary UpdateSummary) HasNews() bool {
+	return len(summary.Regenerated) > 0 || len(summary.Removed) > 0 || summary.failuresChanged
+}
+
+// ChangedPackageDirs returns the directories of the interfaces_to_mock files
+// mocks were (re)generated for, leaving out the ones with failed lines.
+func (summary UpdateSummary) ChangedPackageDirs() (dirs []string) {
+	for _, dir := range summary.regeneratedIn {
+		if !summary.failedIn[dir] && (len(dirs) == 0 || dirs[len(dirs)-1] != dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return
+}
+
+func (summary UpdateSummary) String() string {
+	var result strings.Builder
+	for _, mock := range summary.Regenerated {
+		fmt.Fprintln(&result, "(Re)generated mock for", mock)
+	}
+	for _, mockFile := range summary.Removed {
+		fmt.Fprintln(&result, "Removed stale mock", mockFile)
+	}
+	for _, skipped := range summary.Skipped {
+		fmt.Fprintln(&result, "Skipped", skipped)
+	}
+	for _, failure := range summary.Failures {
+		fmt.Fprintln(&result, "Error while trying to generate mock for", failure)
+	}
+	skippedInfo := ""
+	if len(summary.Skipped) > 0 {
+		skippedInfo = fmt.Sprintf(", %v skipped", len(summary.Skipped))
+	}
+	fmt.Fprintf(&result, "%v mock(s) (re)generated, %v unchanged%v, %v failed.\n",
+		len(summary.Regenerated), len(summary.Unchanged), skippedInfo, len(summary.Failures))
+	return result.String()
+}
+
+// FailureTable renders the failed lines as a table, or returns "" if none failed.
+func (summary UpdateSummary) FailureTable() string {
+	if !summary.HasFailures() {
+		return ""
+	}
+	var result strings.Builder
+	table := tabwriter.NewWriter(&result, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "LINE\tINTERFACE\tREASON")
+	for _, mock := range summary.Mocks {
+		if mock.Status == StatusFailed {
+			fmt.Fprintf(table, "%v\t%v\t%v\n", mock.Line, mock.Interface, strings.Replace(mock.Reason, "\n", " ", -1))
+		}
+	}
+	table.Flush()
+	return result.String()
+}
+
+// WriteJSON writes the Mocks to path, so CI can e.g. diff them to detect
+// interfaces that became unmockable.
+func (summary UpdateSummary) WriteJSON(path string) error {
+	content, err := summary.marshalJSON()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+// EncodeJSON writes the Mocks to out in the format of WriteJSON.
+func (summary UpdateSummary) EncodeJSON(out io.Writer) error {
+	content, err := summary.marshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(content)
+	return err
+}
+
+func (summary UpdateSummary) marshalJSON() ([]byte, error) {
+	mocks := summary.Mocks
+	if mocks == nil {
+		mocks = []MockResult{}
+	}
+	content, err := json.MarshalIndent(struct {
+		Mocks []MockResult `json:"mocks"`
+	}{mocks}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
 }
 
-func NewMockFileUpdater(targetPaths []string, recursive bool) *MockFileUpdater {
+// NewMockFileUpdater returns an updater for the interfaces_to_mock files in
+// targetPaths. Interfaces given without package are looked up in the package of
+// the file's directory, whose import path is taken from the module rooted at
+// moduleRoot. moduleRoot may be "", e.g. outside of modules. Import paths are
+// then determined per directory. All mocks are generated with buildTags, see
+// the generate command's --tags.
+func NewMockFileUpdater(targetPaths []string, recursive bool, moduleRoot string, buildTags ...string) *MockFileUpdater {
 	return &MockFileUpdater{
 		targetPaths: targetPaths,
 		recursive:   recursive,
+		moduleRoot:  moduleRoot,
+		buildTags:   buildTags,
 		lastErrors:  make(map[string]string),
+
+		ownedMockFiles: make(map[string]map[string]bool),
 	}
 }
 
-func (updater *MockFileUpdater) Update() {
+func (updater *MockFileUpdater) Update() UpdateSummary {
+	var summary UpdateSummary
+	updater.packagePaths = nil
+	if updater.moduleRoot != "" {
+		// Packages may have been added or moved since the last pass. If loading
+		// fails, import paths are determined per directory instead.
+		updater.packagePaths, _ = util.PackagePaths(updater.moduleRoot)
+	}
+	updateMockFiles := func(targetPath string) { updater.updateMockFiles(targetPath, &summary) }
 	for _, targetPath := range updater.targetPaths {
 		if updater.recursive {
 			filepath.Walk(targetPath, func(path string, info os.FileInfo, err error) error {
 				if err == nil && info.IsDir() {
-					util.WithinWorkingDir(path, updater.updateMockFiles)
+					util.WithinWorkingDir(path, updateMockFiles)
 				}
 				return nil
 			})
 		} else {
-			util.WithinWorkingDir(targetPath, updater.updateMockFiles)
+			util.WithinWorkingDir(targetPath, updateMockFiles)
 		}
 	}
+	return summary
 }
 
-func (updater *MockFileUpdater) updateMockFiles(targetPath string) {
+func (updater *MockFileUpdater) updateMockFiles(targetPath string, summary *UpdateSummary) {
 	if _, err := os.Stat(wellKnownInterfaceListFile); os.IsNotExist(err) {
 		return
 	}
-	for _, lineParts := range linesIn(wellKnownInterfaceListFile) {
-		lineCmd := kingpin.New("What should go in here", "And what should go in here")
-		destination := lineCmd.Flag("output", "Output file; defaults to mock_<interface>_test.go.").Short('o').String()
-		nameOut := lineCmd.Flag("name", "Struct name of the generated code; defaults to the name of the interface prefixed with Mock").Default(filepath.Base(targetPath) + "_test").String()
-		packageOut := lineCmd.Flag("package", "Package of the generated code; defaults to the package from which pegomock was executed suffixed with _test").Default(filepath.Base(targetPath) + "_test").String()
-		selfPackage := lineCmd.Flag("self_package", "If set, the package this mock will be part of.").String()
-		lineArgs := lineCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file").Required().Strings()
-
-		_, parseErr := lineCmd.Parse(lineParts)
-		if parseErr != nil {
-			fmt.Println("Error while trying to generate mock for line", join(lineParts, " "), ":", parseErr)
+	expectedMockFiles := make(map[string]bool)
+	allMockFilesKnown := true
+	for _, line := range linesIn(wellKnownInterfaceListFile) {
+		mockFilePath := updater.updateMockFile(targetPath, line, summary)
+		if mockFilePath == "" {
+			allMockFilesKnown = false
+		}
+		expectedMockFiles[mockFilePath] = true
+	}
+	// A line that can't be parsed might still refer to any owned mock file.
+	if allMockFilesKnown {
+		updater.removeStaleMockFiles(targetPath, expectedMockFiles, summary)
+	}
+}
+
+func (updater *MockFileUpdater) removeStaleMockFiles(targetPath string, expectedMockFiles map[string]bool, summary *UpdateSummary) {
+	for mockFilePath := range updater.ownedMockFiles[targetPath] {
+		if expectedMockFiles[mockFilePath] {
 			continue
 		}
-		defer func() {
-			err := recover()
-			if err != nil {
-				if updater.lastErrors[errorKey(*lineArgs)] != fmt.Sprint(err) {
-					fmt.Println("Error while trying to generate mock for", join(lineParts, " "), ":", err)
-					updater.lastErrors[errorKey(*lineArgs)] = fmt.Sprint(err)
+		delete(updater.ownedMockFiles[targetPath], mockFilePath)
+		if err := os.Remove(mockFilePath); err != nil {
+			if !os.IsNotExist(err) {
+				summary.Failures = append(summary.Failures, fmt.Sprint("removing stale mock ", filepath.Join(targetPath, mockFilePath), ": ", err))
+			}
+			continue
+		}
+		summary.Removed = append(summary.Removed, filepath.Join(targetPath, mockFilePath))
+	}
+}
+
+// updateMockFile returns the path of the mock file for line, relative to
+// targetPath, or "" if it could not be determined.
+func (updater *MockFileUpdater) updateMockFile(targetPath string, line listLine, summary *UpdateSummary) (mockFilePath string) {
+	lineParts := line.parts
+	key := errorKey(append([]string{targetPath}, lineParts...))
+	result := MockResult{
+		Interface: join(lineParts, " "),
+		Line:      fmt.Sprintf("%v:%v", filepath.Join(targetPath, wellKnownInterfaceListFile), line.number),
+	}
+	defer func() {
+		err := recover()
+		if err != nil {
+			result.Status, result.Reason = StatusFailed, fmt.Sprint(err)
+			if mockFilePath != "" {
+				result.Output = filepath.Join(targetPath, mockFilePath)
+			}
+			message := fmt.Sprintf("%v in %v: %v", join(lineParts, " "), result.Line, err)
+			if strings.Contains(result.Reason, typeConstraintReason) {
+				result.Status = StatusSkipped
+				summary.Skipped = append(summary.Skipped, message)
+			} else {
+				summary.Failures = append(summary.Failures, message)
+				if summary.failedIn == nil {
+					summary.failedIn = make(map[string]bool)
 				}
+				summary.failedIn[targetPath] = true
 			}
-		}()
+			summary.Mocks = append(summary.Mocks, result)
+			if updater.lastErrors[key] != fmt.Sprint(err) {
+				summary.failuresChanged = true
+				updater.lastErrors[key] = fmt.Sprint(err)
+			}
+		}
+	}()
 
-		util.PanicOnError(util.ValidateArgs(*lineArgs))
-		sourceArgs, err := util.SourceArgs(*lineArgs)
-		util.PanicOnError(err)
+	lineCmd := kingpin.New(wellKnownInterfaceListFile, "A line of "+wellKnownInterfaceListFile)
+	flags := util.DefineGenerateFlags(lineCmd)
+	// Lines used --name before the generate command's --mock-name was shared with them.
+	legacyMockName := lineCmd.Flag("name", "Deprecated: use --mock-name.").Hidden().String()
+	lineArgs := lineCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file").Required().Strings()
 
-		generatedMockSourceCode, _ := filehandling.GenerateMockSourceCode(sourceArgs, *nameOut, *packageOut, *selfPackage, false, os.Stdout, false)
-		mockFilePath := filehandling.OutputFilePath(sourceArgs, ".", *destination)
-		hasChanged := util.WriteFileIfChanged(mockFilePath, generatedMockSourceCode)
+	_, parseErr := lineCmd.Parse(lineParts)
+	util.PanicOnError(parseErr)
+	util.PanicOnError(util.ValidateArgs(*lineArgs))
+	sourceArgs, err := updater.sourceArgsFor(*lineArgs)
+	util.PanicOnError(err)
+	result.Interface = join(*lineArgs, " ")
 
-		if hasChanged || updater.lastErrors[errorKey(*lineArgs)] != "" {
-			fmt.Println("(Re)generated mock for", errorKey(*lineArgs), "in", mockFilePath)
+	mockName := *flags.MockName
+	if mockName == "" {
+		mockName = *legacyMockName
+	}
+	packageOut := *flags.Package
+	if packageOut == "" {
+		packageOut = filepath.Base(targetPath) + "_test"
+		if *flags.Export {
+			packageOut = filepath.Base(targetPath)
 		}
-		delete(updater.lastErrors, errorKey(*lineArgs))
 	}
+	if *flags.Export {
+		mockFilePath = filehandling.ExportedOutputFilePath(sourceArgs, ".", *flags.Output)
+		util.PanicOnError(filehandling.ValidateExport(mockName, *flags.NamePrefix, *flags.NameSuffix, packageOut, mockFilePath))
+	} else {
+		mockFilePath = filehandling.OutputFilePath(sourceArgs, ".", *flags.Output)
+	}
+	buildTags := append(append([]string(nil), updater.buildTags...), flags.BuildTags()...)
+	if *flags.Strict {
+		util.PanicOnError(filehandling.ValidateErrorPositions(sourceArgs, false, buildTags...))
+	}
+	util.PanicOnError(flags.ValidateStandalone())
+	util.PanicOnError(flags.ValidateNameAffixes())
+	options := filehandling.GenerateOptionsFromFlags(flags)
+	options.MockName = mockName
+	options.PackageOut = packageOut
+	options.BuildConstraint = util.BuildConstraint(*flags.BuildTag, buildTags)
+	options.BuildTags = buildTags
+	generatedMockSourceCode, _ := filehandling.GenerateMockSourceCode(sourceArgs, options)
+	util.PanicOnError(os.MkdirAll(filepath.Dir(mockFilePath), 0755))
+	hasChanged := util.WriteFileIfChanged(mockFilePath, generatedMockSourceCode)
+
+	result.Output = filepath.Join(targetPath, mockFilePath)
+	mock := fmt.Sprint(join(*lineArgs, " "), " in ", result.Output)
+	if hasChanged || updater.lastErrors[key] != "" {
+		summary.Regenerated = append(summary.Regenerated, mock)
+		summary.regeneratedIn = append(summary.regeneratedIn, targetPath)
+		result.Status = StatusGenerated
+	} else {
+		summary.Unchanged = append(summary.Unchanged, mock)
+		result.Status = StatusUnchanged
+	}
+	summary.Mocks = append(summary.Mocks, result)
+	delete(updater.lastErrors, key)
+	if updater.ownedMockFiles[targetPath] == nil {
+		updater.ownedMockFiles[targetPath] = make(map[string]bool)
+	}
+	updater.ownedMockFiles[targetPath][mockFilePath] = true
+	return
+}
+
+// sourceArgsFor is like util.SourceArgs for the args of a line of the
+// interfaces_to_mock file in the working directory, but takes the import path of
+// the working directory's package from the go command's module information if
+// possible. Deriving it from the directory breaks e.g. in go.work workspaces.
+func (updater *MockFileUpdater) sourceArgsFor(lineArgs []string) ([]string, error) {
+	if len(lineArgs) != 1 || util.SourceMode(lineArgs) {
+		return util.SourceArgs(lineArgs)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	packagePath := updater.packagePaths[dir]
+	if packagePath == "" {
+		if packagePath, err = util.PackagePathOfDir(dir); err != nil {
+			return util.SourceArgs(lineArgs)
+		}
+	}
+	return util.SourceArgsInPackage(lineArgs, packagePath)
 }
 
 func errorKey(args []string) string {
@@ -122,19 +415,39 @@ func CreateWellKnownInterfaceListFileIfNecessary(targetPath string) {
 		panic(err)
 	}
 	defer file.Close()
-	file.WriteString("### List here all interfaces you would like to mock. One per line.\n")
+	file.WriteString(interfaceListFileTemplate)
 }
 
-func linesIn(file string) (result [][]string) {
+const interfaceListFileTemplate = `### List here all interfaces you would like to mock. One per line.
+#
+# A line is an interface of this package, a Go package path followed by interface
+# names, or a .go file. It can have the flags of the "pegomock generate" command
+# that affect a single mock, e.g.:
+#
+#   PhoneBook
+#   path/to/mypackage SomeInterface --mock-name MockSomething
+#   display.go --output mocks/mock_display.go --package mocks
+#
+# Everything after a # is a comment. Blank lines are ignored.
+`
+
+// listLine is a non-empty line of an interfaces_to_mock file, without comments.
+type listLine struct {
+	number int
+	parts  []string
+}
+
+var commentPattern = regexp.MustCompile(`(^|\s)#.*$`)
+
+func linesIn(file string) (result []listLine) {
 	content, err := ioutil.ReadFile(file)
 	util.PanicOnError(err)
-	for _, line := range strings.Split(string(content), "\n") {
-		if strings.HasPrefix(strings.TrimSpace(line), "#") || line == "" {
+	for i, text := range strings.Split(string(content), "\n") {
+		parts := strings.Fields(commentPattern.ReplaceAllString(text, ""))
+		if len(parts) == 0 {
 			continue
 		}
-		parts := regexp.MustCompile(`\s`).Split(line, -1)
-		// TODO: do validation here like in main
-		result = append(result, parts)
+		result = append(result, listLine{number: i + 1, parts: parts})
 	}
 	return
 }
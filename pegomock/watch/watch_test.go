@@ -18,10 +18,13 @@ import (
 	"go/build"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	. "github.com/petergtz/pegomock/pegomock/testutil"
 	"github.com/petergtz/pegomock/pegomock/watch"
 )
@@ -75,7 +78,7 @@ var _ = Describe("NewMockFileUpdater", func() {
 		It(`Eventually creates a file mock_mydisplay_test.go starting with "package pegomocktest_test"`, func() {
 			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay")
 
-			watch.NewMockFileUpdater([]string{packageDir}, false).Update()
+			watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
 
 			Eventually(joinPath(packageDir, "mock_mydisplay_test.go"), "3s").Should(SatisfyAll(
 				BeAnExistingFile(),
@@ -86,7 +89,7 @@ var _ = Describe("NewMockFileUpdater", func() {
 			It(`Eventually creates a file foo.go starting with "package pegomocktest_test"`, func() {
 				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "-o foo.go MyDisplay")
 
-				watch.NewMockFileUpdater([]string{packageDir}, false).Update()
+				watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
 
 				Eventually(joinPath(packageDir, "foo.go"), "3s").Should(SatisfyAll(
 					BeAnExistingFile(),
@@ -98,7 +101,7 @@ var _ = Describe("NewMockFileUpdater", func() {
 			It(`Eventually creates a file starting with "package the_overriden_test_package"`, func() {
 				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "--package the_overriden_test_package MyDisplay")
 
-				watch.NewMockFileUpdater([]string{packageDir}, false).Update()
+				watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
 
 				Eventually(joinPath(packageDir, "mock_mydisplay_test.go"), "3s").Should(SatisfyAll(
 					BeAnExistingFile(),
@@ -111,7 +114,7 @@ var _ = Describe("NewMockFileUpdater", func() {
 			It(`Eventually creates a file containing the import ( vendored_package "github.com/petergtz/vendored_package" )'`, func() {
 				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "VendorDisplay")
 
-				watch.NewMockFileUpdater([]string{packageDir}, false).Update()
+				watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
 
 				Expect(joinPath(packageDir, "mock_vendordisplay_test.go")).To(SatisfyAll(
 					BeAnExistingFile(),
@@ -125,7 +128,7 @@ var _ = Describe("NewMockFileUpdater", func() {
 				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay")
 				WriteFile(joinPath(subPackageDir, "interfaces_to_mock"), "SubDisplay")
 
-				watch.NewMockFileUpdater([]string{"pegomocktest", "pegomocktest/subpackage"}, false).Update()
+				watch.NewMockFileUpdater([]string{"pegomocktest", "pegomocktest/subpackage"}, false, "").Update()
 
 				Eventually(joinPath(packageDir, "mock_mydisplay_test.go"), "3s").Should(SatisfyAll(
 					BeAnExistingFile(),
@@ -141,7 +144,7 @@ var _ = Describe("NewMockFileUpdater", func() {
 				os.Chdir("..")
 				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay\npegomocktest/subpackage SubDisplay")
 
-				watch.NewMockFileUpdater([]string{"pegomocktest", "pegomocktest/subpackage"}, false).Update()
+				watch.NewMockFileUpdater([]string{"pegomocktest", "pegomocktest/subpackage"}, false, "").Update()
 
 				Eventually(joinPath(packageDir, "mock_mydisplay_test.go"), "3s").Should(SatisfyAll(
 					BeAnExistingFile(),
@@ -157,7 +160,7 @@ var _ = Describe("NewMockFileUpdater", func() {
 				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay")
 				WriteFile(joinPath(subPackageDir, "interfaces_to_mock"), "SubDisplay")
 
-				watch.NewMockFileUpdater([]string{packageDir}, true).Update()
+				watch.NewMockFileUpdater([]string{packageDir}, true, "").Update()
 
 				Eventually(joinPath(packageDir, "mock_mydisplay_test.go"), "3s").Should(SatisfyAll(
 					BeAnExistingFile(),
@@ -174,7 +177,7 @@ var _ = Describe("NewMockFileUpdater", func() {
 		It(`Eventually creates a file mock_mydisplay_test.go starting with "package pegomocktest_test"`, func() {
 			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "mydisplay.go")
 
-			watch.NewMockFileUpdater([]string{packageDir}, false).Update()
+			watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
 
 			Eventually(joinPath(packageDir, "mock_mydisplay_test.go"), "3s").Should(SatisfyAll(
 				BeAnExistingFile(),
@@ -182,4 +185,342 @@ var _ = Describe("NewMockFileUpdater", func() {
 		})
 	})
 
+	Context("after populating interfaces_to_mock with comments, blank lines and per-line options", func() {
+		It("generates each mock with its own options", func() {
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "# mocks of this package\n"+
+				"\n"+
+				"   \t\n"+
+				"MyDisplay   --output mocks/my_display.go --package mymocks # trailing comment\n"+
+				"mydisplay.go --mock-name OtherMockDisplay --output other_display.go\n")
+
+			summary := watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
+
+			Expect(summary.Failures).To(BeEmpty())
+			Expect(joinPath(packageDir, "mocks", "my_display.go")).To(SatisfyAll(
+				BeAnExistingFile(),
+				BeAFileContainingSubString("package mymocks"),
+				BeAFileContainingSubString("type MockMyDisplay struct")))
+			Expect(joinPath(packageDir, "other_display.go")).To(SatisfyAll(
+				BeAnExistingFile(),
+				BeAFileContainingSubString("package pegomocktest_test"),
+				BeAFileContainingSubString("type OtherMockDisplay struct")))
+		})
+
+		It("generates mocks to export into a non-test file and package", func() {
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay --export\n"+
+				"mydisplay.go --export --mock-name mockDisplay\n")
+
+			summary := watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
+
+			Expect(joinPath(packageDir, "mock_mydisplay.go")).To(SatisfyAll(
+				BeAnExistingFile(),
+				BeAFileContainingSubString("package pegomocktest\n")))
+			Expect(summary.Failures).To(ConsistOf(ContainSubstring(
+				"Cannot export mock mockDisplay, because its name is not exported.")))
+		})
+
+		It("reports invalid lines with file and line number, but generates the valid ones", func() {
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "# comment\n\nMyDisplay --minimal\nmydisplay.go --output other_display.go\n")
+
+			summary := watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
+
+			Expect(summary.Failures).To(ConsistOf(HavePrefix(
+				"MyDisplay --minimal in " + joinPath(packageDir, "interfaces_to_mock") + ":3: ")))
+			Expect(joinPath(packageDir, "other_display.go")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("creating interfaces_to_mock", func() {
+		It("writes a template explaining the syntax, which generates no mocks", func() {
+			watch.CreateWellKnownInterfaceListFileIfNecessary(packageDir)
+
+			Expect(joinPath(packageDir, "interfaces_to_mock")).To(
+				BeAFileContainingSubString("Everything after a # is a comment."))
+			summary := watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
+			Expect(summary.Regenerated).To(BeEmpty())
+			Expect(summary.Failures).To(BeEmpty())
+		})
+	})
+
+	Context("summarizing an update", func() {
+		It("lists regenerated and failed mocks, and has news only when something changed", func() {
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay\n--no-such-flag MyDisplay")
+			updater := watch.NewMockFileUpdater([]string{packageDir}, false, "")
+
+			summary := updater.Update()
+
+			Expect(summary.Regenerated).To(ConsistOf("MyDisplay in " + joinPath(packageDir, "mock_mydisplay_test.go")))
+			Expect(summary.Unchanged).To(BeEmpty())
+			Expect(summary.Failures).To(ConsistOf(HavePrefix("--no-such-flag MyDisplay in " + joinPath(packageDir, "interfaces_to_mock") + ":2: ")))
+			Expect(summary.HasFailures()).To(BeTrue())
+			Expect(summary.HasNews()).To(BeTrue())
+
+			summary = updater.Update()
+
+			Expect(summary.Regenerated).To(BeEmpty())
+			Expect(summary.Unchanged).To(HaveLen(1))
+			Expect(summary.HasFailures()).To(BeTrue())
+			Expect(summary.HasNews()).To(BeFalse())
+			Expect(summary.String()).To(HaveSuffix("0 mock(s) (re)generated, 1 unchanged, 1 failed.\n"))
+		})
+
+		It("tells the packages with regenerated mocks, but without failures", func() {
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay\nVendorDisplay")
+			WriteFile(joinPath(subPackageDir, "interfaces_to_mock"), "SubDisplay\n--no-such-flag SubDisplay")
+			updater := watch.NewMockFileUpdater([]string{packageDir, subPackageDir}, false, "")
+
+			Expect(updater.Update().ChangedPackageDirs()).To(Equal([]string{packageDir}))
+			Expect(updater.Update().ChangedPackageDirs()).To(BeEmpty())
+		})
+	})
+
+	Context("collecting results per line", func() {
+		It("has the status, reason and output of every line, and skips type constraints", func() {
+			WriteFile(joinPath(packageDir, "constraints.go"), "package pegomocktest; type Number interface { ~int | ~float64 }")
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay\nNumber\n--no-such-flag MyDisplay")
+			listFile := joinPath(packageDir, "interfaces_to_mock")
+
+			summary := watch.NewMockFileUpdater([]string{packageDir}, false, "").Update()
+
+			Expect(summary.Mocks).To(HaveLen(3))
+			Expect(summary.Mocks[0]).To(Equal(watch.MockResult{Interface: "MyDisplay", Line: listFile + ":1",
+				Status: watch.StatusGenerated, Output: joinPath(packageDir, "mock_mydisplay_test.go")}))
+			Expect(summary.Mocks[1].Status).To(Equal(watch.StatusSkipped))
+			Expect(summary.Mocks[1].Reason).To(ContainSubstring("Number is a type constraint, not a mockable interface"))
+			Expect(summary.Mocks[2].Status).To(Equal(watch.StatusFailed))
+			Expect(summary.Mocks[2].Interface).To(Equal("--no-such-flag MyDisplay"))
+			Expect(summary.Mocks[2].Output).To(BeEmpty())
+			Expect(summary.Skipped).To(HaveLen(1))
+			Expect(summary.Failures).To(HaveLen(1))
+			Expect(summary.String()).To(HaveSuffix("1 mock(s) (re)generated, 0 unchanged, 1 skipped, 1 failed.\n"))
+			Expect(summary.FailureTable()).To(ContainSubstring(listFile + ":3  --no-such-flag MyDisplay  unknown long flag"))
+
+			Expect(summary.WriteJSON(joinPath(packageDir, "summary.json"))).To(Succeed())
+			Expect(joinPath(packageDir, "summary.json")).To(BeAFileContainingSubString(
+				`"interface": "Number",` + "\n      " + `"line": "` + listFile + `:2",` + "\n      " + `"status": "skipped",`))
+		})
+	})
+
+	Context("removing or renaming lines of interfaces_to_mock", func() {
+		It("removes the mock files generated for them, but keeps those it didn't generate", func() {
+			WriteFile(joinPath(packageDir, "handwritten_test.go"), "package pegomocktest_test")
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay\nVendorDisplay")
+			updater := watch.NewMockFileUpdater([]string{packageDir}, false, "")
+			updater.Update()
+			Expect(joinPath(packageDir, "mock_vendordisplay_test.go")).To(BeAnExistingFile())
+
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "-o mock_display_test.go MyDisplay")
+			summary := updater.Update()
+
+			Expect(summary.Removed).To(ConsistOf(
+				joinPath(packageDir, "mock_mydisplay_test.go"),
+				joinPath(packageDir, "mock_vendordisplay_test.go")))
+			Expect(summary.HasNews()).To(BeTrue())
+			Expect(summary.String()).To(ContainSubstring("Removed stale mock " + joinPath(packageDir, "mock_mydisplay_test.go")))
+			Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+			Expect(joinPath(packageDir, "mock_vendordisplay_test.go")).NotTo(BeAnExistingFile())
+			Expect(joinPath(packageDir, "mock_display_test.go")).To(BeAnExistingFile())
+			Expect(joinPath(packageDir, "handwritten_test.go")).To(BeAnExistingFile())
+		})
+
+		It("keeps all mock files while a line can't be parsed", func() {
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "MyDisplay")
+			updater := watch.NewMockFileUpdater([]string{packageDir}, false, "")
+			updater.Update()
+
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "--no-such-flag MyDisplay")
+			summary := updater.Update()
+
+			Expect(summary.Removed).To(BeEmpty())
+			Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("with build tags", func() {
+		It("generates the mocks with the updater's and the line's tags", func() {
+			WriteFile(joinPath(packageDir, "integration_store.go"),
+				"//go:build integration && slow\n\npackage pegomocktest\n\ntype IntegrationStore interface { Get(key string) string }")
+			WriteFile(joinPath(packageDir, "interfaces_to_mock"), "IntegrationStore --tags slow")
+
+			summary := watch.NewMockFileUpdater([]string{packageDir}, false, "", "integration").Update()
+
+			Expect(summary.Failures).To(BeEmpty())
+			Expect(joinPath(packageDir, "mock_integrationstore_test.go")).To(
+				BeAFileContainingSubString("//go:build integration && slow\n"))
+		})
+	})
+
+	Context("in a sub-directory of a Go module", func() {
+		var moduleDir, storeDir string
+
+		BeforeEach(func() {
+			tmpDir, e := filepath.EvalSymlinks(os.TempDir())
+			Expect(e).NotTo(HaveOccurred())
+			moduleDir = joinPath(tmpDir, "watchtestmodule")
+			storeDir = joinPath(moduleDir, "internal", "store")
+			Expect(os.MkdirAll(storeDir, 0755)).To(Succeed())
+			// The comment before the module directive used to break deriving the
+			// import path from go.mod.
+			WriteFile(joinPath(moduleDir, "go.mod"),
+				`// Module for testing the watch command.
+				module example.com/watchtestmodule
+				go 1.12
+				require github.com/petergtz/pegomock v2.3.0+incompatible`)
+			WriteFile(joinPath(storeDir, "store.go"), "package store; type Store interface { Get(key string) string }")
+			WriteFile(joinPath(storeDir, "interfaces_to_mock"), "Store")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(moduleDir)).To(Succeed())
+		})
+
+		It("generates the mock with the package's import path from the module", func() {
+			summary := watch.NewMockFileUpdater([]string{storeDir}, false, moduleDir).Update()
+
+			Expect(summary.Failures).To(BeEmpty())
+			Expect(joinPath(storeDir, "mock_store_test.go")).To(SatisfyAll(
+				BeAnExistingFile(),
+				BeAFileContainingSubString("package store_test"),
+				BeAFileContainingSubString(`store "example.com/watchtestmodule/internal/store"`),
+				BeAFileContainingSubString(`"example.com/watchtestmodule/internal/store.Store"`)))
+		})
+
+		It("also determines the import path without the module root", func() {
+			summary := watch.NewMockFileUpdater([]string{storeDir}, false, "").Update()
+
+			Expect(summary.Failures).To(BeEmpty())
+			Expect(joinPath(storeDir, "mock_store_test.go")).To(
+				BeAFileContainingSubString(`store "example.com/watchtestmodule/internal/store"`))
+		})
+	})
+})
+
+var _ = Describe("NewWorkspaceMockFileUpdater", func() {
+	var workspaceDir, alphaDir, betaDir string
+
+	BeforeEach(func() {
+		tmpDir, e := filepath.EvalSymlinks(os.TempDir())
+		Expect(e).NotTo(HaveOccurred())
+		workspaceDir = joinPath(tmpDir, "watchtestworkspace")
+		alphaDir = joinPath(workspaceDir, "alpha")
+		betaDir = joinPath(workspaceDir, "modules", "beta")
+		Expect(os.MkdirAll(alphaDir, 0755)).To(Succeed())
+		Expect(os.MkdirAll(betaDir, 0755)).To(Succeed())
+		WriteFile(joinPath(workspaceDir, "go.work"), "go 1.18\n\nuse ./alpha // the first module\n\nuse (\n\t\"./modules/beta\"\n)\n")
+		WriteFile(joinPath(alphaDir, "go.mod"), "module example.com/alpha\ngo 1.18\n")
+		WriteFile(joinPath(alphaDir, "alpha.go"), "package alpha; type Alpha interface { Get(key string) string }")
+		// Source mode works without pegomock being required by the modules.
+		WriteFile(joinPath(alphaDir, "interfaces_to_mock"), "alpha.go")
+		WriteFile(joinPath(betaDir, "go.mod"), "module example.com/beta\ngo 1.18\n")
+		WriteFile(joinPath(betaDir, "beta.go"), "package beta; type Beta interface { Put(key string, value string) }")
+		WriteFile(joinPath(betaDir, "interfaces_to_mock"), "beta.go")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(workspaceDir)).To(Succeed())
+	})
+
+	It("generates the mocks of each module with the module's import paths", func() {
+		updater, e := watch.NewWorkspaceMockFileUpdater(joinPath(workspaceDir, "go.work"), false)
+		Expect(e).NotTo(HaveOccurred())
+		Expect(updater.ModuleDirs()).To(Equal([]string{alphaDir, betaDir}))
+
+		summary := updater.Update()
+
+		Expect(summary.Failures).To(BeEmpty())
+		Expect(summary.Regenerated).To(HaveLen(2))
+		Expect(joinPath(alphaDir, "mock_alpha_test.go")).To(
+			BeAFileContainingSubString(`alpha "example.com/alpha"`))
+		Expect(joinPath(betaDir, "mock_beta_test.go")).To(
+			BeAFileContainingSubString(`beta "example.com/beta"`))
+	})
+
+	It("regenerates only the mocks of the module whose interface changed", func() {
+		updater, e := watch.NewWorkspaceMockFileUpdater(joinPath(workspaceDir, "go.work"), false)
+		Expect(e).NotTo(HaveOccurred())
+		Expect(updater.Update().Failures).To(BeEmpty())
+
+		WriteFile(joinPath(betaDir, "beta.go"), "package beta; type Beta interface { Put(key string, value string); Delete(key string) }")
+		summary := updater.Update()
+
+		Expect(summary.Failures).To(BeEmpty())
+		Expect(summary.Regenerated).To(ConsistOf(HavePrefix("beta.go in " + betaDir)))
+		Expect(summary.ChangedPackageDirs()).To(Equal([]string{betaDir}))
+		Expect(summary.Unchanged).To(ConsistOf(HavePrefix("alpha.go in " + alphaDir)))
+
+		WriteFile(joinPath(alphaDir, "alpha.go"), "package alpha; type Alpha interface { Get(key string) (string, error) }")
+		summary = updater.Update()
+
+		Expect(summary.Regenerated).To(ConsistOf(HavePrefix("alpha.go in " + alphaDir)))
+		Expect(summary.Unchanged).To(ConsistOf(HavePrefix("beta.go in " + betaDir)))
+	})
+
+	It("fails for unclosed use blocks", func() {
+		WriteFile(joinPath(workspaceDir, "go.work"), "go 1.18\n\nuse (\n\t./alpha\n")
+
+		_, e := watch.NewWorkspaceMockFileUpdater(joinPath(workspaceDir, "go.work"), false)
+
+		Expect(e).To(MatchError("Cannot parse " + joinPath(workspaceDir, "go.work") + ": use block is not closed"))
+	})
+})
+
+var _ = Describe("TestRunner", func() {
+	var (
+		packageDir string
+		out        *gbytes.Buffer
+		runner     *watch.TestRunner
+		output     = func() string { return string(out.Contents()) }
+	)
+
+	BeforeEach(func() {
+		tmpDir, e := filepath.EvalSymlinks(os.TempDir())
+		Expect(e).NotTo(HaveOccurred())
+		packageDir = joinPath(tmpDir, "testrunnermodule")
+		Expect(os.MkdirAll(packageDir, 0755)).To(Succeed())
+		WriteFile(joinPath(packageDir, "go.mod"), "module example.com/testrunnermodule\ngo 1.12\n")
+		out = gbytes.NewBuffer()
+	})
+
+	AfterEach(func() {
+		runner.Stop()
+		Expect(os.RemoveAll(packageDir)).To(Succeed())
+	})
+
+	It("runs the tests of packages scheduled in quick succession once, with the test args", func() {
+		WriteFile(joinPath(packageDir, "passing_test.go"), "package testrunnermodule; import \"testing\"; func TestPasses(t *testing.T) {}; func TestOther(t *testing.T) {}")
+		runner = watch.NewTestRunner(watch.SynchronizedWriter(out), []string{"-v", "-run", "TestPasses"}, 100*time.Millisecond)
+
+		runner.Schedule([]string{packageDir})
+		runner.Schedule([]string{packageDir})
+
+		Eventually(output, "30s").Should(ContainSubstring("--- PASS: TestPasses"))
+		Consistently(output, "300ms").Should(SatisfyAll(
+			HavePrefix("Running tests in "+packageDir+"\n"),
+			Not(ContainSubstring("TestOther"))))
+		Expect(strings.Count(output(), "Running tests in")).To(Equal(1))
+	})
+
+	It("reports failing tests and keeps running tests", func() {
+		WriteFile(joinPath(packageDir, "failing_test.go"), "package testrunnermodule; import \"testing\"; func TestFails(t *testing.T) { t.Fatal(\"boom\") }")
+		runner = watch.NewTestRunner(watch.SynchronizedWriter(out), nil, 10*time.Millisecond)
+
+		runner.Schedule([]string{packageDir})
+		Eventually(output, "30s").Should(ContainSubstring("Tests in " + packageDir + " failed: exit status 1"))
+
+		runner.Schedule([]string{packageDir})
+		Eventually(func() int { return strings.Count(output(), "failed: exit status 1") }, "30s").Should(Equal(2))
+	})
+
+	It("interrupts running tests when stopped", func() {
+		WriteFile(joinPath(packageDir, "slow_test.go"), "package testrunnermodule; import (\"testing\"; \"time\"); func TestSlow(t *testing.T) { time.Sleep(time.Minute) }")
+		runner = watch.NewTestRunner(watch.SynchronizedWriter(out), nil, 10*time.Millisecond)
+		runner.Schedule([]string{packageDir})
+		Eventually(output, "3s").Should(ContainSubstring("Running tests in"))
+
+		start := time.Now()
+		runner.Stop()
+
+		Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+		Expect(output()).To(ContainSubstring("Interrupted tests in " + packageDir))
+	})
 })
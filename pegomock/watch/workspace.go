@@ -0,0 +1,73 @@
+// Copyright 2016 Peter Goetz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"github.com/petergtz/pegomock/pegomock/util"
+)
+
+// WorkspaceMockFileUpdater updates the interfaces_to_mock files of all modules
+// of a go.work workspace. Each module has its own MockFileUpdater, so mocks are
+// generated in the context of the module they are part of.
+type WorkspaceMockFileUpdater struct {
+	moduleDirs []string
+	updaters   []*MockFileUpdater
+}
+
+// NewWorkspaceMockFileUpdater returns an updater for the interfaces_to_mock
+// files in the directories of the modules the go.work file at goWorkPath uses.
+// With recursive, it also updates the ones in their sub-directories.
+func NewWorkspaceMockFileUpdater(goWorkPath string, recursive bool, buildTags ...string) (*WorkspaceMockFileUpdater, error) {
+	moduleDirs, err := util.WorkspaceModules(goWorkPath)
+	if err != nil {
+		return nil, err
+	}
+	updater := &WorkspaceMockFileUpdater{moduleDirs: moduleDirs}
+	for _, moduleDir := range moduleDirs {
+		updater.updaters = append(updater.updaters, NewMockFileUpdater([]string{moduleDir}, recursive, moduleDir, buildTags...))
+	}
+	return updater, nil
+}
+
+// ModuleDirs returns the directories of the workspace's modules.
+func (updater *WorkspaceMockFileUpdater) ModuleDirs() []string {
+	return updater.moduleDirs
+}
+
+// Update updates the mocks of all modules and combines their summaries.
+func (updater *WorkspaceMockFileUpdater) Update() UpdateSummary {
+	var summary UpdateSummary
+	for _, moduleUpdater := range updater.updaters {
+		summary.add(moduleUpdater.Update())
+	}
+	return summary
+}
+
+func (summary *UpdateSummary) add(other UpdateSummary) {
+	summary.Regenerated = append(summary.Regenerated, other.Regenerated...)
+	summary.Unchanged = append(summary.Unchanged, other.Unchanged...)
+	summary.Failures = append(summary.Failures, other.Failures...)
+	summary.Skipped = append(summary.Skipped, other.Skipped...)
+	summary.Removed = append(summary.Removed, other.Removed...)
+	summary.Mocks = append(summary.Mocks, other.Mocks...)
+	summary.failuresChanged = summary.failuresChanged || other.failuresChanged
+	summary.regeneratedIn = append(summary.regeneratedIn, other.regeneratedIn...)
+	for dir := range other.failedIn {
+		if summary.failedIn == nil {
+			summary.failedIn = make(map[string]bool)
+		}
+		summary.failedIn[dir] = true
+	}
+}
@@ -0,0 +1,41 @@
+// Package registry keeps track of the mocks created during each test, so they
+// can be checked when the test finishes. It doesn't depend on pegomock, so
+// integrations with test frameworks can use it without import cycles.
+package registry
+
+import "sync"
+
+// Registry maps tests to the mocks registered for them. Tests are identified by
+// any comparable value, typically their *testing.T. The zero value is ready to
+// use. A Registry is safe for use by tests running in parallel.
+type Registry struct {
+	mutex sync.Mutex
+	mocks map[interface{}][]interface{}
+}
+
+// Register adds mock to the mocks of test.
+func (registry *Registry) Register(test interface{}, mock interface{}) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if registry.mocks == nil {
+		registry.mocks = make(map[interface{}][]interface{})
+	}
+	registry.mocks[test] = append(registry.mocks[test], mock)
+}
+
+// Mocks returns the mocks registered for test, in the order they were registered.
+func (registry *Registry) Mocks(test interface{}) []interface{} {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	return append([]interface{}(nil), registry.mocks[test]...)
+}
+
+// Release returns the mocks registered for test, like Mocks, and forgets them.
+// Call it when the test finishes.
+func (registry *Registry) Release(test interface{}) []interface{} {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	mocks := registry.mocks[test]
+	delete(registry.mocks, test)
+	return mocks
+}
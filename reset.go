@@ -0,0 +1,73 @@
+package pegomock
+
+import "github.com/petergtz/pegomock/internal/verify"
+
+// ResetOption configures ResetForNextTest.
+type ResetOption struct{ keepStubbings bool }
+
+// KeepStubbings makes ResetForNextTest keep the mock's stubbings, e.g. ones
+// shared by all cases of a table-driven test. Stubbings with several return
+// values start over with the first one.
+var KeepStubbings = ResetOption{keepStubbings: true}
+
+// ResetForNextTest makes the mock behave like a new one for the next case of a
+// table-driven test. Call it at the top of each iteration, typically through
+// the generated mock's ResetForNextTest method.
+//
+// It clears:
+//   - all recorded invocations, including whether they were verified and what
+//     was evicted because of WithInvocationLimit,
+//   - all stubbings, unless KeepStubbings is passed,
+//   - argument matchers and the stubbing call left over on the calling goroutine
+//     by a previous case that failed halfway through When or a verification,
+//   - the InOrderContext activated by BeginInOrder on the calling goroutine, so
+//     in-order verification starts over,
+//   - the mock's start, which VerifyWasCalledWithin measures its timeout from,
+//   - the smart nulls returned so far, see ReturnSmartNullsByDefault.
+//
+// The mock keeps its fail handler and the options it was created with, e.g.
+// WithLogger or WithInvocationLimit, and a spy keeps passing calls through to
+// its delegate. InOrderContexts and Checkpoints created by the test stay
+// usable, because invocations keep being numbered across resets.
+func (genericMock *GenericMock) ResetForNextTest(options ...ResetOption) {
+	keepStubbings := false
+	for _, option := range options {
+		keepStubbings = keepStubbings || option.keepStubbings
+	}
+	genericMock.Lock()
+	for _, method := range genericMock.mockedMethods {
+		method.Lock()
+		method.invocations = nil
+		method.evictedCount = 0
+		method.unrecordedCount = 0
+		method.lastEvicted = nil
+		if keepStubbings {
+			for _, stubbing := range method.stubbings {
+				stubbing.rewind()
+			}
+		} else {
+			method.stubbings = nil
+		}
+		method.Unlock()
+	}
+	genericMock.startedAt = now(genericMock.clock)
+	if genericMock.smartNulls != nil {
+		genericMock.smartNulls.returned = make(map[string]ReturnValues)
+	}
+	genericMock.Unlock()
+
+	clearArgMatchersOfCurrentGoroutine()
+	clearLastInvocationOfCurrentGoroutine()
+	resetImplicitInOrder()
+}
+
+// Reset clears all stubbings and recorded invocations of mocks, so they can be
+// reused by the next subtest of a table-driven test without leaking state from
+// earlier cases. It is ResetForNextTest without options for each of mocks, see
+// there for what else is reset and what is kept.
+func Reset(mocks ...Mock) {
+	for _, mock := range mocks {
+		verify.Argument(mock != nil, "Reset requires mocks, but got nil")
+		GetGenericMockFrom(mock).ResetForNextTest()
+	}
+}
@@ -0,0 +1,130 @@
+package pegomock_test
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+// TestResetForNextTestInTableDrivenTest shows how a mock shared by the cases of
+// a table-driven test is reset at the top of each iteration.
+func TestResetForNextTestInTableDrivenTest(t *testing.T) {
+	display := NewMockDisplay(WithT(t))
+	When(display.SomeValue()).ThenReturn("shared")
+
+	for _, testCase := range []struct {
+		name         string
+		greeting     string
+		expectedShow string
+	}{
+		{name: "hello", greeting: "Hello", expectedShow: "Hello shared"},
+		{name: "bye", greeting: "Bye", expectedShow: "Bye shared"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			display.ResetForNextTest(KeepStubbings)
+			display.SetFailHandler(BuildTestingTFailHandler(t))
+
+			display.Show(testCase.greeting + " " + display.SomeValue())
+
+			display.VerifyWasCalledOnce().Show(testCase.expectedShow)
+			display.VerifyWasCalledOnce().SomeValue()
+		})
+	}
+}
+
+var _ = Describe("ResetForNextTest", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("clears invocations", func() {
+		display.Show("Hello")
+
+		display.ResetForNextTest()
+
+		display.VerifyWasCalled(Never()).Show("Hello")
+		Expect(GetGenericMockFrom(display).GetUnverifiedInvocations()).To(gomega.BeEmpty())
+	})
+
+	It("clears stubbings by default", func() {
+		When(display.SomeValue()).ThenReturn("stubbed")
+
+		display.ResetForNextTest()
+
+		Expect(display.SomeValue()).To(Equal(""))
+	})
+
+	It("keeps stubbings with KeepStubbings, starting their sequences over", func() {
+		When(display.SomeValue()).ThenReturn("first").ThenReturn("second")
+		display.SomeValue()
+
+		display.ResetForNextTest(KeepStubbings)
+
+		Expect(display.SomeValue()).To(Equal("first"))
+		Expect(display.SomeValue()).To(Equal("second"))
+	})
+
+	It("starts sequences stubbed with ThenReturnFor over with all their calls", func() {
+		When(display.SomeValue()).ThenReturnFor(2, "first").ThenReturn("second")
+		display.SomeValue()
+
+		display.ResetForNextTest(KeepStubbings)
+
+		Expect(display.SomeValue()).To(Equal("first"))
+		Expect(display.SomeValue()).To(Equal("first"))
+		Expect(display.SomeValue()).To(Equal("second"))
+	})
+
+	It("clears evicted invocations counted because of WithInvocationLimit", func() {
+		display = NewMockDisplay(WithInvocationLimit(1))
+		display.Show("Hello")
+		display.Show("Hello")
+
+		display.ResetForNextTest()
+		display.Show("Hello")
+
+		display.VerifyWasCalledOnce().Show("Hello")
+	})
+
+	It("discards argument matchers left over by a case that failed halfway", func() {
+		AnyString()
+
+		display.ResetForNextTest()
+
+		display.Show("Hello")
+		display.VerifyWasCalledOnce().Show("Hello")
+	})
+
+	It("keeps the fail handler", func() {
+		var failures []string
+		display = NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) { failures = append(failures, message) }))
+
+		display.ResetForNextTest()
+		display.VerifyWasCalledOnce().Show("Hello")
+
+		Expect(failures).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("Reset", func() {
+	It("clears stubbings and invocations of all given mocks", func() {
+		display, query := NewMockDisplay(), NewMockQuery()
+		When(display.SomeValue()).ThenReturn("stubbed")
+		display.Show("Hello")
+		query.Limit(1)
+
+		Reset(display, query)
+
+		Expect(display.SomeValue()).To(Equal(""))
+		display.VerifyWasCalled(Never()).Show(AnyString())
+		query.VerifyWasCalled(Never()).Limit(AnyInt())
+	})
+
+	It("panics when passed nil", func() {
+		Expect(func() { Reset(nil) }).To(PanicWithMessageTo(Equal("Reset requires mocks, but got nil")))
+	})
+})
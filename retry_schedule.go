@@ -0,0 +1,109 @@
+package pegomock
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// ExpectedSchedule describes the invocations of retry logic with exponential
+// backoff: Attempts invocations in total, where the gap after the n-th attempt
+// is BaseDelay*Multiplier^(n-1), give or take Jitter times that gap.
+type ExpectedSchedule struct {
+	Attempts  int
+	BaseDelay time.Duration
+	// Multiplier of 0 is treated as 1, i.e. a constant delay.
+	Multiplier float64
+	// Jitter is the tolerated deviation as a fraction of each gap, e.g. 0.2 for ±20%.
+	Jitter float64
+}
+
+// VerifyRetrySchedule verifies that methodName of mock was invoked with params
+// matching matchers exactly schedule.Attempts times, and that the gaps between
+// the invocations follow schedule. Without matchers, all invocations of methodName
+// count. Invocation times come from the mock's clock (see WithClock).
+func VerifyRetrySchedule(mock Mock, methodName string, matchers []Matcher, schedule ExpectedSchedule) {
+	verify.Argument(schedule.Attempts > 0, "Attempts must be positive, but is %v", schedule.Attempts)
+	verify.Argument(schedule.Jitter >= 0, "Jitter must not be negative, but is %v", schedule.Jitter)
+
+	genericMock := GetGenericMockFrom(mock)
+	genericMock.Lock()
+	var invocations []MethodInvocation
+	if len(matchers) == 0 {
+		invocations = genericMock.allMethodInvocations(methodName)
+	} else {
+		invocations = genericMock.methodInvocations(methodName, nil, matchers)
+	}
+	genericMock.Unlock()
+
+	expectedGaps := schedule.gaps()
+	actualGaps := make([]time.Duration, 0, len(invocations))
+	for i := 1; i < len(invocations); i++ {
+		actualGaps = append(actualGaps, invocations[i].time.Sub(invocations[i-1].time))
+	}
+	matches := len(invocations) == schedule.Attempts
+	for i := 0; matches && i < len(expectedGaps); i++ {
+		matches = schedule.tolerates(expectedGaps[i], actualGaps[i])
+	}
+	if !matches {
+		genericMock.failHandler()(fmt.Sprintf(
+			"Retry schedule of %v(%v) does not match expectation.\n\n"+
+				"\tExpected %v attempts with base delay %v, multiplier %v and jitter %v, but got %v attempts.\n\n%v",
+			methodName, formatRetryMatchers(matchers), schedule.Attempts, schedule.BaseDelay, schedule.multiplier(), schedule.Jitter,
+			len(invocations), schedule.formatGaps(expectedGaps, actualGaps)),
+			1)
+		return
+	}
+	genericMock.markVerified(methodName, invocations)
+}
+
+func (schedule ExpectedSchedule) multiplier() float64 {
+	if schedule.Multiplier == 0 {
+		return 1
+	}
+	return schedule.Multiplier
+}
+
+func (schedule ExpectedSchedule) gaps() []time.Duration {
+	gaps := make([]time.Duration, schedule.Attempts-1)
+	for i := range gaps {
+		gaps[i] = time.Duration(float64(schedule.BaseDelay) * math.Pow(schedule.multiplier(), float64(i)))
+	}
+	return gaps
+}
+
+func (schedule ExpectedSchedule) tolerates(expected, actual time.Duration) bool {
+	return math.Abs(float64(actual-expected)) <= schedule.Jitter*float64(expected)
+}
+
+func (schedule ExpectedSchedule) formatGaps(expectedGaps, actualGaps []time.Duration) string {
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "GAP\tEXPECTED\tACTUAL")
+	for i := 0; i < len(expectedGaps) || i < len(actualGaps); i++ {
+		expected, actual, mark := "-", "-", "  <-- mismatch"
+		if i < len(expectedGaps) {
+			expected = expectedGaps[i].String()
+		}
+		if i < len(actualGaps) {
+			actual = actualGaps[i].String()
+		}
+		if i < len(expectedGaps) && i < len(actualGaps) && schedule.tolerates(expectedGaps[i], actualGaps[i]) {
+			mark = ""
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v%v\n", i+1, expected, actual, mark)
+	}
+	w.Flush()
+	return "\t" + strings.Replace(strings.TrimSuffix(table.String(), "\n"), "\n", "\n\t", -1) + "\n"
+}
+
+func formatRetryMatchers(matchers []Matcher) string {
+	if len(matchers) == 0 {
+		return "<any>"
+	}
+	return formatMatchers(matchers)
+}
@@ -0,0 +1,79 @@
+package pegomock_test
+
+import (
+	"time"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (clock *fakeClock) Now() time.Time { return clock.now }
+
+func (clock *fakeClock) Advance(d time.Duration) { clock.now = clock.now.Add(d) }
+
+var _ = Describe("VerifyRetrySchedule", func() {
+	var (
+		clock   *fakeClock
+		display *MockDisplay
+		retry   func(gaps ...time.Duration)
+	)
+
+	BeforeEach(func() {
+		clock = &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+		display = NewMockDisplay(WithClock(clock))
+		retry = func(gaps ...time.Duration) {
+			display.Show("fetch")
+			for _, gap := range gaps {
+				clock.Advance(gap)
+				display.Show("fetch")
+			}
+		}
+	})
+
+	It("succeeds when the gaps follow the schedule within the jitter", func() {
+		retry(110*time.Millisecond, 190*time.Millisecond, 400*time.Millisecond)
+		display.Show("other")
+
+		VerifyRetrySchedule(display, "Show", []Matcher{&EqMatcher{Value: "fetch"}},
+			ExpectedSchedule{Attempts: 4, BaseDelay: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.2})
+
+		display.VerifyWasCalledOnce().Show("other")
+		Expect(GetGenericMockFrom(display).GetUnverifiedInvocations()).To(gomega.BeEmpty())
+	})
+
+	It("treats a zero multiplier as a constant delay", func() {
+		retry(time.Second, time.Second)
+
+		VerifyRetrySchedule(display, "Show", nil, ExpectedSchedule{Attempts: 3, BaseDelay: time.Second})
+	})
+
+	It("fails with a table of expected and actual gaps when a gap is out of tolerance", func() {
+		retry(100*time.Millisecond, 300*time.Millisecond, 400*time.Millisecond)
+
+		Expect(InterceptMockFailures(func() {
+			VerifyRetrySchedule(display, "Show", nil,
+				ExpectedSchedule{Attempts: 4, BaseDelay: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.2})
+		})).To(ConsistOf(
+			"Retry schedule of Show(<any>) does not match expectation.\n\n" +
+				"\tExpected 4 attempts with base delay 100ms, multiplier 2 and jitter 0.2, but got 4 attempts.\n\n" +
+				"\tGAP  EXPECTED  ACTUAL\n" +
+				"\t1    100ms     100ms\n" +
+				"\t2    200ms     300ms  <-- mismatch\n" +
+				"\t3    400ms     400ms\n"))
+	})
+
+	It("fails when the number of attempts differs", func() {
+		retry(100 * time.Millisecond)
+
+		Expect(InterceptMockFailures(func() {
+			VerifyRetrySchedule(display, "Show", nil, ExpectedSchedule{Attempts: 3, BaseDelay: 100 * time.Millisecond})
+		})).To(ConsistOf(ContainSubstring("but got 2 attempts")))
+	})
+
+	It("panics on a non-positive number of attempts", func() {
+		Expect(func() { VerifyRetrySchedule(display, "Show", nil, ExpectedSchedule{}) }).To(Panic())
+	})
+})
@@ -0,0 +1,33 @@
+package pegomock
+
+import "reflect"
+
+// ReturnSelfByDefault makes the mock return itself from methods that match no
+// stubbing and whose only result is an interface the mock implements, usually
+// the mocked interface itself. This way, chained calls on builders, e.g.
+// query.Where(...).OrderBy(...).Limit(...), work without stubbing every method.
+// Stubbings take precedence.
+func ReturnSelfByDefault() Option {
+	return OptionFunc(func(mock Mock) {
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		defer genericMock.Unlock()
+		genericMock.returnsSelf = true
+	})
+}
+
+// selfReturnValues returns the mock as the only return value if it is set to
+// ReturnSelfByDefault and can be returned as returnTypes, or nil otherwise.
+func (genericMock *GenericMock) selfReturnValues(returnTypes []reflect.Type) ReturnValues {
+	genericMock.Lock()
+	returnsSelf := genericMock.returnsSelf
+	genericMock.Unlock()
+	if !returnsSelf || genericMock.mock == nil || len(returnTypes) != 1 {
+		return nil
+	}
+	returnType := returnTypes[0]
+	if returnType.Kind() != reflect.Interface || returnType.NumMethod() == 0 || !reflect.TypeOf(genericMock.mock).Implements(returnType) {
+		return nil
+	}
+	return ReturnValues{genericMock.mock}
+}
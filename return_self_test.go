@@ -0,0 +1,49 @@
+package pegomock_test
+
+import (
+	"errors"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/petergtz/pegomock/test_interface"
+)
+
+var _ = Describe("ReturnSelfByDefault", func() {
+	var query *MockQuery
+
+	BeforeEach(func() {
+		query = NewMockQuery(ReturnSelfByDefault())
+	})
+
+	It("makes a chain of unstubbed calls work", func() {
+		var result test_interface.Query = query.Where("age > ?", 18).OrderBy("name").Limit(10).Offset(20).Select("name", "age")
+
+		Expect(result).To(gomega.BeIdenticalTo(query))
+		query.VerifyWasCalledOnce().Where("age > ?", 18)
+		query.VerifyWasCalledOnce().OrderBy("name")
+		query.VerifyWasCalledOnce().Limit(10)
+		query.VerifyWasCalledOnce().Offset(20)
+		query.VerifyWasCalledOnce().Select("name", "age")
+	})
+
+	It("gives stubbings precedence", func() {
+		other := NewMockQuery()
+		When(query.Limit(0)).ThenReturn(other)
+
+		Expect(query.Where("x").Limit(0)).To(gomega.BeIdenticalTo(other))
+		Expect(query.Limit(1)).To(gomega.BeIdenticalTo(query))
+	})
+
+	It("returns zero values from methods not returning the mock's interface", func() {
+		Expect(query.Count()).To(Equal(0))
+		Expect(query.Close()).To(BeNil())
+
+		When(query.Close()).ThenReturn(errors.New("closed"))
+		Expect(query.Close()).To(MatchError("closed"))
+	})
+
+	It("is off by default", func() {
+		Expect(NewMockQuery().Where("x")).To(BeNil())
+	})
+})
@@ -0,0 +1,89 @@
+//go:build go1.21
+// +build go1.21
+
+package pegomock
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// WithLogger makes the mock log every invocation to logger, which helps to debug
+// unexpected calls or stubbings that don't fire. Each log record has the fields
+// mock, method, params (JSON-encoded), matched_stubbing and ordering_number.
+// Invocations made while stubbing, i.e. inside When, are not logged. Records are
+// logged at slog.LevelDebug unless configured otherwise with WithLogLevel.
+// Warnings, e.g. about params matched by FmtFallback, are logged at
+// slog.LevelWarn with the fields mock and method.
+func WithLogger(logger *slog.Logger) Option {
+	return OptionFunc(func(mock Mock) {
+		configureSlogInvocationLogger(mock, func(invocationLogger *slogInvocationLogger) {
+			invocationLogger.logger = logger
+		})
+	})
+}
+
+// WithLogLevel sets the level at which a mock configured with WithLogger logs
+// invocations.
+func WithLogLevel(level slog.Level) Option {
+	return OptionFunc(func(mock Mock) {
+		configureSlogInvocationLogger(mock, func(invocationLogger *slogInvocationLogger) {
+			invocationLogger.level = level
+		})
+	})
+}
+
+func configureSlogInvocationLogger(mock Mock, configure func(*slogInvocationLogger)) {
+	genericMock := GetGenericMockFrom(mock)
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	invocationLogger, ok := genericMock.invocationLogger.(*slogInvocationLogger)
+	if !ok {
+		invocationLogger = &slogInvocationLogger{mockTypeName: genericMock.mockTypeName, level: slog.LevelDebug}
+		genericMock.invocationLogger = invocationLogger
+	}
+	configure(invocationLogger)
+}
+
+type slogInvocationLogger struct {
+	logger       *slog.Logger
+	level        slog.Level
+	mockTypeName string
+}
+
+func (invocationLogger *slogInvocationLogger) logInvocation(methodName string, params []Param, matchedStubbing bool, orderingNumber int) {
+	if invocationLogger.logger == nil {
+		return
+	}
+	invocationLogger.logger.LogAttrs(context.Background(), invocationLogger.level, "Mock invoked",
+		slog.String("mock", invocationLogger.mockTypeName),
+		slog.String("method", methodName),
+		slog.String("params", jsonEncoded(params)),
+		slog.Bool("matched_stubbing", matchedStubbing),
+		slog.Int("ordering_number", orderingNumber),
+	)
+}
+
+func (invocationLogger *slogInvocationLogger) logWarning(methodName string, message string) {
+	if invocationLogger.logger == nil {
+		return
+	}
+	invocationLogger.logger.LogAttrs(context.Background(), slog.LevelWarn, message,
+		slog.String("mock", invocationLogger.mockTypeName),
+		slog.String("method", methodName),
+	)
+}
+
+// jsonEncoded falls back to Go syntax for params that can't be encoded as JSON,
+// such as funcs and channels.
+func jsonEncoded(params []Param) string {
+	if params == nil {
+		params = []Param{}
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "[" + formatParams(params) + "]"
+	}
+	return string(encoded)
+}
@@ -0,0 +1,126 @@
+//go:build go1.21
+// +build go1.21
+
+package pegomock_test
+
+import (
+	"bytes"
+	"log/slog"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("Logging invocations with slog", func() {
+	var (
+		logOutput *bytes.Buffer
+		logger    *slog.Logger
+	)
+
+	BeforeEach(func() {
+		logOutput = &bytes.Buffer{}
+		logger = slog.New(slog.NewTextHandler(logOutput, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if attr.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return attr
+			},
+		}))
+	})
+
+	It("logs every invocation with its params and whether it matched a stubbing", func() {
+		display := NewMockDisplay(WithLogger(logger))
+		When(display.MultipleParamsAndReturnValue(EqString("Hello"), AnyInt())).ThenReturn("stubbed")
+
+		display.MultipleParamsAndReturnValue("Hello", 111)
+		display.Flash("Bye", 222)
+
+		Expect(logOutput.String()).To(MatchRegexp(
+			`^level=DEBUG msg="Mock invoked" mock=MockDisplay method=MultipleParamsAndReturnValue params="\[\\"Hello\\",111\]" matched_stubbing=true ordering_number=\d+\n` +
+				`level=DEBUG msg="Mock invoked" mock=MockDisplay method=Flash params="\[\\"Bye\\",222\]" matched_stubbing=false ordering_number=\d+\n$`))
+	})
+
+	It("logs at the level set with WithLogLevel", func() {
+		display := NewMockDisplay(WithLogLevel(slog.LevelInfo), WithLogger(logger))
+
+		display.Show("Hello")
+
+		Expect(logOutput.String()).To(HavePrefix(`level=INFO msg="Mock invoked" mock=MockDisplay method=Show params="[\"Hello\"]"`))
+	})
+
+	It("falls back to Go syntax for params that can't be encoded as JSON", func() {
+		display := NewMockDisplay(WithLogger(logger))
+
+		display.FuncParam(nil)
+
+		Expect(logOutput.String()).To(ContainSubstring(`method=FuncParam params="[(func(string) error)(nil)]"`))
+	})
+
+	It("doesn't log anything without WithLogger", func() {
+		display := NewMockDisplay(WithLogLevel(slog.LevelInfo))
+
+		display.Show("Hello")
+
+		Expect(logOutput.String()).To(gomega.BeEmpty())
+	})
+
+	Context("in FmtFallback mode", func() {
+		withFunc := struct{ F func() }{F: func() {}}
+
+		BeforeEach(func() {
+			SetDeepEqualMode(StrictDeepEqual | RecoverOnPanic | FmtFallback)
+		})
+
+		AfterEach(func() {
+			SetDeepEqualMode(DefaultDeepEqualMode)
+		})
+
+		It("warns once per verification about params that only match because they format the same", func() {
+			display := NewMockDisplay(WithLogger(logger))
+			display.InterfaceParam(withFunc)
+			display.InterfaceParam(withFunc)
+			logOutput.Reset()
+
+			display.VerifyWasCalled(Times(2)).InterfaceParam(withFunc)
+
+			Expect(logOutput.String()).To(MatchRegexp(
+				`^level=WARN msg="pegomock matched {0x[0-9a-f]+} and {0x[0-9a-f]+} of type struct { F func\(\) }, which are not deeply equal, because they format the same" mock=MockDisplay method=InterfaceParam\n$`))
+		})
+
+		It("warns about invocations that only match a stubbing because their params format the same", func() {
+			display := NewMockDisplay(WithLogger(logger))
+			answered := false
+			When(func() { display.InterfaceParam(EqInterface(withFunc)) }).ThenAnswer(func(interface{}) { answered = true })
+			logOutput.Reset()
+
+			display.InterfaceParam(withFunc)
+
+			Expect(answered).To(BeTrue())
+			Expect(logOutput.String()).To(ContainSubstring(`level=WARN msg="pegomock matched`))
+		})
+
+		It("doesn't warn about params that are deeply equal", func() {
+			display := NewMockDisplay(WithLogger(logger))
+			display.InterfaceParam("Hello")
+			logOutput.Reset()
+
+			display.VerifyWasCalledOnce().InterfaceParam("Hello")
+
+			Expect(logOutput.String()).To(gomega.BeEmpty())
+		})
+	})
+
+	It("keeps fail handlers set with options after WithLogger", func() {
+		var failures []string
+		display := NewMockDisplay(WithLogger(logger), WithFailHandler(func(message string, callerSkip ...int) {
+			failures = append(failures, message)
+		}))
+
+		display.VerifyWasCalledOnce().Show("Hello")
+
+		Expect(failures).To(HaveLen(1))
+	})
+})
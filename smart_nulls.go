@@ -0,0 +1,139 @@
+package pegomock
+
+import (
+	"reflect"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+var (
+	optionsType       = reflect.TypeOf([]Option(nil))
+	mockInterfaceType = reflect.TypeOf((*Mock)(nil)).Elem()
+)
+
+// smartNulls holds what ReturnSmartNullsByDefault configured.
+type smartNulls struct {
+	mockConstructors []reflect.Value
+	// returned holds the smart nulls returned so far by the calls they were
+	// returned from, so the same call returns the same smart nulls.
+	returned map[string]ReturnValues
+}
+
+// ReturnSmartNullsByDefault makes the mock return smart nulls instead of nil
+// from methods that match no stubbing: a result of an interface type that one
+// of mockConstructors, e.g. NewMockQuery, creates a mock for is a new mock created
+// with it. This way, chained calls on unstubbed methods don't panic with a nil
+// pointer dereference. Failure messages refer to smart nulls by the call that
+// returned them, e.g. "storage.Repository.Query(\"x\")", which tells which call
+// wasn't stubbed. Calling the same method with the same params again returns
+// the same smart nulls. Smart nulls get the mock's fail handler and return smart
+// nulls themselves. Results of type error or interface{} stay nil. Stubbings,
+// and ReturnSelfByDefault, take precedence.
+//
+// mockConstructors must be functions like the New... functions generated for
+// mocks, i.e. take options only and return a mock; it panics otherwise.
+func ReturnSmartNullsByDefault(mockConstructors ...interface{}) Option {
+	constructors := make([]reflect.Value, len(mockConstructors))
+	for i, mockConstructor := range mockConstructors {
+		constructor := reflect.ValueOf(mockConstructor)
+		verify.Argument(isMockConstructor(constructor),
+			"ReturnSmartNullsByDefault requires mock constructors like NewMockDisplay, i.e. func(...pegomock.Option) <mock>, but got %T", mockConstructor)
+		constructors[i] = constructor
+	}
+	return OptionFunc(func(mock Mock) {
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		defer genericMock.Unlock()
+		genericMock.smartNulls = &smartNulls{mockConstructors: constructors, returned: make(map[string]ReturnValues)}
+	})
+}
+
+func isMockConstructor(constructor reflect.Value) bool {
+	if constructor.Kind() != reflect.Func || constructor.IsNil() {
+		return false
+	}
+	constructorType := constructor.Type()
+	if constructorType.NumOut() != 1 || !constructorType.Out(0).Implements(mockInterfaceType) {
+		return false
+	}
+	return constructorType.NumIn() == 0 ||
+		(constructorType.NumIn() == 1 && constructorType.IsVariadic() && constructorType.In(0) == optionsType)
+}
+
+// smartNullReturnValues returns smart nulls for the results of methodName that
+// the mock's constructors can create mocks for, and nil for its other results,
+// if the mock is set to ReturnSmartNullsByDefault. It returns nil if there are
+// no smart nulls to return.
+func (genericMock *GenericMock) smartNullReturnValues(methodName string, params []Param, returnTypes []reflect.Type) ReturnValues {
+	genericMock.Lock()
+	smartNulls := genericMock.smartNulls
+	genericMock.Unlock()
+	if smartNulls == nil {
+		return nil
+	}
+	call := genericMock.qualified(methodName) + "(" + formatParams(params) + ")"
+	genericMock.Lock()
+	returnValues, returnedBefore := smartNulls.returned[call]
+	genericMock.Unlock()
+	if returnedBefore {
+		return returnValues
+	}
+	returnValues = make(ReturnValues, len(returnTypes))
+	anySmartNull := false
+	for i, returnType := range returnTypes {
+		if returnType.Kind() != reflect.Interface || returnType.NumMethod() == 0 || returnType == errorType {
+			continue
+		}
+		for _, constructor := range smartNulls.mockConstructors {
+			if constructor.Type().Out(0).Implements(returnType) {
+				returnValues[i] = genericMock.newSmartNull(constructor, call)
+				anySmartNull = true
+				break
+			}
+		}
+	}
+	if !anySmartNull {
+		return nil
+	}
+	genericMock.Lock()
+	smartNulls.returned[call] = returnValues
+	genericMock.Unlock()
+	return returnValues
+}
+
+// newSmartNull creates a mock with constructor that is labeled with call and
+// behaves like genericMock's mock.
+func (genericMock *GenericMock) newSmartNull(constructor reflect.Value, call string) Mock {
+	genericMock.Lock()
+	mockConstructors := genericMock.smartNulls.mockConstructors
+	genericMock.Unlock()
+	options := []reflect.Value{}
+	if constructor.Type().NumIn() == 1 {
+		constructorValues := make([]interface{}, len(mockConstructors))
+		for i, mockConstructor := range mockConstructors {
+			constructorValues[i] = mockConstructor.Interface()
+		}
+		options = append(options, reflect.ValueOf(ReturnSmartNullsByDefault(constructorValues...)))
+		if fail := genericMock.failHandlerOrNil(); fail != nil {
+			options = append(options, reflect.ValueOf(WithFailHandler(fail)))
+		}
+	}
+	smartNull := constructor.Call(options)[0].Interface().(Mock)
+	smartNullGenericMock := GetGenericMockFrom(smartNull)
+	smartNullGenericMock.Lock()
+	smartNullGenericMock.label = call
+	smartNullGenericMock.Unlock()
+	return smartNull
+}
+
+// failHandlerOrNil is like failHandler, but returns nil instead of panicking if
+// the mock has no fail handler.
+func (genericMock *GenericMock) failHandlerOrNil() FailHandler {
+	if genericMock.fail != nil {
+		return genericMock.fail
+	}
+	if genericMock.mock != nil {
+		return genericMock.mock.FailHandler()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package pegomock_test
+
+import (
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("ReturnSmartNullsByDefault", func() {
+	var query *MockQuery
+
+	BeforeEach(func() {
+		query = NewMockQuery(ReturnSmartNullsByDefault(NewMockQuery))
+	})
+
+	It("returns mocks from unstubbed methods, so chained calls work", func() {
+		result := query.Where("x").OrderBy("name").Limit(10)
+
+		Expect(result).NotTo(BeNil())
+		Expect(result.Count()).To(Equal(0))
+		Expect(result.Close()).To(BeNil())
+	})
+
+	It("returns the same smart null for the same call", func() {
+		Expect(query.Where("x")).To(gomega.BeIdenticalTo(query.Where("x")))
+		Expect(query.Where("x")).NotTo(gomega.BeIdenticalTo(query.Where("y")))
+	})
+
+	It("names the unstubbed call that returned the smart null in failure messages", func() {
+		query.Where("x").OrderBy("name")
+
+		smartNull := query.Where("x").(*MockQuery)
+		Expect(func() { smartNull.VerifyWasCalledOnce().Limit(10) }).To(PanicWithMessageTo(HavePrefix(
+			"Mock invocation count for test_interface.Query.Where(\"x\").Limit(10) does not match expectation.")))
+		smartNull.VerifyWasCalledOnce().OrderBy("name")
+	})
+
+	It("lets stubbings take precedence", func() {
+		other := NewMockQuery()
+		When(query.Where("x")).ThenReturn(other)
+
+		Expect(query.Where("x")).To(gomega.BeIdenticalTo(other))
+		Expect(query.Where("y")).NotTo(gomega.BeIdenticalTo(other))
+	})
+
+	It("returns new smart nulls after ResetForNextTest", func() {
+		smartNull := query.Where("x")
+		query.ResetForNextTest()
+
+		Expect(query.Where("x")).NotTo(gomega.BeIdenticalTo(smartNull))
+	})
+
+	It("returns nil for interfaces without constructor", func() {
+		Expect(NewMockQuery(ReturnSmartNullsByDefault(NewMockDisplay)).Where("x")).To(BeNil())
+	})
+
+	It("returns nil for the empty interface, which every mock satisfies", func() {
+		Expect(NewMockDisplay(ReturnSmartNullsByDefault(NewMockQuery)).InterfaceReturnValue()).To(BeNil())
+	})
+
+	It("panics on functions that aren't mock constructors", func() {
+		Expect(func() { ReturnSmartNullsByDefault(func() {}) }).To(PanicWith(
+			"ReturnSmartNullsByDefault requires mock constructors like NewMockDisplay, i.e. func(...pegomock.Option) <mock>, but got func()"))
+	})
+})
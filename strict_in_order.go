@@ -0,0 +1,96 @@
+package pegomock
+
+import (
+	"sort"
+)
+
+// NewStrictInOrderContext returns an InOrderContext that, on top of the order,
+// verifies that no other interactions with mocks were interleaved: between the
+// invocations a verification matched and those of the verification before it,
+// mocks must not have been invoked otherwise. mocks are the mocks whose
+// interactions count. Mocks verified with the context count, too, so usually
+// it's only necessary to pass mocks whose interactions must not happen at all
+// in between:
+//
+//	inOrderContext := NewStrictInOrderContext(logger)
+//	db.VerifyWasCalledInOrder(Once(), inOrderContext).Begin()
+//	db.VerifyWasCalledInOrder(Once(), inOrderContext).Commit()
+//
+// fails if anything was invoked on db or logger between Begin and Commit.
+// Interactions before the first verified invocation don't count.
+func NewStrictInOrderContext(mocks ...Mock) *InOrderContext {
+	inOrderContext := &InOrderContext{strict: true}
+	for _, mock := range mocks {
+		inOrderContext.addMock(GetGenericMockFrom(mock))
+	}
+	return inOrderContext
+}
+
+func (inOrderContext *InOrderContext) addMock(genericMock *GenericMock) {
+	for _, mock := range inOrderContext.mocks {
+		if mock == genericMock {
+			return
+		}
+	}
+	// Copy on write, since verify restores earlier copies of the context.
+	inOrderContext.mocks = append(append([]*GenericMock(nil), inOrderContext.mocks...), genericMock)
+}
+
+// interleavedInvocations formats the invocations of the context's mocks and
+// genericMock that happened after the context's last verified invocation, or
+// after first if there is none, and before last, except for verified ones.
+// genericMock must not be locked.
+func (inOrderContext *InOrderContext) interleavedInvocations(genericMock *GenericMock, first, last int, verified []MethodInvocation) string {
+	inOrderContext.addMock(genericMock)
+	from := inOrderContext.invocationCounter
+	if from == 0 {
+		from = first
+	}
+	verifiedNumbers := make(map[int]bool, len(verified))
+	for _, invocation := range verified {
+		verifiedNumbers[invocation.orderingInvocationNumber] = true
+	}
+	type interleaved struct {
+		number      int
+		description string
+	}
+	var interleavedInvocations []interleaved
+	for _, mock := range inOrderContext.mocks {
+		mock.Lock()
+		for methodName, method := range mock.mockedMethods {
+			method.Lock()
+			for _, invocation := range method.invocations {
+				number := invocation.orderingInvocationNumber
+				if number > from && number < last && !verifiedNumbers[number] {
+					interleavedInvocations = append(interleavedInvocations, interleaved{
+						number:      number,
+						description: mock.qualified(methodName) + "(" + formatParams(invocation.params) + ")",
+					})
+				}
+			}
+			method.Unlock()
+		}
+		mock.Unlock()
+	}
+	sort.Slice(interleavedInvocations, func(i, j int) bool {
+		return interleavedInvocations[i].number < interleavedInvocations[j].number
+	})
+	result := ""
+	for _, invocation := range interleavedInvocations {
+		result += "\t" + invocation.description + "\n"
+	}
+	return result
+}
+
+func orderingNumberRange(invocations []MethodInvocation) (first, last int) {
+	first, last = invocations[0].orderingInvocationNumber, invocations[0].orderingInvocationNumber
+	for _, invocation := range invocations[1:] {
+		if invocation.orderingInvocationNumber < first {
+			first = invocation.orderingInvocationNumber
+		}
+		if invocation.orderingInvocationNumber > last {
+			last = invocation.orderingInvocationNumber
+		}
+	}
+	return
+}
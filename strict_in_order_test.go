@@ -0,0 +1,74 @@
+package pegomock_test
+
+import (
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("Strict in-order verification", func() {
+	var display1, display2 *MockDisplay
+
+	BeforeEach(func() {
+		display1 = NewMockDisplay()
+		display2 = NewMockDisplay()
+	})
+
+	It("succeeds when no other interactions were interleaved, across mocks", func() {
+		display1.Show("Before")
+		display1.Show("One")
+		display2.Show("Two")
+		display1.Show("Three")
+
+		inOrderContext := NewStrictInOrderContext()
+		display1.VerifyWasCalledInOrder(Once(), inOrderContext).Show("One")
+		display2.VerifyWasCalledInOrder(Once(), inOrderContext).Show("Two")
+
+		Expect(func() { display1.VerifyWasCalledInOrder(Once(), inOrderContext).Show("Three") }).NotTo(Panic())
+	})
+
+	It("fails listing the interactions interleaved with verified calls", func() {
+		display1.Show("One")
+		display2.Show("Two")
+		display1.Flash("Flash", 1)
+		display1.Show("Three")
+
+		inOrderContext := NewStrictInOrderContext(display2)
+		display1.VerifyWasCalledInOrder(Once(), inOrderContext).Show("One")
+
+		Expect(func() { display1.VerifyWasCalledInOrder(Once(), inOrderContext).Show("Three") }).To(PanicWith(
+			"Expected function call Show(\"Three\") to directly follow function call Show(\"One\"), but there were other interactions in between:\n" +
+				"\ttest_interface.Display.Show(\"Two\")\n" +
+				"\ttest_interface.Display.Flash(\"Flash\", 1)\n"))
+	})
+
+	It("fails when other interactions were interleaved with the invocations of a single verification", func() {
+		display1.Show("Hello")
+		display1.Flash("Flash", 1)
+		display1.Show("Hello")
+
+		Expect(func() { display1.VerifyWasCalledInOrder(Twice(), NewStrictInOrderContext()).Show("Hello") }).To(PanicWith(
+			"Expected the invocations of Show(\"Hello\") to directly follow each other, but there were other interactions in between:\n" +
+				"\ttest_interface.Display.Flash(\"Flash\", 1)\n"))
+	})
+
+	It("ignores interactions with mocks it doesn't know", func() {
+		display1.Show("One")
+		display2.Show("Two")
+		display1.Show("Three")
+
+		inOrderContext := NewStrictInOrderContext()
+		display1.VerifyWasCalledInOrder(Once(), inOrderContext).Show("One")
+
+		Expect(func() { display1.VerifyWasCalledInOrder(Once(), inOrderContext).Show("Three") }).NotTo(Panic())
+	})
+
+	It("still fails when the order is wrong", func() {
+		display1.Show("One")
+		display2.Show("Two")
+
+		inOrderContext := NewStrictInOrderContext()
+		display2.VerifyWasCalledInOrder(Once(), inOrderContext).Show("Two")
+
+		Expect(func() { display1.VerifyWasCalledInOrder(Once(), inOrderContext).Show("One") }).To(PanicWithMessageTo(HavePrefix(
+			"Expected function call Show(\"One\") before function call Show(\"Two\")")))
+	})
+})
@@ -0,0 +1,96 @@
+package pegomock
+
+import (
+	"strings"
+	"sync"
+)
+
+type stubbedMocksUsedT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// stubbedMocksTracker remembers the mocks stubbed on a test's goroutine since
+// RequireStubbedMocksUsed, and where they were stubbed.
+type stubbedMocksTracker struct {
+	t stubbedMocksUsedT
+	// mocks is in the order the mocks were first stubbed in.
+	mocks     []*GenericMock
+	locations map[*GenericMock][]string
+}
+
+// Like implicit ordering contexts, trackers are kept per goroutine, so that each
+// test gets its own.
+var (
+	stubbedMocksTrackers      = make(map[int64]*stubbedMocksTracker)
+	stubbedMocksTrackersMutex sync.Mutex
+)
+
+// RequireStubbedMocksUsed makes the calling test fail when it finishes, if a mock
+// it stubbed with When was never called during the test. This catches stubbing
+// the wrong mock, e.g. after copy-pasting When(mockA.Get(1)) while meaning
+// mockB, which otherwise makes tests pass vacuously. The failure lists where
+// the unused mock was stubbed. Only stubbings on the test's goroutine are tracked.
+func RequireStubbedMocksUsed(t stubbedMocksUsedT) {
+	t.Helper()
+	goroutineID := currentGoroutineID()
+	stubbedMocksTrackersMutex.Lock()
+	if _, exists := stubbedMocksTrackers[goroutineID]; exists {
+		stubbedMocksTrackersMutex.Unlock()
+		t.Errorf("RequireStubbedMocksUsed called twice in the same test.")
+		return
+	}
+	stubbedMocksTrackers[goroutineID] = &stubbedMocksTracker{t: t, locations: make(map[*GenericMock][]string)}
+	stubbedMocksTrackersMutex.Unlock()
+
+	t.Cleanup(func() { checkStubbedMocksUsed(goroutineID) })
+}
+
+// trackStubbing is called by When for every stubbing. location is only looked
+// up if a test on the current goroutine called RequireStubbedMocksUsed, since
+// looking up the caller is slow.
+func trackStubbing(genericMock *GenericMock, location func() string) {
+	stubbedMocksTrackersMutex.Lock()
+	defer stubbedMocksTrackersMutex.Unlock()
+	if len(stubbedMocksTrackers) == 0 {
+		return
+	}
+	tracker, exists := stubbedMocksTrackers[currentGoroutineID()]
+	if !exists {
+		return
+	}
+	if _, stubbedBefore := tracker.locations[genericMock]; !stubbedBefore {
+		tracker.mocks = append(tracker.mocks, genericMock)
+	}
+	tracker.locations[genericMock] = append(tracker.locations[genericMock], location())
+}
+
+func checkStubbedMocksUsed(goroutineID int64) {
+	stubbedMocksTrackersMutex.Lock()
+	tracker := stubbedMocksTrackers[goroutineID]
+	delete(stubbedMocksTrackers, goroutineID)
+	stubbedMocksTrackersMutex.Unlock()
+
+	tracker.t.Helper()
+	for _, genericMock := range tracker.mocks {
+		if genericMock.invocationCount() > 0 {
+			continue
+		}
+		tracker.t.Errorf("%v was stubbed, but never called. Maybe a different mock was meant to be stubbed?\n\tStubbed at:\n\t\t%v",
+			genericMock.mockTypeName, strings.Join(tracker.locations[genericMock], "\n\t\t"))
+	}
+}
+
+// invocationCount counts all invocations of all methods of the mock, including
+// the ones evicted due to WithInvocationLimit or not recorded due to DisableRecording.
+func (genericMock *GenericMock) invocationCount() (count int) {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	for _, method := range genericMock.mockedMethods {
+		method.Lock()
+		count += len(method.invocations) + method.evictedCount + method.unrecordedCount
+		method.Unlock()
+	}
+	return
+}
@@ -0,0 +1,69 @@
+package pegomock_test
+
+import (
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("RequireStubbedMocksUsed", func() {
+	var (
+		t              *fakeInOrderT
+		display, other *MockDisplay
+	)
+
+	BeforeEach(func() {
+		t = &fakeInOrderT{}
+		display = NewMockDisplay()
+		other = NewMockDisplay()
+	})
+
+	It("succeeds when all stubbed mocks were called", func() {
+		RequireStubbedMocksUsed(t)
+		When(display.SomeValue()).ThenReturn("Hello")
+		display.SomeValue()
+		t.runCleanups()
+
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("fails listing the stubbing call sites when a stubbed mock was never called", func() {
+		RequireStubbedMocksUsed(t)
+		When(display.SomeValue()).ThenReturn("Hello")
+		When(display.MultipleParamsAndReturnValue("one", 1)).ThenReturn("two")
+		other.SomeValue()
+		t.runCleanups()
+
+		Expect(t.errors).To(ConsistOf(gomega.And(
+			HavePrefix("MockDisplay was stubbed, but never called."),
+			MatchRegexp(`Stubbed at:\n\t\t.*stubbed_mocks_used_test.go:\d+\n\t\t.*stubbed_mocks_used_test.go:\d+$`))))
+	})
+
+	It("does not count invocations made while stubbing", func() {
+		RequireStubbedMocksUsed(t)
+		When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenReturn("two")
+		t.runCleanups()
+
+		Expect(t.errors).To(HaveLen(1))
+	})
+
+	It("ignores stubbings made before it was called", func() {
+		When(display.SomeValue()).ThenReturn("Hello")
+		RequireStubbedMocksUsed(t)
+		t.runCleanups()
+
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("fails when called twice", func() {
+		RequireStubbedMocksUsed(t)
+		RequireStubbedMocksUsed(t)
+		t.runCleanups()
+
+		Expect(t.errors).To(ConsistOf("RequireStubbedMocksUsed called twice in the same test."))
+	})
+
+	It("tells the type of the stubbed mock from the ongoing stubbing", func() {
+		Expect(When(display.SomeValue()).MockTypeName()).To(Equal("MockDisplay"))
+	})
+})
@@ -0,0 +1,116 @@
+package pegomock
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// Stubber stubs a call on a mock with answers given up front, like Mockito's
+// doReturn(...).when(...), e.g.
+//
+//	DoCallRealMethod().When(func() { spy.MultipleParamsAndReturnValue(EqString("real"), AnyInt()) })
+//
+// Unlike a call inside When, the call inside Stubber.When neither passes through
+// to the real method of a spy nor runs the answers stubbed before. Several
+// answers are returned on consecutive calls, like with When(...).ThenReturn(...).ThenReturn(...).
+type Stubber struct {
+	answers []func(stubbing *ongoingStubbing)
+}
+
+// DoReturn returns a Stubber that stubs the call to return values.
+func DoReturn(values ...ReturnValue) *Stubber { return new(Stubber).DoReturn(values...) }
+
+// DoPanic returns a Stubber that stubs the call to panic with v.
+func DoPanic(v interface{}) *Stubber { return new(Stubber).DoPanic(v) }
+
+// DoAnswer returns a Stubber that stubs the call to return what callback
+// returns for the call's params.
+func DoAnswer(callback func([]Param) ReturnValues) *Stubber { return new(Stubber).DoAnswer(callback) }
+
+// DoCallRealMethod returns a Stubber that stubs the call to pass through to the
+// delegate of a spy.
+func DoCallRealMethod() *Stubber { return new(Stubber).DoCallRealMethod() }
+
+// DoNothing returns a Stubber that stubs the call to return zero values.
+func DoNothing() *Stubber { return new(Stubber).DoNothing() }
+
+// DoReturn adds an answer that returns values.
+func (stubber *Stubber) DoReturn(values ...ReturnValue) *Stubber {
+	return stubber.withAnswer(func(stubbing *ongoingStubbing) { stubbing.ThenReturn(values...) })
+}
+
+// DoPanic adds an answer that panics with v.
+func (stubber *Stubber) DoPanic(v interface{}) *Stubber {
+	return stubber.withAnswer(func(stubbing *ongoingStubbing) { stubbing.ThenPanic(v) })
+}
+
+// DoAnswer adds an answer that returns what callback returns for the call's params.
+func (stubber *Stubber) DoAnswer(callback func([]Param) ReturnValues) *Stubber {
+	verify.Argument(callback != nil, "DoAnswer requires a callback, but got nil")
+	return stubber.withAnswer(func(stubbing *ongoingStubbing) { stubbing.Then(callback) })
+}
+
+// DoCallRealMethod adds an answer that passes the call through to the delegate
+// of a spy. Stubber.When panics if the mock is no spy.
+func (stubber *Stubber) DoCallRealMethod() *Stubber {
+	return stubber.withAnswer(func(stubbing *ongoingStubbing) { stubbing.ThenCallRealMethod() })
+}
+
+// DoNothing adds an answer that returns zero values.
+func (stubber *Stubber) DoNothing() *Stubber {
+	return stubber.withAnswer(func(stubbing *ongoingStubbing) { stubbing.DoNothing() })
+}
+
+func (stubber *Stubber) withAnswer(answer func(stubbing *ongoingStubbing)) *Stubber {
+	stubber.answers = append(stubber.answers, answer)
+	return stubber
+}
+
+// When calls call, which must make the call on a mock to stub, and stubs that
+// call with the stubber's answers. The returned stubbing can add further
+// answers, e.g. DoCallRealMethod().When(...).ThenReturn("fake").
+func (stubber *Stubber) When(call func()) *ongoingStubbing {
+	verify.Argument(call != nil, "Stubber.When() requires a function that makes a call on a mock, but got nil.")
+	verify.Argument(len(stubber.answers) > 0, "Stubber.When() requires a stubber with answers, e.g. from DoReturn(...)")
+	panicOnArgMatchersRegisteredBefore()
+	setStubberCallOfCurrentGoroutine()
+	defer clearStubberCallOfCurrentGoroutine()
+	stubbing := when([]interface{}{call})
+	for _, answer := range stubber.answers {
+		answer(stubbing)
+	}
+	return stubbing
+}
+
+// Goroutines that are running the call inside Stubber.When. activeStubberCalls
+// counts them, so invocations can skip looking up their goroutine otherwise.
+var (
+	stubberCalls       = make(map[int64]bool)
+	stubberCallsMutex  sync.Mutex
+	activeStubberCalls int32
+)
+
+func setStubberCallOfCurrentGoroutine() {
+	stubberCallsMutex.Lock()
+	defer stubberCallsMutex.Unlock()
+	stubberCalls[currentGoroutineID()] = true
+	atomic.AddInt32(&activeStubberCalls, 1)
+}
+
+func clearStubberCallOfCurrentGoroutine() {
+	stubberCallsMutex.Lock()
+	defer stubberCallsMutex.Unlock()
+	delete(stubberCalls, currentGoroutineID())
+	atomic.AddInt32(&activeStubberCalls, -1)
+}
+
+func isStubberCallOfCurrentGoroutine() bool {
+	if atomic.LoadInt32(&activeStubberCalls) == 0 {
+		return false
+	}
+	stubberCallsMutex.Lock()
+	defer stubberCallsMutex.Unlock()
+	return stubberCalls[currentGoroutineID()]
+}
@@ -0,0 +1,76 @@
+package pegomock_test
+
+import (
+	"errors"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("Stubber", func() {
+	var delegate, spy *MockDisplay
+
+	BeforeEach(func() {
+		delegate = NewMockDisplay()
+		spy = NewSpyDisplay(delegate)
+		When(delegate.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenReturn("real")
+	})
+
+	It("calls the real method for some params and returns stubbed values for others", func() {
+		DoReturn("fake").When(func() { spy.MultipleParamsAndReturnValue(AnyString(), AnyInt()) })
+		DoCallRealMethod().When(func() { spy.MultipleParamsAndReturnValue(EqString("real"), AnyInt()) })
+
+		Expect(spy.MultipleParamsAndReturnValue("real", 1)).To(Equal("real"))
+		Expect(spy.MultipleParamsAndReturnValue("other", 1)).To(Equal("fake"))
+		delegate.VerifyWasCalledOnce().MultipleParamsAndReturnValue(AnyString(), AnyInt())
+	})
+
+	It("doesn't pass the call it stubs through to the real method", func() {
+		DoReturn("fake").When(func() { spy.MultipleParamsAndReturnValue("Hello", 1) })
+
+		delegate.VerifyWasCalled(Never()).MultipleParamsAndReturnValue(AnyString(), AnyInt())
+		spy.VerifyWasCalled(Never()).MultipleParamsAndReturnValue(AnyString(), AnyInt())
+		Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("fake"))
+	})
+
+	It("doesn't run answers stubbed before", func() {
+		display := NewMockDisplay()
+		DoPanic("boom").When(func() { display.Show("Hello") })
+
+		DoNothing().When(func() { display.Show("Hello") })
+
+		Expect(func() { display.Show("Hello") }).NotTo(Panic())
+	})
+
+	It("returns consecutive answers on consecutive calls", func() {
+		display := NewMockDisplay()
+		DoReturn(nil).DoReturn(errors.New("second")).DoPanic("third").When(func() { display.ErrorReturnValue() })
+
+		Expect(display.ErrorReturnValue()).To(BeNil())
+		Expect(display.ErrorReturnValue()).To(MatchError("second"))
+		Expect(func() { display.ErrorReturnValue() }).To(PanicWith("third"))
+	})
+
+	It("answers with callbacks and lets the stubbing continue", func() {
+		DoAnswer(func(params []Param) ReturnValues {
+			return ReturnValues{params[0].(string) + "!"}
+		}).When(func() { spy.MultipleParamsAndReturnValue(AnyString(), AnyInt()) }).ThenCallRealMethod().ThenReturn("fake")
+
+		Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("Hello!"))
+		Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("real"))
+		Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("fake"))
+	})
+
+	It("panics on misuse", func() {
+		Expect(func() { DoCallRealMethod().When(func() { delegate.SomeValue() }) }).To(PanicWithMessageTo(HavePrefix(
+			"ThenCallRealMethod requires a spy, i.e. a mock created with NewSpy..., but MockDisplay is no spy")))
+		Expect(func() { DoReturn("fake").When(nil) }).To(PanicWith(
+			"Stubber.When() requires a function that makes a call on a mock, but got nil."))
+		Expect(func() { new(Stubber).When(func() { spy.SomeValue() }) }).To(PanicWith(
+			"Stubber.When() requires a stubber with answers, e.g. from DoReturn(...)"))
+
+		AnyString()
+		Expect(func() { DoReturn("fake").When(func() { spy.MultipleParamsAndReturnValue(AnyString(), AnyInt()) }) }).To(
+			PanicWithMessageTo(HavePrefix("Invalid use of matchers!")))
+		Expect(spy.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("real"))
+	})
+})
@@ -0,0 +1,178 @@
+package pegomock
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// StubbingConfig describes a stubbing of a mock's method, e.g. loaded from a
+// YAML or JSON file:
+//
+//	method: MultipleParamsAndReturnValue
+//	params: [{matcher: AnyString}, {matcher: GreaterThanInt, value: 5}]
+//	returns: [big]
+//
+// See ApplyStubbingConfigs.
+type StubbingConfig struct {
+	Method  string        `json:"method" yaml:"method"`
+	Params  []ParamConfig `json:"params,omitempty" yaml:"params,omitempty"`
+	Returns []interface{} `json:"returns,omitempty" yaml:"returns,omitempty"`
+}
+
+// ParamConfig describes how a StubbingConfig matches a param: with the matcher
+// registered as Matcher, see RegisterNamedMatcher, created with Args, or with
+// Value as its only arg. Without Matcher, the param must equal Value.
+type ParamConfig struct {
+	Matcher string        `json:"matcher,omitempty" yaml:"matcher,omitempty"`
+	Value   interface{}   `json:"value,omitempty" yaml:"value,omitempty"`
+	Args    []interface{} `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
+// ApplyStubbingConfigs stubs the methods of mock as configs describe, in order,
+// like When(...).ThenReturn(...) would. Values from configuration files are
+// converted to the types of the method's params and results where possible,
+// e.g. numbers decoded as float64 to int, and strings to errors. Nothing is
+// stubbed if any of configs is invalid.
+func ApplyStubbingConfigs(mock Mock, configs ...StubbingConfig) error {
+	type stubbing struct {
+		methodName   string
+		matchers     []Matcher
+		returnValues ReturnValues
+	}
+	stubbings := make([]stubbing, len(configs))
+	for i, config := range configs {
+		matchers, returnValues, e := resolveStubbingConfig(mock, config)
+		if e != nil {
+			return fmt.Errorf("Invalid stubbing config %v for %v: %v", i, config.Method, e)
+		}
+		stubbings[i] = stubbing{config.Method, matchers, returnValues}
+	}
+	genericMock := GetGenericMockFrom(mock)
+	for _, stubbing := range stubbings {
+		genericMock.stub(stubbing.methodName, stubbing.matchers, stubbing.returnValues)
+	}
+	return nil
+}
+
+func resolveStubbingConfig(mock Mock, config StubbingConfig) ([]Matcher, ReturnValues, error) {
+	method := reflect.ValueOf(mock).MethodByName(config.Method)
+	if !method.IsValid() {
+		return nil, nil, fmt.Errorf("%T has no method %v", mock, config.Method)
+	}
+	methodType := method.Type()
+	numParams := methodType.NumIn()
+	if methodType.IsVariadic() {
+		if len(config.Params) < numParams-1 {
+			return nil, nil, fmt.Errorf("expected at least %v params, but got %v", numParams-1, len(config.Params))
+		}
+	} else if len(config.Params) != numParams {
+		return nil, nil, fmt.Errorf("expected %v params, but got %v", numParams, len(config.Params))
+	}
+	if len(config.Returns) != methodType.NumOut() {
+		return nil, nil, fmt.Errorf("expected %v return values, but got %v", methodType.NumOut(), len(config.Returns))
+	}
+
+	matchers := make([]Matcher, len(config.Params))
+	for i, param := range config.Params {
+		paramType := paramTypeAt(methodType, i)
+		var e error
+		if param.Matcher == "" {
+			e = convertConfigValue(param.Value, paramType, func(value reflect.Value) { matchers[i] = &EqMatcher{Value: value.Interface()} })
+		} else {
+			args := param.Args
+			if args == nil && param.Value != nil {
+				args = []interface{}{param.Value}
+			}
+			matchers[i], e = LookupMatcher(param.Matcher, args)
+		}
+		if e != nil {
+			return nil, nil, fmt.Errorf("param %v: %v", i, e)
+		}
+	}
+
+	returnValues := make(ReturnValues, len(config.Returns))
+	for i, returnValue := range config.Returns {
+		e := convertConfigValue(returnValue, methodType.Out(i), func(value reflect.Value) { returnValues[i] = value.Interface() })
+		if e != nil {
+			return nil, nil, fmt.Errorf("return value %v: %v", i, e)
+		}
+	}
+	return matchers, returnValues, nil
+}
+
+// paramTypeAt returns the type of the i-th param passed to a method of
+// methodType, whose variadic params are passed one by one.
+func paramTypeAt(methodType reflect.Type, i int) reflect.Type {
+	if methodType.IsVariadic() && i >= methodType.NumIn()-1 {
+		return methodType.In(methodType.NumIn() - 1).Elem()
+	}
+	return methodType.In(i)
+}
+
+func convertConfigValue(value interface{}, typ reflect.Type, use func(reflect.Value)) (e error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			e = fmt.Errorf("%v", recovered)
+		}
+	}()
+	use(configValueAs(value, typ))
+	return nil
+}
+
+// configValueAs converts value, as decoded from a configuration file, to typ. It
+// panics if it can't.
+func configValueAs(value interface{}, typ reflect.Type) reflect.Value {
+	if value == nil {
+		return reflect.Zero(typ)
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(typ) {
+		result := reflect.New(typ).Elem()
+		result.Set(v)
+		return result
+	}
+	switch {
+	case typ == errorType && v.Kind() == reflect.String:
+		return reflect.ValueOf(errors.New(v.String()))
+	case isNumber(v.Kind()) && isNumber(typ.Kind()):
+		if isInteger(typ.Kind()) && isFloat(v.Kind()) && v.Float() != math.Trunc(v.Float()) {
+			panic(fmt.Sprintf("cannot use %v as %v", value, typ))
+		}
+		return v.Convert(typ)
+	case v.Kind() == reflect.String && typ.Kind() == reflect.String:
+		return v.Convert(typ)
+	case v.Kind() == reflect.Slice && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array):
+		result := reflect.New(typ).Elem()
+		if typ.Kind() == reflect.Slice {
+			result.Set(reflect.MakeSlice(typ, v.Len(), v.Len()))
+		} else if v.Len() != typ.Len() {
+			panic(fmt.Sprintf("cannot use %v elements as %v", v.Len(), typ))
+		}
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(configValueAs(v.Index(i).Interface(), typ.Elem()))
+		}
+		return result
+	case v.Kind() == reflect.Map && typ.Kind() == reflect.Map:
+		result := reflect.MakeMapWithSize(typ, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(configValueAs(iter.Key().Interface(), typ.Key()), configValueAs(iter.Value().Interface(), typ.Elem()))
+		}
+		return result
+	}
+	panic(fmt.Sprintf("cannot use %v (%T) as %v", formatCycleSafe("%v", value), value, typ))
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return isInteger(kind) || isFloat(kind)
+}
+
+func isInteger(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Uintptr
+}
+
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
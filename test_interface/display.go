@@ -15,6 +15,7 @@
 package test_interface
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"time"
@@ -32,6 +33,7 @@ type Display interface {
 	FloatParam(float32)
 	InterfaceParam(interface{})
 	InterfaceReturnValue() interface{}
+	PointerReturnValue() *Event
 	ErrorReturnValue() error
 	ErrorParam(e error)
 	NetHttpRequestParam(r http.Request)
@@ -46,4 +48,27 @@ type Display interface {
 	ChanReturnValues() (<-chan string, chan<- error)
 	VariadicWithNonPrimitiveType(m ...map[int]int)
 	MapWithRedundantImports(m map[http.File]http.File)
+	ContextParam(ctx context.Context, s string)
+	ContextAwareCall(ctx context.Context, s string) (string, error)
+	FuncParam(f func(s string) error)
+	RegisterHandler(handler http.Handler)
+	UnnamedParams(string, int) error
+	ParamNamedLikeItsType(string string) string
+	ParamsNamedLikeGeneratedIdentifiers(mock string, params int, result bool, http string, ret0 string)
+	BlankParams(_ string, _ int)
+	ChanOfChanParams(c chan<- chan int, r <-chan (<-chan string))
+	FuncWithOwnParams(f func(s string, n ...int) (bool, error))
+	AnonymousStructParam(s struct {
+		Name string
+		Age  int `json:"age"`
+	}) struct{ OK bool }
+	Subscribe() <-chan Event
+	Publish(events chan<- Event)
+	SubscribeWithCancel() (<-chan Event, func())
+	OnEvent(handler func(Event)) bool
+}
+
+// Event is sent over the channels of Display.
+type Event struct {
+	Name string
 }
@@ -0,0 +1,27 @@
+// Copyright 2015 Peter Goetz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test_interface
+
+// Query is a sample builder-style interface to be mocked: most of its methods
+// return the Query itself, so calls can be chained.
+type Query interface {
+	Where(condition string, args ...interface{}) Query
+	OrderBy(field string) Query
+	Limit(n int) Query
+	Offset(n int) Query
+	Select(fields ...string) Query
+	Count() int
+	Close() error
+}
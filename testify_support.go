@@ -0,0 +1,31 @@
+package pegomock
+
+import "runtime"
+
+type testifyT interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+// BuildTestifyFailHandler builds a FailHandler that reports failures like
+// testify's require package does: with an error trace pointing at the line in
+// the test, followed by stopping the test via t.Fatalf.
+func BuildTestifyFailHandler(t testifyT) FailHandler {
+	return func(message string, callerSkip ...int) {
+		t.Helper()
+		skip := 0
+		if len(callerSkip) > 0 {
+			skip = callerSkip[0]
+		}
+		_, file, line, _ := runtime.Caller(skip + 1)
+		t.Fatalf("\n\tError Trace:\t%v:%v\n\tError:      \t%v", file, line, message)
+	}
+}
+
+func RegisterTestifyT(t testifyT) {
+	RegisterMockFailHandler(BuildTestifyFailHandler(t))
+}
+
+func WithTestifyT(t testifyT) Option {
+	return WithFailHandler(BuildTestifyFailHandler(t))
+}
@@ -0,0 +1,82 @@
+// Package testifysupport integrates Pegomock with testify's suite package.
+package testifysupport
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/petergtz/pegomock"
+	"github.com/petergtz/pegomock/registry"
+	"github.com/stretchr/testify/suite"
+)
+
+// MockSuite is a testify suite that sets up Pegomock for each test. Embed it
+// instead of suite.Suite:
+//
+//	type DisplaySuite struct {
+//		testifysupport.MockSuite
+//	}
+//
+//	func (s *DisplaySuite) TestShow() {
+//		display := s.NewMock(func() pegomock.Mock { return NewMockDisplay() }).(*MockDisplay)
+//		// ...
+//	}
+//
+// Suites that define their own SetupTest or TearDownTest must call the ones of
+// MockSuite, e.g. s.MockSuite.SetupTest().
+type MockSuite struct {
+	suite.Suite
+	mocks registry.Registry
+}
+
+// registerMutex keeps suites running in parallel from setting the global fail
+// handler at the same time.
+var registerMutex sync.Mutex
+
+// SetupTest registers the current test's T as global fail handler, so mocks
+// not created with NewMock report failures to it, too.
+func (s *MockSuite) SetupTest() {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+	pegomock.RegisterMockTestingT(s.T())
+}
+
+// TearDownTest fails the test for every invocation of a mock created with
+// NewMock during the test that no verification matched.
+func (s *MockSuite) TearDownTest() {
+	t := s.T()
+	t.Helper()
+	for _, mock := range s.mocks.Release(t) {
+		unverified := pegomock.GetGenericMockFrom(mock.(pegomock.Mock)).GetUnverifiedInvocations()
+		if len(unverified) == 0 {
+			continue
+		}
+		methodNames := make([]string, 0, len(unverified))
+		for methodName := range unverified {
+			methodNames = append(methodNames, methodName)
+		}
+		sort.Strings(methodNames)
+		var invocations []string
+		for _, methodName := range methodNames {
+			for _, methodInvocation := range unverified[methodName] {
+				invocations = append(invocations,
+					pegomock.Invocation{MethodName: methodName, Params: methodInvocation.Params()}.String())
+			}
+		}
+		t.Errorf("%T has unverified interactions:\n\t%v", mock, strings.Join(invocations, "\n\t"))
+	}
+}
+
+// NewMock creates a mock with factory and registers it for the current test,
+// so TearDownTest checks it for unverified interactions. Unless factory gives
+// the mock a fail handler, it reports failures to the current test's T. Unlike
+// the global fail handler, this works with suites running in parallel.
+func (s *MockSuite) NewMock(factory func() pegomock.Mock) pegomock.Mock {
+	mock := factory()
+	if mock.FailHandler() == nil {
+		pegomock.WithT(s.T()).Apply(mock)
+	}
+	s.mocks.Register(s.T(), mock)
+	return mock
+}
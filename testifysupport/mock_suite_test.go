@@ -0,0 +1,75 @@
+package testifysupport_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/petergtz/pegomock"
+	"github.com/petergtz/pegomock/testifysupport"
+	"github.com/stretchr/testify/suite"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type mockGreeter struct{ fail pegomock.FailHandler }
+
+func (mock *mockGreeter) SetFailHandler(fail pegomock.FailHandler) { mock.fail = fail }
+func (mock *mockGreeter) FailHandler() pegomock.FailHandler        { return mock.fail }
+
+func (mock *mockGreeter) Greet(name string) string {
+	result := pegomock.GetGenericMockFrom(mock).Invoke("Greet", []pegomock.Param{name}, []reflect.Type{reflect.TypeOf("")})
+	if len(result) == 0 || result[0] == nil {
+		return ""
+	}
+	return result[0].(string)
+}
+
+func (mock *mockGreeter) verifyGreetWasCalledOnce(name string) {
+	pegomock.GetGenericMockFrom(mock).Verify(nil, pegomock.Once(), "Greet", []pegomock.Param{name})
+}
+
+type greeterSuite struct {
+	testifysupport.MockSuite
+	name string
+}
+
+func (s *greeterSuite) newGreeter() *mockGreeter {
+	return s.NewMock(func() pegomock.Mock { return &mockGreeter{} }).(*mockGreeter)
+}
+
+func (s *greeterSuite) TestStubbingAndVerifying() {
+	var g greeter = s.newGreeter()
+	pegomock.When(g.Greet(s.name)).ThenReturn("Hello " + s.name)
+
+	s.Equal("Hello "+s.name, g.Greet(s.name))
+
+	g.(*mockGreeter).verifyGreetWasCalledOnce(s.name)
+}
+
+func (s *greeterSuite) TestEachTestGetsItsOwnMocks() {
+	g := s.newGreeter()
+	g.Greet(s.name)
+	g.verifyGreetWasCalledOnce(s.name)
+}
+
+func (s *greeterSuite) TestTrackingUnverifiedInvocations() {
+	g := s.newGreeter()
+	g.Greet(s.name)
+
+	s.Equal(1, len(pegomock.GetGenericMockFrom(g).GetUnverifiedInvocations()["Greet"]),
+		"an unverified invocation would make TearDownTest fail this test")
+	g.verifyGreetWasCalledOnce(s.name)
+}
+
+// The suites run in parallel, and their mocks report to their own tests.
+func TestMockSuite(t *testing.T) {
+	for _, name := range []string{"Tom", "Jerry"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			suite.Run(t, &greeterSuite{name: name})
+		})
+	}
+}
@@ -33,11 +33,13 @@ type testingT interface {
 
 func BuildTestingTFailHandler(t testingT) FailHandler {
 	return func(message string, callerSkip ...int) {
-		skip := 1
+		skip := 0
 		if len(callerSkip) > 0 {
 			skip = callerSkip[0]
 		}
-		stackTrace := pruneStack(string(debug.Stack()), skip)
+		// callerSkip follows gomega's convention: 0 is the function calling the
+		// fail handler. On top of that, skip debug.Stack and this fail handler.
+		stackTrace := pruneStack(string(debug.Stack()), skip+2)
 		t.Errorf("\n%s\n%s", stackTrace, message)
 	}
 }
@@ -1,11 +1,38 @@
 package pegomock
 
+import "github.com/petergtz/pegomock/internal/verify"
+
 type FailHandler func(message string, callerSkip ...int)
 
 type Mock interface {
 	SetFailHandler(FailHandler)
 	FailHandler() FailHandler
 }
+
+// MockWithMethodMetadata is implemented by generated mocks. Invocations of
+// mocks that provide method metadata are checked for the declared number of params.
+type MockWithMethodMetadata interface {
+	Mock
+	MethodMetadata() map[string]MethodMetadata
+}
+
+// MockWithInterfaceName is implemented by generated mocks. PegomockInterfaceName
+// returns the name of the mocked interface qualified with its package path, e.g.
+// "github.com/acme/storage.Repository". Failure messages refer to such mocks by
+// the interface name qualified with the last element of the package path, e.g.
+// "storage.Repository".
+type MockWithInterfaceName interface {
+	Mock
+	PegomockInterfaceName() string
+}
+
+// MethodMetadata describes the signature of a mocked method.
+type MethodMetadata struct {
+	// NumParams includes the variadic param, if any.
+	NumParams int
+	Variadic  bool
+}
+
 type Param interface{}
 type ReturnValue interface{}
 type ReturnValues []ReturnValue
@@ -19,3 +46,24 @@ func (f OptionFunc) Apply(mock Mock) { f(mock) }
 func WithFailHandler(fail FailHandler) Option {
 	return OptionFunc(func(mock Mock) { mock.SetFailHandler(fail) })
 }
+
+// WithInvocationLimit makes the mock retain only the most recent limit
+// invocations per method, for tests that invoke a mock so often that recording
+// every invocation would use up memory. Evicted invocations are still counted,
+// so verifications without params, or with Any matchers only, keep working.
+// Other verifications fail once invocations were evicted, because they can't
+// tell whether evicted invocations would have matched.
+func WithInvocationLimit(limit int) Option {
+	verify.Argument(limit > 0, "Invocation limit must be positive, but is %v", limit)
+	return OptionFunc(func(mock Mock) {
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		defer genericMock.Unlock()
+		genericMock.invocationLimit = limit
+		for _, method := range genericMock.mockedMethods {
+			method.Lock()
+			method.invocationLimit = limit
+			method.Unlock()
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package pegomock
+
+import (
+	"strings"
+	"sync"
+)
+
+// trackedStubbing is a stubbing made with When, and where it was made.
+type trackedStubbing struct {
+	genericMock   *GenericMock
+	methodName    string
+	paramMatchers Matchers
+	location      string
+}
+
+// unusedStubbingsTracker remembers the stubbings made on a test's goroutine
+// since RequireStubbingsUsed, in the order they were made in.
+type unusedStubbingsTracker struct {
+	t         stubbedMocksUsedT
+	stubbings []trackedStubbing
+}
+
+// Like the trackers of RequireStubbedMocksUsed, these are kept per goroutine, so
+// that each test gets its own.
+var (
+	unusedStubbingsTrackers      = make(map[int64]*unusedStubbingsTracker)
+	unusedStubbingsTrackersMutex sync.Mutex
+)
+
+// RequireStubbingsUsed makes the calling test fail when it finishes, listing each
+// stubbing it made with When that answered no call during the test, like
+// Mockito's UnnecessaryStubbingException. Such stubbings are either leftovers
+// or expect calls with different params than the code under test makes, and
+// make tests harder to trust. Calls made in order to stub don't count as uses.
+// Stubbings replaced by a later When with the same params, or removed by
+// ResetForNextTest, are not reported. Only stubbings on the test's goroutine
+// are tracked.
+func RequireStubbingsUsed(t stubbedMocksUsedT) {
+	t.Helper()
+	goroutineID := currentGoroutineID()
+	unusedStubbingsTrackersMutex.Lock()
+	if _, exists := unusedStubbingsTrackers[goroutineID]; exists {
+		unusedStubbingsTrackersMutex.Unlock()
+		t.Errorf("RequireStubbingsUsed called twice in the same test.")
+		return
+	}
+	unusedStubbingsTrackers[goroutineID] = &unusedStubbingsTracker{t: t}
+	unusedStubbingsTrackersMutex.Unlock()
+
+	t.Cleanup(func() { checkStubbingsUsed(goroutineID) })
+}
+
+// trackStubbingUse is called by When for every stubbing. location is only
+// looked up if a test on the current goroutine called RequireStubbingsUsed.
+func trackStubbingUse(genericMock *GenericMock, methodName string, paramMatchers Matchers, location func() string) {
+	unusedStubbingsTrackersMutex.Lock()
+	defer unusedStubbingsTrackersMutex.Unlock()
+	if len(unusedStubbingsTrackers) == 0 {
+		return
+	}
+	tracker, exists := unusedStubbingsTrackers[currentGoroutineID()]
+	if !exists {
+		return
+	}
+	tracker.stubbings = append(tracker.stubbings, trackedStubbing{
+		genericMock:   genericMock,
+		methodName:    methodName,
+		paramMatchers: paramMatchers,
+		location:      location(),
+	})
+}
+
+func checkStubbingsUsed(goroutineID int64) {
+	unusedStubbingsTrackersMutex.Lock()
+	tracker := unusedStubbingsTrackers[goroutineID]
+	delete(unusedStubbingsTrackers, goroutineID)
+	unusedStubbingsTrackersMutex.Unlock()
+
+	tracker.t.Helper()
+	// A When with the same params as an earlier one replaces its stubbing, so
+	// only the last one is reported.
+	lastTracked := make(map[*Stubbing]int)
+	stubbings := make([]*Stubbing, len(tracker.stubbings))
+	timesAnswered := make([]int, len(tracker.stubbings))
+	for i, tracked := range tracker.stubbings {
+		stubbings[i], timesAnswered[i] = tracked.genericMock.stubbingFor(tracked.methodName, tracked.paramMatchers)
+		lastTracked[stubbings[i]] = i
+	}
+	var unused []string
+	for i, tracked := range tracker.stubbings {
+		if stubbings[i] == nil || lastTracked[stubbings[i]] != i || timesAnswered[i] > 0 {
+			continue
+		}
+		unused = append(unused, tracked.genericMock.qualified(tracked.methodName)+"("+formatMatchers(tracked.paramMatchers)+")"+
+			"\n\t\tStubbed at "+tracked.location)
+	}
+	if len(unused) > 0 {
+		tracker.t.Errorf("Unused stubbings. These stubbings answered no call, so they can be removed or their params don't match the calls made:\n\t%v",
+			strings.Join(unused, "\n\t"))
+	}
+}
+
+// stubbingFor returns the stubbing of methodName for paramMatchers, or nil if
+// there is none, and how many calls it answered so far.
+func (genericMock *GenericMock) stubbingFor(methodName string, paramMatchers Matchers) (stubbing *Stubbing, timesAnswered int) {
+	genericMock.Lock()
+	method, exists := genericMock.mockedMethods[methodName]
+	genericMock.Unlock()
+	if !exists {
+		return nil, 0
+	}
+	method.Lock()
+	defer method.Unlock()
+	stubbing = method.stubbings.findByMatchers(paramMatchers)
+	if stubbing == nil {
+		return nil, 0
+	}
+	return stubbing, stubbing.timesAnswered
+}
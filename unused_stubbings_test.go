@@ -0,0 +1,114 @@
+package pegomock_test
+
+import (
+	"sync"
+
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("RequireStubbingsUsed", func() {
+	var (
+		t       *fakeInOrderT
+		display *MockDisplay
+	)
+
+	BeforeEach(func() {
+		t = &fakeInOrderT{}
+		display = NewMockDisplay()
+	})
+
+	It("succeeds when all stubbings answered calls", func() {
+		RequireStubbingsUsed(t)
+		When(display.SomeValue()).ThenReturn("Hello")
+		DoReturn("two").When(func() { display.MultipleParamsAndReturnValue(AnyString(), AnyInt()) })
+		display.SomeValue()
+		display.MultipleParamsAndReturnValue("one", 1)
+		t.runCleanups()
+
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("fails listing the stubbings that answered no call", func() {
+		RequireStubbingsUsed(t)
+		When(display.SomeValue()).ThenReturn("Hello")
+		When(display.MultipleParamsAndReturnValue("one", 1)).ThenReturn("two")
+		When(display.MultipleParamsAndReturnValue(AnyString(), EqInt(2))).ThenReturn("three")
+		display.MultipleParamsAndReturnValue("one", 1)
+		t.runCleanups()
+
+		Expect(t.errors).To(ConsistOf(MatchRegexp(
+			`^Unused stubbings\. .*:\n` +
+				`\t.*SomeValue\(\)\n\t\tStubbed at .*unused_stubbings_test.go:\d+\n` +
+				`\t.*MultipleParamsAndReturnValue\(Any\(string\), Eq\(2\)\)\n\t\tStubbed at .*unused_stubbings_test.go:\d+$`)))
+	})
+
+	It("counts the calls answered from parallel goroutines, also while they stub", func() {
+		RequireStubbingsUsed(t)
+		When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenReturn("any")
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					display.MultipleParamsAndReturnValue("Hello", 0)
+				}
+			}()
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					// Answered by the stubbing above, but made to stub, so not counted.
+					When(display.MultipleParamsAndReturnValue("Hello", 100*(i+1)+j)).ThenReturn("other")
+				}
+			}(i)
+		}
+		wg.Wait()
+		t.runCleanups()
+
+		Expect(StubbingsTimesAnswered(display, "MultipleParamsAndReturnValue")[0]).To(Equal(200))
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("does not count calls made to stub other params", func() {
+		RequireStubbingsUsed(t)
+		When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenReturn("any")
+		When(display.MultipleParamsAndReturnValue("one", 1)).ThenReturn("one")
+		When(display.MultipleParamsAndReturnValue(EqString("two"), AnyInt())).ThenReturn("two")
+		display.MultipleParamsAndReturnValue("one", 1)
+		display.MultipleParamsAndReturnValue("two", 2)
+		t.runCleanups()
+
+		Expect(t.errors).To(ConsistOf(ContainSubstring("MultipleParamsAndReturnValue(Any(string), Any(int))")))
+	})
+
+	It("reports a stubbing replaced with the same params once", func() {
+		RequireStubbingsUsed(t)
+		When(display.SomeValue()).ThenReturn("Hello")
+		When(display.SomeValue()).ThenReturn("World")
+		t.runCleanups()
+
+		Expect(t.errors).To(HaveLen(1))
+		Expect(t.errors[0]).To(MatchRegexp(`SomeValue\(\)\n\t\tStubbed at [^\n]*$`))
+	})
+
+	It("ignores stubbings made before it was called and ones removed by ResetForNextTest", func() {
+		When(display.SomeValue()).ThenReturn("Hello")
+		RequireStubbingsUsed(t)
+		When(display.ErrorReturnValue()).ThenReturn(nil)
+		display.ResetForNextTest()
+		t.runCleanups()
+
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("fails when called twice", func() {
+		RequireStubbingsUsed(t)
+		RequireStubbingsUsed(t)
+		t.runCleanups()
+
+		Expect(t.errors).To(ConsistOf("RequireStubbingsUsed called twice in the same test."))
+	})
+})
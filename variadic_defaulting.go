@@ -0,0 +1,92 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/petergtz/pegomock/internal/verify"
+)
+
+// VariadicDefaultingMatcher matches all variadic arguments of a call. See
+// VariadicDefaulting.
+type VariadicDefaultingMatcher struct {
+	Defaults []Param
+	Matchers Matchers
+}
+
+// VariadicDefaulting registers and returns a matcher for all variadic arguments
+// of a call, which treats omitted arguments like ones passed with their default.
+// This way, Do(x) and Do(x, DefaultOption) match alike. Before matching, the
+// actual variadic arguments are padded with defaults up to the number of
+// matchers, and trailing ones equal to their defaults are dropped down to it.
+// defaults[i] is the default of the i-th variadic argument.
+//
+// It must be the last argument, passed in place of all variadic arguments. Since
+// it returns a Matcher, use it directly for ...interface{} parameters only, and
+// VariadicDefaultingOf otherwise. Matchers that register themselves, like IsA,
+// can be passed as matchers.
+func VariadicDefaulting(defaults []interface{}, matchers ...Matcher) Matcher {
+	verify.Argument(len(matchers) <= len(defaults),
+		"VariadicDefaulting needs a default for each matcher, but got %v defaults for %v matchers", len(defaults), len(matchers))
+	takeOverArgMatchers(matchers)
+	matcher := &VariadicDefaultingMatcher{Matchers: matchers}
+	for _, defaultValue := range defaults {
+		matcher.Defaults = append(matcher.Defaults, defaultValue)
+	}
+	RegisterMatcher(matcher)
+	return matcher
+}
+
+// takeOverArgMatchers unregisters the matchers registered last on the current
+// goroutine, if they are the given ones, e.g. ones created with IsA.
+func takeOverArgMatchers(matchers []Matcher) {
+	globalArgMatchersMutex.Lock()
+	defer globalArgMatchersMutex.Unlock()
+	id := currentGoroutineID()
+	registered := globalArgMatchers[id]
+	taken := 0
+	for i := len(matchers) - 1; i >= 0 && taken < len(registered); i-- {
+		if registered[len(registered)-1-taken] != matchers[i] {
+			break
+		}
+		taken++
+	}
+	if taken == 0 {
+		return
+	}
+	globalArgMatchers[id] = registered[:len(registered)-taken]
+	argMatcherCallersMutex.Lock()
+	defer argMatcherCallersMutex.Unlock()
+	if callers := argMatcherCallers[id]; len(callers) >= taken {
+		argMatcherCallers[id] = callers[:len(callers)-taken]
+	}
+}
+
+func (matcher *VariadicDefaultingMatcher) Matches(param Param) bool {
+	return matcher.matchesVariadic([]Param{param})
+}
+
+// matchesVariadic is used by Matchers.Matches for the variadic arguments, when
+// matcher is the last one.
+func (matcher *VariadicDefaultingMatcher) matchesVariadic(params []Param) bool {
+	normalized := append([]Param(nil), params...)
+	for len(normalized) > len(matcher.Matchers) {
+		last := len(normalized) - 1
+		if last >= len(matcher.Defaults) || !reflect.DeepEqual(normalized[last], matcher.Defaults[last]) {
+			return false
+		}
+		normalized = normalized[:last]
+	}
+	for i := len(normalized); i < len(matcher.Matchers); i++ {
+		normalized = append(normalized, matcher.Defaults[i])
+	}
+	return matcher.Matchers.Matches(normalized)
+}
+
+func (matcher *VariadicDefaultingMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected variadic arguments matching %v", matcher)
+}
+
+func (matcher *VariadicDefaultingMatcher) String() string {
+	return fmt.Sprintf("VariadicDefaulting(%v; defaults: %v)", formatMatchers(matcher.Matchers), formatParams(matcher.Defaults))
+}
@@ -0,0 +1,138 @@
+package pegomock
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type traceVerificationsT interface {
+	Helper()
+	Log(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// TraceVerificationsOption configures TraceVerifications.
+type TraceVerificationsOption struct{ requireVerifications bool }
+
+// RequireVerifications makes TraceVerifications fail the test, if mocks were
+// called during the test, but no verification ran. That usually means asserts
+// got deleted, and the test passes vacuously.
+var RequireVerifications = TraceVerificationsOption{requireVerifications: true}
+
+type tracedVerification struct {
+	description string
+	passed      bool
+}
+
+// verificationTracer remembers the verifications run on a test's goroutine
+// since TraceVerifications, and the mocks called on it.
+type verificationTracer struct {
+	t                    traceVerificationsT
+	requireVerifications bool
+	verifications        []tracedVerification
+	// calledMocks is in the order the mocks were first called in.
+	calledMocks []*GenericMock
+}
+
+// Like the trackers of RequireStubbedMocksUsed, tracers are kept per goroutine,
+// so that each test gets its own.
+var (
+	verificationTracers      = make(map[int64]*verificationTracer)
+	verificationTracersMutex sync.Mutex
+)
+
+// TraceVerifications records every verification run by the calling test, and
+// logs a summary of them with t.Log when the test finishes: the mock and method,
+// the expected invocation count and whether it passed. With RequireVerifications,
+// it also fails the test if mocks were called, but nothing was verified. Only
+// verifications and invocations on the test's goroutine are traced.
+func TraceVerifications(t traceVerificationsT, options ...TraceVerificationsOption) {
+	t.Helper()
+	tracer := &verificationTracer{t: t}
+	for _, option := range options {
+		tracer.requireVerifications = tracer.requireVerifications || option.requireVerifications
+	}
+	goroutineID := currentGoroutineID()
+	verificationTracersMutex.Lock()
+	if _, exists := verificationTracers[goroutineID]; exists {
+		verificationTracersMutex.Unlock()
+		t.Errorf("TraceVerifications called twice in the same test.")
+		return
+	}
+	verificationTracers[goroutineID] = tracer
+	verificationTracersMutex.Unlock()
+
+	t.Cleanup(func() { reportVerifications(goroutineID) })
+}
+
+// currentVerificationTracer is nil, unless a test on the current goroutine
+// called TraceVerifications. The caller must hold verificationTracersMutex.
+func currentVerificationTracer() *verificationTracer {
+	if len(verificationTracers) == 0 {
+		return nil
+	}
+	return verificationTracers[currentGoroutineID()]
+}
+
+// traceVerification is called by verify with its outcome. describe is only
+// called if the verification is traced, since formatting params is slow.
+func traceVerification(describe func() string, passed bool) {
+	verificationTracersMutex.Lock()
+	defer verificationTracersMutex.Unlock()
+	tracer := currentVerificationTracer()
+	if tracer == nil {
+		return
+	}
+	tracer.verifications = append(tracer.verifications, tracedVerification{description: describe(), passed: passed})
+}
+
+// traceInvocation is called by Invoke. Invocations made while stubbing are
+// noted as well, but they are not counted in reportVerifications.
+func traceInvocation(genericMock *GenericMock) {
+	verificationTracersMutex.Lock()
+	defer verificationTracersMutex.Unlock()
+	tracer := currentVerificationTracer()
+	if tracer == nil {
+		return
+	}
+	for _, calledMock := range tracer.calledMocks {
+		if calledMock == genericMock {
+			return
+		}
+	}
+	tracer.calledMocks = append(tracer.calledMocks, genericMock)
+}
+
+func reportVerifications(goroutineID int64) {
+	verificationTracersMutex.Lock()
+	tracer := verificationTracers[goroutineID]
+	delete(verificationTracers, goroutineID)
+	verificationTracersMutex.Unlock()
+
+	tracer.t.Helper()
+	if len(tracer.verifications) == 0 {
+		tracer.t.Log("No verifications ran.")
+		var calledMocks []string
+		for _, genericMock := range tracer.calledMocks {
+			if genericMock.invocationCount() > 0 {
+				calledMocks = append(calledMocks, genericMock.mockTypeName)
+			}
+		}
+		if tracer.requireVerifications && len(calledMocks) > 0 {
+			tracer.t.Errorf("%v called, but no verifications ran. Maybe verifications got deleted?",
+				strings.Join(calledMocks, ", "))
+		}
+		return
+	}
+	summary := "Verifications run by this test:"
+	for _, verification := range tracer.verifications {
+		outcome := "passed"
+		if !verification.passed {
+			outcome = "FAILED"
+		}
+		summary += fmt.Sprintf("\n\t%v: %v", verification.description, outcome)
+	}
+	tracer.t.Log(summary)
+}
@@ -0,0 +1,78 @@
+package pegomock_test
+
+import (
+	"github.com/onsi/gomega"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("TraceVerifications", func() {
+	var (
+		t       *fakeInOrderT
+		display *MockDisplay
+	)
+
+	BeforeEach(func() {
+		t = &fakeInOrderT{}
+		display = NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) {}))
+	})
+
+	It("logs all verifications with their outcome when the test finishes", func() {
+		TraceVerifications(t)
+		display.Show("Hello")
+		display.VerifyWasCalledOnce().Show("Hello")
+		display.VerifyWasCalled(Never()).Show(AnyString())
+		display.VerifyWasCalledEventually(AtLeast(2), 0).Show("Hello")
+		Expect(t.logs).To(gomega.BeEmpty())
+
+		t.runCleanups()
+
+		Expect(t.logs).To(ConsistOf("Verifications run by this test:\n" +
+			"\ttest_interface.Display.Show(\"Hello\") once: passed\n" +
+			"\ttest_interface.Display.Show(Any(string)) never: FAILED\n" +
+			"\ttest_interface.Display.Show(\"Hello\") at least twice: FAILED"))
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("does not fail when mocks were called without verifications by default", func() {
+		TraceVerifications(t)
+		display.Show("Hello")
+		t.runCleanups()
+
+		Expect(t.logs).To(ConsistOf("No verifications ran."))
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("fails with RequireVerifications when mocks were called without verifications", func() {
+		TraceVerifications(t, RequireVerifications)
+		display.Show("Hello")
+		t.runCleanups()
+
+		Expect(t.errors).To(ConsistOf("MockDisplay called, but no verifications ran. Maybe verifications got deleted?"))
+	})
+
+	It("does not count invocations made while stubbing as calls with RequireVerifications", func() {
+		TraceVerifications(t, RequireVerifications)
+		When(display.MultipleParamsAndReturnValue(AnyString(), AnyInt())).ThenReturn("two")
+		t.runCleanups()
+
+		Expect(t.errors).To(gomega.BeEmpty())
+	})
+
+	It("stops tracing when the test finishes", func() {
+		TraceVerifications(t)
+		t.runCleanups()
+		display.Show("Hello")
+		display.VerifyWasCalledOnce().Show("Hello")
+
+		Expect(t.logs).To(ConsistOf("No verifications ran."))
+	})
+
+	It("fails when called twice", func() {
+		TraceVerifications(t)
+		TraceVerifications(t)
+		t.runCleanups()
+
+		Expect(t.errors).To(ConsistOf("TraceVerifications called twice in the same test."))
+	})
+})
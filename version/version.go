@@ -0,0 +1,82 @@
+// Package version tells which version of pegomock generated code and which
+// version of the pegomock library it was built against.
+package version
+
+import (
+	"regexp"
+	"runtime/debug"
+)
+
+// ModulePath is the path of pegomock's module, without major version suffix.
+const ModulePath = "github.com/petergtz/pegomock"
+
+// Version and Commit can be injected at build time, e.g. with
+//
+//	go build -ldflags "-X github.com/petergtz/pegomock/version.Version=v2.9.0 -X github.com/petergtz/pegomock/version.Commit=$(git rev-parse HEAD)" ./pegomock
+//
+// If they are not, Generator and GeneratorCommit fall back to what the go
+// command stamped into the binary.
+var (
+	Version string
+	Commit  string
+)
+
+// Unknown is returned for versions that can't be determined, e.g. for binaries
+// built from a local checkout without injected Version.
+const Unknown = "(devel)"
+
+var modulePathPattern = regexp.MustCompile(`^` + regexp.QuoteMeta(ModulePath) + `(/v\d+)?$`)
+
+// IsModulePath reports whether path is pegomock's module path, with or without
+// major version suffix.
+func IsModulePath(path string) bool {
+	return modulePathPattern.MatchString(path)
+}
+
+// Generator returns the version of the running pegomock binary, which is also
+// embedded into the header of generated mocks.
+func Generator() string {
+	if Version != "" {
+		return Version
+	}
+	return Library()
+}
+
+// GeneratorCommit returns the commit the running pegomock binary was built
+// from, or "unknown".
+func GeneratorCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// Library returns the version of the pegomock library module the running
+// binary was built against, e.g. v2.9.0 when installed with
+// go install github.com/petergtz/pegomock/pegomock@v2.9.0, or Unknown.
+func Library() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Unknown
+	}
+	modules := append([]*debug.Module{&buildInfo.Main}, buildInfo.Deps...)
+	for _, module := range modules {
+		if !IsModulePath(module.Path) {
+			continue
+		}
+		if module.Replace != nil && module.Replace.Version != "" {
+			return module.Replace.Version
+		}
+		if module.Version != "" {
+			return module.Version
+		}
+	}
+	return Unknown
+}
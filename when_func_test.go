@@ -0,0 +1,87 @@
+package pegomock_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/onsi/ginkgo"
+
+	. "github.com/petergtz/pegomock"
+)
+
+var _ = Describe("WhenFunc", func() {
+	var display *MockDisplay
+
+	BeforeEach(func() {
+		display = NewMockDisplay()
+	})
+
+	It("stubs the call on a mock the function makes", func() {
+		WhenFunc(func() { display.MultipleParamsAndReturnValue("Hello", 1) }).ThenReturn("stubbed")
+		WhenFunc(func() { display.Show(AnyString()) }).ThenPanic("shown")
+
+		Expect(display.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("stubbed"))
+		Expect(display.MultipleParamsAndReturnValue("Hello", 2)).To(Equal(""))
+		Expect(func() { display.Show("anything") }).To(PanicWith("shown"))
+	})
+
+	It("uses argument matchers registered by the call", func() {
+		WhenFunc(func() { display.MultipleParamsAndReturnValue(AnyString(), EqInt(2)) }).ThenReturn("two")
+
+		Expect(display.MultipleParamsAndReturnValue("any", 2)).To(Equal("two"))
+		Expect(display.MultipleParamsAndReturnValue("any", 3)).To(Equal(""))
+		display.VerifyWasCalled(Times(2)).MultipleParamsAndReturnValue(AnyString(), AnyInt())
+	})
+
+	It("doesn't stub calls on mocks made before", func() {
+		display.SomeValue()
+
+		Expect(func() { WhenFunc(func() {}) }).To(PanicWith(
+			"When() requires an argument which has to be 'a method call on a mock'."))
+		Expect(display.SomeValue()).To(Equal(""))
+	})
+
+	It("panics on argument matchers registered before and leaves no state behind", func() {
+		AnyString()
+
+		Expect(func() {
+			WhenFunc(func() { display.MultipleParamsAndReturnValue(AnyString(), AnyInt()) })
+		}).To(PanicWithMessageTo(HavePrefix("Invalid use of matchers!\n\nArgument matchers were not passed to a call on a mock.")))
+
+		WhenFunc(func() { display.MultipleParamsAndReturnValue("Hello", 1) }).ThenReturn("stubbed")
+		Expect(display.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("stubbed"))
+	})
+
+	It("panics on nil functions", func() {
+		Expect(func() { WhenFunc(nil) }).To(PanicWith(
+			"WhenFunc() requires a function that makes a call on a mock, but got nil."))
+	})
+
+	It("tells where the call was stubbed", func() {
+		value := display.SomeValue()
+		WhenFunc(func() { display.SomeValue() }).ThenReturn("Hello")
+
+		Expect(func() { When(value) }).To(PanicWithMessageTo(MatchRegexp(
+			`was already stubbed at .*when_func_test.go:\d+. `)))
+	})
+
+	It("can be used from several goroutines at once", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer ginkgo.GinkgoRecover()
+				ownDisplay := NewMockDisplay()
+				expected := fmt.Sprint("stubbed ", i)
+				WhenFunc(func() { ownDisplay.MultipleParamsAndReturnValue(AnyString(), EqInt(i)) }).ThenReturn(expected)
+				WhenFunc(func() { display.MultipleParamsAndReturnValue(EqString("shared"), EqInt(i)) }).ThenReturn(expected)
+
+				Expect(ownDisplay.MultipleParamsAndReturnValue("any", i)).To(Equal(expected))
+				Expect(display.MultipleParamsAndReturnValue("shared", i)).To(Equal(expected))
+			}(i)
+		}
+		wg.Wait()
+		Expect(display.InvocationCount("MultipleParamsAndReturnValue")).To(Equal(20))
+	})
+})